@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/handler"
+)
+
+// Logger receives formatted log lines. *log.Logger implements it, and most structured
+// loggers provide a Printf adapter.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// AccessLogFormat selects the layout of access log lines
+type AccessLogFormat string
+
+const (
+	// AccessLogCombined writes the Apache combined log format, followed by the quoted
+	// operation name and client name and the latency:
+	//
+	//	203.0.113.7 - - [16/Oct/2026:13:55:36 +0000] "POST /graphql HTTP/1.1" 200 512 "-" "curl/8.4.0" "GetUser" "ios-app" 1.204ms
+	AccessLogCombined AccessLogFormat = "combined"
+
+	// AccessLogJSON writes one JSON object per request
+	AccessLogJSON AccessLogFormat = "json"
+)
+
+// AccessLogConfig configures the access log of NewHTTP
+type AccessLogConfig struct {
+	// Logger receives one line per request. Default: log.Default()
+	Logger Logger
+
+	// Format of the lines. Default: AccessLogCombined
+	Format AccessLogFormat
+}
+
+// AccessLogEntry describes a request served by NewHTTP, as written to the access log
+type AccessLogEntry struct {
+	Time       time.Time     `json:"time"`
+	RemoteAddr string        `json:"remote_addr"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	Protocol   string        `json:"protocol"`
+	Operation  string        `json:"operation,omitempty"`
+	Status     int           `json:"status"`
+	Bytes      int64         `json:"bytes"`
+	Latency    time.Duration `json:"-"` // Logged as latency_ms in JSON
+	Client     string        `json:"client"`
+	Referer    string        `json:"referer,omitempty"`
+	UserAgent  string        `json:"user_agent,omitempty"`
+}
+
+// combinedTimeFormat is the timestamp layout of the Apache log formats
+const combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// Combined formats the entry in the Apache combined log format, extended with the
+// operation name, the client name and the latency
+func (e AccessLogEntry) Combined() string {
+	size := "-"
+	if e.Bytes > 0 {
+		size = strconv.FormatInt(e.Bytes, 10)
+	}
+	return e.RemoteAddr + " - - [" + e.Time.Format(combinedTimeFormat) + "] " +
+		strconv.Quote(e.Method+" "+e.Path+" "+e.Protocol) + " " +
+		strconv.Itoa(e.Status) + " " + size + " " +
+		quoteOrDash(e.Referer) + " " + quoteOrDash(e.UserAgent) + " " +
+		quoteOrDash(e.Operation) + " " + quoteOrDash(e.Client) + " " +
+		e.Latency.String()
+}
+
+// quoteOrDash quotes a field of a combined log line, or returns "-" when it is empty
+func quoteOrDash(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+	return strconv.Quote(s)
+}
+
+// accessLogger writes an entry for each request once it is served
+type accessLogger struct {
+	logger Logger
+	format AccessLogFormat
+	now    func() time.Time
+}
+
+func newAccessLogger(config *AccessLogConfig) *accessLogger {
+	if config == nil {
+		return nil
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	format := config.Format
+	if format == "" {
+		format = AccessLogCombined
+	}
+	return &accessLogger{logger: logger, format: format, now: time.Now}
+}
+
+// accessLogContextKey stores the entry of the request being served
+type accessLogContextKey struct{}
+
+// begin starts the entry of r. The returned writer counts the response, and done
+// writes the entry once the request is served.
+func (l *accessLogger) begin(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	entry := &AccessLogEntry{
+		Time:       l.now(),
+		RemoteAddr: remoteHost(r.RemoteAddr),
+		Method:     r.Method,
+		Path:       r.URL.RequestURI(),
+		Protocol:   r.Proto,
+		Client:     clientName(r),
+		Referer:    r.Referer(),
+		UserAgent:  r.UserAgent(),
+	}
+	if r.Method == http.MethodGet {
+		entry.Operation = queryParam(r.URL.RawQuery, "operationName")
+	}
+	counter := &accessLogWriter{ResponseWriter: w}
+	r = r.WithContext(context.WithValue(r.Context(), accessLogContextKey{}, entry))
+
+	return counter, r, func() {
+		entry.Latency = l.now().Sub(entry.Time)
+		entry.Status = counter.status
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+		entry.Bytes = counter.bytes
+		l.write(entry)
+	}
+}
+
+// write logs entry in the configured format
+func (l *accessLogger) write(entry *AccessLogEntry) {
+	if l.format == AccessLogJSON {
+		var line bytes.Buffer
+		encoder := json.NewEncoder(&line)
+		encoder.SetEscapeHTML(false)
+		err := encoder.Encode(struct {
+			*AccessLogEntry
+			LatencyMS float64 `json:"latency_ms"`
+		}{entry, float64(entry.Latency) / float64(time.Millisecond)})
+		if err == nil {
+			l.logger.Printf("%s", bytes.TrimSuffix(line.Bytes(), []byte("\n")))
+		}
+		return
+	}
+	l.logger.Printf("%s", entry.Combined())
+}
+
+// logOperation records the operation executed for the access log entry of ctx, naming
+// anonymous requests after their selected operation
+func logOperation(ctx context.Context, opts *handler.RequestOptions) {
+	entry, ok := ctx.Value(accessLogContextKey{}).(*AccessLogEntry)
+	if !ok {
+		return
+	}
+	entry.Operation = opts.OperationName
+	if entry.Operation == "" && opts.Query != "" {
+		if op := selectOperation(opts.Query, ""); op != nil && op.Name != nil {
+			entry.Operation = op.Name.Value
+		}
+	}
+}
+
+// remoteHost strips the port from a remote address
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	if addr == "" {
+		return "-"
+	}
+	return addr
+}
+
+// accessLogWriter counts the status and the bytes written to a response
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessLogWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps streamed responses (SSE) flushing through the writer
+func (w *accessLogWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AdaptiveLimitConfig configures an AdaptiveLimiter. At least one of MaxLatency and
+// MaxInFlight should be set.
+type AdaptiveLimitConfig struct {
+	// MaxLatency is the p99 request latency above which the server is overloaded.
+	// 0 ignores latency.
+	MaxLatency time.Duration
+
+	// MaxInFlight is the number of concurrent requests above which the server is
+	// overloaded. 0 ignores concurrency.
+	MaxInFlight int
+
+	// Interval between two evaluations of the load. Default: 5s
+	Interval time.Duration
+
+	// MinFactor is the lowest fraction of the limits accepted under load. Default: 0.25
+	MinFactor float64
+
+	// RecoveryStep is the fraction of the limits restored after each interval without
+	// overload. Default: 0.25
+	RecoveryStep float64
+}
+
+// AdaptiveLimiter lowers the complexity and depth limits of operations while the server
+// is overloaded, so expensive operations are rejected during traffic spikes and cheap
+// ones keep being served. Load is evaluated once per interval: when the p99 latency or
+// the peak number of requests in flight of the interval exceeds its threshold, the
+// limits are halved, down to MinFactor; after each interval without overload, they
+// regain RecoveryStep until they are back to normal.
+//
+// The limits scaled are MaxComplexity and MaxDepth of the request (DefaultQueryLimits,
+// or those of the tenant's policy), checked when EnableValidation is set.
+//
+// Example:
+//
+//	limiter := graph.NewAdaptiveLimiter(graph.AdaptiveLimitConfig{
+//	    MaxLatency:  500 * time.Millisecond,
+//	    MaxInFlight: 200,
+//	})
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams:     params,
+//	    EnableValidation: true,
+//	    AdaptiveLimits:   limiter,
+//	})
+type AdaptiveLimiter struct {
+	config   AdaptiveLimitConfig
+	inFlight atomic.Int64
+	now      func() time.Time
+
+	mu        sync.Mutex
+	factor    float64
+	samples   []time.Duration // Latencies of the current interval
+	next      int             // Sample replaced next once samples is full
+	peak      int64           // Requests in flight at most during the current interval
+	evaluated time.Time
+}
+
+// maxLatencySamples bounds the latencies kept per interval; once full, the oldest
+// samples are replaced
+const maxLatencySamples = 2048
+
+// NewAdaptiveLimiter creates a limiter applying the full limits until it detects overload
+func NewAdaptiveLimiter(config AdaptiveLimitConfig) *AdaptiveLimiter {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.MinFactor <= 0 || config.MinFactor > 1 {
+		config.MinFactor = 0.25
+	}
+	if config.RecoveryStep <= 0 {
+		config.RecoveryStep = 0.25
+	}
+	return &AdaptiveLimiter{config: config, factor: 1, now: time.Now}
+}
+
+// Factor returns the fraction of the complexity and depth limits currently accepted,
+// 1 when the server is not overloaded
+func (l *AdaptiveLimiter) Factor() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.factor
+}
+
+// InFlight returns the number of requests being served
+func (l *AdaptiveLimiter) InFlight() int {
+	return int(l.inFlight.Load())
+}
+
+type adaptiveFactorContextKey struct{}
+
+// begin counts r in flight and attaches the current factor to it. done records its
+// latency once it is served.
+func (l *AdaptiveLimiter) begin(r *http.Request) (_ *http.Request, done func()) {
+	inFlight := l.inFlight.Add(1)
+	start := l.now()
+
+	l.mu.Lock()
+	if inFlight > l.peak {
+		l.peak = inFlight
+	}
+	l.evaluate(start)
+	factor := l.factor
+	l.mu.Unlock()
+
+	if factor < 1 {
+		r = r.WithContext(context.WithValue(r.Context(), adaptiveFactorContextKey{}, factor))
+	}
+	return r, func() {
+		latency := l.now().Sub(start)
+		l.inFlight.Add(-1)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.samples) < maxLatencySamples {
+			l.samples = append(l.samples, latency)
+			return
+		}
+		l.samples[l.next] = latency
+		l.next = (l.next + 1) % maxLatencySamples
+	}
+}
+
+// evaluate adjusts the factor to the load of the interval ending at now, if it is over.
+// l.mu must be held.
+func (l *AdaptiveLimiter) evaluate(now time.Time) {
+	if l.evaluated.IsZero() {
+		l.evaluated = now
+		return
+	}
+	if now.Sub(l.evaluated) < l.config.Interval {
+		return
+	}
+
+	overloaded := l.config.MaxInFlight > 0 && l.peak > int64(l.config.MaxInFlight)
+	if l.config.MaxLatency > 0 && len(l.samples) > 0 {
+		overloaded = overloaded || percentile(l.samples, 0.99) > l.config.MaxLatency
+	}
+	if overloaded {
+		l.factor = max(l.config.MinFactor, l.factor/2)
+	} else {
+		l.factor = min(1, l.factor+l.config.RecoveryStep)
+	}
+
+	l.samples = l.samples[:0]
+	l.next = 0
+	l.peak = l.inFlight.Load()
+	l.evaluated = now
+}
+
+// percentile returns the q-th quantile of samples, reordering them
+func percentile(samples []time.Duration, q float64) time.Duration {
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	index := int(float64(len(samples))*q+0.5) - 1
+	return samples[min(max(index, 0), len(samples)-1)]
+}
+
+// scaleLimits lowers the complexity and depth limits by the factor the AdaptiveLimiter
+// attached to ctx, if any
+func scaleLimits(ctx context.Context, limits QueryCostLimits) QueryCostLimits {
+	factor, ok := ctx.Value(adaptiveFactorContextKey{}).(float64)
+	if !ok {
+		return limits
+	}
+	limits.MaxComplexity = max(1, int(float64(limits.MaxComplexity)*factor))
+	limits.MaxDepth = max(1, int(float64(limits.MaxDepth)*factor))
+	return limits
+}
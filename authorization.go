@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// AuthorizationRequest describes a field about to be resolved, as given to an
+// AuthorizationPolicy
+type AuthorizationRequest struct {
+	Principal     interface{}            // User details from UserDetailsFn, nil for anonymous requests
+	Scopes        []string               // Scopes granted to the principal (see ScopesFn)
+	Operation     string                 // "query", "mutation" or "subscription"
+	OperationName string                 // Name of the operation, empty when anonymous
+	Field         string                 // Schema coordinate, e.g. "User.email"
+	Path          string                 // Response path, e.g. "users.0.email"
+	Args          map[string]interface{} // Arguments of the field
+}
+
+// AuthorizationPolicy decides whether fields may be resolved, so authorization rules
+// kept in an external engine such as Open Policy Agent or Cedar apply to every field
+// without checks in the resolvers. Authorize is called before each object field is
+// resolved; denied fields resolve to null with a FORBIDDEN error. An error fails the
+// field closed and is reported as is.
+type AuthorizationPolicy interface {
+	Authorize(ctx context.Context, request AuthorizationRequest) (bool, error)
+}
+
+// AuthorizationPolicyFunc adapts a function to an AuthorizationPolicy
+//
+// Example:
+//
+//	policy := graph.AuthorizationPolicyFunc(func(ctx context.Context, req graph.AuthorizationRequest) (bool, error) {
+//	    return opa.Allow(ctx, map[string]interface{}{
+//	        "principal": req.Principal,
+//	        "operation": req.Operation,
+//	        "field":     req.Field,
+//	        "args":      req.Args,
+//	    })
+//	})
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams:        params,
+//	    AuthorizationPolicy: policy,
+//	})
+type AuthorizationPolicyFunc func(ctx context.Context, request AuthorizationRequest) (bool, error)
+
+// Authorize calls f
+func (f AuthorizationPolicyFunc) Authorize(ctx context.Context, request AuthorizationRequest) (bool, error) {
+	return f(ctx, request)
+}
+
+type authorizationContextKey struct{}
+
+// withAuthorizationPolicy attaches the policy applied to the fields resolved for a request
+func withAuthorizationPolicy(r *http.Request, policy AuthorizationPolicy) *http.Request {
+	if policy == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), authorizationContextKey{}, policy))
+}
+
+// authorizeResolvers asks the policy of the request, if any, before resolving a field.
+// The policy is read from the context so handlers sharing a memoized schema can use
+// different policies.
+func authorizeResolvers(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		if p.Context == nil {
+			return next(p)
+		}
+		policy, ok := p.Context.Value(authorizationContextKey{}).(AuthorizationPolicy)
+		if !ok {
+			return next(p)
+		}
+
+		request := AuthorizationRequest{
+			Scopes: scopesOf(p),
+			Field:  p.Info.ParentType.Name() + "." + p.Info.FieldName,
+			Args:   p.Args,
+		}
+		if principal, ok := principalFromContext(p.Context); ok {
+			principal.mu.Lock()
+			request.Principal = principal.details
+			principal.mu.Unlock()
+		} else if root, ok := p.Info.RootValue.(map[string]interface{}); ok {
+			request.Principal = root["details"]
+		}
+		if p.Info.Path != nil {
+			request.Path = explainPath(p.Info.Path.AsArray())
+		}
+		if operation, ok := p.Info.Operation.(*ast.OperationDefinition); ok {
+			request.Operation = operation.Operation
+			if operation.Name != nil {
+				request.OperationName = operation.Name.Value
+			}
+		}
+
+		allowed, err := policy.Authorize(p.Context, request)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, &ForbiddenError{Field: request.Field}
+		}
+		return next(p)
+	}
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RemainingBudget returns the time left before the deadline of ctx, such as the
+// RequestTimeout of NewHTTP or a stage of SplitBudget. It reports false when ctx has
+// no deadline.
+//
+// Example:
+//
+//	if remaining, ok := graph.RemainingBudget(p.Context); ok && remaining < 50*time.Millisecond {
+//	    return cachedSummary(id), nil // not enough time for the full computation
+//	}
+func RemainingBudget(ctx context.Context) (time.Duration, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// BudgetExhaustedError is returned by fields that ran out of their share of the
+// request deadline, so the rest of the operation is served with partial results
+type BudgetExhaustedError struct {
+	Field string
+}
+
+// Error names the field out of time
+func (e *BudgetExhaustedError) Error() string {
+	return "time budget exhausted resolving " + e.Field
+}
+
+// Extensions exposes the BUDGET_EXHAUSTED code in the GraphQL error
+func (e *BudgetExhaustedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "BUDGET_EXHAUSTED", "field": e.Field}
+}
+
+// Budget splits the time left before the deadline of a context across sequential
+// stages, created with SplitBudget
+type Budget struct {
+	ctx    context.Context
+	shares []float64
+	stage  int
+}
+
+// SplitBudget splits the time left before the deadline of ctx across sequential
+// stages in proportion to shares. Each call to Next starts the next stage with its
+// share of the time then left, so time a stage does not use is passed on to the
+// following ones. Without a deadline, stages are not limited.
+//
+// Example:
+//
+//	budget := graph.SplitBudget(ctx, 0.6, 0.4)
+//
+//	ctx1, cancel := budget.Next() // 60% of the remaining time
+//	user, err := users.Get(ctx1, id)
+//	cancel()
+//
+//	ctx2, cancel := budget.Next() // the rest
+//	defer cancel()
+//	orders, err := orders.ForUser(ctx2, user.ID)
+func SplitBudget(ctx context.Context, shares ...float64) *Budget {
+	return &Budget{ctx: ctx, shares: shares}
+}
+
+// Next returns the context of the next stage, whose deadline is the stage's share of
+// the remaining time. Stages beyond the shares get all the remaining time.
+func (b *Budget) Next() (context.Context, context.CancelFunc) {
+	if b.stage >= len(b.shares) {
+		return context.WithCancel(b.ctx)
+	}
+
+	var rest float64
+	for _, share := range b.shares[b.stage:] {
+		rest += share
+	}
+	share := b.shares[b.stage]
+	b.stage++
+
+	remaining, ok := RemainingBudget(b.ctx)
+	if !ok || rest <= 0 {
+		return context.WithCancel(b.ctx)
+	}
+	return context.WithTimeout(b.ctx, time.Duration(float64(remaining)*share/rest))
+}
+
+// BudgetMiddleware gives the resolver of a field share (between 0 and 1) of the time
+// left before the request deadline, through the deadline of its context. A resolver
+// running out of its share fails with BUDGET_EXHAUSTED, leaving the rest of the time
+// to the other fields, so slow operations return partial results rather than all
+// fields timing out at once. Without a request deadline (see
+// GraphContext.RequestTimeout), the field is not limited.
+//
+// Example:
+//
+//	graph.NewResolver[Recommendation]("recommendations").
+//	    WithMiddleware(graph.BudgetMiddleware(0.4)).
+//	    AsList().
+//	    BuildQuery()
+func BudgetMiddleware(share float64) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			remaining, ok := RemainingBudget(p.Context)
+			if !ok || share <= 0 || share >= 1 {
+				return next(p)
+			}
+			field := p.Info.ParentType.Name() + "." + p.Info.FieldName
+			if remaining <= 0 {
+				return nil, &BudgetExhaustedError{Field: field}
+			}
+
+			parent := p.Context
+			ctx, cancel := context.WithTimeout(parent, time.Duration(float64(remaining)*share))
+			p.Context = ctx
+			finish := func(value interface{}, err error) (interface{}, error) {
+				defer cancel()
+				if err != nil && errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+					return nil, &BudgetExhaustedError{Field: field}
+				}
+				return value, err
+			}
+
+			value, err := next(p)
+			if thunk, ok := value.(func() (interface{}, error)); ok && err == nil {
+				return func() (interface{}, error) {
+					return finish(thunk())
+				}, nil
+			}
+			return finish(value, err)
+		}
+	}
+}
+
+// RequireBudget fails a field with BUDGET_EXHAUSTED, without calling its resolver,
+// when less than minimum is left before the request deadline, e.g. the typical latency
+// of its backend
+//
+// Example:
+//
+//	graph.NewResolver[Report]("report").
+//	    WithMiddleware(graph.RequireBudget(200 * time.Millisecond)).
+//	    BuildQuery()
+func RequireBudget(minimum time.Duration) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			if remaining, ok := RemainingBudget(p.Context); ok && remaining < minimum {
+				return nil, &BudgetExhaustedError{Field: p.Info.ParentType.Name() + "." + p.Info.FieldName}
+			}
+			return next(p)
+		}
+	}
+}
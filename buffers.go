@@ -0,0 +1,32 @@
+package graph
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize caps the buffers returned to the pool, so one very large request
+// or response does not keep its memory alive for the lifetime of the process
+const maxPooledBufferSize = 1 << 20
+
+// bufferPool recycles the buffers used to re-read request bodies and capture responses
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return bytes.NewBuffer(make([]byte, 0, responseBufferSize))
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns a buffer to the pool. The buffer and the slices obtained from it
+// must not be used afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
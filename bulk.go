@@ -0,0 +1,289 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// DefaultBulkConcurrency is the number of items a bulk mutation resolves at once
+const DefaultBulkConcurrency = 4
+
+// BulkResult is the outcome of one item of a bulk mutation: the resolved item, or the
+// error that failed it
+type BulkResult[T any] struct {
+	Index int        `json:"index"` // Position of the item in the input list
+	Item  *T         `json:"item"`  // Nil when the item failed
+	Error *BulkError `json:"error"` // Nil when the item succeeded
+}
+
+// BulkError describes why an item of a bulk mutation failed
+type BulkError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"` // The "code" extension of the error, if any
+}
+
+// newBulkError describes err, with the code of errors exposing extensions
+func newBulkError(err error) *BulkError {
+	bulkErr := &BulkError{Message: err.Error()}
+	if extended, ok := err.(interface{ Extensions() map[string]interface{} }); ok {
+		bulkErr.Code, _ = extended.Extensions()["code"].(string)
+	}
+	return bulkErr
+}
+
+// BulkLimitError rejects a bulk mutation with more items than BulkResolver.WithMaxItems
+// allows
+type BulkLimitError struct {
+	Argument string
+	Count    int
+	Max      int
+}
+
+// Error describes the rejected argument
+func (e *BulkLimitError) Error() string {
+	return fmt.Sprintf("argument %q must hold at most %d items, got %d", e.Argument, e.Max, e.Count)
+}
+
+// Extensions exposes the BULK_LIMIT_EXCEEDED code in the GraphQL error
+func (e *BulkLimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "BULK_LIMIT_EXCEEDED", "max": e.Max}
+}
+
+// BulkResolver resolves a list of inputs item by item, with bounded concurrency. An
+// item failing doesn't fail the mutation: every item gets a BulkResult, holding either
+// the resolved item or its error.
+type BulkResolver[T any, I any] struct {
+	base        *UnifiedResolver[T]
+	argName     string
+	isScalar    bool
+	concurrency int
+	maxItems    int
+}
+
+// NewBulkResolver creates a bulk mutation taking a non-null list of I, as input objects
+// for structs or scalars for primitives, in the argument named argName ("input" by
+// default). It returns a non-null list of results, one per input in the same order:
+//
+//	createUsers(input: [CreateUserInput!]!): [UserBulkResult!]!
+//	type UserBulkResult { index: Int! item: User error: BulkError }
+//
+// Example usage:
+//
+//	type CreateUserInput struct {
+//		Name  string `json:"name"`
+//		Email string `json:"email"`
+//	}
+//
+//	NewBulkResolver[User, CreateUserInput]("createUsers").
+//		WithConcurrency(8).
+//		WithMaxItems(100).
+//		WithResolver(func(ctx context.Context, p graph.ResolveParams, input CreateUserInput) (*User, error) {
+//			return userService.Create(ctx, input.Name, input.Email)
+//		}).
+//		BuildMutation()
+func NewBulkResolver[T any, I any](name string, argName ...string) *BulkResolver[T, I] {
+	r := &BulkResolver[T, I]{
+		base:        NewResolver[T](name),
+		argName:     "input",
+		concurrency: DefaultBulkConcurrency,
+	}
+	if len(argName) > 0 && argName[0] != "" {
+		r.argName = argName[0]
+	}
+	r.base.inputName = r.argName
+
+	var input I
+	inputType := reflect.TypeOf(input)
+	var itemType graphql.Input
+	if inputType != nil && inputType.Kind() == reflect.Struct {
+		inputName := inputType.Name()
+		if !strings.HasSuffix(inputName, "Input") {
+			inputName += "Input"
+		}
+		itemType = r.base.generateInputObject(input, inputName)
+		r.base.inputType = input
+		r.base.useInputObject = true
+		r.base.addTaggedSanitizers(inputType, r.argName)
+	} else {
+		itemType = getPrimitiveGraphQLType(inputType)
+		r.isScalar = true
+	}
+	if itemType != nil {
+		r.base.args = graphql.FieldConfigArgument{
+			r.argName: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType))),
+				Description: "Items to process",
+			},
+		}
+	}
+	return r
+}
+
+// WithResolver sets the resolver of one item. It runs concurrently for different items,
+// each with the context and parameters of the mutation field.
+func (r *BulkResolver[T, I]) WithResolver(resolver func(ctx context.Context, p ResolveParams, input I) (*T, error)) *BulkResolver[T, I] {
+	r.base.resolver = func(p graphql.ResolveParams) (interface{}, error) {
+		items, _ := p.Args[r.argName].([]interface{})
+		if r.maxItems > 0 && len(items) > r.maxItems {
+			return nil, &BulkLimitError{Argument: r.argName, Count: len(items), Max: r.maxItems}
+		}
+
+		ctx := p.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		results := make([]BulkResult[T], len(items))
+		slots := make(chan struct{}, r.concurrency)
+		var wg sync.WaitGroup
+		for i, item := range items {
+			results[i].Index = i
+			// Items not started by the end of the request fail with its error
+			if ctx.Err() != nil {
+				results[i].Error = newBulkError(ctx.Err())
+				continue
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				results[i].Error = newBulkError(ctx.Err())
+				continue
+			}
+
+			wg.Add(1)
+			go func(result *BulkResult[T], item interface{}) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				// A panic would otherwise take down the process: the executor only
+				// recovers panics of the goroutine resolving the field
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						result.Item = nil
+						result.Error = &BulkError{Message: fmt.Sprintf("panic resolving item %d: %v", result.Index, recovered)}
+					}
+				}()
+
+				input, err := r.decodeItem(item)
+				if err == nil {
+					result.Item, err = resolver(ctx, ResolveParams(p), input)
+				}
+				if err != nil {
+					result.Item = nil
+					result.Error = newBulkError(err)
+				}
+			}(&results[i], item)
+		}
+		wg.Wait()
+		return results, nil
+	}
+	return r
+}
+
+// decodeItem converts an item of the input list to I
+func (r *BulkResolver[T, I]) decodeItem(item interface{}) (I, error) {
+	var input I
+	if r.isScalar {
+		if err := setFieldValue(reflect.ValueOf(&input).Elem(), item); err != nil {
+			return input, fmt.Errorf("failed to parse item: %w", err)
+		}
+		return input, nil
+	}
+	fields, _ := item.(map[string]interface{})
+	if err := mapArgsToStruct(fields, &input); err != nil {
+		return input, fmt.Errorf("failed to parse item: %w", err)
+	}
+	return input, nil
+}
+
+// WithConcurrency sets the number of items resolved at once. Default: DefaultBulkConcurrency
+func (r *BulkResolver[T, I]) WithConcurrency(n int) *BulkResolver[T, I] {
+	if n > 0 {
+		r.concurrency = n
+	}
+	return r
+}
+
+// WithMaxItems rejects mutations with more than n items with a BULK_LIMIT_EXCEEDED error,
+// before any item is resolved
+func (r *BulkResolver[T, I]) WithMaxItems(n int) *BulkResolver[T, I] {
+	r.maxItems = n
+	return r
+}
+
+// WithDescription sets the field description
+func (r *BulkResolver[T, I]) WithDescription(desc string) *BulkResolver[T, I] {
+	r.base.WithDescription(desc)
+	return r
+}
+
+// WithMiddleware adds middleware around the whole mutation, not each item
+func (r *BulkResolver[T, I]) WithMiddleware(middleware FieldMiddleware) *BulkResolver[T, I] {
+	r.base.WithMiddleware(middleware)
+	return r
+}
+
+// BuildMutation builds and returns a MutationField
+func (r *BulkResolver[T, I]) BuildMutation() MutationField {
+	r.base.isMutation = true
+	return r
+}
+
+// Name returns the field name used in the GraphQL schema
+func (r *BulkResolver[T, I]) Name() string {
+	return r.base.Name()
+}
+
+// Serve returns the mutation field, whose type is the list of results of the item type
+func (r *BulkResolver[T, I]) Serve() *graphql.Field {
+	field := r.base.Serve()
+	// The error type is created before the result type: a registry can't create types
+	// while creating one
+	errorType := typeRegistry.loadOrCreate("BulkError", newBulkErrorType)
+	itemType := field.Type
+	resultType := typeRegistry.loadOrCreate(r.base.objectName+"BulkResult", func() *graphql.Object {
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name:        r.base.objectName + "BulkResult",
+			Description: "Outcome of one item of a bulk mutation",
+			Fields: graphql.Fields{
+				"index": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "Position of the item in the input list",
+				},
+				"item": &graphql.Field{
+					Type:        itemType,
+					Description: "Resolved item, null when the item failed",
+				},
+				"error": &graphql.Field{
+					Type:        errorType,
+					Description: "Why the item failed, null when it succeeded",
+				},
+			},
+		})
+	})
+	field.Type = graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(resultType)))
+	return field
+}
+
+// newBulkErrorType creates the type of the errors of bulk mutation items
+func newBulkErrorType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        "BulkError",
+		Description: "Error of one item of a bulk mutation",
+		Fields: graphql.Fields{
+			"message": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"code": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if bulkErr, ok := p.Source.(*BulkError); ok && bulkErr.Code != "" {
+						return bulkErr.Code, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,282 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// CacheScope tells shared caches (CDNs) whether a response may be served to other users
+type CacheScope string
+
+const (
+	// CacheScopePublic responses may be cached by shared caches
+	CacheScopePublic CacheScope = "PUBLIC"
+	// CacheScopePrivate responses depend on the user and may only be cached by the client
+	CacheScopePrivate CacheScope = "PRIVATE"
+)
+
+// CacheHint is the cache policy of a field: how long its value stays fresh, in seconds,
+// and who may cache it
+type CacheHint struct {
+	MaxAge int        `json:"maxAge"`
+	Scope  CacheScope `json:"scope"`
+}
+
+// CacheControlConfig configures the cache policy computed from field hints.
+//
+// Example:
+//
+//	graphCtx := &graph.GraphContext{
+//	    SchemaParams: params,
+//	    CacheControl: &graph.CacheControlConfig{
+//	        DefaultMaxAge: 0,
+//	        // Internal dashboards may accept responses up to 10 minutes old
+//	        TrustClientMaxAge: func(r *http.Request) bool {
+//	            id, ok := graph.ClientIdentityFromRequest(r)
+//	            return ok && id.Matches("dashboards.internal")
+//	        },
+//	        MaxClientMaxAge: 600,
+//	    },
+//	}
+type CacheControlConfig struct {
+	// DefaultMaxAge applies to root fields and fields returning objects or lists that
+	// have no hint, in seconds. The default 0 makes such responses uncacheable, so
+	// only operations whose fields all have hints are cached.
+	DefaultMaxAge int
+
+	// TrustClientMaxAge reports whether the client of a request may choose the max age of
+	// its response with the "cacheControl": {"maxAge": N} request extension, so trusted
+	// internal clients such as dashboards get relaxed freshness without schema changes.
+	// Nil trusts no client.
+	TrustClientMaxAge func(r *http.Request) bool
+
+	// MaxClientMaxAge bounds the max age trusted clients may choose, in seconds. Client
+	// max ages are ignored while it is 0.
+	MaxClientMaxAge int
+}
+
+// clientMaxAge returns the max age the client of r chose, bounded by MaxClientMaxAge
+func (c *CacheControlConfig) clientMaxAge(r *http.Request) (int, bool) {
+	if c.TrustClientMaxAge == nil || c.MaxClientMaxAge <= 0 {
+		return 0, false
+	}
+	hint, ok := requestExtensions(r)["cacheControl"].(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	maxAge, ok := hint["maxAge"].(float64)
+	if !ok || maxAge < 0 || !c.TrustClientMaxAge(r) {
+		return 0, false
+	}
+	if maxAge > float64(c.MaxClientMaxAge) {
+		return c.MaxClientMaxAge, true
+	}
+	return int(maxAge), true
+}
+
+// cacheControlScopeEnum is the type of the scope argument of @cacheControl
+var cacheControlScopeEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "CacheControlScope",
+	Values: graphql.EnumValueConfigMap{
+		string(CacheScopePublic):  &graphql.EnumValueConfig{Value: string(CacheScopePublic)},
+		string(CacheScopePrivate): &graphql.EnumValueConfig{Value: string(CacheScopePrivate)},
+	},
+})
+
+// CacheControlDirective declares @cacheControl(maxAge, scope), the cache hint of a field
+// or type, as understood by Apollo tooling and CDN integrations. It is added to built
+// schemas when GraphContext.CacheControl is set. Schemas built in code attach hints
+// with CacheControl, WithCacheControl or SetCacheHint.
+var CacheControlDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "cacheControl",
+	Description: "Cache policy of a field or type: maximum age in seconds and scope.",
+	Locations: []string{
+		graphql.DirectiveLocationFieldDefinition,
+		graphql.DirectiveLocationObject,
+		graphql.DirectiveLocationInterface,
+		graphql.DirectiveLocationUnion,
+	},
+	Args: graphql.FieldConfigArgument{
+		"maxAge":        &graphql.ArgumentConfig{Type: graphql.Int},
+		"scope":         &graphql.ArgumentConfig{Type: cacheControlScopeEnum},
+		"inheritMaxAge": &graphql.ArgumentConfig{Type: graphql.Boolean},
+	},
+})
+
+// cachePolicy aggregates the cache hints of one operation
+type cachePolicy struct {
+	mu         sync.Mutex
+	defaultAge int
+	operation  string
+	hints      map[string]CacheHint // By response path
+	maxAge     int
+	hinted     bool
+	private    bool
+
+	clientMaxAge int // Max age chosen by a trusted client, when clientHinted
+	clientHinted bool
+}
+
+type cachePolicyContextKey struct{}
+
+// cachePolicyFrom returns the cache policy of the operation being executed, if any
+func cachePolicyFrom(ctx context.Context) *cachePolicy {
+	if ctx == nil {
+		return nil
+	}
+	policy, _ := ctx.Value(cachePolicyContextKey{}).(*cachePolicy)
+	return policy
+}
+
+// restrict lowers the policy to hint
+func (c *cachePolicy) restrict(path string, hint CacheHint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hints == nil {
+		c.hints = make(map[string]CacheHint)
+	}
+	c.hints[path] = hint
+	if !c.hinted || hint.MaxAge < c.maxAge {
+		c.maxAge = hint.MaxAge
+	}
+	c.hinted = true
+	if hint.Scope == CacheScopePrivate {
+		c.private = true
+	}
+}
+
+// hintedAt reports whether the field at path received a hint
+func (c *cachePolicy) hintedAt(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.hints[path]
+	return ok
+}
+
+// result returns the aggregated hint of the operation, and whether it may be cached
+func (c *cachePolicy) result() (CacheHint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hint := CacheHint{MaxAge: c.maxAge, Scope: CacheScopePublic}
+	if c.private {
+		hint.Scope = CacheScopePrivate
+	}
+	if c.clientHinted {
+		hint.MaxAge = c.clientMaxAge
+	}
+	cacheable := (c.hinted || c.clientHinted) && hint.MaxAge > 0 && c.operation == ast.OperationTypeQuery
+	if !cacheable {
+		hint.MaxAge = 0
+	}
+	return hint, cacheable
+}
+
+// SetCacheHint restricts the cache policy of the response to hint, for policies known
+// only at resolution time, e.g. a shorter max age for items about to change. The
+// response is cached for the lowest max age of its fields, privately if any field is
+// private.
+//
+// Example:
+//
+//	graph.SetCacheHint(p, graph.CacheHint{MaxAge: 30, Scope: graph.CacheScopePrivate})
+func SetCacheHint(p ResolveParams, hint CacheHint) {
+	policy := cachePolicyFrom(p.Context)
+	if policy == nil || p.Info.Path == nil {
+		return
+	}
+	policy.restrict(explainPath(p.Info.Path.AsArray()), hint)
+}
+
+// CacheControl is a middleware giving a field a static cache hint, the code-first
+// equivalent of @cacheControl(maxAge: maxAge, scope: scope) on the field.
+//
+// Example:
+//
+//	graph.NewResolver[Product]("products").
+//	    WithMiddleware(graph.CacheControl(300, graph.CacheScopePublic)).
+//	    WithFieldMiddleware("stock", graph.CacheControl(10, graph.CacheScopePublic)).
+//	    BuildListQuery()
+func CacheControl(maxAge int, scope CacheScope) FieldMiddleware {
+	hint := CacheHint{MaxAge: maxAge, Scope: scope}
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			SetCacheHint(p, hint)
+			return next(p)
+		}
+	}
+}
+
+// WithCacheControl gives the resolver's field a cache hint (see CacheControl)
+func (r *UnifiedResolver[T]) WithCacheControl(maxAge int, scope CacheScope) *UnifiedResolver[T] {
+	return r.WithMiddleware(CacheControl(maxAge, scope))
+}
+
+// cacheControlResolvers applies the default max age to root fields and fields returning
+// objects or lists that received no hint. Scalar fields inherit the policy of their parent.
+func cacheControlResolvers(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		policy := cachePolicyFrom(p.Context)
+		if policy == nil || p.Info.Path == nil {
+			return next(p)
+		}
+
+		value, err := next(p)
+
+		path := explainPath(p.Info.Path.AsArray())
+		if (p.Info.Path.Prev == nil || isCompositeOutput(p.Info.ReturnType)) && !policy.hintedAt(path) {
+			policy.restrict(path, CacheHint{MaxAge: policy.defaultAge, Scope: CacheScopePublic})
+		}
+		return value, err
+	}
+}
+
+// isCompositeOutput reports whether a field type is an object, interface, union or list
+func isCompositeOutput(t graphql.Output) bool {
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+	}
+	switch t.(type) {
+	case *graphql.Object, *graphql.Interface, *graphql.Union, *graphql.List:
+		return true
+	}
+	return false
+}
+
+// cacheControlRequest attaches a cache policy to the request and returns the decorator
+// setting the Cache-Control header and the cacheControl extension from it
+func cacheControlRequest(r *http.Request, config *CacheControlConfig, header http.Header) (*http.Request, ResponseDecorator) {
+	policy := &cachePolicy{defaultAge: config.DefaultMaxAge}
+	policy.clientMaxAge, policy.clientHinted = config.clientMaxAge(r)
+	if opts := peekRequestOptions(r); opts.Query != "" {
+		if op := selectOperation(opts.Query, opts.OperationName); op != nil {
+			policy.operation = op.Operation
+		}
+	}
+	r = r.WithContext(context.WithValue(r.Context(), cachePolicyContextKey{}, policy))
+
+	return r, func(ctx context.Context, response *graphql.Result) {
+		hint, cacheable := policy.result()
+		if len(response.Errors) > 0 {
+			hint.MaxAge, cacheable = 0, false
+		}
+
+		if cacheable {
+			header.Set("Cache-Control", fmt.Sprintf("max-age=%d, %s", hint.MaxAge, strings.ToLower(string(hint.Scope))))
+		} else {
+			header.Set("Cache-Control", "no-store")
+		}
+
+		if response.Extensions == nil {
+			response.Extensions = make(map[string]interface{})
+		}
+		response.Extensions["cacheControl"] = hint
+	}
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+// ClientIdentity is the identity of a client authenticated by a verified TLS client
+// certificate (mutual TLS)
+type ClientIdentity struct {
+	CommonName     string   // Subject common name
+	DNSNames       []string // DNS subject alternative names
+	EmailAddresses []string // Email subject alternative names
+	URIs           []string // URI subject alternative names, e.g. SPIFFE IDs
+	Certificate    *x509.Certificate
+}
+
+// Name returns the principal name of the client: its first URI, DNS or email subject
+// alternative name, in that order, or its common name
+func (id *ClientIdentity) Name() string {
+	for _, names := range [][]string{id.URIs, id.DNSNames, id.EmailAddresses} {
+		if len(names) > 0 {
+			return names[0]
+		}
+	}
+	return id.CommonName
+}
+
+// Matches reports whether name is the common name or one of the subject alternative
+// names of the client
+func (id *ClientIdentity) Matches(name string) bool {
+	if name == id.CommonName {
+		return true
+	}
+	for _, names := range [][]string{id.URIs, id.DNSNames, id.EmailAddresses} {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClientIdentityFromRequest returns the identity of the verified client certificate of
+// a request. Certificates the server did not verify, as with tls.RequestClientCert, are
+// ignored.
+func ClientIdentityFromRequest(r *http.Request) (*ClientIdentity, bool) {
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+	id := &ClientIdentity{
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		Certificate:    cert,
+	}
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+	return id, true
+}
+
+// ClientIdentityFromContext returns the client certificate identity of the request
+// served by NewHTTP, for use in resolvers and authorization middleware.
+//
+// Example:
+//
+//	if id, ok := graph.ClientIdentityFromContext(p.Context); ok && id.Matches("billing.internal") {
+//	    // trusted service
+//	}
+func ClientIdentityFromContext(ctx context.Context) (*ClientIdentity, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	r, ok := RequestFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return ClientIdentityFromRequest(r)
+}
+
+// RequireClientCertificate is a permission middleware allowing only clients with a
+// verified TLS client certificate, matching one of names when any are given.
+//
+// Example:
+//
+//	graph.NewResolver[Invoice]("invoices").
+//	    WithPermission(graph.RequireClientCertificate("billing.internal")).
+//	    BuildListQuery()
+func RequireClientCertificate(names ...string) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			id, ok := ClientIdentityFromContext(p.Context)
+			if !ok {
+				return nil, fmt.Errorf("client certificate required")
+			}
+			if len(names) == 0 {
+				return next(p)
+			}
+			for _, name := range names {
+				if id.Matches(name) {
+					return next(p)
+				}
+			}
+			return nil, fmt.Errorf("insufficient permissions")
+		}
+	}
+}
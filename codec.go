@@ -0,0 +1,92 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/handler"
+)
+
+// Codec encodes and decodes the JSON of requests and responses. The configuration
+// objects of github.com/json-iterator/go and github.com/bytedance/sonic implement it,
+// e.g. jsoniter.ConfigCompatibleWithStandardLibrary or sonic.ConfigStd.
+//
+// Decoded values must follow encoding/json: objects as map[string]interface{}, arrays
+// as []interface{} and numbers as float64.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// StdCodec is the default Codec, backed by encoding/json
+type StdCodec struct{}
+
+// Marshal calls json.Marshal
+func (StdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal calls json.Unmarshal
+func (StdCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// codecFor returns the configured codec, or encoding/json
+func codecFor(graphCtx *GraphContext) Codec {
+	if graphCtx == nil || graphCtx.Codec == nil {
+		return StdCodec{}
+	}
+	return graphCtx.Codec
+}
+
+// encodeJSON encodes v with codec, indented with tabs when pretty is set
+func encodeJSON(codec Codec, v interface{}, pretty bool) ([]byte, error) {
+	data, err := codec.Marshal(v)
+	if err != nil || !pretty {
+		return data, err
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "\t"); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+// requestOptions parses the GraphQL request options of r like handler.NewRequestOptions,
+// decoding JSON bodies and URL variables with codec
+func requestOptions(r *http.Request, codec Codec) *handler.RequestOptions {
+	// Parameters of the URL take precedence over the body
+	if queryParam(r.URL.RawQuery, "query") != "" {
+		opts, _ := getRequestOptions(r, codec)
+		return opts
+	}
+	if _, ok := codec.(StdCodec); ok || r.Method != http.MethodPost || r.Body == nil {
+		return handler.NewRequestOptions(r)
+	}
+	switch strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]) {
+	case handler.ContentTypeGraphQL, handler.ContentTypeFormURLEncoded:
+		return handler.NewRequestOptions(r)
+	}
+
+	var opts handler.RequestOptions
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return &opts
+	}
+	if err := codec.Unmarshal(body, &opts); err != nil {
+		// Variables sent as a JSON string rather than an object
+		var compatible struct {
+			Query         string `json:"query"`
+			Variables     string `json:"variables"`
+			OperationName string `json:"operationName"`
+		}
+		_ = codec.Unmarshal(body, &compatible)
+		opts = handler.RequestOptions{Query: compatible.Query, OperationName: compatible.OperationName}
+		_ = codec.Unmarshal([]byte(compatible.Variables), &opts.Variables)
+	}
+	return &opts
+}
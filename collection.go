@@ -0,0 +1,225 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// CollectionFormat is the API client format written by ExportCollection
+type CollectionFormat string
+
+const (
+	// PostmanCollection is a Postman collection, format v2.1
+	PostmanCollection CollectionFormat = "postman"
+	// InsomniaCollection is an Insomnia export, format 4
+	InsomniaCollection CollectionFormat = "insomnia"
+)
+
+// collectionURLVariable holds the endpoint URL in exported collections, so it can be
+// switched between environments in the API client
+const collectionURLVariable = "graphqlUrl"
+
+// CollectionOptions configures ExportCollection
+type CollectionOptions struct {
+	// Name of the collection. Default: "GraphQL API"
+	Name string
+
+	// Endpoint is the URL of the GraphQL endpoint, stored in the graphqlUrl variable of
+	// the collection. Default: "http://localhost:8080/graphql"
+	Endpoint string
+
+	// Headers are sent with every request, e.g. {"Authorization": "Bearer {{token}}"}
+	// with a variable of the API client
+	Headers map[string]string
+}
+
+// ExportCollection turns schema into a collection for Postman or Insomnia, with a
+// request per query and mutation root field, grouped in a folder per operation type.
+// Each request runs the operation of GenerateExampleQueries with its example variables,
+// ready to be edited and handed to QA or partner teams. Subscriptions are left out.
+//
+// Example:
+//
+//	collection, err := graph.ExportCollection(&schema, graph.PostmanCollection, graph.CollectionOptions{
+//	    Name:     "Orders API",
+//	    Endpoint: "https://staging.example.com/graphql",
+//	    Headers:  map[string]string{"Authorization": "Bearer {{token}}"},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("orders.postman_collection.json", collection, 0o644)
+func ExportCollection(schema *graphql.Schema, format CollectionFormat, options CollectionOptions) ([]byte, error) {
+	if options.Name == "" {
+		options.Name = "GraphQL API"
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = "http://localhost:8080/graphql"
+	}
+
+	var requests []collectionRequest
+	for _, example := range GenerateExampleQueries(schema) {
+		if example.Operation == "subscription" {
+			continue
+		}
+		request := collectionRequest{ExampleQuery: example, folder: "Query"}
+		root := schema.QueryType()
+		if example.Operation == "mutation" {
+			request.folder = "Mutation"
+			root = schema.MutationType()
+		}
+		if field, ok := root.Fields()[example.Field]; ok {
+			request.description = field.Description
+		}
+		requests = append(requests, request)
+	}
+
+	var collection interface{}
+	switch format {
+	case PostmanCollection:
+		collection = postmanCollection(requests, options)
+	case InsomniaCollection:
+		collection = insomniaCollection(requests, options)
+	default:
+		return nil, fmt.Errorf("graph: unknown collection format %q", format)
+	}
+	return json.MarshalIndent(collection, "", "  ")
+}
+
+// collectionRequest is an example operation exported as a request
+type collectionRequest struct {
+	ExampleQuery
+	folder      string // "Query" or "Mutation"
+	description string
+}
+
+// variablesJSON returns the example variables as indented JSON text, "{}" without any
+func (r collectionRequest) variablesJSON() string {
+	if len(r.Variables) == 0 {
+		return "{}"
+	}
+	variables, _ := json.MarshalIndent(r.Variables, "", "  ")
+	return string(variables)
+}
+
+// collectionHeaders returns the headers of every request, sorted by name
+func collectionHeaders(options CollectionOptions) [][2]string {
+	headers := [][2]string{{"Content-Type", "application/json"}}
+	names := make([]string, 0, len(options.Headers))
+	for name := range options.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		headers = append(headers, [2]string{name, options.Headers[name]})
+	}
+	return headers
+}
+
+// postmanCollection returns the Postman collection (format v2.1) of requests
+func postmanCollection(requests []collectionRequest, options CollectionOptions) map[string]interface{} {
+	var headers []map[string]string
+	for _, header := range collectionHeaders(options) {
+		headers = append(headers, map[string]string{"key": header[0], "value": header[1]})
+	}
+	url := "{{" + collectionURLVariable + "}}"
+
+	var folders []map[string]interface{}
+	byFolder := make(map[string]*[]map[string]interface{})
+	for _, request := range requests {
+		items, ok := byFolder[request.folder]
+		if !ok {
+			items = &[]map[string]interface{}{}
+			byFolder[request.folder] = items
+			folders = append(folders, map[string]interface{}{"name": request.folder})
+		}
+		*items = append(*items, map[string]interface{}{
+			"name": request.Field,
+			"request": map[string]interface{}{
+				"method":      "POST",
+				"header":      headers,
+				"description": request.description,
+				"body": map[string]interface{}{
+					"mode": "graphql",
+					"graphql": map[string]string{
+						"query":     request.Query,
+						"variables": request.variablesJSON(),
+					},
+				},
+				"url": map[string]interface{}{"raw": url, "host": []string{url}},
+			},
+		})
+	}
+	for _, folder := range folders {
+		folder["item"] = *byFolder[folder["name"].(string)]
+	}
+
+	return map[string]interface{}{
+		"info": map[string]string{
+			"name":   options.Name,
+			"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json",
+		},
+		"item":     folders,
+		"variable": []map[string]string{{"key": collectionURLVariable, "value": options.Endpoint}},
+	}
+}
+
+// insomniaCollection returns the Insomnia export (format 4) of requests
+func insomniaCollection(requests []collectionRequest, options CollectionOptions) map[string]interface{} {
+	var headers []map[string]string
+	for _, header := range collectionHeaders(options) {
+		headers = append(headers, map[string]string{"name": header[0], "value": header[1]})
+	}
+	const workspaceID = "wrk_graph"
+
+	resources := []map[string]interface{}{
+		{"_id": workspaceID, "_type": "workspace", "parentId": nil, "name": options.Name, "scope": "collection"},
+		{
+			"_id":      "env_graph",
+			"_type":    "environment",
+			"parentId": workspaceID,
+			"name":     "Base Environment",
+			"data":     map[string]string{collectionURLVariable: options.Endpoint},
+		},
+	}
+	folders := make(map[string]bool)
+	for _, request := range requests {
+		folderID := "fld_" + strings.ToLower(request.folder)
+		if !folders[folderID] {
+			folders[folderID] = true
+			resources = append(resources, map[string]interface{}{
+				"_id":      folderID,
+				"_type":    "request_group",
+				"parentId": workspaceID,
+				"name":     request.folder,
+			})
+		}
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"query":     request.Query,
+			"variables": json.RawMessage(request.variablesJSON()),
+		})
+		resources = append(resources, map[string]interface{}{
+			"_id":         "req_" + strings.ToLower(request.folder) + "_" + request.Field,
+			"_type":       "request",
+			"parentId":    folderID,
+			"name":        request.Field,
+			"description": request.description,
+			"method":      "POST",
+			"url":         "{{ _." + collectionURLVariable + " }}",
+			"headers":     headers,
+			"body":        map[string]string{"mimeType": "application/graphql", "text": string(body)},
+		})
+	}
+
+	return map[string]interface{}{
+		"_type":           "export",
+		"__export_format": 4,
+		"__export_source": "go-graph",
+		"resources":       resources,
+	}
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minCompressSize is the smallest body compressed: below it, the gzip header and
+// footer outweigh the savings
+const minCompressSize = 1 << 10
+
+// gzipWriters pools gzip writers, which allocate large tables when created
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of r allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		if name = strings.TrimSpace(name); name != "gzip" && name != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressResponse gzips the bodies of responses to clients accepting it. ETags set
+// before compression are weakened, since they were computed over the identity coding.
+func compressResponse(r *http.Request, response *Response) {
+	response.Header.Add("Vary", "Accept-Encoding")
+
+	body := response.Body()
+	if len(body) < minCompressSize || response.Header.Get("Content-Encoding") != "" || !acceptsGzip(r) {
+		return
+	}
+
+	var compressed bytes.Buffer
+	gz := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(gz)
+	gz.Reset(&compressed)
+	if _, err := gz.Write(body); err != nil {
+		return
+	}
+	if err := gz.Close(); err != nil {
+		return
+	}
+
+	response.Header.Set("Content-Encoding", "gzip")
+	response.Header.Del("Content-Length")
+	if etag := response.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		response.Header.Set("ETag", "W/"+etag)
+	}
+	response.SetBody(compressed.Bytes())
+}
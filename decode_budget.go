@@ -0,0 +1,225 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Estimated bytes allocated on 64-bit platforms when JSON decodes into interface{}
+// values: boxed values, string headers and map or slice bookkeeping
+const (
+	decodedValueSize = 16 // interface{} holding a value
+	decodedNumber    = 8  // float64 boxed in an interface{}
+	decodedString    = 16 // string header boxed in an interface{}
+	decodedObject    = 48 // map header
+	decodedEntry     = 40 // map bucket slot: key header, value interface{} and overhead
+	decodedArray     = 24 // slice header
+)
+
+// DecodeBudgetError reports a request whose variables or input objects would take more
+// memory to decode than GraphContext.DecodeBudget allows
+type DecodeBudgetError struct {
+	Budget   int64
+	Argument string // Input object argument, or "" for the request body
+}
+
+// Error describes the input that exceeds the budget
+func (e *DecodeBudgetError) Error() string {
+	if e.Argument == "" {
+		return fmt.Sprintf("request exceeds the decode budget of %d bytes", e.Budget)
+	}
+	return fmt.Sprintf("argument %q exceeds the decode budget of %d bytes", e.Argument, e.Budget)
+}
+
+// Extensions exposes the DECODE_BUDGET_EXCEEDED code in the GraphQL error
+func (e *DecodeBudgetError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "DECODE_BUDGET_EXCEEDED", "budget": e.Budget}
+}
+
+// decodeBudget is the memory a request may still spend decoding inputs, shared by the
+// request body and the resolvers of the request
+type decodeBudget struct {
+	limit     int64
+	remaining int64
+}
+
+// charge spends n bytes of the budget, and reports whether it was not exceeded
+func (b *decodeBudget) charge(n int64) bool {
+	return atomic.AddInt64(&b.remaining, -n) >= 0
+}
+
+type decodeBudgetContextKey struct{}
+
+// withDecodeBudget gives the request the decode budget of graphCtx, if any
+func withDecodeBudget(r *http.Request, graphCtx *GraphContext) *http.Request {
+	if graphCtx.DecodeBudget <= 0 {
+		return r
+	}
+	budget := &decodeBudget{limit: graphCtx.DecodeBudget, remaining: graphCtx.DecodeBudget}
+	return r.WithContext(context.WithValue(r.Context(), decodeBudgetContextKey{}, budget))
+}
+
+// decodeBudgetFrom returns the decode budget of the request being served, or nil
+func decodeBudgetFrom(ctx context.Context) *decodeBudget {
+	if ctx == nil {
+		return nil
+	}
+	budget, _ := ctx.Value(decodeBudgetContextKey{}).(*decodeBudget)
+	return budget
+}
+
+// checkDecodeBudget charges the decode budget of r with the memory its body, and the
+// variables it carries, will take once decoded. The body is read into a pooled buffer,
+// no further than the budget, and restored for later reads from that buffer; the caller
+// returns the buffer with putBuffer once the request is served.
+func checkDecodeBudget(r *http.Request) (*bytes.Buffer, *DecodeBudgetError) {
+	budget := decodeBudgetFrom(r.Context())
+	if budget == nil {
+		return nil, nil
+	}
+	exceeded := &DecodeBudgetError{Budget: budget.limit}
+
+	if variables := queryParam(r.URL.RawQuery, "variables"); variables != "" {
+		if !budget.charge(decodedJSONSize([]byte(variables))) {
+			return nil, exceeded
+		}
+	}
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil, nil
+	}
+
+	buf := getBuffer()
+	// One byte past the budget tells bodies over it from bodies that fill it
+	if _, err := buf.ReadFrom(io.LimitReader(r.Body, budget.limit+1)); err != nil {
+		putBuffer(buf)
+		return nil, nil
+	}
+	body := buf.Bytes()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if !budget.charge(int64(len(body))) {
+		return buf, exceeded
+	}
+
+	cost := decodedJSONSize(body)
+	if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return buf, nil
+		}
+		cost = decodedJSONSize([]byte(form.Get("variables")))
+	}
+	if !budget.charge(cost) {
+		return buf, exceeded
+	}
+	return buf, nil
+}
+
+// writeDecodeBudgetExceeded rejects a request over its decode budget
+func writeDecodeBudgetExceeded(w http.ResponseWriter, err *DecodeBudgetError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    err.Error(),
+			"extensions": err.Extensions(),
+		}},
+	})
+}
+
+// decodedJSONSize estimates the bytes allocated decoding data into interface{} values.
+// Data is scanned token by token, so the estimate costs no more than the largest
+// string of data. Malformed JSON is estimated up to the error.
+func decodedJSONSize(data []byte) int64 {
+	var size int64
+	var stack []bool // Whether each open container is an object
+	expectKey := false
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return size
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			expectKey = len(stack) > 0 && stack[len(stack)-1]
+			continue
+		}
+		if expectKey {
+			// Object key
+			size += decodedEntry + int64(len(token.(string)))
+			expectKey = false
+			continue
+		}
+		if n := len(stack); n > 0 {
+			if stack[n-1] {
+				expectKey = true
+			} else {
+				size += decodedValueSize // Slice element
+			}
+		}
+
+		switch v := token.(type) {
+		case json.Delim:
+			object := v == '{'
+			stack = append(stack, object)
+			expectKey = object
+			if object {
+				size += decodedObject
+			} else {
+				size += decodedArray
+			}
+		case string:
+			size += decodedString + int64(len(v))
+		case json.Number:
+			size += decodedNumber
+		}
+	}
+}
+
+// decodedValueBytes estimates the bytes allocated by a decoded input value
+func decodedValueBytes(value interface{}) int64 {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		size := int64(decodedObject)
+		for key, field := range v {
+			size += decodedEntry + int64(len(key)) + decodedValueBytes(field)
+		}
+		return size
+	case []interface{}:
+		size := int64(decodedArray)
+		for _, item := range v {
+			size += decodedValueSize + decodedValueBytes(item)
+		}
+		return size
+	case string:
+		return decodedString + int64(len(v))
+	case nil, bool:
+		return 0
+	}
+	return decodedNumber
+}
+
+// chargeInputObject charges the decode budget of the request with the memory an input
+// object argument takes once decoded into its struct, failing the field when the budget
+// is exceeded
+func chargeInputObject(resolve graphql.FieldResolveFn, argName string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if budget := decodeBudgetFrom(p.Context); budget != nil {
+			if !budget.charge(decodedValueBytes(p.Args[argName])) {
+				return nil, &DecodeBudgetError{Budget: budget.limit, Argument: argName}
+			}
+		}
+		return resolve(p)
+	}
+}
@@ -0,0 +1,37 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ResponseDecorator edits a GraphQL response before it is serialized, typically to
+// attach metadata to its extensions.
+//
+// Example:
+//
+//	ResponseDecorator: func(ctx context.Context, response *graphql.Result) {
+//	    if response.Extensions == nil {
+//	        response.Extensions = map[string]interface{}{}
+//	    }
+//	    response.Extensions["service"] = map[string]interface{}{
+//	        "version": version,
+//	        "region":  os.Getenv("REGION"),
+//	    }
+//	}
+type ResponseDecorator func(ctx context.Context, response *graphql.Result)
+
+// decodeResult decodes a JSON GraphQL response, keeping numbers exact
+func decodeResult(body []byte) (*graphql.Result, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var result graphql.Result
+	if err := decoder.Decode(&result); err != nil {
+		return nil, false
+	}
+	return &result, true
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// DefaultedField is a field that resolved to its declared default, as listed by path in
+// the defaults extension when GraphContext.ReportDefaults is set
+type DefaultedField struct {
+	Path   string `json:"path"`   // Response path, e.g. "user.avatar"
+	Field  string `json:"field"`  // Schema coordinate, e.g. "User.avatar"
+	Reason string `json:"reason"` // "error" when the resolver failed, "null" when it returned nil
+}
+
+// defaultsReport collects the defaulted fields of a request
+type defaultsReport struct {
+	mu     sync.Mutex
+	fields []DefaultedField
+}
+
+type defaultsContextKey struct{}
+
+// defaultsRequest attaches a report of defaulted fields to the request and returns the
+// decorator adding them to the extensions of its response
+func defaultsRequest(r *http.Request) (*http.Request, ResponseDecorator) {
+	report := &defaultsReport{}
+	r = r.WithContext(context.WithValue(r.Context(), defaultsContextKey{}, report))
+
+	return r, func(ctx context.Context, response *graphql.Result) {
+		report.mu.Lock()
+		defer report.mu.Unlock()
+		if len(report.fields) == 0 {
+			return
+		}
+		// Fields resolve concurrently, so list them by path for a stable response
+		sort.SliceStable(report.fields, func(i, j int) bool { return report.fields[i].Path < report.fields[j].Path })
+		if response.Extensions == nil {
+			response.Extensions = make(map[string]interface{})
+		}
+		response.Extensions["defaults"] = report.fields
+	}
+}
+
+// DefaultValue is a middleware resolving a field to value when its resolver fails or
+// returns null, so the rest of the response keeps rendering. The error is dropped from
+// the response; set GraphContext.ReportDefaults to list the defaulted fields in the
+// defaults extension. Errors of the middleware added after it are covered too.
+//
+// Example:
+//
+//	graph.NewResolver[Settings]("settings").
+//	    WithMiddleware(graph.DefaultValue(&Settings{Theme: "light"})).
+//	    BuildQuery()
+func DefaultValue(value interface{}) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			substitute := func(resolved interface{}, err error) (interface{}, error) {
+				switch {
+				case err != nil:
+					recordDefault(p, "error")
+				case isNullValue(resolved):
+					recordDefault(p, "null")
+				default:
+					return resolved, nil
+				}
+				return value, nil
+			}
+
+			resolved, err := next(p)
+			if thunk, ok := resolved.(func() (interface{}, error)); ok && err == nil {
+				return func() (interface{}, error) {
+					return substitute(thunk())
+				}, nil
+			}
+			return substitute(resolved, err)
+		}
+	}
+}
+
+// WithDefault resolves the field to value when the resolver fails or returns nil (see
+// DefaultValue)
+//
+// Example:
+//
+//	graph.NewResolver[Settings]("settings").
+//	    WithDefault(Settings{Theme: "light"}).
+//	    WithResolver(loadSettings).
+//	    BuildQuery()
+func (r *UnifiedResolver[T]) WithDefault(value T) *UnifiedResolver[T] {
+	return r.WithMiddleware(DefaultValue(&value))
+}
+
+// WithFieldDefault resolves a field of the object type, overridden with
+// WithFieldResolver, to value when its resolver fails or returns nil (see DefaultValue)
+//
+// Example:
+//
+//	graph.NewResolver[User]("user").
+//	    WithFieldResolver("avatarUrl", resolveAvatar).
+//	    WithFieldDefault("avatarUrl", "https://cdn.example.com/avatar.png").
+//	    BuildQuery()
+func (r *UnifiedResolver[T]) WithFieldDefault(fieldName string, value interface{}) *UnifiedResolver[T] {
+	return r.WithFieldMiddleware(fieldName, DefaultValue(value))
+}
+
+// recordDefault adds the field of p to the defaults report of the request, if any
+func recordDefault(p ResolveParams, reason string) {
+	if p.Context == nil || p.Info.Path == nil {
+		return
+	}
+	report, ok := p.Context.Value(defaultsContextKey{}).(*defaultsReport)
+	if !ok {
+		return
+	}
+
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	report.fields = append(report.fields, DefaultedField{
+		Path:   explainPath(p.Info.Path.AsArray()),
+		Field:  p.Info.ParentType.Name() + "." + p.Info.FieldName,
+		Reason: reason,
+	})
+}
@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// HealthSignal reports whether the downstream dependencies of sheddable fields are
+// available. Healthy is called each time a field marked with Shed is resolved, so it
+// should answer from memory, e.g. from the state of a circuit breaker or of a
+// background health check.
+type HealthSignal interface {
+	Healthy(dependency string) bool
+}
+
+// HealthSignalFunc adapts a function to a HealthSignal
+//
+// Example:
+//
+//	graph.HealthSignalFunc(func(dependency string) bool {
+//	    return breakers[dependency].State() != gobreaker.StateOpen
+//	})
+type HealthSignalFunc func(dependency string) bool
+
+// Healthy calls f
+func (f HealthSignalFunc) Healthy(dependency string) bool {
+	return f(dependency)
+}
+
+// DegradationSwitch is a HealthSignal toggled by the application, for instance by an
+// admin endpoint or a health probe. Dependencies are healthy until marked down.
+type DegradationSwitch struct {
+	mu   sync.RWMutex
+	down map[string]bool
+}
+
+// NewDegradationSwitch creates a switch with every dependency healthy
+func NewDegradationSwitch() *DegradationSwitch {
+	return &DegradationSwitch{down: make(map[string]bool)}
+}
+
+// MarkDown sheds the fields depending on dependency until MarkUp is called
+func (s *DegradationSwitch) MarkDown(dependency string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down[dependency] = true
+}
+
+// MarkUp resolves the fields depending on dependency again
+func (s *DegradationSwitch) MarkUp(dependency string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.down, dependency)
+}
+
+// Healthy reports whether dependency is not marked down
+func (s *DegradationSwitch) Healthy(dependency string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.down[dependency]
+}
+
+// Down lists the dependencies marked down, sorted
+func (s *DegradationSwitch) Down() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	down := make([]string, 0, len(s.down))
+	for dependency := range s.down {
+		down = append(down, dependency)
+	}
+	sort.Strings(down)
+	return down
+}
+
+// DegradedError is returned by fields shed because their dependency is down
+type DegradedError struct {
+	Dependency string
+}
+
+// Error names the unavailable dependency
+func (e *DegradedError) Error() string {
+	return "degraded: " + e.Dependency + " is unavailable"
+}
+
+// Extensions exposes the DEGRADED code and the dependency in the GraphQL error
+func (e *DegradedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "DEGRADED", "dependency": e.Dependency}
+}
+
+type healthSignalContextKey struct{}
+
+// withHealthSignal attaches the health signal consulted by the sheddable fields of a request
+func withHealthSignal(r *http.Request, signal HealthSignal) *http.Request {
+	if signal == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), healthSignalContextKey{}, signal))
+}
+
+// WithHealthSignal returns a copy of ctx whose sheddable fields consult signal, for
+// schemas executed outside NewHTTP
+func WithHealthSignal(ctx context.Context, signal HealthSignal) context.Context {
+	return context.WithValue(ctx, healthSignalContextKey{}, signal)
+}
+
+// Shed is a middleware marking a field as sheddable: while the HealthSignal of the
+// request (GraphContext.HealthSignal) reports dependency down, the field resolves to
+// null with a DEGRADED error at once, instead of waiting on the dependency, and the
+// rest of the operation is served as usual. Without a health signal the field always
+// resolves.
+//
+// Shed non-null fields with care: their null propagates to the nearest nullable parent.
+//
+// Example:
+//
+//	graph.NewResolver[Recommendation]("recommendations").
+//	    WithMiddleware(graph.Shed("recommender")).
+//	    AsList().
+//	    BuildQuery()
+func Shed(dependency string) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			if p.Context != nil {
+				if signal, ok := p.Context.Value(healthSignalContextKey{}).(HealthSignal); ok && !signal.Healthy(dependency) {
+					return nil, &DegradedError{Dependency: dependency}
+				}
+			}
+			return next(p)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// ClientNameHeader identifies the client application of a request for usage reporting.
+// The apollographql-client-name header is accepted as well.
+const ClientNameHeader = "X-GraphQL-Client-Name"
+
+// unknownClient is reported for requests without a client name
+const unknownClient = "unknown"
+
+// clientName returns the client application name of a request
+func clientName(r *http.Request) string {
+	if name := r.Header.Get(ClientNameHeader); name != "" {
+		return name
+	}
+	if name := r.Header.Get("apollographql-client-name"); name != "" {
+		return name
+	}
+	return unknownClient
+}
+
+// DeprecatedFieldUsage counts the operations of a client that selected a deprecated field
+type DeprecatedFieldUsage struct {
+	Type     string    `json:"type"`
+	Field    string    `json:"field"`
+	Reason   string    `json:"reason"`
+	Client   string    `json:"client"`
+	Count    int64     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// DeprecationTracker records which clients still request deprecated fields, so schema
+// owners know when a field is safe to remove. It is safe for concurrent use.
+//
+// Example:
+//
+//	deprecations := graph.NewDeprecationTracker()
+//
+//	http.Handle("/graphql", graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams:       params,
+//	    DeprecationTracker: deprecations,
+//	}))
+//	http.Handle("/graphql/deprecations", deprecations)
+type DeprecationTracker struct {
+	mu    sync.Mutex
+	usage map[deprecationKey]*DeprecatedFieldUsage
+}
+
+type deprecationKey struct {
+	typeName, field, client string
+}
+
+// NewDeprecationTracker creates an empty DeprecationTracker
+func NewDeprecationTracker() *DeprecationTracker {
+	return &DeprecationTracker{usage: make(map[deprecationKey]*DeprecatedFieldUsage)}
+}
+
+// Record counts one use of a deprecated field by client
+func (t *DeprecationTracker) Record(typeName, field, reason, client string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := deprecationKey{typeName: typeName, field: field, client: client}
+	usage, ok := t.usage[key]
+	if !ok {
+		usage = &DeprecatedFieldUsage{Type: typeName, Field: field, Reason: reason, Client: client}
+		t.usage[key] = usage
+	}
+	usage.Count++
+	usage.LastSeen = time.Now()
+}
+
+// Report returns the recorded usage ordered by type, field and client
+func (t *DeprecationTracker) Report() []DeprecatedFieldUsage {
+	t.mu.Lock()
+	report := make([]DeprecatedFieldUsage, 0, len(t.usage))
+	for _, usage := range t.usage {
+		report = append(report, *usage)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i], report[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.Client < b.Client
+	})
+	return report
+}
+
+// Reset clears the recorded usage
+func (t *DeprecationTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage = make(map[deprecationKey]*DeprecatedFieldUsage)
+}
+
+// ServeHTTP writes the usage report as JSON
+func (t *DeprecationTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"deprecations": t.Report()})
+}
+
+// deprecationWarning describes a deprecated field selected by an operation
+type deprecationWarning struct {
+	Field  string `json:"field"` // Schema coordinate, e.g. "User.name"
+	Reason string `json:"reason"`
+	Sunset string `json:"sunset,omitempty"` // RFC 3339 date the field is removed, from its reason
+
+	typeName, fieldName string
+	sunset              time.Time
+}
+
+// deprecatedFields returns the deprecated fields selected by an operation
+func deprecatedFields(schema *graphql.Schema, query, operationName string) []deprecationWarning {
+	doc, err := parseGraphQLQuery(query)
+	if err != nil {
+		return nil
+	}
+
+	var warnings []deprecationWarning
+	seen := make(map[string]bool)
+	visitSelectedFields(schema, doc, operationName, func(parent graphql.Type, field *graphql.FieldDefinition, _ *ast.Field) {
+		if field.DeprecationReason == "" {
+			return
+		}
+		coordinate := parent.Name() + "." + field.Name
+		if seen[coordinate] {
+			return
+		}
+		seen[coordinate] = true
+		warning := deprecationWarning{
+			Field:     coordinate,
+			Reason:    field.DeprecationReason,
+			typeName:  parent.Name(),
+			fieldName: field.Name,
+		}
+		if sunset, ok := parseSunset(field.DeprecationReason); ok {
+			warning.Sunset = sunset.UTC().Format(time.RFC3339)
+			warning.sunset = sunset
+		}
+		warnings = append(warnings, warning)
+	})
+	return warnings
+}
+
+// trackDeprecations records the deprecated fields a request selects and returns them.
+// When warnings is set, it also returns a decorator adding them to the response
+// extensions.
+func trackDeprecations(r *http.Request, schema *graphql.Schema, tracker *DeprecationTracker, warnings bool) ([]deprecationWarning, ResponseDecorator) {
+	opts := peekRequestOptions(r)
+	if opts.Query == "" {
+		return nil, nil
+	}
+
+	fields := deprecatedFields(schema, opts.Query, opts.OperationName)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	if tracker != nil {
+		client := clientName(r)
+		for _, field := range fields {
+			tracker.Record(field.typeName, field.fieldName, field.Reason, client)
+		}
+	}
+
+	if !warnings {
+		return fields, nil
+	}
+	return fields, func(ctx context.Context, response *graphql.Result) {
+		if response.Extensions == nil {
+			response.Extensions = make(map[string]interface{})
+		}
+		response.Extensions["deprecations"] = fields
+	}
+}
@@ -0,0 +1,408 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Files written by GenerateDocs
+const (
+	DocsHTMLFile     = "index.html"
+	DocsMarkdownFile = "schema.md"
+)
+
+// docsPage is the documentation of a schema
+type docsPage struct {
+	Operations []docsOperation
+	Types      []docsType
+}
+
+// docsOperation lists the root fields of an operation type
+type docsOperation struct {
+	Name   string // "Query", "Mutation" or "Subscription"
+	Fields []docsField
+}
+
+// docsType documents a named type
+type docsType struct {
+	Name        string
+	Kind        string
+	Description string
+	Interfaces  []string
+	Members     []string // Union members
+	Fields      []docsField
+	Values      []docsField // Enum values
+}
+
+// docsField documents a field, argument, input field or enum value
+type docsField struct {
+	Name        string
+	Type        string // Type with modifiers, e.g. [User!]!
+	TypeName    string // Named type, for links
+	Description string
+	Deprecation string
+	Default     string
+	Args        []docsField
+	Example     string // Example operation of a root field
+	Variables   string // Variables of Example, as JSON
+}
+
+// GenerateDocs writes static documentation of schema to outDir: an HTML page
+// (DocsHTMLFile) and its Markdown equivalent (DocsMarkdownFile). The root fields of
+// each operation type come first, with their arguments and a runnable example
+// operation, followed by every type with its fields, descriptions and deprecations.
+// Run it from a build step or a test so the docs follow the schema.
+//
+// Example:
+//
+//	schema, err := graph.NewSchemaBuilder(params).Build()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := graph.GenerateDocs(&schema, "docs/api"); err != nil {
+//	    log.Fatal(err)
+//	}
+func GenerateDocs(schema *graphql.Schema, outDir string) error {
+	page, err := buildDocsPage(schema)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("graph: create docs directory: %w", err)
+	}
+
+	var html strings.Builder
+	if err := docsTemplate.Execute(&html, page); err != nil {
+		return fmt.Errorf("graph: render docs: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, DocsHTMLFile), []byte(html.String()), 0o644); err != nil {
+		return fmt.Errorf("graph: write docs: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, DocsMarkdownFile), []byte(renderDocsMarkdown(page)), 0o644); err != nil {
+		return fmt.Errorf("graph: write docs: %w", err)
+	}
+	return nil
+}
+
+// buildDocsPage collects the documentation of schema
+func buildDocsPage(schema *graphql.Schema) (docsPage, error) {
+	var page docsPage
+	roots := []struct {
+		operation string
+		object    *graphql.Object
+	}{
+		{"query", schema.QueryType()},
+		{"mutation", schema.MutationType()},
+		{"subscription", schema.SubscriptionType()},
+	}
+	examples := make(map[string]ExampleQuery)
+	for _, example := range GenerateExampleQueries(schema) {
+		examples[example.Name] = example
+	}
+
+	isRoot := make(map[string]bool)
+	for _, root := range roots {
+		if root.object == nil {
+			continue
+		}
+		isRoot[root.object.Name()] = true
+
+		operation := docsOperation{Name: strings.ToUpper(root.operation[:1]) + root.operation[1:]}
+		fields := root.object.Fields()
+		for _, name := range sortedKeys(fields) {
+			field := docsFieldOf(fields[name])
+			example := examples[operation.Name+"."+name]
+			field.Example = example.Query
+			if len(example.Variables) > 0 {
+				encoded, _ := json.MarshalIndent(example.Variables, "", "  ")
+				field.Variables = string(encoded)
+			}
+			operation.Fields = append(operation.Fields, field)
+		}
+		page.Operations = append(page.Operations, operation)
+	}
+
+	err := SchemaVisitor{
+		Type: func(t graphql.Type) error {
+			if isRoot[t.Name()] {
+				return SkipType
+			}
+			documented := docsType{Name: t.Name(), Description: t.Description()}
+			switch t := t.(type) {
+			case *graphql.Object:
+				documented.Kind = "type"
+				for _, iface := range t.Interfaces() {
+					documented.Interfaces = append(documented.Interfaces, iface.Name())
+				}
+				sort.Strings(documented.Interfaces)
+			case *graphql.Interface:
+				documented.Kind = "interface"
+			case *graphql.Union:
+				documented.Kind = "union"
+				for _, member := range t.Types() {
+					documented.Members = append(documented.Members, member.Name())
+				}
+				sort.Strings(documented.Members)
+			case *graphql.Enum:
+				documented.Kind = "enum"
+			case *graphql.InputObject:
+				documented.Kind = "input"
+			case *graphql.Scalar:
+				documented.Kind = "scalar"
+			}
+			page.Types = append(page.Types, documented)
+			return nil
+		},
+		Field: func(parent graphql.Type, field *graphql.FieldDefinition) error {
+			current := &page.Types[len(page.Types)-1]
+			current.Fields = append(current.Fields, docsFieldOf(field))
+			return nil
+		},
+		InputField: func(parent *graphql.InputObject, field *graphql.InputObjectField) error {
+			current := &page.Types[len(page.Types)-1]
+			current.Fields = append(current.Fields, docsField{
+				Name:        field.Name(),
+				Type:        field.Type.String(),
+				TypeName:    graphql.GetNamed(field.Type).String(),
+				Description: field.Description(),
+				Default:     strings.TrimPrefix(printDefault(field.DefaultValue, field.Type), " = "),
+			})
+			return nil
+		},
+		EnumValue: func(parent *graphql.Enum, value *graphql.EnumValueDefinition) error {
+			current := &page.Types[len(page.Types)-1]
+			current.Values = append(current.Values, docsField{
+				Name:        value.Name,
+				Description: value.Description,
+				Deprecation: value.DeprecationReason,
+			})
+			return nil
+		},
+	}.Walk(schema)
+	return page, err
+}
+
+// docsFieldOf documents a field of an object or interface type with its arguments
+func docsFieldOf(field *graphql.FieldDefinition) docsField {
+	documented := docsField{
+		Name:        field.Name,
+		Type:        field.Type.String(),
+		TypeName:    graphql.GetNamed(field.Type).String(),
+		Description: field.Description,
+		Deprecation: field.DeprecationReason,
+	}
+	args := append([]*graphql.Argument(nil), field.Args...)
+	sort.Slice(args, func(i, j int) bool { return args[i].Name() < args[j].Name() })
+	for _, arg := range args {
+		documented.Args = append(documented.Args, docsField{
+			Name:        arg.Name(),
+			Type:        arg.Type.String(),
+			TypeName:    graphql.GetNamed(arg.Type).String(),
+			Description: arg.Description(),
+			Default:     strings.TrimPrefix(printDefault(arg.DefaultValue, arg.Type), " = "),
+		})
+	}
+	return documented
+}
+
+// renderDocsMarkdown renders the documentation as Markdown
+func renderDocsMarkdown(page docsPage) string {
+	var b strings.Builder
+	b.WriteString("# API Reference\n")
+
+	for _, operation := range page.Operations {
+		b.WriteString("\n## " + operation.Name + "\n")
+		for _, field := range operation.Fields {
+			b.WriteString("\n### " + operation.Name + "." + field.Name + "\n\n")
+			b.WriteString("Returns " + markdownTypeLink(field) + "\n")
+			writeMarkdownNotes(&b, field)
+			if len(field.Args) > 0 {
+				b.WriteString("\n| Argument | Type | Default | Description |\n|----------|------|---------|-------------|\n")
+				for _, arg := range field.Args {
+					b.WriteString("| `" + arg.Name + "` | " + markdownTypeLink(arg) + " | " + markdownCode(arg.Default) +
+						" | " + markdownCell(arg.Description) + " |\n")
+				}
+			}
+			b.WriteString("\n```graphql\n" + field.Example + "\n```\n")
+			if field.Variables != "" {
+				b.WriteString("\n```json\n" + field.Variables + "\n```\n")
+			}
+		}
+	}
+
+	if len(page.Types) > 0 {
+		b.WriteString("\n## Types\n")
+	}
+	for _, t := range page.Types {
+		b.WriteString("\n### " + t.Name + "\n\n`" + t.Kind + "`")
+		if len(t.Interfaces) > 0 {
+			b.WriteString(" implementing " + markdownLinks(t.Interfaces))
+		}
+		if len(t.Members) > 0 {
+			b.WriteString(" of " + markdownLinks(t.Members))
+		}
+		b.WriteString("\n")
+		if t.Description != "" {
+			b.WriteString("\n" + t.Description + "\n")
+		}
+		if len(t.Fields) > 0 {
+			b.WriteString("\n| Field | Type | Description |\n|-------|------|-------------|\n")
+			for _, field := range t.Fields {
+				b.WriteString("| `" + field.Name + "` | " + markdownTypeLink(field) + " | " + markdownFieldNotes(field) + " |\n")
+			}
+		}
+		if len(t.Values) > 0 {
+			b.WriteString("\n| Value | Description |\n|-------|-------------|\n")
+			for _, value := range t.Values {
+				b.WriteString("| `" + value.Name + "` | " + markdownFieldNotes(value) + " |\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeMarkdownNotes writes the description and deprecation of a root field
+func writeMarkdownNotes(b *strings.Builder, field docsField) {
+	if field.Description != "" {
+		b.WriteString("\n" + field.Description + "\n")
+	}
+	if field.Deprecation != "" {
+		b.WriteString("\n> **Deprecated:** " + field.Deprecation + "\n")
+	}
+}
+
+// markdownFieldNotes returns the description and deprecation of a field as a table cell
+func markdownFieldNotes(field docsField) string {
+	notes := markdownCell(field.Description)
+	if field.Default != "" {
+		notes = strings.TrimSpace(notes + " Default: " + markdownCode(field.Default))
+	}
+	var args []string
+	for _, arg := range field.Args {
+		args = append(args, "`"+arg.Name+": "+arg.Type+"`")
+	}
+	if len(args) > 0 {
+		notes = strings.TrimSpace(notes + " Arguments: " + strings.Join(args, ", "))
+	}
+	if field.Deprecation != "" {
+		notes = strings.TrimSpace(notes + " **Deprecated:** " + markdownCell(field.Deprecation))
+	}
+	return notes
+}
+
+// markdownTypeLink links the type of a field to its documentation
+func markdownTypeLink(field docsField) string {
+	if isBuiltInScalar(field.TypeName) {
+		return "`" + field.Type + "`"
+	}
+	return "[`" + field.Type + "`](#" + strings.ToLower(field.TypeName) + ")"
+}
+
+// markdownLinks links type names to their documentation
+func markdownLinks(names []string) string {
+	links := make([]string, len(names))
+	for i, name := range names {
+		links[i] = "[" + name + "](#" + strings.ToLower(name) + ")"
+	}
+	return strings.Join(links, ", ")
+}
+
+// markdownCode formats a value as inline code, or nothing when empty
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
+
+// markdownCell escapes text for a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// docsTemplate renders the documentation as a standalone HTML page
+var docsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"builtIn": isBuiltInScalar,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>API Reference</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+code, pre { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; text-align: left; vertical-align: top; }
+.kind { color: #6e7781; }
+.deprecated { color: #9a6700; }
+</style>
+</head>
+<body>
+<h1>API Reference</h1>
+{{define "type"}}{{if builtIn .TypeName}}<code>{{.Type}}</code>{{else}}<a href="#{{.TypeName}}"><code>{{.Type}}</code></a>{{end}}{{end}}
+{{- define "notes"}}{{if .Description}}<p>{{.Description}}</p>{{end}}{{if .Default}}<p>Default: <code>{{.Default}}</code></p>{{end}}{{if .Deprecation}}<p class="deprecated"><strong>Deprecated:</strong> {{.Deprecation}}</p>{{end}}{{end}}
+{{- range .Operations}}
+<h2>{{.Name}}</h2>
+{{- $operation := .Name}}
+{{- range .Fields}}
+<h3 id="{{$operation}}.{{.Name}}">{{.Name}}</h3>
+<p>Returns {{template "type" .}}</p>
+{{template "notes" .}}
+{{- if .Args}}
+<table>
+<tr><th>Argument</th><th>Type</th><th>Default</th><th>Description</th></tr>
+{{- range .Args}}
+<tr><td><code>{{.Name}}</code></td><td>{{template "type" .}}</td><td>{{if .Default}}<code>{{.Default}}</code>{{end}}</td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<pre><code>{{.Example}}</code></pre>
+{{- if .Variables}}
+<pre><code>{{.Variables}}</code></pre>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Types}}
+<h2>Types</h2>
+{{- end}}
+{{- range .Types}}
+<h3 id="{{.Name}}">{{.Name}} <span class="kind">{{.Kind}}</span></h3>
+{{- if .Interfaces}}
+<p>Implements {{range $i, $name := .Interfaces}}{{if $i}}, {{end}}<a href="#{{$name}}">{{$name}}</a>{{end}}</p>
+{{- end}}
+{{- if .Members}}
+<p>One of {{range $i, $name := .Members}}{{if $i}}, {{end}}<a href="#{{$name}}">{{$name}}</a>{{end}}</p>
+{{- end}}
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+{{- if .Fields}}
+<table>
+<tr><th>Field</th><th>Type</th><th>Description</th></tr>
+{{- range .Fields}}
+<tr><td><code>{{.Name}}</code>{{if .Args}}({{range $i, $arg := .Args}}{{if $i}}, {{end}}<code>{{$arg.Name}}: {{$arg.Type}}</code>{{end}}){{end}}</td><td>{{template "type" .}}</td><td>{{template "notes" .}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Values}}
+<table>
+<tr><th>Value</th><th>Description</th></tr>
+{{- range .Values}}
+<tr><td><code>{{.Name}}</code></td><td>{{template "notes" .}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
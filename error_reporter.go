@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// ErrorReport describes an unexpected error returned by a resolver, or a panic, as
+// given to an ErrorReporter
+type ErrorReport struct {
+	Err           error           // Error returned, or the recovered panic value as an error
+	Panic         bool            // Whether the resolver panicked
+	OperationName string          // Name of the operation, empty when anonymous
+	Field         string          // Schema coordinate, e.g. "Query.user"
+	Path          string          // Response path, e.g. "users.0.email"
+	Stack         []runtime.Frame // Stack of the panic, innermost frame first; nil for returned errors
+}
+
+// ErrorReporter sends the unexpected errors of resolvers to an error tracker such as
+// Sentry or Bugsnag. Errors meant for clients are not reported: errors carrying GraphQL
+// extensions (UNAUTHENTICATED, FORBIDDEN, DEGRADED, ...) and canceled or expired
+// contexts. Report is called on the resolving goroutine, so it should hand the report
+// off rather than send it inline.
+type ErrorReporter interface {
+	Report(ctx context.Context, report ErrorReport)
+}
+
+// ErrorReporterFunc adapts a function to an ErrorReporter
+//
+// Example (Bugsnag):
+//
+//	reporter := graph.ErrorReporterFunc(func(ctx context.Context, report graph.ErrorReport) {
+//	    bugsnag.Notify(report.Err, ctx, bugsnag.MetaData{"graphql": {
+//	        "operation": report.OperationName,
+//	        "field":     report.Field,
+//	        "path":      report.Path,
+//	    }})
+//	})
+type ErrorReporterFunc func(ctx context.Context, report ErrorReport)
+
+// Report calls f
+func (f ErrorReporterFunc) Report(ctx context.Context, report ErrorReport) {
+	f(ctx, report)
+}
+
+type errorReporterContextKey struct{}
+
+// withErrorReporter attaches the reporter of the unexpected resolver errors of a request
+func withErrorReporter(r *http.Request, reporter ErrorReporter) *http.Request {
+	if reporter == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), errorReporterContextKey{}, reporter))
+}
+
+// WithErrorReporter returns a copy of ctx whose unexpected resolver errors are sent to
+// reporter, for schemas executed outside NewHTTP
+func WithErrorReporter(ctx context.Context, reporter ErrorReporter) context.Context {
+	return context.WithValue(ctx, errorReporterContextKey{}, reporter)
+}
+
+// reportResolverErrors sends the unexpected errors and panics of resolvers to the
+// ErrorReporter of the request, if any. Panics are reported, then resumed for the
+// executor to turn into a field error as usual.
+func reportResolverErrors(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		if p.Context == nil {
+			return next(p)
+		}
+		reporter, ok := p.Context.Value(errorReporterContextKey{}).(ErrorReporter)
+		if !ok {
+			return next(p)
+		}
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				report := newErrorReport(p, panicError(recovered))
+				report.Panic = true
+				report.Stack = panicStack()
+				reporter.Report(p.Context, report)
+				panic(recovered)
+			}
+		}()
+
+		value, err := next(p)
+		if err != nil && !isExpectedError(err) {
+			reporter.Report(p.Context, newErrorReport(p, err))
+		}
+		return value, err
+	}
+}
+
+// newErrorReport describes err, returned or raised while resolving the field of p
+func newErrorReport(p ResolveParams, err error) ErrorReport {
+	report := ErrorReport{
+		Err:   err,
+		Field: p.Info.ParentType.Name() + "." + p.Info.FieldName,
+	}
+	if p.Info.Path != nil {
+		report.Path = explainPath(p.Info.Path.AsArray())
+	}
+	if operation, ok := p.Info.Operation.(*ast.OperationDefinition); ok && operation.Name != nil {
+		report.OperationName = operation.Name.Value
+	}
+	return report
+}
+
+// isExpectedError reports whether err is meant for the client rather than an error
+// tracker: a GraphQL error with extensions, or a canceled or expired context
+func isExpectedError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var extended interface{ Extensions() map[string]interface{} }
+	return errors.As(err, &extended)
+}
+
+// panicError converts a recovered panic value to an error
+func panicError(recovered interface{}) error {
+	if err, ok := recovered.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return fmt.Errorf("panic: %v", recovered)
+}
+
+// panicStack returns the stack of the panic being recovered, innermost frame first,
+// from the frame that panicked
+func panicStack() []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	// Skip runtime.Callers, panicStack and the deferred function recovering
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []runtime.Frame
+	for {
+		frame, more := frames.Next()
+		// Drop the frames of the runtime raising the panic
+		if len(stack) > 0 || !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
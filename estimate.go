@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/handler"
+)
+
+// QueryEstimate is the computed cost of an operation together with the limits it is checked against
+type QueryEstimate struct {
+	Depth        int             `json:"depth"`
+	Aliases      int             `json:"aliases"`
+	Complexity   int             `json:"complexity"`
+	Operations   int             `json:"operations"`
+	Variables    int             `json:"variables"`
+	StringLength int             `json:"stringLength"`
+	ListLength   int             `json:"listLength"`
+	InputDepth   int             `json:"inputDepth"`
+	Limits       QueryCostLimits `json:"limits"`
+	WithinLimits bool            `json:"withinLimits"`
+}
+
+// EstimateGraphQLQuery parses, validates and measures a query without executing it.
+//
+// The returned errors cover syntax errors, schema validation errors (unknown fields,
+// wrong argument types, ...) and violations of the ValidateGraphQLQuery security rules.
+// The estimate is zero when the query cannot be parsed.
+//
+// Example usage:
+//
+//	estimate, errs := graph.EstimateGraphQLQuery(query, schema)
+//	if len(errs) > 0 || !estimate.WithinLimits {
+//	    log.Fatalf("query rejected: %v (complexity %d)", errs, estimate.Complexity)
+//	}
+func EstimateGraphQLQuery(queryString string, schema *graphql.Schema) (QueryEstimate, []gqlerrors.FormattedError) {
+	return estimateGraphQLQuery(queryString, schema, DefaultQueryLimits)
+}
+
+// estimateGraphQLQuery measures a query against limits
+func estimateGraphQLQuery(queryString string, schema *graphql.Schema, limits QueryCostLimits) (QueryEstimate, []gqlerrors.FormattedError) {
+	estimate := QueryEstimate{Limits: limits}
+
+	doc, err := parseGraphQLQuery(queryString)
+	if err != nil {
+		return estimate, gqlerrors.FormatErrors(err)
+	}
+
+	estimate.Depth = calculateQueryDepth(doc, 0, nil)
+	estimate.Aliases = countAliases(doc, nil)
+	estimate.Complexity = calculateQueryComplexity(doc, 1, nil)
+	estimate.Operations, estimate.Variables = countDefinitions(doc)
+	size := measureInputs(doc, nil)
+	estimate.StringLength, estimate.ListLength, estimate.InputDepth = size.stringLength, size.listLength, size.depth
+	estimate.WithinLimits = estimate.Depth <= estimate.Limits.MaxDepth &&
+		estimate.Aliases <= estimate.Limits.MaxAliases &&
+		estimate.Complexity <= estimate.Limits.MaxComplexity &&
+		estimate.Operations <= estimate.Limits.MaxOperations &&
+		estimate.Variables <= estimate.Limits.MaxVariables &&
+		estimate.StringLength <= estimate.Limits.MaxStringLength &&
+		estimate.ListLength <= estimate.Limits.MaxListLength &&
+		estimate.InputDepth <= estimate.Limits.MaxInputDepth
+
+	var errs []gqlerrors.FormattedError
+	if schema != nil {
+		if result := graphql.ValidateDocument(schema, doc, nil); !result.IsValid {
+			errs = append(errs, result.Errors...)
+		}
+	}
+	if err := analyzeDocument(doc, nil, limits).Err(); err != nil {
+		errs = append(errs, gqlerrors.FormatError(err))
+	}
+
+	return estimate, errs
+}
+
+// isEstimateRequest reports whether a request asks for a cost estimate: a POST to a
+// path ending in /estimate, or a request with "estimate": true in its extensions
+func isEstimateRequest(r *http.Request) bool {
+	if r.Method == http.MethodPost && strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/estimate") {
+		return true
+	}
+	estimate, _ := requestExtensions(r)["estimate"].(bool)
+	return estimate
+}
+
+// serveEstimate writes the cost estimate of the requested operation without executing it
+func serveEstimate(w http.ResponseWriter, r *http.Request, schema *graphql.Schema) {
+	opts := handler.NewRequestOptions(r)
+	estimate, errs := estimateGraphQLQuery(opts.Query, schema, queryLimits(r.Context()))
+
+	response := map[string]interface{}{
+		"extensions": map[string]interface{}{"cost": estimate},
+	}
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
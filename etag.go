@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// responseETag returns the strong entity tag of a serialized response
+func responseETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. The comparison is
+// weak, as RFC 9110 requires for If-None-Match, so W/ prefixes are ignored.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// isETagCacheable reports whether the response to r gets an ETag: successful GET
+// responses, and POST responses marked cacheable by CacheControl
+func isETagCacheable(r *http.Request, statusCode int, header http.Header) bool {
+	if statusCode != http.StatusOK {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return strings.HasPrefix(header.Get("Cache-Control"), "max-age=")
+	}
+	return false
+}
+
+// tagResponse tags a response with its ETag, and answers 304 Not Modified without the
+// body when the client already holds it
+func tagResponse(r *http.Request, response *Response) {
+	if !isETagCacheable(r, response.StatusCode, response.Header) {
+		return
+	}
+
+	etag := responseETag(response.Body())
+	response.Header.Set("ETag", etag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		response.Header.Del("Content-Type")
+		response.Header.Del("Content-Length")
+		response.StatusCode = http.StatusNotModified
+		response.SetBody(nil)
+	}
+}
@@ -0,0 +1,239 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// exampleSelectionDepth bounds the nested objects selected by example operations
+	exampleSelectionDepth = 2
+	// exampleInputDepth bounds the nested input objects of example variables
+	exampleInputDepth = 3
+)
+
+// ExampleQuery is a runnable operation calling one root field of a schema
+type ExampleQuery struct {
+	Name      string                 `json:"name"`      // e.g. "Query.user"
+	Operation string                 `json:"operation"` // "query", "mutation" or "subscription"
+	Field     string                 `json:"field"`
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GenerateExampleQueries returns an example operation for every root field of schema,
+// queries first, then mutations and subscriptions, each sorted by field name. Every
+// argument is passed as a variable, set to the argument default or a placeholder of
+// its type ("example" for strings, 0 for numbers, the first value of enums, input
+// objects with all their fields), and the leaf fields of the result are selected, two
+// levels deep. Fields with required arguments are not selected.
+//
+// Example:
+//
+//	for _, example := range graph.GenerateExampleQueries(&schema) {
+//	    fmt.Printf("# %s\n%s\n", example.Name, example.Query)
+//	}
+func GenerateExampleQueries(schema *graphql.Schema) []ExampleQuery {
+	var examples []ExampleQuery
+	roots := []struct {
+		operation string
+		object    *graphql.Object
+	}{
+		{"query", schema.QueryType()},
+		{"mutation", schema.MutationType()},
+		{"subscription", schema.SubscriptionType()},
+	}
+	for _, root := range roots {
+		if root.object == nil {
+			continue
+		}
+		fields := root.object.Fields()
+		for _, name := range sortedKeys(fields) {
+			query, variables := exampleOperation(root.operation, fields[name])
+			if len(variables) == 0 {
+				variables = nil
+			}
+			examples = append(examples, ExampleQuery{
+				Name:      strings.ToUpper(root.operation[:1]) + root.operation[1:] + "." + name,
+				Operation: root.operation,
+				Field:     name,
+				Query:     query,
+				Variables: variables,
+			})
+		}
+	}
+	return examples
+}
+
+// PlaygroundTab is a tab of GraphQL Playground, as listed in the "tabs" setting of
+// GraphQLPlayground.init
+type PlaygroundTab struct {
+	Endpoint  string            `json:"endpoint"`
+	Name      string            `json:"name"`
+	Query     string            `json:"query"`
+	Variables string            `json:"variables,omitempty"` // JSON text
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// PlaygroundTabs turns examples into GraphQL Playground tabs opened on endpoint, so
+// consumers can run every operation of the API from the playground.
+//
+// Example:
+//
+//	tabs, _ := json.Marshal(graph.PlaygroundTabs("/graphql", graph.GenerateExampleQueries(&schema)))
+//	// GraphQLPlayground.init(root, {endpoint: "/graphql", tabs: <tabs>})
+func PlaygroundTabs(endpoint string, examples []ExampleQuery) []PlaygroundTab {
+	tabs := make([]PlaygroundTab, len(examples))
+	for i, example := range examples {
+		tabs[i] = PlaygroundTab{Endpoint: endpoint, Name: example.Name, Query: example.Query}
+		if len(example.Variables) > 0 {
+			variables, _ := json.MarshalIndent(example.Variables, "", "  ")
+			tabs[i].Variables = string(variables)
+		}
+	}
+	return tabs
+}
+
+// exampleOperation returns an operation calling the root field of the operation type
+// ("query", "mutation" or "subscription"), passing every argument as a variable, with
+// the argument defaults, or placeholders, as variable values
+func exampleOperation(operation string, field *graphql.FieldDefinition) (string, map[string]interface{}) {
+	args := append([]*graphql.Argument(nil), field.Args...)
+	sort.Slice(args, func(i, j int) bool { return args[i].Name() < args[j].Name() })
+
+	var b strings.Builder
+	b.WriteString(operation + " " + strings.ToUpper(field.Name[:1]) + field.Name[1:])
+
+	variables := make(map[string]interface{}, len(args))
+	if len(args) > 0 {
+		definitions := make([]string, len(args))
+		passed := make([]string, len(args))
+		for i, arg := range args {
+			definitions[i] = "$" + arg.Name() + ": " + arg.Type.String()
+			passed[i] = arg.Name() + ": $" + arg.Name()
+			variables[arg.Name()] = exampleValue(arg.Type, arg.DefaultValue)
+		}
+		b.WriteString("(" + strings.Join(definitions, ", ") + ")")
+		b.WriteString(" {\n  " + field.Name + "(" + strings.Join(passed, ", ") + ")")
+	} else {
+		b.WriteString(" {\n  " + field.Name)
+	}
+	b.WriteString(exampleSelection(field.Type, "  ", 0))
+	b.WriteString("\n}")
+	return b.String(), variables
+}
+
+// exampleSelection selects the leaf fields of an output type, and those of the objects
+// it links to, up to exampleSelectionDepth
+func exampleSelection(t graphql.Type, indent string, depth int) string {
+	var fields graphql.FieldDefinitionMap
+	switch t := graphql.GetNamed(t).(type) {
+	case *graphql.Object:
+		fields = t.Fields()
+	case *graphql.Interface:
+		fields = t.Fields()
+	case *graphql.Union:
+		return " {\n" + indent + "  __typename\n" + indent + "}"
+	default:
+		return ""
+	}
+
+	var lines []string
+	for _, name := range sortedKeys(fields) {
+		field := fields[name]
+		if field.DeprecationReason != "" || hasRequiredArgs(field) {
+			continue
+		}
+		if graphql.IsLeafType(field.Type) {
+			lines = append(lines, indent+"  "+name)
+		} else if depth+1 < exampleSelectionDepth {
+			if selection := exampleSelection(field.Type, indent+"  ", depth+1); selection != "" {
+				lines = append(lines, indent+"  "+name+selection)
+			}
+		}
+	}
+	if len(lines) == 0 {
+		lines = []string{indent + "  __typename"}
+	}
+	return " {\n" + strings.Join(lines, "\n") + "\n" + indent + "}"
+}
+
+// exampleValue returns the default value of an argument as a variable value, or a
+// placeholder when it has none
+func exampleValue(t graphql.Type, defaultValue interface{}) interface{} {
+	if defaultValue == nil {
+		return examplePlaceholder(t, 0)
+	}
+	// Variables name enum values; defaults hold their internal value
+	if enum, ok := graphql.GetNullable(t).(*graphql.Enum); ok {
+		for _, value := range enum.Values() {
+			if reflect.DeepEqual(value.Value, defaultValue) {
+				return value.Name
+			}
+		}
+	}
+	return defaultValue
+}
+
+// examplePlaceholder returns a placeholder value of an input type
+func examplePlaceholder(t graphql.Type, depth int) interface{} {
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+	}
+
+	switch t := t.(type) {
+	case *graphql.List:
+		return []interface{}{examplePlaceholder(t.OfType, depth)}
+	case *graphql.Enum:
+		values := t.Values()
+		if len(values) == 0 {
+			return nil
+		}
+		names := make([]string, len(values))
+		for i, value := range values {
+			names[i] = value.Name
+		}
+		sort.Strings(names)
+		return names[0]
+	case *graphql.InputObject:
+		if depth >= exampleInputDepth {
+			return nil
+		}
+		fields := t.Fields()
+		placeholder := make(map[string]interface{}, len(fields))
+		for _, name := range sortedKeys(fields) {
+			placeholder[name] = examplePlaceholder(fields[name].Type, depth+1)
+		}
+		return placeholder
+	case *graphql.Scalar:
+		switch t.Name() {
+		case "String":
+			return "example"
+		case "ID":
+			return "1"
+		case "Int":
+			return 0
+		case "Float":
+			return 0.0
+		case "Boolean":
+			return false
+		case DateTime.Name():
+			return "2024-01-15T14:30"
+		}
+	}
+	return nil
+}
+
+// hasRequiredArgs reports whether field has a non-null argument without a default
+func hasRequiredArgs(field *graphql.FieldDefinition) bool {
+	for _, arg := range field.Args {
+		if _, ok := arg.Type.(*graphql.NonNull); ok && arg.DefaultValue == nil {
+			return true
+		}
+	}
+	return false
+}
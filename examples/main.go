@@ -15,9 +15,9 @@ func main() {
 		Playground: true,
 		DEBUG:      true, // Set to true to disable validation/sanitization
 
-		// Enable security features (ignored if DEBUG=true)
-		EnableValidation:   true, // Validates depth, complexity, blocks introspection
-		EnableSanitization: true, // Removes field suggestions from errors
+		// Enable security features in production (rejected with DEBUG=true, which skips them)
+		// EnableValidation:   true, // Validates depth, complexity, blocks introspection
+		// EnableSanitization: true, // Removes field suggestions from errors
 
 		// Optional: Custom token extraction
 		// If not provided, default Bearer token extraction is used
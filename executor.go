@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// ExecuteParams describes one GraphQL operation to execute
+type ExecuteParams struct {
+	Context       context.Context
+	Schema        *graphql.Schema
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	RootValue     map[string]interface{}
+}
+
+// Executor runs GraphQL operations. NewHTTP, the Server-Sent Events transport and the
+// REST bridge execute through it, so the execution backend can be swapped without
+// changing resolvers or handler configuration. GraphQLGoExecutor is the default.
+//
+// Example:
+//
+//	type tracingExecutor struct{ graph.GraphQLGoExecutor }
+//
+//	func (e tracingExecutor) Execute(params graph.ExecuteParams) *graphql.Result {
+//	    start := time.Now()
+//	    defer func() { log.Printf("%s took %s", params.OperationName, time.Since(start)) }()
+//	    return e.GraphQLGoExecutor.Execute(params)
+//	}
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams: params,
+//	    Executor:     tracingExecutor{},
+//	})
+type Executor interface {
+	// Execute runs a query or mutation
+	Execute(params ExecuteParams) *graphql.Result
+
+	// Subscribe runs a subscription, sending one result per event. The channel is
+	// closed when the event stream ends or the context is done.
+	Subscribe(params ExecuteParams) chan *graphql.Result
+}
+
+// GraphQLGoExecutor executes operations with github.com/graphql-go/graphql
+type GraphQLGoExecutor struct{}
+
+// Execute runs a query or mutation with graphql.Do, or, when NewHTTP has already
+// validated the query against the schema, executes the validated document
+func (GraphQLGoExecutor) Execute(params ExecuteParams) *graphql.Result {
+	p := params.graphqlParams()
+	if doc := params.validatedDocument(); doc != nil {
+		return graphql.Execute(graphql.ExecuteParams{
+			Schema:        p.Schema,
+			Root:          p.RootObject,
+			AST:           doc,
+			OperationName: p.OperationName,
+			Args:          p.VariableValues,
+			Context:       p.Context,
+		})
+	}
+	return graphql.Do(p)
+}
+
+// Subscribe runs a subscription with graphql.Subscribe
+func (GraphQLGoExecutor) Subscribe(params ExecuteParams) chan *graphql.Result {
+	return graphql.Subscribe(params.graphqlParams())
+}
+
+// graphqlParams converts the params for graphql-go
+func (params ExecuteParams) graphqlParams() graphql.Params {
+	p := graphql.Params{
+		RequestString:  params.Query,
+		VariableValues: params.Variables,
+		OperationName:  params.OperationName,
+		Context:        params.Context,
+	}
+	if params.Schema != nil {
+		p.Schema = *params.Schema
+	}
+	if params.RootValue != nil {
+		p.RootObject = params.RootValue
+	}
+	return p
+}
+
+// validatedDocumentKey stores the document of the query validated for a request
+type validatedDocumentKey struct{}
+
+// validatedDocument is a query parsed and checked against the rules of a schema
+type validatedDocument struct {
+	query  string
+	schema *graphql.Schema
+	doc    *ast.Document
+}
+
+// withValidatedDocument records that query was validated against schema as doc
+func withValidatedDocument(ctx context.Context, query string, schema *graphql.Schema, doc *ast.Document) context.Context {
+	return context.WithValue(ctx, validatedDocumentKey{}, &validatedDocument{query: query, schema: schema, doc: doc})
+}
+
+// validatedDocument returns the validated document of the query of params, or nil when
+// the query was not validated against the schema of params
+func (params ExecuteParams) validatedDocument() *ast.Document {
+	if params.Context == nil || params.Schema == nil {
+		return nil
+	}
+	validated, ok := params.Context.Value(validatedDocumentKey{}).(*validatedDocument)
+	if !ok || validated.query != params.Query || validated.schema != params.Schema {
+		return nil
+	}
+	return validated.doc
+}
+
+// executorFor returns the configured executor, or the graphql-go executor, applying
+// the null propagation policy, the slow operation log and the DEBUG recorder
+func executorFor(graphCtx *GraphContext) Executor {
+	var executor Executor = GraphQLGoExecutor{}
+	if graphCtx == nil {
+		return executor
+	}
+	if graphCtx.Executor != nil {
+		executor = graphCtx.Executor
+	}
+	if graphCtx.NullPropagation == MaskNulls {
+		executor = maskingExecutor{executor}
+	}
+	if graphCtx.SlowQueries != nil {
+		executor = newSlowQueryExecutor(executor, graphCtx.SlowQueries)
+	}
+	if graphCtx.DEBUG && graphCtx.Recorder != nil {
+		executor = recordingExecutor{Executor: executor, recorder: graphCtx.Recorder}
+	}
+	return executor
+}
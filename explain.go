@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ExplainHeader requests the resolver call tree of an operation, like "explain": true in the extensions
+const ExplainHeader = "X-GraphQL-Explain"
+
+// ExplainedField is one resolver call of an explained operation
+type ExplainedField struct {
+	Path     string            `json:"path"`     // Response path, e.g. "user.posts.0.title"
+	Field    string            `json:"field"`    // Schema coordinate, e.g. "Post.title"
+	Offset   time.Duration     `json:"offset"`   // Start relative to the operation, nanoseconds in JSON
+	Duration time.Duration     `json:"duration"` // Nanoseconds in JSON, including deferred (batched) work
+	CacheHit bool              `json:"cacheHit,omitempty"`
+	Hedges   int               `json:"hedges,omitempty"` // Attempts beyond the first (see HedgeMiddleware)
+	Error    string            `json:"error,omitempty"`
+	Children []*ExplainedField `json:"children,omitempty"`
+}
+
+// explainTrace collects the resolver calls of one operation
+type explainTrace struct {
+	mu     sync.Mutex
+	start  time.Time
+	fields []*ExplainedField
+	byPath map[string]*ExplainedField
+}
+
+type explainContextKey struct{}
+
+// newExplainTrace starts a trace at the current time
+func newExplainTrace() *explainTrace {
+	return &explainTrace{start: time.Now(), byPath: make(map[string]*ExplainedField)}
+}
+
+// explainTraceFrom returns the trace of the operation being executed, if it is explained
+func explainTraceFrom(ctx context.Context) *explainTrace {
+	if ctx == nil {
+		return nil
+	}
+	trace, _ := ctx.Value(explainContextKey{}).(*explainTrace)
+	return trace
+}
+
+// markCacheHit records that the field being resolved was served from a cache
+func markCacheHit(p graphql.ResolveParams) {
+	trace := explainTraceFrom(p.Context)
+	if trace == nil || p.Info.Path == nil {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if field, ok := trace.byPath[explainPath(p.Info.Path.AsArray())]; ok {
+		field.CacheHit = true
+	}
+}
+
+// markHedged records the extra attempts made by the field being resolved
+func markHedged(p graphql.ResolveParams, hedges int) {
+	trace := explainTraceFrom(p.Context)
+	if trace == nil || p.Info.Path == nil {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if field, ok := trace.byPath[explainPath(p.Info.Path.AsArray())]; ok {
+		field.Hedges += hedges
+	}
+}
+
+// explainResolvers times every resolver of an explained operation; other operations
+// are resolved directly
+func explainResolvers(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		trace := explainTraceFrom(p.Context)
+		if trace == nil || p.Info.Path == nil {
+			return next(p)
+		}
+
+		field := &ExplainedField{
+			Path:  explainPath(p.Info.Path.AsArray()),
+			Field: p.Info.ParentType.Name() + "." + p.Info.FieldName,
+		}
+		start := time.Now()
+
+		trace.mu.Lock()
+		field.Offset = start.Sub(trace.start)
+		trace.fields = append(trace.fields, field)
+		trace.byPath[field.Path] = field
+		trace.mu.Unlock()
+
+		finish := func(value interface{}, err error) (interface{}, error) {
+			trace.mu.Lock()
+			field.Duration = time.Since(start)
+			if err != nil {
+				field.Error = err.Error()
+			}
+			trace.mu.Unlock()
+			return value, err
+		}
+
+		value, err := next(p)
+		if thunk, ok := value.(func() (interface{}, error)); ok && err == nil {
+			return func() (interface{}, error) {
+				return finish(thunk())
+			}, nil
+		}
+		return finish(value, err)
+	}
+}
+
+// tree returns the resolver calls nested under their parent fields, in call order
+func (t *explainTrace) tree() []*ExplainedField {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var roots []*ExplainedField
+	for _, field := range t.fields {
+		field.Children = nil
+	}
+	for _, field := range t.fields {
+		if parent, ok := t.byPath[explainParentPath(field.Path)]; ok {
+			parent.Children = append(parent.Children, field)
+		} else {
+			roots = append(roots, field)
+		}
+	}
+	return roots
+}
+
+// explainPath joins a response path with dots
+func explainPath(path []interface{}) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = fmt.Sprint(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// explainParentPath returns the path of the field a path belongs to, skipping list indexes
+func explainParentPath(path string) string {
+	keys := strings.Split(path, ".")
+	keys = keys[:len(keys)-1]
+	for len(keys) > 0 && isListIndex(keys[len(keys)-1]) {
+		keys = keys[:len(keys)-1]
+	}
+	return strings.Join(keys, ".")
+}
+
+// isListIndex reports whether a path key is a list index
+func isListIndex(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isExplainRequest reports whether a request asks for its resolver call tree
+func isExplainRequest(r *http.Request) bool {
+	if strings.EqualFold(r.Header.Get(ExplainHeader), "true") {
+		return true
+	}
+	explain, _ := requestExtensions(r)["explain"].(bool)
+	return explain
+}
+
+// explainRequest attaches a trace to the request and returns the decorator adding the
+// call tree to the response extensions
+func explainRequest(r *http.Request) (*http.Request, ResponseDecorator) {
+	trace := newExplainTrace()
+	r = r.WithContext(context.WithValue(r.Context(), explainContextKey{}, trace))
+
+	return r, func(ctx context.Context, response *graphql.Result) {
+		if response.Extensions == nil {
+			response.Extensions = make(map[string]interface{})
+		}
+		response.Extensions["explain"] = map[string]interface{}{
+			"duration":  time.Since(trace.start),
+			"resolvers": trace.tree(),
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HealthCheckPath is where Apollo Router, Apollo Gateway and most load balancers
+// configured for Apollo Server look for the health of a subgraph
+const HealthCheckPath = "/.well-known/apollo/server-health"
+
+// HealthHandler reports whether the schema of graphCtx can be built, in the format of
+// Apollo Server's health check: 200 with {"status":"pass"}, or 503 with
+// {"status":"fail"}. The schema is built once, when the handler is created.
+//
+// Example:
+//
+//	http.Handle(graph.HealthCheckPath, graph.HealthHandler(graphCtx))
+func HealthHandler(graphCtx *GraphContext) http.HandlerFunc {
+	if graphCtx == nil {
+		graphCtx = &GraphContext{}
+	}
+	_, err := buildSchemaFromContext(graphCtx)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/health+json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		status := "pass"
+		if err != nil {
+			status = "fail"
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": status})
+	}
+}
+
+// SDLHandler serves the schema of graphCtx in the schema definition language, so
+// gateways and schema registries can fetch it without running an introspection query,
+// which production setups usually block.
+//
+// The schema describes the whole API, so the endpoint is authenticated: authorize
+// decides which requests may read it. When authorize is nil, requests need either a
+// caller authenticated by a valid RequestSignature or, with ClientCertAuth, a client
+// certificate, that the context's CallerDetailsFn, if set, accepts; or a token found by
+// its TokenExtractorFn (a Bearer token by default) that TokenRevokedFn, if set, does
+// not report and UserDetailsFn accepts. Without UserDetailsFn nothing vouches for
+// tokens, so they are all refused. Other requests get 401.
+//
+// Example:
+//
+//	http.Handle("/graphql/schema", graph.SDLHandler(graphCtx, func(r *http.Request) bool {
+//	    return r.Header.Get("X-Gateway-Key") == os.Getenv("GATEWAY_KEY")
+//	}))
+func SDLHandler(graphCtx *GraphContext, authorize func(r *http.Request) bool) http.HandlerFunc {
+	if graphCtx == nil {
+		graphCtx = &GraphContext{}
+	}
+	schema, err := buildSchemaFromContext(graphCtx)
+	if err != nil {
+		panic("failed to build GraphQL schema: " + err.Error())
+	}
+	sdl := PrintSchema(schema)
+
+	if authorize == nil {
+		revocations := newRevocationCache(graphCtx.TokenRevokedFn)
+		authorize = func(r *http.Request) bool {
+			return hasValidToken(graphCtx, revocations, r)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorize(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(sdl))
+	}
+}
+
+// hasValidToken reports whether a request carries a caller or a token accepted by the
+// context, failing closed for tokens when UserDetailsFn is unset
+func hasValidToken(graphCtx *GraphContext, revocations *revocationCache, r *http.Request) bool {
+	if graphCtx.RequestSignature != nil && graphCtx.RequestSignature.isSigned(r) {
+		keyID, err := VerifySignature(r, *graphCtx.RequestSignature)
+		if err != nil {
+			return false
+		}
+		r = withCaller(r, Caller{Method: CallerSignature, ID: keyID})
+	}
+	if caller, ok := requestCaller(graphCtx, r); ok {
+		if graphCtx.CallerDetailsFn != nil {
+			if _, err := graphCtx.CallerDetailsFn(caller); err != nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	token := requestToken(graphCtx, r)
+	if token == "" || graphCtx.UserDetailsFn == nil {
+		return false
+	}
+	if revocations != nil && revocations.isRevoked(token) {
+		return false
+	}
+	_, err := graphCtx.UserDetailsFn(token)
+	return err == nil
+}
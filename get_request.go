@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/handler"
+)
+
+// queryParam returns the first value of the name parameter of a raw URL query, or "".
+// The query is scanned in place, so only the value found is unescaped, unlike
+// url.ParseQuery which allocates every parameter on each call.
+func queryParam(rawQuery, name string) string {
+	for rawQuery != "" {
+		var pair string
+		pair, rawQuery, _ = strings.Cut(rawQuery, "&")
+		key, value, _ := strings.Cut(pair, "=")
+		if key != name {
+			if !strings.ContainsAny(key, "%+") {
+				continue
+			}
+			if unescaped, err := url.QueryUnescape(key); err != nil || unescaped != name {
+				continue
+			}
+		}
+		if !strings.ContainsAny(value, "%+") {
+			return value
+		}
+		unescaped, err := url.QueryUnescape(value)
+		if err != nil {
+			return ""
+		}
+		return unescaped
+	}
+	return ""
+}
+
+// getRequestOptions reads the query, variables and operationName parameters of a
+// request URL, per the GraphQL over HTTP specification. variables must be a
+// JSON-encoded object, decoded with codec.
+func getRequestOptions(r *http.Request, codec Codec) (*handler.RequestOptions, error) {
+	opts := &handler.RequestOptions{
+		Query:         queryParam(r.URL.RawQuery, "query"),
+		OperationName: queryParam(r.URL.RawQuery, "operationName"),
+	}
+	if variables := queryParam(r.URL.RawQuery, "variables"); variables != "" {
+		if err := codec.Unmarshal([]byte(variables), &opts.Variables); err != nil {
+			return opts, fmt.Errorf("variables must be a JSON-encoded object: %v", err)
+		}
+	}
+	return opts, nil
+}
+
+// rejectGetRequest answers the GET requests the specification does not allow to
+// execute, and reports true: malformed variables with 400, and mutations with
+// 405 Method Not Allowed
+func rejectGetRequest(w http.ResponseWriter, r *http.Request, codec Codec) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	opts, err := getRequestOptions(r, codec)
+	if err != nil {
+		writeRequestError(w, http.StatusBadRequest, err.Error(), "BAD_REQUEST")
+		return true
+	}
+	if opts.Query == "" {
+		return false
+	}
+	if op := selectOperation(opts.Query, opts.OperationName); op != nil && op.Operation == ast.OperationTypeMutation {
+		w.Header().Set("Allow", http.MethodPost)
+		writeRequestError(w, http.StatusMethodNotAllowed, "mutations cannot be sent with GET, use POST", "METHOD_NOT_ALLOWED")
+		return true
+	}
+	return false
+}
+
+// writeRequestError answers a request rejected before execution with a GraphQL error
+func writeRequestError(w http.ResponseWriter, status int, message, code string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    message,
+			"extensions": map[string]interface{}{"code": code},
+		}},
+	})
+}
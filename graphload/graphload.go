@@ -0,0 +1,348 @@
+// Package graphload runs load tests against a GraphQL endpoint.
+//
+// A load test sends a weighted mix of operations, each with its own variables and
+// auth token, from concurrent workers to an in-process handler or a remote URL, and
+// reports latency percentiles per operation.
+//
+// Example:
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{SchemaParams: params})
+//
+//	result, err := graphload.Run(ctx, graphload.Config{
+//	    Handler:     handler, // or URL: "https://api.example.com/graphql"
+//	    Concurrency: 50,
+//	    Duration:    time.Minute,
+//	    Operations: []graphload.Operation{
+//	        {Name: "feed", Query: feedQuery, Weight: 8, Token: readerToken},
+//	        {Name: "post", Query: postMutation, Variables: post, Weight: 2, Token: writerToken},
+//	    },
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result.WriteReport(os.Stdout)
+package graphload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Operation is one GraphQL operation of a load test mix
+type Operation struct {
+	// Name labels the operation in results. Default: "operation <index>"
+	Name string
+
+	Query     string
+	Variables map[string]interface{}
+
+	// Weight is the share of requests sending the operation, relative to the weights
+	// of the other operations. Default: 1
+	Weight int
+
+	// Token is sent as a Bearer Authorization header when set
+	Token string
+}
+
+// Config describes a load test
+type Config struct {
+	// Operations is the mix of operations to send
+	Operations []Operation
+
+	// Handler serves the requests in process, without a network. Set Handler or URL.
+	Handler http.Handler
+
+	// URL of a remote GraphQL endpoint
+	URL string
+
+	// Client sends requests to URL. Default: http.DefaultClient
+	Client *http.Client
+
+	// Header is added to every request
+	Header http.Header
+
+	// Concurrency is the number of workers sending requests. Default: 1
+	Concurrency int
+
+	// Requests stops the test after this many requests. Default: 1000 when Duration
+	// is zero
+	Requests int
+
+	// Duration stops the test after this long
+	Duration time.Duration
+
+	// Seed seeds the choice of operations, so a mix is replayed in the same order
+	Seed int64
+}
+
+// Percentiles summarizes a latency distribution
+type Percentiles struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// OperationResult is the outcome of the requests sending one operation
+type OperationResult struct {
+	Requests int         `json:"requests"`
+	Errors   int         `json:"errors"` // Failed requests, or responses with GraphQL errors
+	Latency  Percentiles `json:"latency"`
+}
+
+// Result is the outcome of a load test
+type Result struct {
+	Requests   int                         `json:"requests"`
+	Errors     int                         `json:"errors"`
+	Elapsed    time.Duration               `json:"elapsed"`
+	Throughput float64                     `json:"throughput"` // Requests per second
+	Latency    Percentiles                 `json:"latency"`
+	Operations map[string]*OperationResult `json:"operations"`
+}
+
+// sample is the outcome of one request
+type sample struct {
+	operation int
+	latency   time.Duration
+	failed    bool
+}
+
+// Run sends the operations of cfg until Requests are sent, Duration is over or ctx is
+// done, and returns the latencies measured. Requests fail on transport errors, non-2xx
+// statuses and responses with GraphQL errors; failures are counted, not returned.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	if len(cfg.Operations) == 0 {
+		return nil, errors.New("graphload: no operations")
+	}
+	if (cfg.Handler == nil) == (cfg.URL == "") {
+		return nil, errors.New("graphload: set either Handler or URL")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Requests <= 0 && cfg.Duration <= 0 {
+		cfg.Requests = 1000
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	bodies := make([][]byte, len(cfg.Operations))
+	cumulative := make([]int, len(cfg.Operations))
+	total := 0
+	for i, op := range cfg.Operations {
+		body, err := json.Marshal(map[string]interface{}{"query": op.Query, "variables": op.Variables})
+		if err != nil {
+			return nil, fmt.Errorf("graphload: operation %s: %w", operationName(cfg.Operations, i), err)
+		}
+		bodies[i] = body
+		if op.Weight > 0 {
+			total += op.Weight
+		} else {
+			total++
+		}
+		cumulative[i] = total
+	}
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var sent int64
+	samples := make([][]sample, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(cfg.Seed + int64(w)))
+			for ctx.Err() == nil {
+				if cfg.Requests > 0 && atomic.AddInt64(&sent, 1) > int64(cfg.Requests) {
+					return
+				}
+				i := sort.SearchInts(cumulative, r.Intn(total)+1)
+				begin := time.Now()
+				err := send(ctx, cfg, cfg.Operations[i], bodies[i])
+				if err != nil && ctx.Err() != nil {
+					// Cut short by the end of the test
+					return
+				}
+				samples[w] = append(samples[w], sample{operation: i, latency: time.Since(begin), failed: err != nil})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return summarize(cfg.Operations, samples, time.Since(start)), nil
+}
+
+// send sends one operation and returns why it failed
+func send(ctx context.Context, cfg Config, op Operation, body []byte) error {
+	url := cfg.URL
+	if url == "" {
+		url = "/graphql"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range cfg.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if op.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+op.Token)
+	}
+
+	var status int
+	var response []byte
+	if cfg.Handler != nil {
+		recorder := httptest.NewRecorder()
+		cfg.Handler.ServeHTTP(recorder, req)
+		status, response = recorder.Code, recorder.Body.Bytes()
+	} else {
+		resp, err := cfg.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if response, err = io.ReadAll(resp.Body); err != nil {
+			return err
+		}
+		status = resp.StatusCode
+	}
+
+	if status < 200 || status > 299 {
+		return fmt.Errorf("status %d", status)
+	}
+	var result struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if json.Unmarshal(response, &result) == nil && len(result.Errors) > 0 {
+		return errors.New(string(result.Errors[0]))
+	}
+	return nil
+}
+
+// summarize computes the result of a load test from the samples of its workers
+func summarize(ops []Operation, samples [][]sample, elapsed time.Duration) *Result {
+	result := &Result{Elapsed: elapsed, Operations: make(map[string]*OperationResult, len(ops))}
+	var all []time.Duration
+	latencies := make([][]time.Duration, len(ops))
+	for i := range ops {
+		result.Operations[operationName(ops, i)] = &OperationResult{}
+	}
+
+	for _, worker := range samples {
+		for _, s := range worker {
+			op := result.Operations[operationName(ops, s.operation)]
+			op.Requests++
+			result.Requests++
+			if s.failed {
+				op.Errors++
+				result.Errors++
+			}
+			latencies[s.operation] = append(latencies[s.operation], s.latency)
+			all = append(all, s.latency)
+		}
+	}
+
+	for i := range ops {
+		result.Operations[operationName(ops, i)].Latency = percentiles(latencies[i])
+	}
+	result.Latency = percentiles(all)
+	if elapsed > 0 {
+		result.Throughput = float64(result.Requests) / elapsed.Seconds()
+	}
+	return result
+}
+
+// percentiles summarizes latencies with the nearest-rank method
+func percentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+	rank := func(p int) time.Duration {
+		i := (p*len(latencies)+99)/100 - 1
+		return latencies[max(i, 0)]
+	}
+	return Percentiles{
+		Min:  latencies[0],
+		Mean: sum / time.Duration(len(latencies)),
+		P50:  rank(50),
+		P90:  rank(90),
+		P95:  rank(95),
+		P99:  rank(99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
+
+// operationName returns the name of operation i in results
+func operationName(ops []Operation, i int) string {
+	if ops[i].Name != "" {
+		return ops[i].Name
+	}
+	return fmt.Sprintf("operation %d", i)
+}
+
+// WriteReport writes the result as a table of latency percentiles per operation
+func (r *Result) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "operation\trequests\terrors\tmean\tp50\tp90\tp95\tp99\tmax\t")
+
+	names := make([]string, 0, len(r.Operations))
+	for name := range r.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	row := func(name string, requests, errors int, l Percentiles) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t\n", name, requests, errors,
+			round(l.Mean), round(l.P50), round(l.P90), round(l.P95), round(l.P99), round(l.Max))
+	}
+	for _, name := range names {
+		op := r.Operations[name]
+		row(name, op.Requests, op.Errors, op.Latency)
+	}
+	row("total", r.Requests, r.Errors, r.Latency)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%.1f requests/s over %s\n", r.Throughput, round(r.Elapsed))
+	return err
+}
+
+// round rounds a latency for reports
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,165 @@
+package graphload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer answers every operation, failing those named "fail"
+type fakeServer struct {
+	mu      sync.Mutex
+	queries map[string]int
+	tokens  map[string]string
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	s.mu.Lock()
+	s.queries[body.Query]++
+	s.tokens[body.Query] = r.Header.Get("Authorization")
+	s.mu.Unlock()
+
+	switch body.Query {
+	case "fail":
+		_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"boom"}]}`))
+	case "unavailable":
+		w.WriteHeader(http.StatusServiceUnavailable)
+	default:
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}
+}
+
+// served returns the number of requests received for query
+func (s *fakeServer) served(query string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries[query]
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{queries: make(map[string]int), tokens: make(map[string]string)}
+}
+
+func TestRun_Handler(t *testing.T) {
+	server := newFakeServer()
+	result, err := Run(context.Background(), Config{
+		Handler:     server,
+		Concurrency: 4,
+		Requests:    400,
+		Operations: []Operation{
+			{Name: "read", Query: "read", Weight: 3, Token: "reader"},
+			{Name: "broken", Query: "fail"},
+			{Query: "unavailable", Variables: map[string]interface{}{"id": 1}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Requests != 400 {
+		t.Errorf("expected 400 requests, got %d", result.Requests)
+	}
+	read, broken, unnamed := result.Operations["read"], result.Operations["broken"], result.Operations["operation 2"]
+	if read == nil || broken == nil || unnamed == nil {
+		t.Fatalf("expected results per operation, got %v", result.Operations)
+	}
+	if read.Requests < 200 || read.Requests > 280 {
+		t.Errorf("expected about 3/5 of the requests to read, got %d", read.Requests)
+	}
+	if read.Errors != 0 || broken.Errors != broken.Requests || unnamed.Errors != unnamed.Requests {
+		t.Errorf("unexpected errors: read %d, broken %d/%d, unavailable %d/%d",
+			read.Errors, broken.Errors, broken.Requests, unnamed.Errors, unnamed.Requests)
+	}
+	if result.Errors != broken.Requests+unnamed.Requests {
+		t.Errorf("expected %d errors, got %d", broken.Requests+unnamed.Requests, result.Errors)
+	}
+	if server.tokens["read"] != "Bearer reader" || server.tokens["fail"] != "" {
+		t.Errorf("unexpected Authorization headers: %v", server.tokens)
+	}
+
+	l := result.Latency
+	if l.Min <= 0 || l.Min > l.P50 || l.P50 > l.P90 || l.P90 > l.P99 || l.P99 > l.Max || result.Throughput <= 0 {
+		t.Errorf("unexpected latency summary: %+v, throughput %v", l, result.Throughput)
+	}
+
+	var report bytes.Buffer
+	if err := result.WriteReport(&report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"operation", "p99", "read", "broken", "total", "requests/s"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("expected %q in report:\n%s", want, report.String())
+		}
+	}
+}
+
+func TestRun_URL(t *testing.T) {
+	server := newFakeServer()
+	remote := httptest.NewServer(server)
+	defer remote.Close()
+
+	result, err := Run(context.Background(), Config{
+		URL:         remote.URL,
+		Header:      http.Header{"X-Load-Test": {"1"}},
+		Concurrency: 2,
+		Duration:    50 * time.Millisecond,
+		Operations:  []Operation{{Name: "read", Query: "read"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Requests cut short at the end of the test are served but not measured; closing
+	// the server waits for them
+	remote.Close()
+	if served := server.served("read"); result.Requests == 0 || result.Requests > served || result.Errors != 0 {
+		t.Errorf("expected up to %d error-free requests, got %d with %d errors", served, result.Requests, result.Errors)
+	}
+}
+
+func TestRun_Config(t *testing.T) {
+	ops := []Operation{{Query: "read"}}
+	for name, cfg := range map[string]Config{
+		"no operations":   {Handler: newFakeServer()},
+		"no target":       {Operations: ops},
+		"handler and URL": {Operations: ops, Handler: newFakeServer(), URL: "http://localhost"},
+	} {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	got := percentiles(latencies)
+	want := Percentiles{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P95:  95 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if percentiles(nil) != (Percentiles{}) {
+		t.Error("expected zero percentiles without latencies")
+	}
+}
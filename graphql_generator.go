@@ -14,15 +14,14 @@ import (
 var (
 	globalProcessingTypes   = make(map[reflect.Type]bool)
 	globalProcessingTypesMu sync.RWMutex
-	// Object type registry to avoid duplicate type creation
-	objectTypeRegistry   = make(map[string]*graphql.Object)
-	objectTypeRegistryMu sync.RWMutex
 )
 
 type FieldGenerator[T any] struct {
 	typeCache       map[reflect.Type]graphql.Output
 	processingTypes map[reflect.Type]bool
 	objectTypeName  *string
+	// anonymousTypeName names the next anonymous struct type encountered
+	anonymousTypeName string
 }
 
 func NewFieldGenerator[T any]() *FieldGenerator[T] {
@@ -49,6 +48,104 @@ func GenerateGraphQLObject[T any](name string) *graphql.Object {
 	})
 }
 
+// structField is an exported struct field together with its index path from the
+// outer struct, so fields promoted from embedded structs can be read with fieldByIndex
+type structField struct {
+	reflect.StructField
+	index []int
+}
+
+// collectStructFields returns the exported fields of a struct type, promoting the
+// fields of embedded structs to the outer level the way encoding/json does:
+//   - An embedded struct (or pointer to struct) without a json name is flattened
+//   - An embedded struct with a json name stays a nested field
+//   - Fields declared at a shallower depth shadow promoted fields with the same name
+func collectStructFields(t reflect.Type) []structField {
+	var fields []structField
+	var embedded []structField
+	seen := make(map[string]bool)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if isPromotedEmbedded(field) {
+			embedded = append(embedded, structField{StructField: field, index: []int{i}})
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fieldName := getFieldName(field)
+		if fieldName == "-" {
+			continue
+		}
+
+		seen[fieldName] = true
+		fields = append(fields, structField{StructField: field, index: []int{i}})
+	}
+
+	for _, embed := range embedded {
+		embedType := embed.Type
+		if embedType.Kind() == reflect.Ptr {
+			embedType = embedType.Elem()
+		}
+
+		for _, promoted := range collectStructFields(embedType) {
+			fieldName := getFieldName(promoted.StructField)
+			if seen[fieldName] {
+				continue
+			}
+			seen[fieldName] = true
+
+			promoted.index = append(append([]int{}, embed.index...), promoted.index...)
+			fields = append(fields, promoted)
+		}
+	}
+
+	return fields
+}
+
+// isPromotedEmbedded reports whether an embedded field's own fields should be promoted
+func isPromotedEmbedded(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		// encoding/json ignores embedded pointers to unexported struct types
+		if field.PkgPath != "" {
+			return false
+		}
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(JSONTime{}) {
+		return false
+	}
+
+	// An explicit json name keeps the embedded struct as a nested field
+	jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+	return jsonName == ""
+}
+
+// fieldByIndex returns the nested field for an index path. Unlike reflect.Value.FieldByIndex
+// it reports false instead of panicking when the path passes through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
 func (g *FieldGenerator[T]) generateFields(t reflect.Type) graphql.Fields {
 
 	if t.Kind() == reflect.Ptr {
@@ -61,26 +158,27 @@ func (g *FieldGenerator[T]) generateFields(t reflect.Type) graphql.Fields {
 
 	fields := graphql.Fields{}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-
-		if field.PkgPath != "" {
-			continue
-		}
+	for _, sf := range collectStructFields(t) {
+		field := sf.StructField
+		index := sf.index
 
 		fieldName := g.getFieldName(field)
 		if fieldName == "-" {
 			continue
 		}
+
+		// Anonymous nested structs are named after their parent type and field
+		g.anonymousTypeName = t.Name() + field.Name
 		graphqlType := g.getGraphQLType(field.Type, field)
 		if graphqlType == nil {
 			continue
 		}
 
-		description := field.Tag.Get("description")
+		description := fieldDescription(field)
 		fields[fieldName] = &graphql.Field{
-			Type:        graphqlType,
-			Description: description,
+			Type:              graphqlType,
+			Description:       description,
+			DeprecationReason: fieldDeprecationReason(field),
 			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 				source := reflect.ValueOf(p.Source)
 				if source.Kind() == reflect.Ptr {
@@ -91,7 +189,12 @@ func (g *FieldGenerator[T]) generateFields(t reflect.Type) graphql.Fields {
 					return nil, fmt.Errorf("expected struct, got %v", source.Kind())
 				}
 
-				fieldValue := source.FieldByName(field.Name)
+				var fieldValue reflect.Value
+				if source.Type() == t {
+					fieldValue, _ = fieldByIndex(source, index)
+				} else {
+					fieldValue = source.FieldByName(field.Name)
+				}
 				if !fieldValue.IsValid() {
 					return nil, nil
 				}
@@ -105,9 +208,14 @@ func (g *FieldGenerator[T]) generateFields(t reflect.Type) graphql.Fields {
 }
 
 func (g *FieldGenerator[T]) getGraphQLType(t reflect.Type, field reflect.StructField) graphql.Output {
-	isRequired := strings.Contains(field.Tag.Get("graphql"), "required")
+	isRequired := isRequiredField(field)
 
-	baseType := g.getBaseGraphQLType(t, g.objectTypeName)
+	var baseType graphql.Output
+	if idType := idFieldType(t, field); idType != nil {
+		baseType = idType
+	} else {
+		baseType = g.getBaseGraphQLType(t, g.objectTypeName)
+	}
 
 	if baseType == nil {
 		return nil
@@ -162,11 +270,15 @@ func (g *FieldGenerator[T]) getBaseGraphQLType(t reflect.Type, objectTypeName *s
 		} else if t == reflect.TypeOf(JSONTime{}) {
 			return DateTime
 		}
+		typeName := t.Name()
+		if typeName == "" {
+			typeName = g.anonymousTypeName
+		}
 		nameObject := ""
 		if g.objectTypeName != nil {
-			nameObject = fmt.Sprintf("%s_%s", *g.objectTypeName, t.Name())
+			nameObject = fmt.Sprintf("%s_%s", *g.objectTypeName, typeName)
 		} else {
-			nameObject = t.Name()
+			nameObject = typeName
 		}
 		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
 			elemType := g.getBaseGraphQLType(t.Elem(), objectTypeName)
@@ -175,45 +287,41 @@ func (g *FieldGenerator[T]) getBaseGraphQLType(t reflect.Type, objectTypeName *s
 			}
 			return graphql.NewList(elemType)
 		} else {
-			// Check if object type already exists in the registry
-			objectTypeRegistryMu.RLock()
-			if existingType, exists := objectTypeRegistry[nameObject]; exists {
-				objectTypeRegistryMu.RUnlock()
-				return existingType
-			}
-			objectTypeRegistryMu.RUnlock()
-
-			// Create new object type
-			objectTypeRegistryMu.Lock()
-			defer objectTypeRegistryMu.Unlock()
-
-			// Double-check in case another goroutine created it
-			if existingType, exists := objectTypeRegistry[nameObject]; exists {
+			// Reuse a type registered by a resolver (e.g. NewResolver[T]) so
+			// references back to a root type don't create a duplicate
+			if existingType, exists := typeRegistry.load(nameObject); exists {
 				return existingType
 			}
 
-			newObjectType := graphql.NewObject(graphql.ObjectConfig{
-				Name: nameObject,
-				Fields: (graphql.FieldsThunk)(func() graphql.Fields {
-					fields := g.generateFields(t)
-					if len(fields) == 0 {
-						// Add a placeholder field if no fields generated
-						fields = graphql.Fields{
-							"id": &graphql.Field{
-								Type:        graphql.String,
-								Description: "Placeholder field for " + nameObject,
-							},
+			return objectTypeRegistry.loadOrCreate(nameObject, func() *graphql.Object {
+				newObjectType := graphql.NewObject(graphql.ObjectConfig{
+					Name:       nameObject,
+					Interfaces: objectInterfacesThunk(t),
+					Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+						fields := g.generateFields(t)
+						if len(fields) == 0 {
+							// Add a placeholder field if no fields generated
+							fields = graphql.Fields{
+								"id": &graphql.Field{
+									Type:        graphql.String,
+									Description: "Placeholder field for " + nameObject,
+								},
+							}
 						}
-					}
-					return fields
-				}),
+						return wrapFieldsWithPostProcessHook(fields)
+					}),
+				})
+				registerObjectGoType(t, newObjectType, false)
+				return newObjectType
 			})
-
-			// Register the new object type
-			objectTypeRegistry[nameObject] = newObjectType
-			return newObjectType
 		}
 	case reflect.Interface:
+		if iface := lookupInterface(t); iface != nil {
+			return iface
+		}
+		if union := lookupUnion(t); union != nil {
+			return union
+		}
 		return graphql.NewScalar(graphql.ScalarConfig{
 			Name: "Interface",
 			Serialize: func(value interface{}) interface{} {
@@ -227,35 +335,7 @@ func (g *FieldGenerator[T]) getBaseGraphQLType(t reflect.Type, objectTypeName *s
 }
 
 func (g *FieldGenerator[T]) getFieldName(field reflect.StructField) string {
-	jsonTag := field.Tag.Get("json")
-	if jsonTag != "" {
-		parts := strings.Split(jsonTag, ",")
-		if parts[0] != "" {
-			return parts[0]
-		}
-	}
-
-	graphqlTag := field.Tag.Get("graphql")
-	if graphqlTag != "" {
-		parts := strings.Split(graphqlTag, ",")
-		for _, part := range parts {
-			if !strings.Contains(part, "=") && part != "required" {
-				return part
-			}
-		}
-	}
-
-	return g.toGraphQLFieldName(field.Name)
-}
-
-func (g *FieldGenerator[T]) toGraphQLFieldName(name string) string {
-	if name == "" {
-		return ""
-	}
-
-	runes := []rune(name)
-	runes[0] = []rune(strings.ToLower(string(runes[0])))[0]
-	return string(runes)
+	return getFieldName(field)
 }
 
 func GenerateInputObject[T any](name string) *graphql.InputObject {
@@ -280,12 +360,8 @@ func (g *FieldGenerator[T]) generateInputFields(t reflect.Type) graphql.InputObj
 
 	fields := graphql.InputObjectConfigFieldMap{}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-
-		if field.PkgPath != "" {
-			continue
-		}
+	for _, sf := range collectStructFields(t) {
+		field := sf.StructField
 
 		fieldName := g.getFieldName(field)
 		if fieldName == "-" {
@@ -297,7 +373,7 @@ func (g *FieldGenerator[T]) generateInputFields(t reflect.Type) graphql.InputObj
 			continue
 		}
 
-		description := field.Tag.Get("description")
+		description := fieldDescription(field)
 		defaultValue := field.Tag.Get("default")
 
 		fieldConfig := &graphql.InputObjectFieldConfig{
@@ -316,9 +392,14 @@ func (g *FieldGenerator[T]) generateInputFields(t reflect.Type) graphql.InputObj
 }
 
 func (g *FieldGenerator[T]) getInputType(t reflect.Type, field reflect.StructField) graphql.Input {
-	isRequired := strings.Contains(field.Tag.Get("graphql"), "required")
+	isRequired := isRequiredField(field)
 
-	baseType := g.getBaseInputType(t, field.Name)
+	var baseType graphql.Input
+	if idType := idFieldType(t, field); idType != nil {
+		baseType = idType
+	} else {
+		baseType = g.getBaseInputType(t, field.Name)
+	}
 
 	if baseType == nil {
 		return nil
@@ -332,9 +413,14 @@ func (g *FieldGenerator[T]) getInputType(t reflect.Type, field reflect.StructFie
 }
 
 func (g *FieldGenerator[T]) getInputTypeWithContext(t reflect.Type, field reflect.StructField, parentTypeName string) graphql.Input {
-	isRequired := strings.Contains(field.Tag.Get("graphql"), "required")
+	isRequired := isRequiredField(field)
 
-	baseType := g.getBaseInputTypeWithContext(t, field.Name, parentTypeName)
+	var baseType graphql.Input
+	if idType := idFieldType(t, field); idType != nil {
+		baseType = idType
+	} else {
+		baseType = g.getBaseInputTypeWithContext(t, field.Name, parentTypeName)
+	}
 
 	if baseType == nil {
 		return nil
@@ -389,35 +475,16 @@ func (g *FieldGenerator[T]) getBaseInputTypeWithContext(t reflect.Type, fieldNam
 			inputTypeName = getInputTypeName(t, fieldName)
 		}
 
-		// Check if input type already exists in the global registry (from unified resolver)
-		inputTypeRegistryMu.RLock()
-		if existingType, exists := inputTypeRegistry[inputTypeName]; exists {
-			inputTypeRegistryMu.RUnlock()
-			return existingType
-		}
-		inputTypeRegistryMu.RUnlock()
-
-		// Create new input type
-		inputTypeRegistryMu.Lock()
-		defer inputTypeRegistryMu.Unlock()
-
-		// Double-check in case another goroutine created it
-		if existingType, exists := inputTypeRegistry[inputTypeName]; exists {
-			return existingType
-		}
-
-		newInputType := graphql.NewInputObject(graphql.InputObjectConfig{
-			Name: inputTypeName,
-			Fields: (graphql.InputObjectConfigFieldMapThunk)(func() graphql.InputObjectConfigFieldMap {
-				return g.generateInputFields(t)
-			}),
+		// Shares the global registry with the unified resolver
+		return inputTypeRegistry.loadOrCreate(inputTypeName, func() *graphql.InputObject {
+			return graphql.NewInputObject(graphql.InputObjectConfig{
+				Name: inputTypeName,
+				Fields: (graphql.InputObjectConfigFieldMapThunk)(func() graphql.InputObjectConfigFieldMap {
+					return g.generateInputFields(t)
+				}),
+			})
 		})
 
-		// Register the new input type
-		inputTypeRegistry[inputTypeName] = newInputType
-
-		return newInputType
-
 	default:
 		return nil
 	}
@@ -445,12 +512,8 @@ func GenerateArgsFromStruct[T any]() graphql.FieldConfigArgument {
 
 	args := graphql.FieldConfigArgument{}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-
-		if field.PkgPath != "" {
-			continue
-		}
+	for _, sf := range collectStructFields(t) {
+		field := sf.StructField
 
 		fieldName := gen.getFieldName(field)
 		if fieldName == "-" {
@@ -462,7 +525,7 @@ func GenerateArgsFromStruct[T any]() graphql.FieldConfigArgument {
 			continue
 		}
 
-		description := field.Tag.Get("description")
+		description := fieldDescription(field)
 		defaultValue := field.Tag.Get("default")
 
 		argConfig := &graphql.ArgumentConfig{
@@ -530,7 +593,7 @@ func (g *FieldGenerator[T]) createWrapperObject(t reflect.Type, typeName string)
 				dataType := field.Type
 				graphqlType = g.getBaseGraphQLType(dataType, &typeName)
 
-				description := field.Tag.Get("description")
+				description := fieldDescription(field)
 				fields[fieldName] = &graphql.Field{
 					Type:        graphqlType,
 					Description: description,
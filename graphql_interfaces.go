@@ -0,0 +1,243 @@
+package graph
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// interfaceBinding ties a registered GraphQL interface to the Go types that implement it
+type interfaceBinding struct {
+	iface           *graphql.Interface
+	embedded        reflect.Type // struct whose embedding implies the interface
+	marker          reflect.Type // Go interface whose implementation implies the interface
+	implementations []*graphql.Object
+}
+
+var (
+	interfaceBindings   []*interfaceBinding
+	interfaceBindingsMu sync.RWMutex
+
+	// objectTypesByGoType maps Go types to their generated object types for ResolveType
+	objectTypesByGoType   = make(map[reflect.Type]*graphql.Object)
+	objectTypesByGoTypeMu sync.RWMutex
+)
+
+// RegisterInterface registers a GraphQL interface whose fields are the fields of
+// struct F. Every generated object type for a struct that embeds F declares that it
+// implements the interface, so queries can select the shared fields polymorphically.
+//
+// Implementations that are only reachable through the interface (e.g. returned from
+// a field typed as the interface) can be passed as sample values so their object
+// types are added to every schema built afterwards.
+//
+// Interfaces must be registered before the resolvers that use them are built.
+//
+// Example:
+//
+//	type Entity struct {
+//	    ID        string    `json:"id"`
+//	    CreatedAt time.Time `json:"createdAt"`
+//	}
+//
+//	type User struct {
+//	    Entity
+//	    Name string `json:"name"`
+//	}
+//
+//	graph.RegisterInterface[Entity]("Entity", User{}, Post{})
+func RegisterInterface[F any](name string, implementations ...interface{}) *graphql.Interface {
+	fieldsType := reflect.TypeOf((*F)(nil)).Elem()
+	if fieldsType.Kind() != reflect.Struct {
+		panic("graph: RegisterInterface requires a struct type, got " + fieldsType.String())
+	}
+	return registerInterface(name, &interfaceBinding{embedded: fieldsType}, fieldsType, implementations)
+}
+
+// RegisterMarkerInterface registers a GraphQL interface for the Go interface I, with
+// the fields of struct F. Every generated object type whose Go type (or pointer to it)
+// implements I declares that it implements the interface, and resolvers returning I
+// or []I (NewResolver[Node], NewResolver[[]Node]) use the GraphQL interface as their type.
+//
+// Example:
+//
+//	type Node interface{ isNode() }
+//	type NodeFields struct {
+//	    ID string `json:"id"`
+//	}
+//
+//	graph.RegisterMarkerInterface[Node, NodeFields]("Node", User{}, Post{})
+//
+//	graph.NewResolver[[]Node]("search").
+//	    WithResolver(func(p graph.ResolveParams) (*[]Node, error) { ... }).
+//	    BuildQuery()
+func RegisterMarkerInterface[I any, F any](name string, implementations ...interface{}) *graphql.Interface {
+	markerType := reflect.TypeOf((*I)(nil)).Elem()
+	if markerType.Kind() != reflect.Interface {
+		panic("graph: RegisterMarkerInterface requires an interface type, got " + markerType.String())
+	}
+	fieldsType := reflect.TypeOf((*F)(nil)).Elem()
+	if fieldsType.Kind() != reflect.Struct {
+		panic("graph: RegisterMarkerInterface requires a struct fields type, got " + fieldsType.String())
+	}
+	return registerInterface(name, &interfaceBinding{marker: markerType}, fieldsType, implementations)
+}
+
+// registerInterface creates the GraphQL interface for a binding, or returns the
+// interface already registered under the same name
+func registerInterface(name string, binding *interfaceBinding, fieldsType reflect.Type, implementations []interface{}) *graphql.Interface {
+	interfaceBindingsMu.Lock()
+	for _, existing := range interfaceBindings {
+		if existing.iface.Name() == name {
+			interfaceBindingsMu.Unlock()
+			return existing.iface
+		}
+	}
+
+	binding.iface = graphql.NewInterface(graphql.InterfaceConfig{
+		Name: name,
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return NewFieldGenerator[any]().generateFields(fieldsType)
+		}),
+		ResolveType: resolveInterfaceType,
+	})
+	interfaceBindings = append(interfaceBindings, binding)
+	interfaceBindingsMu.Unlock()
+
+	// Generate implementations outside the lock: object generation consults the bindings
+	var objects []*graphql.Object
+	for _, impl := range implementations {
+		t := reflect.TypeOf(impl)
+		if t == nil {
+			continue
+		}
+		if obj, ok := NewFieldGenerator[any]().getBaseGraphQLType(t, nil).(*graphql.Object); ok {
+			objects = append(objects, obj)
+		}
+	}
+
+	interfaceBindingsMu.Lock()
+	binding.implementations = objects
+	interfaceBindingsMu.Unlock()
+
+	return binding.iface
+}
+
+// lookupInterface returns the GraphQL interface registered for a Go interface type, if any
+func lookupInterface(t reflect.Type) *graphql.Interface {
+	if t == nil || t.Kind() != reflect.Interface {
+		return nil
+	}
+
+	interfaceBindingsMu.RLock()
+	defer interfaceBindingsMu.RUnlock()
+	for _, binding := range interfaceBindings {
+		if binding.marker == t {
+			return binding.iface
+		}
+	}
+	return nil
+}
+
+// interfacesFor returns the registered GraphQL interfaces implemented by Go type t
+func interfacesFor(t reflect.Type) []*graphql.Interface {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	interfaceBindingsMu.RLock()
+	defer interfaceBindingsMu.RUnlock()
+
+	var ifaces []*graphql.Interface
+	for _, binding := range interfaceBindings {
+		switch {
+		case binding.marker != nil:
+			if t.Implements(binding.marker) || reflect.PointerTo(t).Implements(binding.marker) {
+				ifaces = append(ifaces, binding.iface)
+			}
+		case embedsStruct(t, binding.embedded):
+			ifaces = append(ifaces, binding.iface)
+		}
+	}
+	return ifaces
+}
+
+// interfaceImplementations returns the implementation object types passed at registration
+func interfaceImplementations() []graphql.Type {
+	interfaceBindingsMu.RLock()
+	defer interfaceBindingsMu.RUnlock()
+
+	var types []graphql.Type
+	for _, binding := range interfaceBindings {
+		for _, obj := range binding.implementations {
+			types = append(types, obj)
+		}
+	}
+	return types
+}
+
+// embedsStruct reports whether struct t embeds target, directly or through
+// other promoted embedded structs
+func embedsStruct(t, target reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !isPromotedEmbedded(field) {
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft == target || embedsStruct(ft, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// objectInterfacesThunk returns the lazily resolved interfaces of the object type for t
+func objectInterfacesThunk(t reflect.Type) graphql.InterfacesThunk {
+	return func() []*graphql.Interface {
+		return interfacesFor(t)
+	}
+}
+
+// registerObjectGoType records the object type generated for Go type t so interface
+// values can be resolved to it. With override set, it replaces an earlier entry.
+func registerObjectGoType(t reflect.Type, obj *graphql.Object, override bool) {
+	if t == nil {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	objectTypesByGoTypeMu.Lock()
+	defer objectTypesByGoTypeMu.Unlock()
+	if _, exists := objectTypesByGoType[t]; exists && !override {
+		return
+	}
+	objectTypesByGoType[t] = obj
+}
+
+// resolveInterfaceType resolves a value of an interface field to the object type
+// generated for its Go type
+func resolveInterfaceType(p graphql.ResolveTypeParams) *graphql.Object {
+	t := reflect.TypeOf(p.Value)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	objectTypesByGoTypeMu.RLock()
+	defer objectTypesByGoTypeMu.RUnlock()
+	return objectTypesByGoType[t]
+}
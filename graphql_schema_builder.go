@@ -26,13 +26,25 @@ type SchemaBuilderParams struct {
 
 	// MutationFields: List of mutation fields to include in the schema
 	MutationFields []MutationField `group:"mutation_fields"`
+
+	// SubscriptionFields: List of subscription fields to include in the schema
+	SubscriptionFields []SubscriptionField `group:"subscription_fields"`
+
+	// Directives: Additional directives to declare in the schema, alongside @include, @skip and @deprecated
+	Directives []*graphql.Directive
+
+	// Instrumentation: Wrappers applied to every field resolver of the schema (see InstrumentResolvers)
+	Instrumentation []FieldMiddleware
 }
 
-// SchemaBuilder builds GraphQL schemas from QueryFields and MutationFields.
+// SchemaBuilder builds GraphQL schemas from QueryFields, MutationFields and SubscriptionFields.
 // Use NewSchemaBuilder to create an instance and Build() to generate the schema.
 type SchemaBuilder struct {
-	queryFields    []QueryField
-	mutationFields []MutationField
+	queryFields        []QueryField
+	mutationFields     []MutationField
+	subscriptionFields []SubscriptionField
+	directives         []*graphql.Directive
+	instrumentation    []FieldMiddleware
 }
 
 // NewSchemaBuilder creates a new schema builder with the provided query and mutation fields.
@@ -47,13 +59,16 @@ type SchemaBuilder struct {
 //	schema, err := builder.Build()
 func NewSchemaBuilder(params SchemaBuilderParams) *SchemaBuilder {
 	return &SchemaBuilder{
-		queryFields:    params.QueryFields,
-		mutationFields: params.MutationFields,
+		queryFields:        params.QueryFields,
+		mutationFields:     params.MutationFields,
+		subscriptionFields: params.SubscriptionFields,
+		directives:         params.Directives,
+		instrumentation:    params.Instrumentation,
 	}
 }
 
 // Build constructs and returns a graphql.Schema from the configured fields.
-// It creates Query and Mutation root types based on the provided fields.
+// It creates Query, Mutation and Subscription root types based on the provided fields.
 //
 // Returns an error if:
 //   - Schema construction fails due to type conflicts
@@ -75,6 +90,11 @@ func (sb *SchemaBuilder) Build() (graphql.Schema, error) {
 		mutationFields[field.Name()] = field.Serve()
 	}
 
+	subscriptionFields := graphql.Fields{}
+	for _, field := range sb.subscriptionFields {
+		subscriptionFields[field.Name()] = field.Serve()
+	}
+
 	schemaConfig := graphql.SchemaConfig{}
 
 	if len(queryFields) > 0 {
@@ -91,5 +111,28 @@ func (sb *SchemaBuilder) Build() (graphql.Schema, error) {
 		})
 	}
 
-	return graphql.NewSchema(schemaConfig)
+	if len(subscriptionFields) > 0 {
+		schemaConfig.Subscription = graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Subscription",
+			Fields: subscriptionFields,
+		})
+	}
+
+	if len(sb.directives) > 0 {
+		schemaConfig.Directives = append(append([]*graphql.Directive{}, graphql.SpecifiedDirectives...), sb.directives...)
+	}
+
+	// Implementations only reachable through an interface must be listed explicitly
+	schemaConfig.Types = interfaceImplementations()
+
+	schema, err := newSchema(schemaConfig)
+	if err != nil {
+		return schema, err
+	}
+
+	if len(sb.instrumentation) > 0 {
+		instrumentSchema(&schema, sb.instrumentation)
+	}
+
+	return schema, nil
 }
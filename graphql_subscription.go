@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"github.com/graphql-go/graphql"
+)
+
+// WithSubscriber sets the event source of a subscription field. The subscriber is
+// called once per subscription and returns a channel of events; each event is
+// resolved against the subscription's selection set and delivered to the client.
+// The subscription ends when the channel is closed or the client disconnects.
+//
+// Example:
+//
+//	NewResolver[Message]("messageAdded").
+//		WithArgs(graphql.FieldConfigArgument{
+//			"room": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+//		}).
+//		WithSubscriber(func(p ResolveParams) (<-chan Message, error) {
+//			return chat.Watch(p.Context, p.Args["room"].(string))
+//		}).
+//		BuildSubscription()
+func (r *UnifiedResolver[T]) WithSubscriber(subscriber func(p ResolveParams) (<-chan T, error)) *UnifiedResolver[T] {
+	r.subscriber = func(p graphql.ResolveParams) (interface{}, error) {
+		events, err := subscriber(ResolveParams(p))
+		if err != nil {
+			return nil, err
+		}
+
+		// graphql-go consumes subscriptions as chan interface{}
+		out := make(chan interface{})
+		go func() {
+			defer close(out)
+			for {
+				select {
+				case <-p.Context.Done():
+					return
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+					select {
+					case out <- event:
+					case <-p.Context.Done():
+						return
+					}
+				}
+			}
+		}()
+		return out, nil
+	}
+	return r
+}
+
+// BuildSubscription returns the resolver as a subscription field
+func (r *UnifiedResolver[T]) BuildSubscription() SubscriptionField {
+	return r
+}
+
+// resolveSubscriptionEvent resolves a subscription field to the event being delivered
+func resolveSubscriptionEvent(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source, nil
+}
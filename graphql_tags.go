@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// graphqlTag holds the options parsed from a field's `graphql` struct tag.
+//
+// Supported options (comma separated, values may be single-quoted to contain commas):
+//
+//	`graphql:"userName"`                  // bare field name
+//	`graphql:"name=userName"`             // explicit field name, takes precedence over json
+//	`graphql:"required"` / `graphql:"nonnull"` // wrap the type in NonNull
+//	`graphql:"deprecated=Use fullName"`   // deprecation reason (output fields)
+//	`graphql:"sunset=2025-06-30"`         // date the deprecated field is removed (see SunsetPolicy)
+//	`graphql:"description='Name, as shown'"` // field description
+//	`graphql:"ignore"`                    // exclude the field from the schema
+//	`graphql:"id"`                        // map to the ID scalar (also implied for fields named ID)
+//	`graphql:"version"`                   // version checked by WithVersionCheck
+type graphqlTag struct {
+	name         string // bare name
+	explicitName string // name=...
+	nonNull      bool
+	deprecated   string
+	sunset       string
+	description  string
+	ignore       bool
+	id           bool // id: map to the ID scalar
+	version      bool // version: the version of optimistic concurrency checks
+}
+
+// parseGraphQLTag parses the `graphql` struct tag of a field
+func parseGraphQLTag(field reflect.StructField) graphqlTag {
+	var tag graphqlTag
+
+	for _, part := range splitTagOptions(field.Tag.Get("graphql")) {
+		key, value, hasValue := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		value = unquoteTagValue(strings.TrimSpace(value))
+
+		if !hasValue {
+			switch key {
+			case "":
+			case "required", "nonnull":
+				tag.nonNull = true
+			case "ignore":
+				tag.ignore = true
+			default:
+				// A bare "id" or "version" both names the field and marks it
+				switch key {
+				case "id":
+					tag.id = true
+				case "version":
+					tag.version = true
+				}
+				if tag.name == "" {
+					tag.name = key
+				}
+			}
+			continue
+		}
+
+		switch key {
+		case "name":
+			tag.explicitName = value
+		case "deprecated":
+			tag.deprecated = value
+		case "sunset":
+			tag.sunset = value
+		case "description":
+			tag.description = value
+		}
+	}
+
+	return tag
+}
+
+// splitTagOptions splits a tag value on commas that are not inside single quotes
+func splitTagOptions(tag string) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range tag {
+		switch {
+		case r == '\'':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case r == ',' && !inQuotes:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+// unquoteTagValue strips surrounding single quotes from a tag option value
+func unquoteTagValue(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// isRequiredField reports whether a field is marked required/nonnull in its graphql tag
+func isRequiredField(field reflect.StructField) bool {
+	return parseGraphQLTag(field).nonNull
+}
+
+// fieldDescription returns the description from the graphql tag, falling back to the description tag
+func fieldDescription(field reflect.StructField) string {
+	if description := parseGraphQLTag(field).description; description != "" {
+		return description
+	}
+	return field.Tag.Get("description")
+}
+
+// fieldDeprecationReason returns the deprecation reason from the graphql tag, with its
+// sunset date if any
+func fieldDeprecationReason(field reflect.StructField) string {
+	tag := parseGraphQLTag(field)
+	if tag.sunset == "" {
+		return tag.deprecated
+	}
+	if tag.deprecated == "" {
+		return "Sunset: " + tag.sunset
+	}
+	return tag.deprecated + " (sunset: " + tag.sunset + ")"
+}
+
+// idFieldType returns graphql.ID for identifier fields: fields named ID or tagged
+// graphql:"id" whose (pointer-dereferenced) type is a string or integer.
+// It returns nil for all other fields.
+func idFieldType(t reflect.Type, field reflect.StructField) *graphql.Scalar {
+	if field.Name != "ID" && !parseGraphQLTag(field).id {
+		return nil
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return graphql.ID
+	default:
+		return nil
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -12,36 +13,12 @@ import (
 	"github.com/mitchellh/mapstructure"
 )
 
-// Global type registry to prevent duplicate type creation
-var (
-	typeRegistry        = make(map[string]*graphql.Object)
-	typeRegistryMu      sync.RWMutex
-	inputTypeRegistry   = make(map[string]*graphql.InputObject)
-	inputTypeRegistryMu sync.RWMutex
-)
-
 // RegisterObjectType registers a GraphQL object type in the global registry
-// Returns existing type if already registered, otherwise creates and registers new type
+// Returns existing type if already registered, otherwise creates and registers new type.
+// It is safe for concurrent use: typeFactory runs once per name, and must not register
+// object types itself.
 func RegisterObjectType(name string, typeFactory func() *graphql.Object) *graphql.Object {
-	typeRegistryMu.RLock()
-	if existingType, exists := typeRegistry[name]; exists {
-		typeRegistryMu.RUnlock()
-		return existingType
-	}
-	typeRegistryMu.RUnlock()
-
-	// Create new type
-	typeRegistryMu.Lock()
-	defer typeRegistryMu.Unlock()
-
-	// Double-check in case another goroutine created it
-	if existingType, exists := typeRegistry[name]; exists {
-		return existingType
-	}
-
-	newType := typeFactory()
-	typeRegistry[name] = newType
-	return newType
+	return typeRegistry.loadOrCreate(name, typeFactory)
 }
 
 // PaginatedResponse represents a paginated response structure
@@ -76,7 +53,12 @@ type UnifiedResolver[T any] struct {
 	objectName             string
 	isList                 bool
 	isListManuallyAssigned bool
+	nonNullList            bool // List itself is non-null: [T]!
+	nonNullItems           bool // List elements are non-null: [T!]
 	isPaginated            bool
+	pagination             PaginationConfig
+	totalCounter           func(p ResolveParams) (int, error) // Counts totalCount when selected
+	lazyTotalCount         bool                               // totalCount comes from totalCounter only
 	isMutation             bool
 	fieldOverrides         map[string]graphql.FieldResolveFn
 	fieldMiddleware        map[string][]FieldMiddleware
@@ -85,7 +67,14 @@ type UnifiedResolver[T any] struct {
 	useInputObject         bool
 	nullableInput          bool
 	inputName              string
-	resolverMiddlewares    []FieldMiddleware // Middleware stack applied to the main resolver
+	resolverMiddlewares    []FieldMiddleware      // Middleware stack applied to the main resolver
+	subscriber             graphql.FieldResolveFn // Event source for subscription fields
+	postProcessors         []PostProcessFn
+	fieldPostProcessors    map[string][]PostProcessFn
+	sensitiveFields        map[string]Sensitivity
+	inputSanitizers        map[string][]InputSanitizer                // Sanitizers by argument path
+	versionLoader          func(p ResolveParams) (interface{}, error) // Loads the stored object of version checks
+	versionIndex           []int                                      // Version field of T
 }
 
 // FieldMiddleware wraps a field resolver with additional functionality (auth, logging, caching, etc.)
@@ -201,6 +190,7 @@ type FieldMiddleware func(next FieldResolveFn) FieldResolveFn
 //
 // Available Configuration Methods:
 //   - AsList() - Configure as list query (returns []T)
+//   - AsNonNullList() / AsListOfNonNull() / AsNonNullListOfNonNull() - List with nullability modifiers
 //   - AsPaginated() - Configure as paginated query (returns PaginatedResponse[T])
 //   - AsMutation() - Configure as mutation
 //   - WithDescription(string) - Add field description
@@ -440,7 +430,7 @@ func NewResolver[T any](name string) *UnifiedResolver[T] {
 			resolver.isList = true
 		}
 	} else {
-		if t.Kind() == reflect.Slice {
+		if t != nil && t.Kind() == reflect.Slice {
 			resolver.isList = true
 			resolver.isListManuallyAssigned = true
 		}
@@ -455,9 +445,92 @@ func (r *UnifiedResolver[T]) AsList() *UnifiedResolver[T] {
 	return r
 }
 
+// AsNonNullList configures the resolver to return a non-null list of nullable items: [T]!
+func (r *UnifiedResolver[T]) AsNonNullList() *UnifiedResolver[T] {
+	r.AsList()
+	r.nonNullList = true
+	return r
+}
+
+// AsListOfNonNull configures the resolver to return a nullable list of non-null items: [T!]
+func (r *UnifiedResolver[T]) AsListOfNonNull() *UnifiedResolver[T] {
+	r.AsList()
+	r.nonNullItems = true
+	return r
+}
+
+// AsNonNullListOfNonNull configures the resolver to return a non-null list of non-null items: [T!]!
+//
+// Example usage:
+//
+//	NewResolver[User]("users").
+//		AsNonNullListOfNonNull().
+//		WithResolver(func(p ResolveParams) (*User, error) { ... }).
+//		BuildQuery() // users: [User!]!
+func (r *UnifiedResolver[T]) AsNonNullListOfNonNull() *UnifiedResolver[T] {
+	r.AsList()
+	r.nonNullList = true
+	r.nonNullItems = true
+	return r
+}
+
+// AsPaginated configures the resolver to return paginated results, with the default
+// pagination arguments (see WithPagination)
 func (r *UnifiedResolver[T]) AsPaginated() *UnifiedResolver[T] {
 	r.isPaginated = true
 	r.isList = false // Paginated overrides list
+	if r.pagination.MaxLimit == 0 {
+		r.pagination = r.pagination.withDefaults()
+	}
+	return r
+}
+
+// WithPagination configures the resolver as paginated with the arguments of config:
+// their style, default and maximum page sizes, and descriptions. AsPaginated uses the
+// default configuration: cursor arguments, 20 items per page and at most 100.
+//
+// Example usage:
+//
+//	NewResolver[User]("users").
+//		WithPagination(graph.PaginationConfig{
+//			Style:        graph.OffsetPagination, // page and limit
+//			DefaultLimit: 50,
+//			MaxLimit:     500,
+//		}).
+//		WithResolver(listUsers).
+//		BuildQuery()
+func (r *UnifiedResolver[T]) WithPagination(config PaginationConfig) *UnifiedResolver[T] {
+	r.AsPaginated()
+	r.pagination = config.withDefaults()
+	return r
+}
+
+// WithTotalCount counts the items of a paginated resolver with counter instead of the
+// resolver. Counting is often the expensive part of a page: counter runs only when the
+// client selects totalCount, at most once per field, with the arguments of the field.
+//
+// Example usage:
+//
+//	NewResolver[graph.PaginatedResponse[User]]("users").
+//		AsPaginated().
+//		WithResolver(listUsers). // leaves TotalCount unset
+//		WithTotalCount(func(p graph.ResolveParams) (int, error) {
+//			return userService.Count(p.Context)
+//		}).
+//		BuildQuery()
+func (r *UnifiedResolver[T]) WithTotalCount(counter func(p ResolveParams) (int, error)) *UnifiedResolver[T] {
+	r.AsPaginated()
+	r.totalCounter = counter
+	r.lazyTotalCount = true
+	return r
+}
+
+// WithoutTotalCount opts a paginated resolver out of counting: totalCount resolves to
+// null whatever the resolver returns
+func (r *UnifiedResolver[T]) WithoutTotalCount() *UnifiedResolver[T] {
+	r.AsPaginated()
+	r.totalCounter = nil
+	r.lazyTotalCount = true
 	return r
 }
 
@@ -488,12 +561,10 @@ func (r *UnifiedResolver[T]) WithInputObject(inputType interface{}) *UnifiedReso
 	}
 	inputName := t.Name() + "Input"
 
-	fieldName := "input"
-	if r.inputName != "" {
-		fieldName = r.inputName
-	}
+	fieldName := r.inputFieldName()
 
 	inputGraphQLType := r.generateInputObject(inputType, inputName)
+	r.addTaggedSanitizers(t, fieldName)
 	if r.nullableInput {
 		r.args = graphql.FieldConfigArgument{
 			fieldName: &graphql.ArgumentConfig{
@@ -512,6 +583,50 @@ func (r *UnifiedResolver[T]) WithInputObject(inputType interface{}) *UnifiedReso
 	return r
 }
 
+// inputFieldName returns the name of the input object argument
+func (r *UnifiedResolver[T]) inputFieldName() string {
+	if r.inputName != "" {
+		return r.inputName
+	}
+	return "input"
+}
+
+// WithVersionCheck makes an update mutation version-checked: it takes a required
+// expectedVersion argument, and fails with a CONFLICT error before the resolver runs
+// when the version of the object load returns is another one. The version is the
+// integer field of T tagged graphql:"version". It panics when T has none.
+//
+// The check narrows the window for lost updates without closing it: make the write
+// conditional on ExpectedVersion too, returning a VersionConflictError when it matches
+// no row.
+//
+// Example usage:
+//
+//	type Document struct {
+//		ID      int    `json:"id"`
+//		Body    string `json:"body"`
+//		Version int    `json:"version" graphql:"version"`
+//	}
+//
+//	NewResolver[Document]("updateDocument").
+//		WithInputObject(UpdateDocumentInput{}).
+//		WithVersionCheck(func(p graph.ResolveParams) (*Document, error) {
+//			return documents.Get(p.Context, p.Args["input"].(map[string]interface{})["id"].(int))
+//		}).
+//		WithResolver(updateDocument). // updateDocument(input: {...}, expectedVersion: 3)
+//		BuildMutation()
+func (r *UnifiedResolver[T]) WithVersionCheck(load func(p ResolveParams) (*T, error)) *UnifiedResolver[T] {
+	index, ok := versionFieldIndex(reflect.TypeOf((*T)(nil)).Elem())
+	if !ok {
+		panic(fmt.Sprintf("graph: WithVersionCheck of %q requires an integer field tagged graphql:\"version\"", r.name))
+	}
+	r.versionIndex = index
+	r.versionLoader = func(p ResolveParams) (interface{}, error) {
+		return load(p)
+	}
+	return r
+}
+
 // Basic Configuration
 func (r *UnifiedResolver[T]) WithDescription(desc string) *UnifiedResolver[T] {
 	r.description = desc
@@ -526,6 +641,7 @@ func (r *UnifiedResolver[T]) WithArgs(args graphql.FieldConfigArgument) *Unified
 func (r *UnifiedResolver[T]) WithArgsFromStruct(structType interface{}) *UnifiedResolver[T] {
 	t := reflect.TypeOf(structType)
 	r.args = generateArgsFromType(t)
+	r.addTaggedSanitizers(t, "")
 	return r
 }
 
@@ -552,12 +668,8 @@ func generateArgsFromTypeWithContext(t reflect.Type, parentTypeName string) grap
 	args := graphql.FieldConfigArgument{}
 	gen := NewFieldGenerator[any]()
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-
-		if field.PkgPath != "" {
-			continue
-		}
+	for _, sf := range collectStructFields(t) {
+		field := sf.StructField
 
 		fieldName := gen.getFieldName(field)
 		if fieldName == "-" {
@@ -569,7 +681,7 @@ func generateArgsFromTypeWithContext(t reflect.Type, parentTypeName string) grap
 			continue
 		}
 
-		description := field.Tag.Get("description")
+		description := fieldDescription(field)
 		defaultValue := field.Tag.Get("default")
 
 		argConfig := &graphql.ArgumentConfig{
@@ -589,24 +701,12 @@ func generateArgsFromTypeWithContext(t reflect.Type, parentTypeName string) grap
 
 // createPageInfoType creates the PageInfo GraphQL type
 func createPageInfoType() *graphql.Object {
-	// Check if PageInfo type already exists
-	typeRegistryMu.RLock()
-	if existingType, exists := typeRegistry["PageInfo"]; exists {
-		typeRegistryMu.RUnlock()
-		return existingType
-	}
-	typeRegistryMu.RUnlock()
-
-	// Create new PageInfo type
-	typeRegistryMu.Lock()
-	defer typeRegistryMu.Unlock()
-
-	// Double-check in case another goroutine created it
-	if existingType, exists := typeRegistry["PageInfo"]; exists {
-		return existingType
-	}
+	return typeRegistry.loadOrCreate("PageInfo", newPageInfoType)
+}
 
-	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+// newPageInfoType builds the PageInfo GraphQL type
+func newPageInfoType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
 		Name: "PageInfo",
 		Fields: graphql.Fields{
 			"hasNextPage": &graphql.Field{
@@ -647,10 +747,6 @@ func createPageInfoType() *graphql.Object {
 			},
 		},
 	})
-
-	// Register the type
-	typeRegistry["PageInfo"] = pageInfoType
-	return pageInfoType
 }
 
 // WithResolver sets a type-safe resolver function that returns *T instead of interface{}
@@ -683,6 +779,45 @@ func (r *UnifiedResolver[T]) WithResolver(resolver func(p ResolveParams) (*T, er
 	return r
 }
 
+// WithIterator sets a resolver producing the items of a list one at a time, as an
+// iter.Seq[E], an iter.Seq2[E, error] or a channel of E, instead of a slice. The items
+// are collected into the list, or sent incrementally when the field is selected with
+// @stream over Server-Sent Events (see GraphContext.EnableStreaming).
+//
+// Example usage:
+//
+//	NewResolver[[]LogEntry]("auditLog").
+//		WithIterator(func(p graph.ResolveParams) (interface{}, error) {
+//			rows, err := db.QueryContext(p.Context, "SELECT id, action FROM audit_log")
+//			if err != nil {
+//				return nil, err
+//			}
+//			return iter.Seq2[LogEntry, error](func(yield func(LogEntry, error) bool) {
+//				defer rows.Close()
+//				for rows.Next() {
+//					var entry LogEntry
+//					err := rows.Scan(&entry.ID, &entry.Action)
+//					if !yield(entry, err) || err != nil {
+//						return
+//					}
+//				}
+//			}), nil
+//		}).BuildQuery()
+func (r *UnifiedResolver[T]) WithIterator(resolver func(p ResolveParams) (interface{}, error)) *UnifiedResolver[T] {
+	r.AsList()
+	r.resolver = func(p graphql.ResolveParams) (interface{}, error) {
+		items, err := resolver(ResolveParams(p))
+		if err != nil || items == nil {
+			return items, err
+		}
+		if _, ok := newListIterator(items); !ok {
+			return nil, fmt.Errorf("iterator resolver of %q returned %T, not an iterator or a channel", r.name, items)
+		}
+		return items, nil
+	}
+	return r
+}
+
 // WithMiddleware adds middleware to the main resolver.
 // Middleware functions are applied in the order they are added (first added = outermost layer).
 // This is the foundation for all resolver-level middleware (auth, logging, caching, etc.).
@@ -748,6 +883,7 @@ func NewArgsResolver[T any, A any](name string, argName ...string) *TypedArgsRes
 		// Pass the parent type name for anonymous struct naming
 		parentTypeName := argsType.Name()
 		base.args = generateArgsFromTypeWithContext(argsType, parentTypeName)
+		base.addTaggedSanitizers(argsType, "")
 	} else {
 		// Primitive type (string, int, bool, etc.) - create single argument
 		fieldName := "input"
@@ -862,12 +998,55 @@ func (r *TypedArgsResolver[T, A]) AsList() *TypedArgsResolver[T, A] {
 	return r
 }
 
+// AsNonNullList configures the resolver to return a non-null list: [T]!
+func (r *TypedArgsResolver[T, A]) AsNonNullList() *TypedArgsResolver[T, A] {
+	r.base.AsNonNullList()
+	return r
+}
+
+// AsListOfNonNull configures the resolver to return a list of non-null items: [T!]
+func (r *TypedArgsResolver[T, A]) AsListOfNonNull() *TypedArgsResolver[T, A] {
+	r.base.AsListOfNonNull()
+	return r
+}
+
+// AsNonNullListOfNonNull configures the resolver to return a non-null list of non-null items: [T!]!
+func (r *TypedArgsResolver[T, A]) AsNonNullListOfNonNull() *TypedArgsResolver[T, A] {
+	r.base.AsNonNullListOfNonNull()
+	return r
+}
+
 // AsPaginated configures the resolver to return paginated results
 func (r *TypedArgsResolver[T, A]) AsPaginated() *TypedArgsResolver[T, A] {
 	r.base.AsPaginated()
 	return r
 }
 
+// WithPagination configures the resolver as paginated with the arguments of config
+func (r *TypedArgsResolver[T, A]) WithPagination(config PaginationConfig) *TypedArgsResolver[T, A] {
+	r.base.WithPagination(config)
+	return r
+}
+
+// WithTotalCount counts the items of the paginated resolver with counter, only when
+// totalCount is selected
+func (r *TypedArgsResolver[T, A]) WithTotalCount(counter func(p ResolveParams) (int, error)) *TypedArgsResolver[T, A] {
+	r.base.WithTotalCount(counter)
+	return r
+}
+
+// WithoutTotalCount opts the paginated resolver out of counting: totalCount is null
+func (r *TypedArgsResolver[T, A]) WithoutTotalCount() *TypedArgsResolver[T, A] {
+	r.base.WithoutTotalCount()
+	return r
+}
+
+// WithVersionCheck makes the mutation version-checked against the object load returns
+func (r *TypedArgsResolver[T, A]) WithVersionCheck(load func(p ResolveParams) (*T, error)) *TypedArgsResolver[T, A] {
+	r.base.WithVersionCheck(load)
+	return r
+}
+
 // WithDescription sets the field description
 func (r *TypedArgsResolver[T, A]) WithDescription(desc string) *TypedArgsResolver[T, A] {
 	r.base.WithDescription(desc)
@@ -1062,6 +1241,7 @@ func (r *UnifiedResolver[T]) Name() string {
 
 func (r *UnifiedResolver[T]) Serve() *graphql.Field {
 	var outputType graphql.Output
+	isUnion := false
 
 	if r.isPaginated {
 		outputType = r.generatePaginatedType()
@@ -1077,14 +1257,22 @@ func (r *UnifiedResolver[T]) Serve() *graphql.Field {
 		}
 
 		// Check if element type is scalar
+		var itemType graphql.Output
 		elementScalarType := r.getScalarType(elementType)
 		if elementScalarType != nil {
 			// List of scalars
-			outputType = graphql.NewList(elementScalarType)
+			itemType = elementScalarType
+		} else if iface := lookupInterface(elementType); iface != nil {
+			// List of a registered marker interface
+			itemType = iface
+		} else if union := lookupUnion(elementType); union != nil {
+			// List of a registered union
+			itemType = union
 		} else {
 			// List of objects
-			outputType = graphql.NewList(r.generateObjectTypeWithOverrides())
+			itemType = r.generateObjectTypeWithOverrides()
 		}
+		outputType = r.wrapListType(itemType)
 	} else {
 		// Check if T is a primitive/scalar type
 		var instance T
@@ -1094,6 +1282,13 @@ func (r *UnifiedResolver[T]) Serve() *graphql.Field {
 		if scalarType != nil {
 			// Use scalar type directly for primitives
 			outputType = scalarType
+		} else if iface := lookupInterface(reflect.TypeOf((*T)(nil)).Elem()); iface != nil {
+			// T is a registered marker interface
+			outputType = iface
+		} else if union := lookupUnion(reflect.TypeOf((*T)(nil)).Elem()); union != nil {
+			// T is a registered union
+			outputType = union
+			isUnion = true
 		} else {
 			// Generate object type for struct types
 			outputType = r.generateObjectTypeWithOverrides()
@@ -1102,6 +1297,33 @@ func (r *UnifiedResolver[T]) Serve() *graphql.Field {
 
 	// Apply middleware stack to the resolver
 	resolver := r.resolver
+	if resolver == nil && r.subscriber != nil {
+		// Subscription events are delivered as the source of the field
+		resolver = resolveSubscriptionEvent
+	}
+
+	// Reject updates based on a stale version before the resolver runs
+	if r.versionLoader != nil && resolver != nil {
+		resolver = checkVersion(resolver, r.versionLoader, r.versionIndex)
+	}
+
+	// Resolve a returned *U to the union member it holds
+	if isUnion && resolver != nil {
+		resolver = resolveUnionMember(resolver)
+	}
+
+	// Collect, or stream, the items of lists returned as iterators or channels
+	if r.isList && resolver != nil {
+		resolver = resolveListItems(resolver)
+	}
+
+	// Count the total of a page only when it is selected
+	if r.isPaginated && r.lazyTotalCount && resolver != nil {
+		resolver = countTotal(resolver, r.totalCounter)
+	}
+
+	// Post-process the result before middleware sees it
+	resolver = chainPostProcess(resolver, r.postProcessors)
 
 	// Convert and apply middlewares if any exist
 	if len(r.resolverMiddlewares) > 0 {
@@ -1115,14 +1337,51 @@ func (r *UnifiedResolver[T]) Serve() *graphql.Field {
 		resolver = unwrapGraphQLResolver(wrappedResolver)
 	}
 
+	// Sanitize inputs before any middleware sees them
+	if len(r.inputSanitizers) > 0 {
+		resolver = sanitizeArgs(resolver, r.inputSanitizers)
+	}
+
+	// Reject deeply nested input objects, then those over the decode budget of the
+	// request, before anything decodes them
+	if r.useInputObject {
+		resolver = chargeInputObject(resolver, r.inputFieldName())
+		resolver = limitInputObjectDepth(resolver, r.inputFieldName())
+	}
+
+	// Declare the pagination arguments and reject pages over the limit
+	args := r.args
+	if r.versionLoader != nil {
+		args = withVersionArg(args)
+	}
+	if r.isPaginated {
+		args = withPaginationArgs(args, r.pagination)
+		if resolver != nil {
+			resolver = limitPagination(resolver, r.pagination)
+		}
+	}
+
 	return &graphql.Field{
 		Type:        outputType,
 		Description: r.description,
-		Args:        r.args,
-		Resolve:     resolver,
+		Args:        args,
+		Resolve:     withPostProcessHook(resolver),
+		Subscribe:   r.subscriber,
 	}
 }
 
+// wrapListType wraps an item type in a list, applying the configured nullability modifiers
+func (r *UnifiedResolver[T]) wrapListType(itemType graphql.Output) graphql.Output {
+	if r.nonNullItems {
+		itemType = graphql.NewNonNull(itemType)
+	}
+	var listType graphql.Output = graphql.NewList(itemType)
+	if r.nonNullList {
+		listType = graphql.NewNonNull(listType)
+	}
+	return listType
+}
+
 // getScalarType returns the GraphQL scalar type for primitive Go types
 func (r *UnifiedResolver[T]) getScalarType(t reflect.Type) graphql.Output {
 	if t == nil {
@@ -1147,32 +1406,57 @@ func (r *UnifiedResolver[T]) getScalarType(t reflect.Type) graphql.Output {
 
 // Internal Generation Methods
 func (r *UnifiedResolver[T]) generateObjectTypeWithOverrides() *graphql.Object {
-	// Check if type already exists in registry
-
-	typeRegistryMu.RLock()
-	if existingType, exists := typeRegistry[r.objectName]; exists {
-		typeRegistryMu.RUnlock()
-		return existingType
-	}
-	typeRegistryMu.RUnlock()
+	return typeRegistry.loadOrCreate(r.objectName, func() *graphql.Object {
+		// Reuse a type already generated for a nested reference when this
+		// resolver doesn't customize its fields, so both share one schema type
+		if !r.hasFieldCustomizations() {
+			if existingType, exists := objectTypeRegistry.load(r.objectName); exists {
+				return existingType
+			}
+		}
 
-	// Create new type
-	typeRegistryMu.Lock()
-	defer typeRegistryMu.Unlock()
+		// Fields are generated lazily so self-referential and mutually-referential
+		// types resolve back to this registered object instead of recursing
+		goType := r.elementGoType()
+		newType := graphql.NewObject(graphql.ObjectConfig{
+			Name:       r.objectName,
+			Interfaces: objectInterfacesThunk(goType),
+			Fields:     (graphql.FieldsThunk)(r.generateFieldsWithOverrides),
+		})
+
+		registerObjectGoType(goType, newType, true)
+		objectTypeRegistry.loadOrStore(r.objectName, newType)
+		return newType
+	})
+}
 
-	// Double-check in case another goroutine created it
-	if existingType, exists := typeRegistry[r.objectName]; exists {
-		return existingType
+// elementGoType returns the Go type the object type is generated from: T, or its
+// element type when T is a slice or a PaginatedResponse
+func (r *UnifiedResolver[T]) elementGoType() reflect.Type {
+	var instance T
+	t := reflect.TypeOf(instance)
+	if t != nil && t.Kind() == reflect.Slice {
+		t = t.Elem()
 	}
+	if t != nil && t.Implements(paginatedResultType) {
+		if items, ok := t.FieldByName("Items"); ok {
+			t = items.Type.Elem()
+		}
+	}
+	return t
+}
 
-	gen := NewFieldGenerator[T]()
-	var instance T
-	typeToUse := reflect.TypeOf(instance)
+// hasFieldCustomizations reports whether the resolver overrides or adds object fields
+func (r *UnifiedResolver[T]) hasFieldCustomizations() bool {
+	return len(r.fieldOverrides) > 0 || len(r.fieldMiddleware) > 0 || len(r.customFields) > 0 ||
+		len(r.fieldPostProcessors) > 0 || len(r.sensitiveFields) > 0
+}
 
-	// If T is a slice type, extract the element type for field generation
-	if typeToUse != nil && typeToUse.Kind() == reflect.Slice {
-		typeToUse = typeToUse.Elem()
-	}
+// generateFieldsWithOverrides generates the object fields for T and applies
+// field resolver overrides, middleware and custom fields
+func (r *UnifiedResolver[T]) generateFieldsWithOverrides() graphql.Fields {
+	gen := NewFieldGenerator[T]()
+	typeToUse := r.elementGoType()
 
 	// Check if this is a wrapper type and handle it specially
 	var baseFields graphql.Fields
@@ -1230,87 +1514,86 @@ func (r *UnifiedResolver[T]) generateObjectTypeWithOverrides() *graphql.Object {
 		baseFields[fieldName] = customField
 	}
 
-	newType := graphql.NewObject(graphql.ObjectConfig{
-		Name:   r.objectName,
-		Fields: baseFields,
-	})
+	// Apply field post-processors
+	for fieldName, fns := range r.fieldPostProcessors {
+		if field, exists := baseFields[fieldName]; exists {
+			processed := *field
+			processed.Resolve = chainPostProcess(field.Resolve, fns)
+			baseFields[fieldName] = &processed
+		}
+	}
+
+	// Mask sensitive fields after post-processing so nothing downstream sees raw values
+	for fieldName, sensitivity := range r.sensitiveFields {
+		if field, exists := baseFields[fieldName]; exists {
+			redacted := *field
+			redacted.Resolve = redactField(field.Resolve, sensitivity)
+			baseFields[fieldName] = &redacted
+		}
+	}
 
-	// Register the type
-	typeRegistry[r.objectName] = newType
-	return newType
+	return wrapFieldsWithPostProcessHook(baseFields)
 }
 
+// generatePaginatedType returns the connection type of the resolver, shared by the
+// paginated resolvers of the same item type
 func (r *UnifiedResolver[T]) generatePaginatedType() *graphql.Object {
 	itemType := r.generateObjectTypeWithOverrides()
-
-	return graphql.NewObject(graphql.ObjectConfig{
-		Name: r.objectName + "Connection",
-		Fields: graphql.Fields{
-			"items": &graphql.Field{
-				Type: graphql.NewList(itemType),
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					if paginated, ok := p.Source.(PaginatedResponse[T]); ok {
-						return paginated.Items, nil
-					}
-					return nil, nil
+	pageInfoType := createPageInfoType()
+
+	return typeRegistry.loadOrCreate(r.objectName+"Connection", func() *graphql.Object {
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name: r.objectName + "Connection",
+			Fields: graphql.Fields{
+				"items": &graphql.Field{
+					Type: graphql.NewList(itemType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if page, ok := asPaginatedResult(p.Source); ok {
+							return page.paginatedItems(), nil
+						}
+						return nil, nil
+					},
 				},
-			},
-			"totalCount": &graphql.Field{
-				Type: graphql.Int,
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					if paginated, ok := p.Source.(PaginatedResponse[T]); ok {
-						return paginated.TotalCount, nil
-					}
-					return 0, nil
+				"totalCount": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if page, ok := asPaginatedResult(p.Source); ok {
+							return page.paginatedTotalCount()
+						}
+						return nil, nil
+					},
 				},
-			},
-			"pageInfo": &graphql.Field{
-				Type: createPageInfoType(),
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					if paginated, ok := p.Source.(PaginatedResponse[T]); ok {
-						return paginated.PageInfo, nil
-					}
-					return PageInfo{}, nil
+				"pageInfo": &graphql.Field{
+					Type: pageInfoType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if page, ok := asPaginatedResult(p.Source); ok {
+							return page.paginatedPageInfo(), nil
+						}
+						return PageInfo{}, nil
+					},
 				},
 			},
-		},
+		})
 	})
 }
 
 func (r *UnifiedResolver[T]) generateInputObject(inputType interface{}, name string) *graphql.InputObject {
-	// Check if input type already exists in registry
-	inputTypeRegistryMu.RLock()
-	if existingType, exists := inputTypeRegistry[name]; exists {
-		inputTypeRegistryMu.RUnlock()
-		return existingType
-	}
-	inputTypeRegistryMu.RUnlock()
-
-	// Create new input type
-	inputTypeRegistryMu.Lock()
-	defer inputTypeRegistryMu.Unlock()
-
-	// Double-check in case another goroutine created it
-	if existingType, exists := inputTypeRegistry[name]; exists {
-		return existingType
-	}
-
-	t := reflect.TypeOf(inputType)
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-
-	gen := NewFieldGenerator[any]()
-	fields := gen.generateInputFields(t)
+	return inputTypeRegistry.loadOrCreate(name, func() *graphql.InputObject {
+		t := reflect.TypeOf(inputType)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
 
-	newInputType := graphql.NewInputObject(graphql.InputObjectConfig{
-		Name:   name,
-		Fields: fields,
+		// Fields are generated lazily: nested input types take the registry lock
+		// themselves, and recursive input types must find this registered type
+		gen := NewFieldGenerator[any]()
+		return graphql.NewInputObject(graphql.InputObjectConfig{
+			Name: name,
+			Fields: (graphql.InputObjectConfigFieldMapThunk)(func() graphql.InputObjectConfigFieldMap {
+				return gen.generateInputFields(t)
+			}),
+		})
 	})
-
-	// Register the input type
-	inputTypeRegistry[name] = newInputType
-	return newInputType
 }
 
 // Utility Functions for Middleware and Resolvers
@@ -1364,20 +1647,25 @@ func AuthMiddleware(requiredRole string) FieldMiddleware {
 	}
 }
 
-// CacheMiddleware caches field results based on a key function
+// CacheMiddleware caches field results based on a key function.
+// The cache is safe for concurrent use and shared by every field the middleware
+// wraps. Concurrent misses on a key may each resolve it; all of them then return the
+// result cached first. Errors are not cached.
 func CacheMiddleware(cacheKey func(ResolveParams) string) FieldMiddleware {
-	cache := make(map[string]interface{})
+	var cache sync.Map // key → result
 	return func(next FieldResolveFn) FieldResolveFn {
 		return func(p ResolveParams) (interface{}, error) {
 			key := cacheKey(p)
-			if cached, exists := cache[key]; exists {
+			if cached, exists := cache.Load(key); exists {
+				markCacheHit(graphql.ResolveParams(p))
 				return cached, nil
 			}
 			result, err := next(p)
-			if err == nil {
-				cache[key] = result
+			if err != nil {
+				return result, err
 			}
-			return result, err
+			cached, _ := cache.LoadOrStore(key, result)
+			return cached, nil
 		}
 	}
 }
@@ -1403,21 +1691,26 @@ func AsyncFieldResolver(resolver graphql.FieldResolveFn) graphql.FieldResolveFn
 	}
 }
 
-// CachedFieldResolver caches field results with a key function
+// CachedFieldResolver caches field results with a key function.
+// The cache is safe for concurrent use, so the resolver can serve parallel requests.
+// Concurrent misses on a key may each call resolver; all of them then return the
+// result cached first. Errors are not cached.
 func CachedFieldResolver(cacheKey func(graphql.ResolveParams) string, resolver graphql.FieldResolveFn) graphql.FieldResolveFn {
-	cache := make(map[string]interface{})
+	var cache sync.Map // key → result
 
 	return func(p graphql.ResolveParams) (interface{}, error) {
 		key := cacheKey(p)
-		if cached, exists := cache[key]; exists {
+		if cached, exists := cache.Load(key); exists {
+			markCacheHit(p)
 			return cached, nil
 		}
 
 		result, err := resolver(p)
-		if err == nil {
-			cache[key] = result
+		if err != nil {
+			return result, err
 		}
-		return result, err
+		cached, _ := cache.LoadOrStore(key, result)
+		return cached, nil
 	}
 }
 
@@ -1481,6 +1774,20 @@ func mapArgsToStruct(args map[string]interface{}, output interface{}) error {
 		field := outputType.Field(i)
 		fieldValue := outputValue.Field(i)
 
+		// Embedded structs read their promoted fields from the same args
+		if isPromotedEmbedded(field) && fieldValue.CanSet() {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if err := mapArgsToStruct(args, fieldValue.Addr().Interface()); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if !fieldValue.CanSet() {
 			continue
 		}
@@ -1501,28 +1808,24 @@ func mapArgsToStruct(args map[string]interface{}, output interface{}) error {
 	return nil
 }
 
-// getFieldName extracts the field name from struct tags
+// getFieldName returns the GraphQL name of a struct field using the configured
+// FieldNamingStrategy. Fields tagged json:"-" or graphql:"ignore" are always skipped,
+// and graphql:"name=..." overrides the strategy.
 func getFieldName(field reflect.StructField) string {
-	// Check json tag first
-	if jsonTag := field.Tag.Get("json"); jsonTag != "" {
-		parts := strings.Split(jsonTag, ",")
-		if parts[0] != "" {
-			return parts[0]
-		}
+	if jsonTagName(field) == "-" {
+		return "-"
 	}
 
-	// Check graphql tag
-	if graphqlTag := field.Tag.Get("graphql"); graphqlTag != "" {
-		parts := strings.Split(graphqlTag, ",")
-		for _, part := range parts {
-			if !strings.Contains(part, "=") && part != "required" {
-				return part
-			}
-		}
+	// Per-field overrides from the graphql tag take precedence over the strategy
+	tag := parseGraphQLTag(field)
+	if tag.ignore {
+		return "-"
+	}
+	if tag.explicitName != "" {
+		return tag.explicitName
 	}
 
-	// Convert field name to camelCase
-	return toCamelCase(field.Name)
+	return currentFieldNamingStrategy()(field)
 }
 
 // toCamelCase converts PascalCase to camelCase
@@ -1566,6 +1869,24 @@ func setFieldValue(fieldValue reflect.Value, argValue interface{}) error {
 			fieldValue.SetInt(int64(argReflectValue.Float()))
 			return nil
 		}
+		// ID arguments arrive as strings
+		if argReflectValue.Kind() == reflect.String {
+			i, err := strconv.ParseInt(argReflectValue.String(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to %s", argValue, fieldValue.Type())
+			}
+			fieldValue.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if argReflectValue.Kind() == reflect.String {
+			u, err := strconv.ParseUint(argReflectValue.String(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to %s", argValue, fieldValue.Type())
+			}
+			fieldValue.SetUint(u)
+			return nil
+		}
 	case reflect.Float32, reflect.Float64:
 		if argReflectValue.Kind() == reflect.Int {
 			fieldValue.SetFloat(float64(argReflectValue.Int()))
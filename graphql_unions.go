@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// unionBinding ties a registered GraphQL union to the Go interface its members implement
+type unionBinding struct {
+	union   *graphql.Union
+	marker  reflect.Type
+	members []reflect.Type
+
+	once    sync.Once
+	objects map[reflect.Type]*graphql.Object // Member object types by Go type
+}
+
+var (
+	unionBindings   []*unionBinding
+	unionBindingsMu sync.RWMutex
+)
+
+// RegisterUnion registers a GraphQL union for the Go interface U, whose members are the
+// object types of the sample values. Resolvers returning U or []U (NewResolver[U],
+// NewResolver[[]U]) and struct fields of type U use the union as their type, and values
+// resolve to the member of their Go type.
+//
+// Unions model the outcomes of a mutation as types, the payload union pattern: expected
+// failures are members clients select fields of, rather than top-level errors they
+// match by message.
+//
+// Unions must be registered before the resolvers that use them are built.
+//
+// Example:
+//
+//	type CreateUserPayload interface{ isCreateUserPayload() }
+//
+//	type ValidationError struct {
+//	    Field   string `json:"field"`
+//	    Message string `json:"message"`
+//	}
+//	type Conflict struct {
+//	    ExistingID string `json:"existingId"`
+//	}
+//
+//	func (User) isCreateUserPayload()            {}
+//	func (ValidationError) isCreateUserPayload() {}
+//	func (Conflict) isCreateUserPayload()        {}
+//
+//	// union CreateUserPayload = User | ValidationError | Conflict
+//	graph.RegisterUnion[CreateUserPayload]("CreateUserPayload", User{}, ValidationError{}, Conflict{})
+//
+//	graph.NewResolver[CreateUserPayload]("createUser").
+//	    WithInputObject(CreateUserInput{}).
+//	    WithResolver(func(p graph.ResolveParams) (*CreateUserPayload, error) {
+//	        var payload CreateUserPayload = ValidationError{Field: "email", Message: "is taken"}
+//	        return &payload, nil
+//	    }).
+//	    BuildMutation()
+func RegisterUnion[U any](name string, members ...interface{}) *graphql.Union {
+	markerType := reflect.TypeOf((*U)(nil)).Elem()
+	if markerType.Kind() != reflect.Interface {
+		panic("graph: RegisterUnion requires an interface type, got " + markerType.String())
+	}
+
+	binding := &unionBinding{marker: markerType}
+	for _, member := range members {
+		t := reflect.TypeOf(member)
+		if t == nil {
+			continue
+		}
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			panic("graph: union members must be structs, got " + t.String())
+		}
+		if !t.Implements(markerType) && !reflect.PointerTo(t).Implements(markerType) {
+			panic("graph: union member " + t.String() + " does not implement " + markerType.String())
+		}
+		binding.members = append(binding.members, t)
+	}
+	if len(binding.members) == 0 {
+		panic("graph: union " + name + " has no members")
+	}
+
+	unionBindingsMu.Lock()
+	defer unionBindingsMu.Unlock()
+	for _, existing := range unionBindings {
+		if existing.union.Name() == name {
+			return existing.union
+		}
+	}
+
+	// Members are generated when a schema first reaches the union, so they reuse the
+	// object types of resolvers built in the meantime
+	binding.union = graphql.NewUnion(graphql.UnionConfig{
+		Name: name,
+		Types: (graphql.UnionTypesThunk)(func() []*graphql.Object {
+			var objects []*graphql.Object
+			for _, t := range binding.members {
+				if obj := binding.memberObject(t); obj != nil {
+					objects = append(objects, obj)
+				}
+			}
+			return objects
+		}),
+		ResolveType: func(p graphql.ResolveTypeParams) *graphql.Object {
+			value := unwrapUnionValue(p.Value)
+			if value == nil {
+				return nil
+			}
+			t := reflect.TypeOf(value)
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			return binding.memberObject(t)
+		},
+	})
+	unionBindings = append(unionBindings, binding)
+	return binding.union
+}
+
+// memberObject returns the object type of member t, or nil when t is not a member
+func (b *unionBinding) memberObject(t reflect.Type) *graphql.Object {
+	b.once.Do(func() {
+		b.objects = make(map[reflect.Type]*graphql.Object, len(b.members))
+		for _, member := range b.members {
+			if obj, ok := NewFieldGenerator[any]().getBaseGraphQLType(member, nil).(*graphql.Object); ok {
+				b.objects[member] = obj
+			}
+		}
+	})
+	return b.objects[t]
+}
+
+// lookupUnion returns the GraphQL union registered for a Go interface type, if any
+func lookupUnion(t reflect.Type) *graphql.Union {
+	if t == nil || t.Kind() != reflect.Interface {
+		return nil
+	}
+
+	unionBindingsMu.RLock()
+	defer unionBindingsMu.RUnlock()
+	for _, binding := range unionBindings {
+		if binding.marker == t {
+			return binding.union
+		}
+	}
+	return nil
+}
+
+// unwrapUnionValue returns the member value a resolver returned behind pointers to the
+// union interface, as in WithResolver returning *U
+func unwrapUnionValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Interface {
+		v = v.Elem().Elem()
+	}
+	if !v.IsValid() || ((v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()) {
+		return nil
+	}
+	return v.Interface()
+}
+
+// resolveUnionMember lets resolvers of union fields return a pointer to the union
+// interface, resolving the field to the member value it holds
+func resolveUnionMember(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := resolve(p)
+		if err != nil {
+			return result, err
+		}
+		return unwrapUnionValue(result), nil
+	}
+}
@@ -2,36 +2,43 @@ package graph
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
 	"github.com/graphql-go/graphql/language/parser"
 	"github.com/graphql-go/graphql/language/source"
 )
 
-// calculateQueryDepth recursively calculates the maximum depth of a query
-func calculateQueryDepth(node ast.Node, currentDepth int) int {
+// calculateQueryDepth recursively calculates the maximum depth of a query.
+// Selections excluded by @skip or @include are not counted; directives on variables
+// are evaluated when variables holds their values.
+func calculateQueryDepth(node ast.Node, currentDepth int, variables map[string]interface{}) int {
 	maxDepth := currentDepth
 
 	switch n := node.(type) {
 	case *ast.Document:
 		for _, def := range n.Definitions {
-			depth := calculateQueryDepth(def, currentDepth)
+			depth := calculateQueryDepth(def, currentDepth, variables)
 			if depth > maxDepth {
 				maxDepth = depth
 			}
 		}
 	case *ast.OperationDefinition:
 		if n.SelectionSet != nil {
-			depth := calculateSelectionSetDepth(n.SelectionSet, currentDepth)
+			depth := calculateSelectionSetDepth(n.SelectionSet, currentDepth, variables)
 			if depth > maxDepth {
 				maxDepth = depth
 			}
 		}
 	case *ast.FragmentDefinition:
 		if n.SelectionSet != nil {
-			depth := calculateSelectionSetDepth(n.SelectionSet, currentDepth)
+			depth := calculateSelectionSetDepth(n.SelectionSet, currentDepth, variables)
 			if depth > maxDepth {
 				maxDepth = depth
 			}
@@ -42,21 +49,24 @@ func calculateQueryDepth(node ast.Node, currentDepth int) int {
 }
 
 // calculateSelectionSetDepth calculates depth for a selection set
-func calculateSelectionSetDepth(selectionSet *ast.SelectionSet, currentDepth int) int {
+func calculateSelectionSetDepth(selectionSet *ast.SelectionSet, currentDepth int, variables map[string]interface{}) int {
 	maxDepth := currentDepth
 
 	for _, selection := range selectionSet.Selections {
+		if !includeSelection(selection, variables) {
+			continue
+		}
 		var depth int
 		switch sel := selection.(type) {
 		case *ast.Field:
 			if sel.SelectionSet != nil {
-				depth = calculateSelectionSetDepth(sel.SelectionSet, currentDepth+1)
+				depth = calculateSelectionSetDepth(sel.SelectionSet, currentDepth+1, variables)
 			} else {
 				depth = currentDepth + 1
 			}
 		case *ast.InlineFragment:
 			if sel.SelectionSet != nil {
-				depth = calculateSelectionSetDepth(sel.SelectionSet, currentDepth)
+				depth = calculateSelectionSetDepth(sel.SelectionSet, currentDepth, variables)
 			}
 		case *ast.FragmentSpread:
 			// Fragment spreads don't increase depth by themselves
@@ -72,21 +82,21 @@ func calculateSelectionSetDepth(selectionSet *ast.SelectionSet, currentDepth int
 }
 
 // countAliases recursively counts the number of field aliases in a query
-func countAliases(node ast.Node) int {
+func countAliases(node ast.Node, variables map[string]interface{}) int {
 	count := 0
 
 	switch n := node.(type) {
 	case *ast.Document:
 		for _, def := range n.Definitions {
-			count += countAliases(def)
+			count += countAliases(def, variables)
 		}
 	case *ast.OperationDefinition:
 		if n.SelectionSet != nil {
-			count += countSelectionSetAliases(n.SelectionSet)
+			count += countSelectionSetAliases(n.SelectionSet, variables)
 		}
 	case *ast.FragmentDefinition:
 		if n.SelectionSet != nil {
-			count += countSelectionSetAliases(n.SelectionSet)
+			count += countSelectionSetAliases(n.SelectionSet, variables)
 		}
 	}
 
@@ -94,10 +104,13 @@ func countAliases(node ast.Node) int {
 }
 
 // countSelectionSetAliases counts aliases in a selection set
-func countSelectionSetAliases(selectionSet *ast.SelectionSet) int {
+func countSelectionSetAliases(selectionSet *ast.SelectionSet, variables map[string]interface{}) int {
 	count := 0
 
 	for _, selection := range selectionSet.Selections {
+		if !includeSelection(selection, variables) {
+			continue
+		}
 		switch sel := selection.(type) {
 		case *ast.Field:
 			// If the field has an alias, count it
@@ -106,11 +119,11 @@ func countSelectionSetAliases(selectionSet *ast.SelectionSet) int {
 			}
 			// Recursively count aliases in nested selections
 			if sel.SelectionSet != nil {
-				count += countSelectionSetAliases(sel.SelectionSet)
+				count += countSelectionSetAliases(sel.SelectionSet, variables)
 			}
 		case *ast.InlineFragment:
 			if sel.SelectionSet != nil {
-				count += countSelectionSetAliases(sel.SelectionSet)
+				count += countSelectionSetAliases(sel.SelectionSet, variables)
 			}
 		case *ast.FragmentSpread:
 			// Fragment spreads themselves don't have aliases
@@ -122,21 +135,21 @@ func countSelectionSetAliases(selectionSet *ast.SelectionSet) int {
 }
 
 // calculateQueryComplexity calculates query complexity based on depth and field count
-func calculateQueryComplexity(node ast.Node, multiplier int) int {
+func calculateQueryComplexity(node ast.Node, multiplier int, variables map[string]interface{}) int {
 	complexity := 0
 
 	switch n := node.(type) {
 	case *ast.Document:
 		for _, def := range n.Definitions {
-			complexity += calculateQueryComplexity(def, multiplier)
+			complexity += calculateQueryComplexity(def, multiplier, variables)
 		}
 	case *ast.OperationDefinition:
 		if n.SelectionSet != nil {
-			complexity += calculateSelectionSetComplexity(n.SelectionSet, multiplier)
+			complexity += calculateSelectionSetComplexity(n.SelectionSet, multiplier, variables)
 		}
 	case *ast.FragmentDefinition:
 		if n.SelectionSet != nil {
-			complexity += calculateSelectionSetComplexity(n.SelectionSet, multiplier)
+			complexity += calculateSelectionSetComplexity(n.SelectionSet, multiplier, variables)
 		}
 	}
 
@@ -144,10 +157,13 @@ func calculateQueryComplexity(node ast.Node, multiplier int) int {
 }
 
 // calculateSelectionSetComplexity calculates complexity for a selection set
-func calculateSelectionSetComplexity(selectionSet *ast.SelectionSet, multiplier int) int {
+func calculateSelectionSetComplexity(selectionSet *ast.SelectionSet, multiplier int, variables map[string]interface{}) int {
 	complexity := 0
 
 	for _, selection := range selectionSet.Selections {
+		if !includeSelection(selection, variables) {
+			continue
+		}
 		switch sel := selection.(type) {
 		case *ast.Field:
 			// Base complexity for the field
@@ -155,12 +171,12 @@ func calculateSelectionSetComplexity(selectionSet *ast.SelectionSet, multiplier
 
 			// If field has nested selections, multiply complexity
 			if sel.SelectionSet != nil {
-				nestedComplexity := calculateSelectionSetComplexity(sel.SelectionSet, multiplier*2)
+				nestedComplexity := calculateSelectionSetComplexity(sel.SelectionSet, multiplier*2, variables)
 				complexity += nestedComplexity
 			}
 		case *ast.InlineFragment:
 			if sel.SelectionSet != nil {
-				complexity += calculateSelectionSetComplexity(sel.SelectionSet, multiplier)
+				complexity += calculateSelectionSetComplexity(sel.SelectionSet, multiplier, variables)
 			}
 		case *ast.FragmentSpread:
 			// Fragment spreads add base complexity
@@ -171,6 +187,19 @@ func calculateSelectionSetComplexity(selectionSet *ast.SelectionSet, multiplier
 	return complexity
 }
 
+// includeSelection evaluates the @skip and @include directives of a selection
+func includeSelection(selection ast.Selection, variables map[string]interface{}) bool {
+	switch sel := selection.(type) {
+	case *ast.Field:
+		return includeNode(sel.Directives, variables)
+	case *ast.InlineFragment:
+		return includeNode(sel.Directives, variables)
+	case *ast.FragmentSpread:
+		return includeNode(sel.Directives, variables)
+	}
+	return true
+}
+
 // ValidateGraphQLQuery validates a GraphQL query against security rules.
 // This function implements multiple layers of protection against malicious or expensive queries.
 //
@@ -178,13 +207,28 @@ func calculateSelectionSetComplexity(selectionSet *ast.SelectionSet, multiplier
 //   - Max Query Depth: 10 levels (prevents deeply nested queries)
 //   - Max Aliases: 4 per query (prevents alias-based DoS attacks)
 //   - Max Complexity: 200 (prevents computationally expensive queries)
-//   - Introspection: Blocked (__schema and __type queries are rejected)
+//   - Introspection: Blocked (__schema and __type queries are rejected), unless the
+//     request carries an introspection token (see IntrospectionTokenConfig)
+//   - Max Operations: 10 operation definitions per document
+//   - Max Variables: 50 variable definitions per document
+//   - Max String Length: 100000 characters in any argument or variable value
+//   - Max List Length: 1000 items in any argument or variable value
+//   - Max Input Depth: 10 levels of nested input objects
+//   - Schema Rules: graphql-go's specified rules (unknown fields, argument types,
+//     undefined variables, fragment cycles, ...)
+//
+// Schema rule violations are returned as a *QueryValidationError carrying the
+// location of each error in the query. Use AnalyzeGraphQLQuery to measure the query
+// and get every violation instead of the first one.
 //
 // Returns an error if:
 //   - Query depth exceeds 10 levels
 //   - Query contains more than 4 aliases
 //   - Query complexity exceeds 200
 //   - Query contains __schema or __type introspection fields
+//   - Document defines more than 10 operations or 50 variables
+//   - An argument or variable value exceeds the string, list or nesting limits
+//   - Query does not validate against the schema
 //   - Query parsing fails (though parsing errors are allowed to pass through)
 //
 // Example usage:
@@ -197,11 +241,206 @@ func calculateSelectionSetComplexity(selectionSet *ast.SelectionSet, multiplier
 //
 // Enable this in production with GraphContext.EnableValidation = true.
 func ValidateGraphQLQuery(queryString string, schema *graphql.Schema) error {
+	_, err := validateGraphQLQuery(queryString, nil, schema, false, DefaultQueryLimits)
+	return err
+}
+
+// ValidateGraphQLQueryStrict is ValidateGraphQLQuery, but a query that fails to parse
+// is rejected with a *QueryValidationError locating the syntax error, instead of being
+// left for the GraphQL handler. Malformed queries are then turned away cheaply.
+//
+// Enable this in NewHTTP with GraphContext.RejectParseErrors = true.
+func ValidateGraphQLQueryStrict(queryString string, schema *graphql.Schema) error {
+	_, err := validateGraphQLQuery(queryString, nil, schema, true, DefaultQueryLimits)
+	return err
+}
+
+// validateGraphQLQuery validates a query with its variables against limits, optionally
+// rejecting parse errors. A query that passes every rule, the schema rules included, is
+// returned parsed, so it can be executed without being parsed and validated again.
+func validateGraphQLQuery(queryString string, variables map[string]interface{}, schema *graphql.Schema, rejectParseErrors bool, limits QueryCostLimits) (*ast.Document, error) {
+	result, doc := analyzeGraphQLQuery(queryString, schema, variables, limits)
+	if !rejectParseErrors && result.violates(RuleSyntax) {
+		// If parsing fails, let the GraphQL handler deal with it
+		return nil, nil
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	// parseGraphQLQuery unwraps JSON request bodies, which the executor would not
+	if schema == nil || doc == nil || doc.Loc == nil || doc.Loc.Source == nil || string(doc.Loc.Source.Body) != queryString {
+		return nil, nil
+	}
+	return doc, nil
+}
+
+// Rules reported in Violation.Rule
+const (
+	RuleSyntax        = "syntax"
+	RuleIntrospection = "introspection"
+	RuleDepth         = "depth"
+	RuleAliases       = "aliases"
+	RuleComplexity    = "complexity"
+	RuleOperations    = "operations"
+	RuleVariables     = "variables"
+	RuleStringLength  = "stringLength"
+	RuleListLength    = "listLength"
+	RuleInputDepth    = "inputDepth"
+	RuleSchema        = "schema"
+)
+
+// Violation is one validation rule a query breaks
+type Violation struct {
+	Rule      string                    `json:"rule"`
+	Message   string                    `json:"message"`
+	Locations []location.SourceLocation `json:"locations,omitempty"`
+}
+
+// ValidationResult is the measured cost of a query and every rule it violates
+type ValidationResult struct {
+	Depth          int         `json:"depth"`
+	AliasCount     int         `json:"aliasCount"`
+	Complexity     int         `json:"complexity"`
+	OperationCount int         `json:"operationCount"`
+	VariableCount  int         `json:"variableCount"`
+	StringLength   int         `json:"stringLength"` // Longest string argument, in characters
+	ListLength     int         `json:"listLength"`   // Longest list argument
+	InputDepth     int         `json:"inputDepth"`   // Deepest input object nesting
+	Violations     []Violation `json:"violations,omitempty"`
+}
+
+// AnalyzeGraphQLQuery validates a query like ValidateGraphQLQuery, but measures it and
+// collects all violations instead of stopping at the first one. Callers can log the
+// cost of queries that pass and show users everything that is wrong at once.
+// A query that fails to parse is reported with a single RuleSyntax violation.
+//
+// Example usage:
+//
+//	result := graph.AnalyzeGraphQLQuery(queryString, schema)
+//	metrics.Observe("graphql_query_complexity", result.Complexity)
+//	if !result.Valid() {
+//	    for _, v := range result.Violations {
+//	        log.Printf("%s: %s", v.Rule, v.Message)
+//	    }
+//	}
+func AnalyzeGraphQLQuery(queryString string, schema *graphql.Schema) ValidationResult {
+	return AnalyzeGraphQLQueryWithVariables(queryString, schema, nil)
+}
+
+// AnalyzeGraphQLQueryWithVariables is AnalyzeGraphQLQuery for an operation sent with
+// variables, so selections under @skip(if: $var) and @include(if: $var) are charged
+// only when they will be executed. Literal conditions are honored either way.
+func AnalyzeGraphQLQueryWithVariables(queryString string, schema *graphql.Schema, variables map[string]interface{}) ValidationResult {
+	result, _ := analyzeGraphQLQuery(queryString, schema, variables, DefaultQueryLimits)
+	return result
+}
+
+// analyzeGraphQLQuery measures a query with its variables against limits, returning
+// the parsed document unless the query fails to parse
+func analyzeGraphQLQuery(queryString string, schema *graphql.Schema, variables map[string]interface{}, limits QueryCostLimits) (ValidationResult, *ast.Document) {
 	// Handle empty query
 	if queryString == "" {
-		return nil
+		return ValidationResult{}, nil
+	}
+
+	doc, err := parseGraphQLQuery(queryString)
+	if err != nil {
+		var result ValidationResult
+		result.addErrors(RuleSyntax, gqlerrors.FormatErrors(err))
+		return result, nil
+	}
+
+	result := analyzeDocument(doc, variables, limits)
+	result.checkSchemaRules(schema, doc)
+	return result, doc
+}
+
+// Valid reports whether the query violates no rule
+func (r ValidationResult) Valid() bool {
+	return len(r.Violations) == 0
+}
+
+// Err returns the error ValidateGraphQLQuery reports: the first security rule
+// violation, or a *QueryValidationError with all syntax and schema violations
+func (r ValidationResult) Err() error {
+	var located []gqlerrors.FormattedError
+	for _, v := range r.Violations {
+		if v.Rule != RuleSyntax && v.Rule != RuleSchema {
+			return errors.New(v.Message)
+		}
+		located = append(located, gqlerrors.FormattedError{Message: v.Message, Locations: v.Locations})
+	}
+	if len(located) > 0 {
+		return &QueryValidationError{Errors: located}
+	}
+	return nil
+}
+
+// violates reports whether the query violates rule
+func (r ValidationResult) violates(rule string) bool {
+	for _, v := range r.Violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// addErrors records GraphQL errors as violations of rule
+func (r *ValidationResult) addErrors(rule string, errs []gqlerrors.FormattedError) {
+	for _, err := range errs {
+		r.Violations = append(r.Violations, Violation{Rule: rule, Message: err.Message, Locations: err.Locations})
+	}
+}
+
+// checkSchemaRules checks a parsed document against the schema with graphql-go's
+// specified validation rules
+func (r *ValidationResult) checkSchemaRules(schema *graphql.Schema, doc *ast.Document) {
+	if schema == nil {
+		return
 	}
 
+	if result := graphql.ValidateDocument(schema, doc, graphql.SpecifiedRules); !result.IsValid {
+		r.addErrors(RuleSchema, result.Errors)
+	}
+}
+
+// QueryValidationError reports the schema rule violations of a query, with the
+// location of each error in the query text
+type QueryValidationError struct {
+	Errors []gqlerrors.FormattedError
+}
+
+// Error joins the messages of all violations
+func (e *QueryValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// prefixValidationErrors marks the errors of err as belonging to batch operation i
+func prefixValidationErrors(err *QueryValidationError, i int) error {
+	for j := range err.Errors {
+		err.Errors[j].Message = fmt.Sprintf("batch operation %d: %s", i, err.Errors[j].Message)
+	}
+	return err
+}
+
+// validationErrors converts a validation error to GraphQL errors, keeping the
+// locations of schema rule violations
+func validationErrors(err error) []gqlerrors.FormattedError {
+	var queryErr *QueryValidationError
+	if errors.As(err, &queryErr) {
+		return queryErr.Errors
+	}
+	return gqlerrors.FormatErrors(err)
+}
+
+// parseGraphQLQuery parses a query string into an AST.
+// The query may also be a JSON request body of the form {"query": "..."}.
+func parseGraphQLQuery(queryString string) (*ast.Document, error) {
 	// Try to parse as JSON (for POST requests with JSON body)
 	var queryData map[string]interface{}
 	if err := json.Unmarshal([]byte(queryString), &queryData); err == nil {
@@ -216,44 +455,307 @@ func ValidateGraphQLQuery(queryString string, schema *graphql.Schema) error {
 		Name: "GraphQL request",
 	})
 
-	doc, err := parser.Parse(parser.ParseParams{
+	return parser.Parse(parser.ParseParams{
 		Source: src,
 	})
-	if err != nil {
-		// If parsing fails, let the GraphQL handler deal with it
-		return nil
+}
+
+// QueryCostLimits are the per-operation limits enforced by ValidateGraphQLQuery.
+// The argument limits apply to literal arguments and to variable values alike, so a
+// single huge input is rejected however cheap the rest of the operation is.
+type QueryCostLimits struct {
+	MaxDepth      int `json:"maxDepth"`
+	MaxAliases    int `json:"maxAliases"`
+	MaxComplexity int `json:"maxComplexity"`
+	MaxOperations int `json:"maxOperations"`
+	MaxVariables  int `json:"maxVariables"`
+
+	MaxStringLength int `json:"maxStringLength"` // Characters in any string argument
+	MaxListLength   int `json:"maxListLength"`   // Items in any list argument
+	MaxInputDepth   int `json:"maxInputDepth"`   // Nesting of input objects
+
+	// AllowIntrospection lets __schema and __type queries through, e.g. for requests
+	// with a valid introspection token (see IntrospectionTokenConfig)
+	AllowIntrospection bool `json:"allowIntrospection,omitempty"`
+}
+
+// DefaultQueryLimits are the limits applied when GraphContext.EnableValidation is set.
+// Assign to it at startup to change them.
+var DefaultQueryLimits = QueryCostLimits{
+	MaxDepth:        10,
+	MaxAliases:      4,
+	MaxComplexity:   200,
+	MaxOperations:   10,
+	MaxVariables:    50,
+	MaxStringLength: 100000,
+	MaxListLength:   1000,
+	MaxInputDepth:   10,
+}
+
+// analyzeDocument measures a parsed document and applies the per-operation security rules
+// with limits
+func analyzeDocument(doc *ast.Document, variables map[string]interface{}, limits QueryCostLimits) ValidationResult {
+	var result ValidationResult
+	violate := func(rule, format string, args ...interface{}) {
+		result.Violations = append(result.Violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Limit the definitions of the document, which cost parse and validation time even
+	// when only one operation is executed
+	maxOperations := limits.MaxOperations
+	result.OperationCount, result.VariableCount = countDefinitions(doc)
+	if result.OperationCount > maxOperations {
+		violate(RuleOperations, "document defines too many operations. Maximum allowed: %d, found: %d", maxOperations, result.OperationCount)
+	}
+
+	maxVariables := limits.MaxVariables
+	if result.VariableCount > maxVariables {
+		violate(RuleVariables, "document defines too many variables. Maximum allowed: %d, found: %d", maxVariables, result.VariableCount)
 	}
 
 	// Check for introspection queries (matching Python's NoSchemaIntrospectionCustomRule)
-	if hasIntrospection(doc) {
-		return fmt.Errorf("GraphQL introspection is disabled")
+	if !limits.AllowIntrospection && hasIntrospection(doc) {
+		violate(RuleIntrospection, "GraphQL introspection is disabled")
 	}
 
 	// Apply validation rules
 	// Limit query depth to 10 (matching Python's QueryDepthLimiter(max_depth=10))
-	maxDepth := 10
-	depth := calculateQueryDepth(doc, 0)
-	if depth > maxDepth {
-		return fmt.Errorf("query depth exceeds maximum allowed depth of %d (actual: %d)", maxDepth, depth)
+	maxDepth := limits.MaxDepth
+	result.Depth = calculateQueryDepth(doc, 0, variables)
+	if result.Depth > maxDepth {
+		violate(RuleDepth, "query depth exceeds maximum allowed depth of %d (actual: %d)", maxDepth, result.Depth)
 	}
 
 	// Limit max aliases to 10 (matching Python's MaxAliasesLimiter(max_alias_count=10))
-	maxAliases := 4
-	aliasCount := countAliases(doc)
-	if aliasCount > maxAliases {
-		return fmt.Errorf("query contains too many aliases. Maximum allowed: %d, found: %d", maxAliases, aliasCount)
+	maxAliases := limits.MaxAliases
+	result.AliasCount = countAliases(doc, variables)
+	if result.AliasCount > maxAliases {
+		violate(RuleAliases, "query contains too many aliases. Maximum allowed: %d, found: %d", maxAliases, result.AliasCount)
 	}
 
 	// Optional: Limit query complexity
-	maxComplexity := 200
-	complexity := calculateQueryComplexity(doc, 1)
-	if complexity > maxComplexity {
-		return fmt.Errorf("query complexity exceeds maximum allowed complexity of %d (actual: %d)", maxComplexity, complexity)
+	maxComplexity := limits.MaxComplexity
+	result.Complexity = calculateQueryComplexity(doc, 1, variables)
+	if result.Complexity > maxComplexity {
+		violate(RuleComplexity, "query complexity exceeds maximum allowed complexity of %d (actual: %d)", maxComplexity, result.Complexity)
+	}
+
+	// Limit the size of individual arguments, which the limits above do not see
+	size := measureInputs(doc, variables)
+	result.StringLength, result.ListLength, result.InputDepth = size.stringLength, size.listLength, size.depth
+	if maxStringLength := limits.MaxStringLength; result.StringLength > maxStringLength {
+		violate(RuleStringLength, "argument string length exceeds maximum allowed length of %d (actual: %d)", maxStringLength, result.StringLength)
+	}
+	if maxListLength := limits.MaxListLength; result.ListLength > maxListLength {
+		violate(RuleListLength, "argument list length exceeds maximum allowed length of %d (actual: %d)", maxListLength, result.ListLength)
+	}
+	if maxInputDepth := limits.MaxInputDepth; result.InputDepth > maxInputDepth {
+		violate(RuleInputDepth, "input object nesting exceeds maximum allowed depth of %d (actual: %d)", maxInputDepth, result.InputDepth)
+	}
+
+	return result
+}
+
+// ValidateGraphQLBatch validates a batch of GraphQL operations received in a single request
+// (for example a JSON array of {"query": "..."} objects).
+//
+// Every operation must pass the same rules as ValidateGraphQLQuery, including the
+// schema rules. In addition, the aggregate cost of the whole batch is enforced so
+// that many individually cheap operations cannot be combined into one expensive request.
+//
+// Batch Rules:
+//   - Max Batch Size: 10 operations per request
+//   - Max Total Aliases: 10 across all operations
+//   - Max Total Complexity: 500 across all operations
+//
+// Operations that fail to parse contribute no cost and are left for the GraphQL
+// handler to report, matching ValidateGraphQLQuery, unless GraphContext.RejectParseErrors
+// is set.
+//
+// Example usage:
+//
+//	if err := graph.ValidateGraphQLBatch([]string{q1, q2}, schema); err != nil {
+//	    // Reject the whole batch with HTTP 400
+//	}
+func ValidateGraphQLBatch(queries []string, schema *graphql.Schema) error {
+	return validateGraphQLBatch(queries, nil, schema, false, DefaultQueryLimits)
+}
+
+// validateGraphQLBatch validates a batch, optionally rejecting operations that fail to parse.
+// variables holds the variables of each operation, and may be shorter than queries.
+// Each operation is checked against limits.
+func validateGraphQLBatch(queries []string, variables []map[string]interface{}, schema *graphql.Schema, rejectParseErrors bool, limits QueryCostLimits) error {
+	maxBatchSize := 10
+	if len(queries) > maxBatchSize {
+		return fmt.Errorf("batch contains too many operations. Maximum allowed: %d, found: %d", maxBatchSize, len(queries))
+	}
+
+	var total ValidationResult
+	for i, queryString := range queries {
+		if queryString == "" {
+			continue
+		}
+
+		doc, err := parseGraphQLQuery(queryString)
+		if err != nil {
+			if rejectParseErrors {
+				return prefixValidationErrors(&QueryValidationError{Errors: gqlerrors.FormatErrors(err)}, i)
+			}
+			continue
+		}
+
+		var operationVariables map[string]interface{}
+		if i < len(variables) {
+			operationVariables = variables[i]
+		}
+
+		result := analyzeDocument(doc, operationVariables, limits)
+		result.checkSchemaRules(schema, doc)
+		if err := result.Err(); err != nil {
+			if queryErr, ok := err.(*QueryValidationError); ok {
+				return prefixValidationErrors(queryErr, i)
+			}
+			return fmt.Errorf("batch operation %d: %w", i, err)
+		}
+
+		total.AliasCount += result.AliasCount
+		total.Complexity += result.Complexity
+	}
+
+	maxTotalAliases := 10
+	if total.AliasCount > maxTotalAliases {
+		return fmt.Errorf("batch contains too many aliases. Maximum allowed: %d, found: %d", maxTotalAliases, total.AliasCount)
+	}
+
+	maxTotalComplexity := 500
+	if total.Complexity > maxTotalComplexity {
+		return fmt.Errorf("batch complexity exceeds maximum allowed complexity of %d (actual: %d)", maxTotalComplexity, total.Complexity)
 	}
 
 	return nil
 }
 
+// countDefinitions counts the operation and variable definitions of a document
+func countDefinitions(doc *ast.Document) (operations, variables int) {
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok {
+			operations++
+			variables += len(op.VariableDefinitions)
+		}
+	}
+	return operations, variables
+}
+
+// inputSize is the largest string, list and input object nesting among the arguments
+// of a document
+type inputSize struct {
+	stringLength int
+	listLength   int
+	depth        int
+}
+
+// measureInputs measures the literal arguments, variable defaults and variable values
+// of a document
+func measureInputs(doc *ast.Document, variables map[string]interface{}) inputSize {
+	var size inputSize
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			for _, variable := range def.VariableDefinitions {
+				if variable.DefaultValue != nil {
+					size.measureValue(variable.DefaultValue, 0)
+				}
+			}
+			size.measureDirectives(def.Directives)
+			size.measureSelectionSet(def.SelectionSet)
+		case *ast.FragmentDefinition:
+			size.measureDirectives(def.Directives)
+			size.measureSelectionSet(def.SelectionSet)
+		}
+	}
+	for _, value := range variables {
+		size.measureVariable(value, 0)
+	}
+	return size
+}
+
+// measureSelectionSet measures the arguments of the fields and directives of a selection set
+func (s *inputSize) measureSelectionSet(selectionSet *ast.SelectionSet) {
+	if selectionSet == nil {
+		return
+	}
+	for _, selection := range selectionSet.Selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			s.measureArguments(sel.Arguments)
+			s.measureDirectives(sel.Directives)
+			s.measureSelectionSet(sel.SelectionSet)
+		case *ast.InlineFragment:
+			s.measureDirectives(sel.Directives)
+			s.measureSelectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			s.measureDirectives(sel.Directives)
+		}
+	}
+}
+
+// measureDirectives measures the arguments of directives
+func (s *inputSize) measureDirectives(directives []*ast.Directive) {
+	for _, directive := range directives {
+		s.measureArguments(directive.Arguments)
+	}
+}
+
+// measureArguments measures literal argument values
+func (s *inputSize) measureArguments(arguments []*ast.Argument) {
+	for _, argument := range arguments {
+		s.measureValue(argument.Value, 0)
+	}
+}
+
+// measureValue measures a literal value nested in depth input objects
+func (s *inputSize) measureValue(value ast.Value, depth int) {
+	switch v := value.(type) {
+	case *ast.StringValue:
+		s.observe(utf8.RuneCountInString(v.Value), 0, depth)
+	case *ast.ListValue:
+		s.observe(0, len(v.Values), depth)
+		for _, item := range v.Values {
+			s.measureValue(item, depth)
+		}
+	case *ast.ObjectValue:
+		s.observe(0, 0, depth+1)
+		for _, field := range v.Fields {
+			s.measureValue(field.Value, depth+1)
+		}
+	}
+}
+
+// measureVariable measures a variable value nested in depth input objects
+func (s *inputSize) measureVariable(value interface{}, depth int) {
+	switch v := value.(type) {
+	case string:
+		s.observe(utf8.RuneCountInString(v), 0, depth)
+	case []interface{}:
+		s.observe(0, len(v), depth)
+		for _, item := range v {
+			s.measureVariable(item, depth)
+		}
+	case map[string]interface{}:
+		s.observe(0, 0, depth+1)
+		for _, field := range v {
+			s.measureVariable(field, depth+1)
+		}
+	}
+}
+
+// observe records a string length, list length and nesting depth
+func (s *inputSize) observe(stringLength, listLength, depth int) {
+	s.stringLength = max(s.stringLength, stringLength)
+	s.listLength = max(s.listLength, listLength)
+	s.depth = max(s.depth, depth)
+}
+
 // hasIntrospection checks if the query contains introspection fields
 func hasIntrospection(node ast.Node) bool {
 	switch n := node.(type) {
@@ -0,0 +1,449 @@
+package graphtest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/paulmanoni/go-graph"
+)
+
+// Mutations a Fuzzer applies to make an operation near-valid
+const (
+	MutationUnknownField      = "unknownField"      // Selects a field the type does not have
+	MutationUnknownArgument   = "unknownArgument"   // Passes an argument the field does not take
+	MutationMissingArgument   = "missingArgument"   // Leaves out a required argument
+	MutationWrongArgumentType = "wrongArgumentType" // Passes a literal of the wrong type
+	MutationLeafSelection     = "leafSelection"     // Selects fields of a scalar or enum
+	MutationMissingSelection  = "missingSelection"  // Selects an object without fields
+	MutationUndefinedVariable = "undefinedVariable" // Uses a variable the operation does not define
+)
+
+var fuzzMutations = []string{
+	MutationUnknownField,
+	MutationUnknownArgument,
+	MutationMissingArgument,
+	MutationWrongArgumentType,
+	MutationLeafSelection,
+	MutationMissingSelection,
+	MutationUndefinedVariable,
+}
+
+// FuzzedOperation is an operation generated by a Fuzzer
+type FuzzedOperation struct {
+	Query     string
+	Variables map[string]interface{}
+
+	// Valid is false when Mutation made the operation fail validation
+	Valid    bool
+	Mutation string
+}
+
+// Fuzzer derives random operations from a schema, for tests of validation, complexity
+// limits and resolver robustness. Operations select random fields with aliases,
+// inline fragments and @include/@skip, and pass random arguments as literals or
+// variables: edge-case numbers, strings with escapes and non-ASCII characters, nulls,
+// lists and input objects. With InvalidRate set, some operations are near-valid: they
+// parse, but one mutation (MutationUnknownField, ...) makes them fail validation.
+//
+// Operations are valid for schemas whose custom scalars accept string literals;
+// DateTime gets times in graph.SpringShortLayout. A Fuzzer is deterministic for a
+// seed, so a failing operation is reproduced from the seed, and it is not safe for
+// concurrent use.
+//
+// Example:
+//
+//	func FuzzValidation(f *testing.F) {
+//	    f.Add(int64(1))
+//	    f.Fuzz(func(t *testing.T, seed int64) {
+//	        fuzzer := graphtest.NewFuzzer(&schema, seed)
+//	        fuzzer.InvalidRate = 0.3
+//	        op := fuzzer.Next()
+//	        result := graph.AnalyzeGraphQLQueryWithVariables(op.Query, &schema, op.Variables)
+//	        if op.Valid && !result.Valid() {
+//	            t.Errorf("%s rejected: %v", op.Query, result.Violations)
+//	        }
+//	    })
+//	}
+type Fuzzer struct {
+	// MaxDepth bounds the nesting of selections. Default: 4
+	MaxDepth int
+
+	// MaxFields bounds the fields selected on each type. Default: 4
+	MaxFields int
+
+	// InvalidRate is the share of near-valid operations, from 0 to 1. Default: 0
+	InvalidRate float64
+
+	schema *graphql.Schema
+	rand   *rand.Rand
+}
+
+// NewFuzzer returns a Fuzzer for schema seeded with seed
+func NewFuzzer(schema *graphql.Schema, seed int64) *Fuzzer {
+	return &Fuzzer{
+		MaxDepth:  4,
+		MaxFields: 4,
+		schema:    schema,
+		rand:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Next generates an operation on a random root type of the schema
+func (f *Fuzzer) Next() FuzzedOperation {
+	type root struct {
+		operation string
+		object    *graphql.Object
+	}
+	var roots []root
+	for _, r := range []root{
+		{"query", f.schema.QueryType()},
+		{"mutation", f.schema.MutationType()},
+		{"subscription", f.schema.SubscriptionType()},
+	} {
+		if r.object != nil {
+			roots = append(roots, r)
+		}
+	}
+	chosen := roots[f.rand.Intn(len(roots))]
+
+	op := &fuzzOperation{fuzzer: f, variables: make(map[string]interface{})}
+	if f.InvalidRate > 0 && f.rand.Float64() < f.InvalidRate {
+		op.pending = fuzzMutations[f.rand.Intn(len(fuzzMutations))]
+	}
+
+	// Subscriptions select a single root field
+	lines := op.selection(chosen.object, "  ", 1, chosen.operation == "subscription")
+	if op.pending != "" {
+		// No chance to apply the planned mutation came up
+		op.apply(MutationUnknownField)
+		lines = append(lines, "  fuzzUnknownField")
+	}
+
+	var b strings.Builder
+	b.WriteString(chosen.operation + " Fuzz")
+	if len(op.definitions) > 0 {
+		b.WriteString("(" + strings.Join(op.definitions, ", ") + ")")
+	}
+	b.WriteString(" {\n" + strings.Join(lines, "\n") + "\n}")
+
+	fuzzed := FuzzedOperation{Query: b.String(), Valid: op.mutation == "", Mutation: op.mutation}
+	if len(op.variables) > 0 {
+		fuzzed.Variables = op.variables
+	}
+	return fuzzed
+}
+
+// fuzzOperation is the state of an operation being generated
+type fuzzOperation struct {
+	fuzzer      *Fuzzer
+	definitions []string // Variable definitions, e.g. "$v1: Int!"
+	variables   map[string]interface{}
+	aliases     int
+
+	pending  string // Mutation to apply at the next chance
+	mutation string // Mutation applied
+}
+
+// chance reports whether the pending mutation is kind, and applies it when so
+func (op *fuzzOperation) chance(kind string) bool {
+	if op.pending != kind || op.fuzzer.rand.Intn(3) != 0 {
+		return false
+	}
+	op.apply(kind)
+	return true
+}
+
+// apply records kind as the mutation of the operation
+func (op *fuzzOperation) apply(kind string) {
+	op.pending = ""
+	op.mutation = kind
+}
+
+// selection returns the lines selecting random fields of a named output type
+func (op *fuzzOperation) selection(t graphql.Named, indent string, depth int, single bool) []string {
+	r := op.fuzzer.rand
+	var lines []string
+
+	var fields graphql.FieldDefinitionMap
+	switch t := t.(type) {
+	case *graphql.Object:
+		fields = t.Fields()
+	case *graphql.Interface:
+		fields = t.Fields()
+	}
+	names := sortedNames(fields)
+	r.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+
+	count := 1
+	if !single {
+		count = 1 + r.Intn(max(op.fuzzer.MaxFields, 1))
+	}
+	for _, name := range names {
+		if len(lines) == count {
+			break
+		}
+		if line, ok := op.field(fields[name], indent, depth); ok {
+			lines = append(lines, line)
+		}
+	}
+	if single {
+		return lines
+	}
+
+	// Abstract types are selected through inline fragments on their possible types
+	if abstract, ok := t.(graphql.Abstract); ok {
+		possibleTypes := append([]*graphql.Object(nil), op.fuzzer.schema.PossibleTypes(abstract)...)
+		sort.Slice(possibleTypes, func(i, j int) bool { return possibleTypes[i].Name() < possibleTypes[j].Name() })
+		for _, possible := range possibleTypes {
+			if r.Intn(2) == 0 && depth < op.fuzzer.MaxDepth {
+				inner := op.selection(possible, indent+"  ", depth+1, false)
+				lines = append(lines, indent+"... on "+possible.Name()+" {\n"+strings.Join(inner, "\n")+"\n"+indent+"}")
+			}
+		}
+	}
+	if len(lines) == 0 || r.Intn(5) == 0 {
+		lines = append(lines, indent+op.alias()+"__typename")
+	}
+	if op.chance(MutationUnknownField) {
+		lines = append(lines, indent+"fuzzUnknownField")
+	}
+	return lines
+}
+
+// field returns the line selecting field, or false when it cannot be selected this deep
+func (op *fuzzOperation) field(field *graphql.FieldDefinition, indent string, depth int) (string, bool) {
+	leaf := graphql.IsLeafType(field.Type)
+	if !leaf && depth >= op.fuzzer.MaxDepth {
+		return "", false
+	}
+
+	line := indent + op.alias() + field.Name + op.arguments(field.Args)
+	switch r := op.fuzzer.rand.Intn(10); {
+	case r == 0:
+		line += " @include(if: true)"
+	case r == 1:
+		line += " @skip(if: false)"
+	}
+
+	switch {
+	case leaf:
+		if op.chance(MutationLeafSelection) {
+			line += " {\n" + indent + "  fuzz\n" + indent + "}"
+		}
+	case op.chance(MutationMissingSelection):
+	default:
+		inner := op.selection(graphql.GetNamed(field.Type), indent+"  ", depth+1, false)
+		line += " {\n" + strings.Join(inner, "\n") + "\n" + indent + "}"
+	}
+	return line, true
+}
+
+// alias returns a unique alias for a selection, or none; unique aliases keep fields of
+// different arguments or types from conflicting
+func (op *fuzzOperation) alias() string {
+	if op.fuzzer.rand.Intn(3) != 0 {
+		return ""
+	}
+	op.aliases++
+	return "a" + strconv.Itoa(op.aliases) + ": "
+}
+
+// arguments returns the arguments passed to a field: the required ones, and optional
+// ones at random
+func (op *fuzzOperation) arguments(args []*graphql.Argument) string {
+	r := op.fuzzer.rand
+	args = append([]*graphql.Argument(nil), args...)
+	sort.Slice(args, func(i, j int) bool { return args[i].Name() < args[j].Name() })
+
+	var passed []string
+	for _, arg := range args {
+		_, required := arg.Type.(*graphql.NonNull)
+		required = required && arg.DefaultValue == nil
+		if required && op.chance(MutationMissingArgument) {
+			continue
+		}
+		if !required && r.Intn(2) == 0 {
+			continue
+		}
+
+		switch {
+		case op.chance(MutationUndefinedVariable):
+			passed = append(passed, arg.Name()+": $fuzzUndefined")
+		case wrongLiteral(arg.Type) != "" && op.chance(MutationWrongArgumentType):
+			passed = append(passed, arg.Name()+": "+wrongLiteral(arg.Type))
+		case r.Intn(3) == 0:
+			name := "v" + strconv.Itoa(len(op.definitions)+1)
+			op.definitions = append(op.definitions, "$"+name+": "+arg.Type.String())
+			op.variables[name] = op.value(arg.Type, 0, true)
+			passed = append(passed, arg.Name()+": $"+name)
+		default:
+			passed = append(passed, arg.Name()+": "+printLiteral(arg.Type, op.value(arg.Type, 0, false)))
+		}
+	}
+	if op.chance(MutationUnknownArgument) {
+		passed = append(passed, "fuzzUnknownArgument: 1")
+	}
+	if len(passed) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(passed, ", ") + ")"
+}
+
+// fuzzStrings are the pieces of random strings: escapes, non-ASCII and injection-like text
+var fuzzStrings = []string{"a", "Z", "0", " ", "\"", "\\", "/", "\n", "\t", "é", "漢", "\U0001F600", "'", "<b>", "${x}", "%s", "\x00"}
+
+// value returns a random value of an input type, as a variable value. Null values are
+// only generated when nulls is set: graphql-go does not parse null literals.
+func (op *fuzzOperation) value(t graphql.Type, depth int, nulls bool) interface{} {
+	r := op.fuzzer.rand
+	nonNull, required := t.(*graphql.NonNull)
+	if required {
+		t = nonNull.OfType
+	} else if nulls && r.Intn(10) == 0 {
+		return nil
+	}
+
+	switch t := t.(type) {
+	case *graphql.List:
+		items := make([]interface{}, r.Intn(4))
+		for i := range items {
+			items[i] = op.value(t.OfType, depth, nulls)
+		}
+		return items
+	case *graphql.Enum:
+		values := t.Values()
+		if len(values) == 0 {
+			return nil
+		}
+		names := make([]string, len(values))
+		for i, value := range values {
+			names[i] = value.Name
+		}
+		sort.Strings(names)
+		return names[r.Intn(len(names))]
+	case *graphql.InputObject:
+		fields := t.Fields()
+		object := make(map[string]interface{}, len(fields))
+		for _, name := range sortedNames(fields) {
+			field := fields[name]
+			_, required := field.Type.(*graphql.NonNull)
+			if (required && field.DefaultValue == nil) || (depth < 3 && r.Intn(2) == 0) {
+				object[name] = op.value(field.Type, depth+1, nulls)
+			}
+		}
+		return object
+	case *graphql.Scalar:
+		switch t.Name() {
+		case "Int":
+			return []int{0, 1, -1, 42, 2147483647, -2147483648}[r.Intn(6)]
+		case "Float":
+			return []float64{0, 1.5, -2.25, 3.14159, 1e10, -1e-10}[r.Intn(6)]
+		case "Boolean":
+			return r.Intn(2) == 0
+		case "ID":
+			return strconv.Itoa(r.Intn(1000))
+		case graph.DateTime.Name():
+			return []string{"2024-01-15T14:30", "1970-01-01T00:00", "2038-01-19T03:14"}[r.Intn(3)]
+		}
+	}
+
+	var b strings.Builder
+	for i := r.Intn(12); i > 0; i-- {
+		b.WriteString(fuzzStrings[r.Intn(len(fuzzStrings))])
+	}
+	return b.String()
+}
+
+// printLiteral prints a variable value of an input type as a GraphQL literal
+func printLiteral(t graphql.Type, value interface{}) string {
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+	}
+
+	switch t := t.(type) {
+	case *graphql.List:
+		items := value.([]interface{})
+		printed := make([]string, len(items))
+		for i, item := range items {
+			printed[i] = printLiteral(t.OfType, item)
+		}
+		return "[" + strings.Join(printed, ", ") + "]"
+	case *graphql.Enum:
+		return value.(string)
+	case *graphql.InputObject:
+		object := value.(map[string]interface{})
+		fields := t.Fields()
+		printed := make([]string, 0, len(object))
+		for _, name := range sortedNames(object) {
+			printed = append(printed, name+": "+printLiteral(fields[name].Type, object[name]))
+		}
+		return "{" + strings.Join(printed, ", ") + "}"
+	}
+
+	switch v := value.(type) {
+	case string:
+		return quoteString(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// wrongLiteral returns a literal an input type rejects, or "" for custom scalars, which
+// may accept anything
+func wrongLiteral(t graphql.Type) string {
+	switch t := graphql.GetNullable(t).(type) {
+	case *graphql.List:
+		if inner := wrongLiteral(t.OfType); inner != "" {
+			return "[" + inner + "]"
+		}
+	case *graphql.Enum:
+		return "FUZZ_UNKNOWN_VALUE"
+	case *graphql.InputObject:
+		return "{fuzzUnknownField: 1}"
+	case *graphql.Scalar:
+		switch t.Name() {
+		case "Int", "Float", "String", "Boolean", "ID":
+			return "{fuzz: 1}"
+		}
+	}
+	return ""
+}
+
+// quoteString quotes s as a GraphQL string literal
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// sortedNames returns the keys of m in order, so a seed generates the same operation
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
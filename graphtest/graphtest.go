@@ -0,0 +1,330 @@
+// Package graphtest holds test helpers for go-graph schemas.
+//
+// AssertCompatible guards a committed schema snapshot: it fails the test when the
+// schema being built would break clients written against the snapshot, such as a
+// removed field, a field that became nullable or a new required argument. Fuzzer
+// derives random valid and near-valid operations from a schema for fuzz tests.
+//
+// Example:
+//
+//	func TestSchemaCompatible(t *testing.T) {
+//	    schema, err := graph.NewSchemaBuilder(params).Build()
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    snapshot, err := os.ReadFile("testdata/schema.graphql")
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    graphtest.AssertCompatible(t, string(snapshot), &schema,
+//	        "User.legacyId", // removed on purpose, see CHANGELOG
+//	    )
+//	}
+//
+// Refresh the snapshot with graph.PrintSchema once the test passes.
+package graphtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/paulmanoni/go-graph"
+)
+
+// BreakingChange is a change that can break clients of a schema
+type BreakingChange struct {
+	// Coordinate of the changed element: "Type", "Type.field", "Type.field(arg)",
+	// "Enum.VALUE" or "@directive"
+	Coordinate  string
+	Description string
+}
+
+// String describes the change
+func (c BreakingChange) String() string {
+	return c.Coordinate + ": " + c.Description
+}
+
+// AssertCompatible fails t for every breaking change of newSchema relative to oldSDL,
+// except those at a coordinate listed in allowed ("Type", "Type.field",
+// "Type.field(arg)", "Enum.VALUE" or "@directive"). Allowing a type or field also
+// allows the changes of its members.
+func AssertCompatible(t testing.TB, oldSDL string, newSchema *graphql.Schema, allowed ...string) {
+	t.Helper()
+
+	changes, err := BreakingChanges(oldSDL, graph.PrintSchema(newSchema))
+	if err != nil {
+		t.Fatalf("graphtest: %v", err)
+		return
+	}
+	for _, change := range changes {
+		if !isAllowed(change.Coordinate, allowed) {
+			t.Errorf("breaking schema change: %s", change)
+		}
+	}
+}
+
+// isAllowed reports whether coordinate, or an element containing it, is allowed
+func isAllowed(coordinate string, allowed []string) bool {
+	for _, a := range allowed {
+		if coordinate == a || strings.HasPrefix(coordinate, a+".") || strings.HasPrefix(coordinate, a+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakingChanges compares two schemas in SDL and returns the changes of newSDL that
+// can break clients of oldSDL, sorted by coordinate
+func BreakingChanges(oldSDL, newSDL string) ([]BreakingChange, error) {
+	oldTypes, err := parseSDL(oldSDL)
+	if err != nil {
+		return nil, fmt.Errorf("parse old schema: %w", err)
+	}
+	newTypes, err := parseSDL(newSDL)
+	if err != nil {
+		return nil, fmt.Errorf("parse new schema: %w", err)
+	}
+
+	var changes []BreakingChange
+	report := func(coordinate, format string, args ...interface{}) {
+		changes = append(changes, BreakingChange{Coordinate: coordinate, Description: fmt.Sprintf(format, args...)})
+	}
+
+	for name, oldDef := range oldTypes {
+		newDef, ok := newTypes[name]
+		if !ok {
+			report(name, "%s was removed", kindOf(oldDef))
+			continue
+		}
+		if kindOf(oldDef) != kindOf(newDef) {
+			report(name, "changed from %s to %s", kindOf(oldDef), kindOf(newDef))
+			continue
+		}
+
+		switch oldDef := oldDef.(type) {
+		case *ast.ObjectDefinition:
+			newDef := newDef.(*ast.ObjectDefinition)
+			compareFields(name, oldDef.Fields, newDef.Fields, report)
+			for _, iface := range missingNames(oldDef.Interfaces, newDef.Interfaces) {
+				report(name, "no longer implements %s", iface)
+			}
+		case *ast.InterfaceDefinition:
+			compareFields(name, oldDef.Fields, newDef.(*ast.InterfaceDefinition).Fields, report)
+		case *ast.InputObjectDefinition:
+			compareInputValues("input field", func(field string) string { return name + "." + field },
+				oldDef.Fields, newDef.(*ast.InputObjectDefinition).Fields, report)
+		case *ast.UnionDefinition:
+			for _, member := range missingNames(oldDef.Types, newDef.(*ast.UnionDefinition).Types) {
+				report(name, "member %s was removed", member)
+			}
+		case *ast.EnumDefinition:
+			values := make(map[string]bool)
+			for _, value := range newDef.(*ast.EnumDefinition).Values {
+				values[value.Name.Value] = true
+			}
+			for _, value := range oldDef.Values {
+				if !values[value.Name.Value] {
+					report(name+"."+value.Name.Value, "enum value was removed")
+				}
+			}
+		case *ast.DirectiveDefinition:
+			newDirective := newDef.(*ast.DirectiveDefinition)
+			compareInputValues("argument", func(arg string) string { return name + "(" + arg + ")" },
+				oldDef.Arguments, newDirective.Arguments, report)
+			locations := make(map[string]bool)
+			for _, location := range newDirective.Locations {
+				locations[location.Value] = true
+			}
+			for _, location := range oldDef.Locations {
+				if !locations[location.Value] {
+					report(name, "location %s was removed", location.Value)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Coordinate < changes[j].Coordinate })
+	return changes, nil
+}
+
+// compareFields reports the breaking changes of the fields of an object or interface
+func compareFields(typeName string, oldFields, newFields []*ast.FieldDefinition, report func(string, string, ...interface{})) {
+	fields := make(map[string]*ast.FieldDefinition, len(newFields))
+	for _, field := range newFields {
+		fields[field.Name.Value] = field
+	}
+
+	for _, oldField := range oldFields {
+		coordinate := typeName + "." + oldField.Name.Value
+		newField, ok := fields[oldField.Name.Value]
+		if !ok {
+			report(coordinate, "field was removed")
+			continue
+		}
+		if !safeOutputChange(oldField.Type, newField.Type) {
+			report(coordinate, "type changed from %s to %s", printType(oldField.Type), printType(newField.Type))
+		}
+		compareInputValues("argument", func(arg string) string { return coordinate + "(" + arg + ")" },
+			oldField.Arguments, newField.Arguments, report)
+	}
+}
+
+// compareInputValues reports the breaking changes of arguments or input fields: removed
+// values, unsafe type changes and new required values
+func compareInputValues(noun string, coordinateOf func(string) string, oldValues, newValues []*ast.InputValueDefinition, report func(string, string, ...interface{})) {
+	previous := make(map[string]*ast.InputValueDefinition, len(oldValues))
+	for _, value := range oldValues {
+		previous[value.Name.Value] = value
+	}
+	current := make(map[string]*ast.InputValueDefinition, len(newValues))
+	for _, value := range newValues {
+		current[value.Name.Value] = value
+	}
+
+	for _, oldValue := range oldValues {
+		newValue, ok := current[oldValue.Name.Value]
+		if !ok {
+			report(coordinateOf(oldValue.Name.Value), "%s was removed", noun)
+			continue
+		}
+		if !safeInputChange(oldValue.Type, newValue.Type) {
+			report(coordinateOf(oldValue.Name.Value), "type changed from %s to %s", printType(oldValue.Type), printType(newValue.Type))
+		}
+	}
+	for _, newValue := range newValues {
+		if _, existed := previous[newValue.Name.Value]; existed {
+			continue
+		}
+		if _, required := newValue.Type.(*ast.NonNull); required && newValue.DefaultValue == nil {
+			report(coordinateOf(newValue.Name.Value), "required %s was added", noun)
+		}
+	}
+}
+
+// safeOutputChange reports whether clients reading a value of type old can read new:
+// the same type, made non-null anywhere
+func safeOutputChange(old, new ast.Type) bool {
+	if nonNull, ok := new.(*ast.NonNull); ok {
+		if _, wasNonNull := old.(*ast.NonNull); !wasNonNull {
+			return safeOutputChange(old, nonNull.Type)
+		}
+	}
+	switch old := old.(type) {
+	case *ast.Named:
+		named, ok := new.(*ast.Named)
+		return ok && named.Name.Value == old.Name.Value
+	case *ast.List:
+		list, ok := new.(*ast.List)
+		return ok && safeOutputChange(old.Type, list.Type)
+	case *ast.NonNull:
+		nonNull, ok := new.(*ast.NonNull)
+		return ok && safeOutputChange(old.Type, nonNull.Type)
+	}
+	return false
+}
+
+// safeInputChange reports whether values clients sent as type old are accepted as new:
+// the same type, made nullable anywhere
+func safeInputChange(old, new ast.Type) bool {
+	switch old := old.(type) {
+	case *ast.Named:
+		named, ok := new.(*ast.Named)
+		return ok && named.Name.Value == old.Name.Value
+	case *ast.List:
+		list, ok := new.(*ast.List)
+		return ok && safeInputChange(old.Type, list.Type)
+	case *ast.NonNull:
+		if nonNull, ok := new.(*ast.NonNull); ok {
+			return safeInputChange(old.Type, nonNull.Type)
+		}
+		return safeInputChange(old.Type, new)
+	}
+	return false
+}
+
+// parseSDL returns the type and directive definitions of a schema by name; directives
+// are keyed "@name"
+func parseSDL(sdl string) (map[string]ast.Node, error) {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(sdl), Name: "GraphQL schema"}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]ast.Node)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.ObjectDefinition:
+			definitions[def.Name.Value] = def
+		case *ast.InterfaceDefinition:
+			definitions[def.Name.Value] = def
+		case *ast.InputObjectDefinition:
+			definitions[def.Name.Value] = def
+		case *ast.UnionDefinition:
+			definitions[def.Name.Value] = def
+		case *ast.EnumDefinition:
+			definitions[def.Name.Value] = def
+		case *ast.ScalarDefinition:
+			definitions[def.Name.Value] = def
+		case *ast.DirectiveDefinition:
+			definitions["@"+def.Name.Value] = def
+		}
+	}
+	return definitions, nil
+}
+
+// kindOf names the kind of a definition as SDL spells it
+func kindOf(def ast.Node) string {
+	switch def.(type) {
+	case *ast.ObjectDefinition:
+		return "type"
+	case *ast.InterfaceDefinition:
+		return "interface"
+	case *ast.InputObjectDefinition:
+		return "input"
+	case *ast.UnionDefinition:
+		return "union"
+	case *ast.EnumDefinition:
+		return "enum"
+	case *ast.ScalarDefinition:
+		return "scalar"
+	case *ast.DirectiveDefinition:
+		return "directive"
+	}
+	return def.GetKind()
+}
+
+// missingNames returns the names of old missing from new
+func missingNames(old, new []*ast.Named) []string {
+	present := make(map[string]bool, len(new))
+	for _, named := range new {
+		present[named.Name.Value] = true
+	}
+	var missing []string
+	for _, named := range old {
+		if !present[named.Name.Value] {
+			missing = append(missing, named.Name.Value)
+		}
+	}
+	return missing
+}
+
+// printType prints a type reference as SDL spells it
+func printType(t ast.Type) string {
+	switch t := t.(type) {
+	case *ast.Named:
+		return t.Name.Value
+	case *ast.List:
+		return "[" + printType(t.Type) + "]"
+	case *ast.NonNull:
+		return printType(t.Type) + "!"
+	}
+	return ""
+}
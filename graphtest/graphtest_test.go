@@ -0,0 +1,346 @@
+package graphtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/paulmanoni/go-graph"
+)
+
+const snapshot = `
+type Query {
+  book(id: ID!): Book
+  books(first: Int): [Book!]
+}
+
+type Book {
+  id: ID!
+  title: String
+  genre: Genre
+}
+
+enum Genre {
+  FICTION
+  POETRY
+}
+
+input BookFilter {
+  genre: Genre!
+  title: String
+}
+`
+
+func TestBreakingChanges(t *testing.T) {
+	current := `
+type Query {
+  book(id: ID, locale: String!): Book
+  books(first: String): [Book]
+  shelf(filter: BookFilter): [Book]
+}
+
+type Book {
+  id: ID!
+  title: String!
+}
+
+enum Genre {
+  FICTION
+}
+
+input BookFilter {
+  genre: Genre
+  author: String!
+  year: Int! = 2000
+}
+`
+	changes, err := BreakingChanges(snapshot, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	want := []string{
+		"Book.genre: field was removed",
+		"BookFilter.author: required input field was added",
+		"BookFilter.title: input field was removed",
+		"Genre.POETRY: enum value was removed",
+		"Query.book(locale): required argument was added",
+		"Query.books: type changed from [Book!] to [Book]",
+		"Query.books(first): type changed from Int to String",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\n got %q\nwant %q", got, want)
+	}
+
+	if _, err := BreakingChanges("type {", current); err == nil {
+		t.Error("expected an error for invalid SDL")
+	}
+}
+
+// recorder captures the failures AssertCompatible reports
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.fatal = fmt.Sprintf(format, args...)
+}
+
+func TestAssertCompatible(t *testing.T) {
+	book := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Book",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	genre := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Genre",
+		Values: graphql.EnumValueConfigMap{
+			"FICTION": &graphql.EnumValueConfig{Value: "FICTION"},
+			"POETRY":  &graphql.EnumValueConfig{Value: "POETRY"},
+		},
+	})
+	filter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"genre": &graphql.InputObjectFieldConfig{Type: genre},
+			"title": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"book": &graphql.Field{
+					Type: book,
+					Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.ID}},
+				},
+				"books": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(book)),
+					Args: graphql.FieldConfigArgument{
+						"first":  &graphql.ArgumentConfig{Type: graphql.Int},
+						"filter": &graphql.ArgumentConfig{Type: filter},
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("compatible", func(t *testing.T) {
+		// Book.genre is removed on purpose
+		r := &recorder{TB: t}
+		AssertCompatible(r, snapshot, &schema, "Book.genre")
+		if len(r.errors) > 0 || r.fatal != "" {
+			t.Errorf("expected no failures, got %q %q", r.errors, r.fatal)
+		}
+
+		// A snapshot of the schema itself is compatible
+		AssertCompatible(t, graph.PrintSchema(&schema), &schema)
+	})
+
+	t.Run("breaking", func(t *testing.T) {
+		r := &recorder{TB: t}
+		AssertCompatible(r, snapshot, &schema)
+		want := []string{"breaking schema change: Book.genre: field was removed"}
+		if !reflect.DeepEqual(r.errors, want) {
+			t.Errorf("unexpected failures:\n got %q\nwant %q", r.errors, want)
+		}
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		r := &recorder{TB: t}
+		AssertCompatible(r, "type Query {", &schema)
+		if !strings.HasPrefix(r.fatal, "graphtest: parse old schema") {
+			t.Errorf("expected a fatal parse error, got %q", r.fatal)
+		}
+	})
+}
+
+// fuzzSchema exercises every kind of type the Fuzzer generates
+func fuzzSchema(t testing.TB) *graphql.Schema {
+	node := graphql.NewInterface(graphql.InterfaceConfig{
+		Name:   "FuzzNode",
+		Fields: graphql.Fields{"id": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)}},
+	})
+	genre := graphql.NewEnum(graphql.EnumConfig{
+		Name: "FuzzGenre",
+		Values: graphql.EnumValueConfigMap{
+			"FICTION": &graphql.EnumValueConfig{Value: 1},
+			"POETRY":  &graphql.EnumValueConfig{Value: 2},
+		},
+	})
+	filter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "FuzzFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"genres": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(genre))},
+			"title":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"after":  &graphql.InputObjectFieldConfig{Type: graph.DateTime},
+			"rating": &graphql.InputObjectFieldConfig{Type: graphql.Float, DefaultValue: 1.0},
+		},
+	})
+	author := graphql.NewObject(graphql.ObjectConfig{
+		Name:       "FuzzAuthor",
+		Interfaces: []*graphql.Interface{node},
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name": &graphql.Field{Type: graphql.String},
+		},
+	})
+	book := graphql.NewObject(graphql.ObjectConfig{
+		Name:       "FuzzBook",
+		Interfaces: []*graphql.Interface{node},
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title":  &graphql.Field{Type: graphql.String},
+			"genre":  &graphql.Field{Type: genre},
+			"author": &graphql.Field{Type: author},
+			"pages":  &graphql.Field{Type: graphql.Int, Args: graphql.FieldConfigArgument{"min": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}}},
+		},
+	})
+	result := graphql.NewUnion(graphql.UnionConfig{
+		Name:        "FuzzResult",
+		Types:       []*graphql.Object{book, author},
+		ResolveType: func(graphql.ResolveTypeParams) *graphql.Object { return book },
+	})
+	author.AddFieldConfig("books", &graphql.Field{Type: graphql.NewList(book)})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"node": &graphql.Field{Type: node, Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}}},
+				"search": &graphql.Field{
+					Type: graphql.NewList(result),
+					Args: graphql.FieldConfigArgument{
+						"text":   &graphql.ArgumentConfig{Type: graphql.String},
+						"filter": &graphql.ArgumentConfig{Type: filter},
+						"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+					},
+				},
+				"books": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(book))},
+			},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"rate": &graphql.Field{
+					Type: book,
+					Args: graphql.FieldConfigArgument{
+						"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"stars":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+						"verdict": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					},
+				},
+			},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Subscription",
+			Fields: graphql.Fields{"bookAdded": &graphql.Field{Type: book}, "authorAdded": &graphql.Field{Type: author}},
+		}),
+		Types: []graphql.Type{author, book},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &schema
+}
+
+// schemaViolations returns the syntax and schema violations of an operation
+func schemaViolations(op FuzzedOperation, schema *graphql.Schema) []graph.Violation {
+	var violations []graph.Violation
+	for _, v := range graph.AnalyzeGraphQLQueryWithVariables(op.Query, schema, op.Variables).Violations {
+		if v.Rule == graph.RuleSyntax || v.Rule == graph.RuleSchema {
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+func TestFuzzer(t *testing.T) {
+	schema := fuzzSchema(t)
+
+	t.Run("valid", func(t *testing.T) {
+		fuzzer := NewFuzzer(schema, 1)
+		for i := 0; i < 300; i++ {
+			op := fuzzer.Next()
+			if !op.Valid || op.Mutation != "" {
+				t.Fatalf("expected a valid operation, got mutation %q", op.Mutation)
+			}
+			if violations := schemaViolations(op, schema); len(violations) > 0 {
+				t.Fatalf("operation rejected: %v\n%s", violations, op.Query)
+			}
+			// Variables coerce to their declared types
+			if result := graphql.Do(graphql.Params{Schema: *schema, RequestString: op.Query, VariableValues: op.Variables}); strings.Contains(fmt.Sprint(result.Errors), "Variable") {
+				t.Fatalf("variables rejected: %v\n%s\n%v", result.Errors, op.Query, op.Variables)
+			}
+		}
+	})
+
+	t.Run("near-valid", func(t *testing.T) {
+		fuzzer := NewFuzzer(schema, 2)
+		fuzzer.InvalidRate = 1
+		mutations := make(map[string]bool)
+		for i := 0; i < 300; i++ {
+			op := fuzzer.Next()
+			if op.Valid || op.Mutation == "" {
+				t.Fatal("expected a near-valid operation")
+			}
+			mutations[op.Mutation] = true
+			violations := schemaViolations(op, schema)
+			if len(violations) == 0 {
+				t.Fatalf("%s operation accepted:\n%s", op.Mutation, op.Query)
+			}
+			for _, v := range violations {
+				if v.Rule == graph.RuleSyntax {
+					t.Fatalf("%s operation does not parse: %s\n%s", op.Mutation, v.Message, op.Query)
+				}
+			}
+		}
+		if len(mutations) != len(fuzzMutations) {
+			t.Errorf("expected every mutation, got %v", mutations)
+		}
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		a, b := NewFuzzer(schema, 3), NewFuzzer(schema, 3)
+		a.InvalidRate, b.InvalidRate = 0.5, 0.5
+		for i := 0; i < 50; i++ {
+			if opA, opB := a.Next(), b.Next(); !reflect.DeepEqual(opA, opB) {
+				t.Fatalf("seed generated different operations:\n%s\n%s", opA.Query, opB.Query)
+			}
+		}
+	})
+}
+
+func FuzzAnalyzeGraphQLQuery(f *testing.F) {
+	schema := fuzzSchema(f)
+	for seed := int64(0); seed < 20; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		fuzzer := NewFuzzer(schema, seed)
+		fuzzer.InvalidRate = 0.3
+		op := fuzzer.Next()
+		if violations := schemaViolations(op, schema); op.Valid != (len(violations) == 0) {
+			t.Errorf("valid %v, violations %v:\n%s", op.Valid, violations, op.Query)
+		}
+	})
+}
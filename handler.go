@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
 	"github.com/graphql-go/handler"
 )
 
@@ -78,14 +83,42 @@ func getDefaultEchoMutationTypeSafe() MutationField {
 }
 */
 
+// schemaCacheKey identifies a schema built from a GraphContext: the SchemaParams
+// pointer (nil for the default schema), the SchemaVersion and whether @live, @stream
+// and @cacheControl are declared
+type schemaCacheKey struct {
+	params       *SchemaBuilderParams
+	version      string
+	live         bool
+	stream       bool
+	cacheControl bool
+}
+
+// builtSchemas memoizes the schemas built by buildSchemaFromContext
+var builtSchemas sync.Map // schemaCacheKey → *graphql.Schema
+
 // buildSchemaFromContext builds a GraphQL schema from the GraphContext
 // Priority: Schema > SchemaParams > Default hello world schema
+//
+// Built schemas are memoized by SchemaParams identity and SchemaVersion, so handlers
+// created repeatedly from the same params share one schema.
 func buildSchemaFromContext(graphCtx *GraphContext) (*graphql.Schema, error) {
 	// If Schema is provided, use it
 	if graphCtx.Schema != nil {
 		return graphCtx.Schema, nil
 	}
 
+	key := schemaCacheKey{
+		params:       graphCtx.SchemaParams,
+		version:      graphCtx.SchemaVersion,
+		live:         graphCtx.LivePubSub != nil,
+		stream:       graphCtx.EnableStreaming,
+		cacheControl: graphCtx.CacheControl != nil,
+	}
+	if schema, ok := builtSchemas.Load(key); ok {
+		return schema.(*graphql.Schema), nil
+	}
+
 	// If SchemaParams is provided, build from it
 	var params SchemaBuilderParams
 	if graphCtx.SchemaParams != nil {
@@ -102,31 +135,63 @@ func buildSchemaFromContext(graphCtx *GraphContext) (*graphql.Schema, error) {
 		}
 	}
 
+	// Declare @live when live queries are enabled
+	if graphCtx.LivePubSub != nil {
+		params.Directives = append(append([]*graphql.Directive{}, params.Directives...), LiveDirective)
+	}
+
+	// Declare @stream when list streaming is enabled
+	if graphCtx.EnableStreaming {
+		params.Directives = append(append([]*graphql.Directive{}, params.Directives...), StreamDirective)
+	}
+
+	// Declare @cacheControl when cache hints are enabled
+	if graphCtx.CacheControl != nil {
+		params.Directives = append(append([]*graphql.Directive{}, params.Directives...), CacheControlDirective)
+	}
+
 	// Build schema
 	schema, err := NewSchemaBuilder(params).Build()
 	if err != nil {
 		return nil, err
 	}
 
-	return &schema, nil
+	// Keep the first schema stored when handlers are built concurrently
+	actual, _ := builtSchemas.LoadOrStore(key, &schema)
+	return actual.(*graphql.Schema), nil
 }
 
-// responseWriterWrapper wraps http.ResponseWriter to capture and sanitize responses
+// responseWriterWrapper wraps http.ResponseWriter to capture responses for a responsePipeline
 type responseWriterWrapper struct {
 	http.ResponseWriter
 	body       *bytes.Buffer
 	statusCode int
+
+	// maxBytes caps the captured body size (0 means unlimited)
+	maxBytes int
+	// overflow is set once a write would exceed maxBytes
+	overflow bool
 }
 
+// responseBufferSize is the initial capacity of pooled buffers, which fits typical
+// requests and responses without growing
+const responseBufferSize = 4 << 10
+
 func newResponseWriterWrapper(w http.ResponseWriter) *responseWriterWrapper {
 	return &responseWriterWrapper{
 		ResponseWriter: w,
-		body:           &bytes.Buffer{},
+		body:           getBuffer(),
 		statusCode:     http.StatusOK,
 	}
 }
 
 func (w *responseWriterWrapper) Write(b []byte) (int, error) {
+	if w.maxBytes > 0 && (w.overflow || w.body.Len()+len(b) > w.maxBytes) {
+		// Stop buffering once the limit is exceeded
+		w.overflow = true
+		w.body.Reset()
+		return 0, errResponseTooLarge
+	}
 	return w.body.Write(b)
 }
 
@@ -134,38 +199,73 @@ func (w *responseWriterWrapper) WriteHeader(statusCode int) {
 	w.statusCode = statusCode
 }
 
-// sanitizeAndWrite sanitizes the response body and writes it to the original writer
-func (w *responseWriterWrapper) sanitizeAndWrite() {
-	body := w.body.Bytes()
+// release returns the captured body buffer to the pool once it has been written out
+func (w *responseWriterWrapper) release() {
+	putBuffer(w.body)
+	w.body = nil
+}
 
+// sanitizeResponse removes field suggestions from the error messages of a response
+func sanitizeResponse(r *http.Request, response *Response) {
 	// Try to parse as JSON
 	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err == nil {
-		// Sanitize error messages
-		if errors, ok := data["errors"].([]interface{}); ok {
-			for _, errItem := range errors {
-				if errMap, ok := errItem.(map[string]interface{}); ok {
-					if message, ok := errMap["message"].(string); ok {
-						// Remove field suggestions using regex
-						re := regexp.MustCompile(`Did you mean "[^"]+"\?`)
-						sanitized := re.ReplaceAllString(message, "")
-						// Clean up extra spaces
-						sanitized = regexp.MustCompile(`\s+`).ReplaceAllString(sanitized, " ")
-						sanitized = strings.TrimSpace(sanitized)
-						errMap["message"] = sanitized
-					}
+	if err := json.Unmarshal(response.Body(), &data); err != nil {
+		return
+	}
+
+	// Sanitize error messages
+	if errors, ok := data["errors"].([]interface{}); ok {
+		for _, errItem := range errors {
+			if errMap, ok := errItem.(map[string]interface{}); ok {
+				if message, ok := errMap["message"].(string); ok {
+					errMap["message"] = sanitizeErrorMessage(message)
 				}
 			}
-			// Re-encode to JSON
-			if sanitizedBody, err := json.Marshal(data); err == nil {
-				body = sanitizedBody
-			}
+		}
+		// Re-encode to JSON
+		if sanitizedBody, err := json.Marshal(data); err == nil {
+			response.SetBody(sanitizedBody)
 		}
 	}
+}
 
-	// Write headers and body
-	w.ResponseWriter.WriteHeader(w.statusCode)
-	_, _ = w.ResponseWriter.Write(body)
+// Patterns used by sanitizeErrorMessage, compiled once
+var (
+	fieldSuggestionPattern = regexp.MustCompile(`Did you mean "[^"]+"\?`)
+	whitespacePattern      = regexp.MustCompile(`\s+`)
+)
+
+// sanitizeErrorMessage removes field suggestions from an error message
+func sanitizeErrorMessage(message string) string {
+	// Remove field suggestions using regex
+	sanitized := fieldSuggestionPattern.ReplaceAllString(message, "")
+	// Clean up extra spaces
+	sanitized = whitespacePattern.ReplaceAllString(sanitized, " ")
+	return strings.TrimSpace(sanitized)
+}
+
+// errResponseTooLarge is returned by a size-limited responseWriterWrapper once the limit is exceeded
+var errResponseTooLarge = errors.New("response size limit exceeded")
+
+// limitResponse replaces a response that exceeded maxBytes, while it was buffered or
+// once decorated, with an error response
+func limitResponse(response *Response, maxBytes int, overflow bool) {
+	if !overflow && len(response.Body()) <= maxBytes {
+		return
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"data": nil,
+		"errors": []map[string]interface{}{
+			{
+				"message":    fmt.Sprintf("response size exceeds maximum allowed size of %d bytes", maxBytes),
+				"extensions": map[string]interface{}{"code": "RESPONSE_TOO_LARGE"},
+			},
+		},
+	})
+	response.Header.Set("Content-Type", "application/json; charset=utf-8")
+	response.StatusCode = http.StatusOK
+	response.SetBody(append(body, '\n'))
 }
 
 // New creates a GraphQL handler from the provided GraphContext.
@@ -176,7 +276,10 @@ func (w *responseWriterWrapper) sanitizeAndWrite() {
 //   - Fetches user details using UserDetailsFn if provided
 //   - Adds token and details to the root value for access in resolvers
 //
-// Returns an error if schema building fails.
+// Returns an error if the context is misconfigured (see GraphContext.Validate) or
+// schema building fails. Settings that only NewHTTP enforces, because they depend on
+// the request state it attaches (AuthorizationPolicy, TokenRevokedFn, TenantPolicies,
+// RequireTenant and MutationAudit), are rejected rather than silently ignored.
 //
 // Example:
 //
@@ -187,6 +290,19 @@ func (w *responseWriterWrapper) sanitizeAndWrite() {
 //	    Playground: true,
 //	})
 func New(graphCtx GraphContext) (*handler.Handler, error) {
+	if err := graphCtx.validateForNew(); err != nil {
+		return nil, err
+	}
+	return newHandler(graphCtx)
+}
+
+// newHandler builds the handler of New, which NewHTTP serves pages with while it
+// enforces the settings New rejects itself
+func newHandler(graphCtx GraphContext) (*handler.Handler, error) {
+	if err := graphCtx.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Build schema from context
 	schema, err := buildSchemaFromContext(&graphCtx)
 	if err != nil {
@@ -199,37 +315,280 @@ func New(graphCtx GraphContext) (*handler.Handler, error) {
 		GraphiQL:   graphCtx.GraphiQL,
 		Playground: graphCtx.Playground,
 		RootObjectFn: func(ctx context.Context, r *http.Request) map[string]interface{} {
-			if graphCtx.RootObjectFn != nil {
-				graphCtx.RootObjectFn(ctx, r)
-			}
+			return buildRootValue(ctx, &graphCtx, r)
+		},
+	})
 
-			// Create root value with token for GraphQL resolvers
-			rootValue := make(map[string]interface{})
+	return h, nil
+}
 
-			// Use custom token extractor if provided, otherwise use default Bearer token extractor
-			tokenExtractor := graphCtx.TokenExtractorFn
-			if tokenExtractor == nil {
-				tokenExtractor = ExtractBearerToken
-			}
+// buildRootValue creates the root value for a request, holding the values returned by
+// RootObjectFn, the extracted token and the user details resolved from it
+func buildRootValue(ctx context.Context, graphCtx *GraphContext, r *http.Request) map[string]interface{} {
+	token := requestToken(graphCtx, r)
+	if graphCtx.ClientCertAuth {
+		if caller, ok := requestCaller(graphCtx, r); ok {
+			ctx = context.WithValue(ctx, callerContextKey{}, caller)
+		}
+	}
 
-			token := tokenExtractor(r)
-			if token != "" {
-				rootValue["token"] = token
+	var custom map[string]interface{}
+	if graphCtx.RootObjectFn != nil {
+		custom = graphCtx.RootObjectFn(context.WithValue(ctx, rootTokenContextKey{}, token), r)
+	}
 
-				// Use custom user details fetcher if provided
-				if graphCtx.UserDetailsFn != nil {
-					details, err := graphCtx.UserDetailsFn(token)
-					if err == nil {
-						rootValue["details"] = details
-					}
-				}
-			}
+	// Create root value with token for GraphQL resolvers. Anonymous requests get an
+	// empty map too, so resolvers can store values in it.
+	var rootValue map[string]interface{}
+	if len(custom) > 0 {
+		rootValue = mergeRootValue(ctx, graphCtx, custom, token)
+	} else {
+		rootValue = addRootCaller(ctx, graphCtx, addRootToken(ctx, graphCtx, make(map[string]interface{}, 2), token))
+	}
+	recordPrincipal(ctx, graphCtx, rootValue)
+	return rootValue
+}
 
-			return rootValue
-		},
+// mergeRootValue copies the values returned by RootObjectFn into a new root value with
+// the token, caller and user details. These replace custom values of the same key
+// unless KeepRootObjectValues is set.
+func mergeRootValue(ctx context.Context, graphCtx *GraphContext, custom map[string]interface{}, token string) map[string]interface{} {
+	rootValue := addRootCaller(ctx, graphCtx, addRootToken(ctx, graphCtx, make(map[string]interface{}, len(custom)+2), token))
+	for key, value := range custom {
+		if _, authenticated := rootValue[key]; authenticated && !graphCtx.KeepRootObjectValues {
+			continue
+		}
+		rootValue[key] = value
+	}
+	return rootValue
+}
+
+// rootTokenContextKey stores the extracted token in the context passed to RootObjectFn
+type rootTokenContextKey struct{}
+
+// TokenFromContext returns the token extracted from the request, inside RootObjectFn,
+// so the custom root object can be enriched from it consistently with the user details.
+//
+// Example:
+//
+//	RootObjectFn: func(ctx context.Context, r *http.Request) map[string]interface{} {
+//	    token, _ := graph.TokenFromContext(ctx)
+//	    return map[string]interface{}{"tenant": tenantOf(token)}
+//	},
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(rootTokenContextKey{}).(string)
+	return token, ok && token != ""
+}
+
+// requestToken returns the token found in a request by TokenExtractorFn. Callers
+// authenticated by a signature or client certificate are never taken for a token.
+func requestToken(graphCtx *GraphContext, r *http.Request) string {
+	// Use custom token extractor if provided, otherwise use default Bearer token extractor
+	tokenExtractor := graphCtx.TokenExtractorFn
+	if tokenExtractor == nil {
+		tokenExtractor = ExtractBearerToken
+	}
+	return tokenExtractor(r)
+}
+
+// requestCaller returns the authenticated caller of a request: the key of its verified
+// signature or, with ClientCertAuth, its verified client certificate when it carries
+// no token
+func requestCaller(graphCtx *GraphContext, r *http.Request) (Caller, bool) {
+	if caller, ok := CallerFromContext(r.Context()); ok {
+		return caller, true
+	}
+	if !graphCtx.ClientCertAuth || requestToken(graphCtx, r) != "" {
+		return Caller{}, false
+	}
+	if id, ok := ClientIdentityFromRequest(r); ok {
+		return Caller{Method: CallerClientCert, ID: id.Name()}, true
+	}
+	return Caller{}, false
+}
+
+// addRootToken adds a token and the user details fetched for it to the root value,
+// allocating the root value if needed, and returns it
+func addRootToken(ctx context.Context, graphCtx *GraphContext, rootValue map[string]interface{}, token string) map[string]interface{} {
+	if token == "" {
+		return rootValue
+	}
+
+	if rootValue == nil {
+		rootValue = make(map[string]interface{}, 2)
+	}
+	rootValue["token"] = token
+
+	// Use custom user details fetcher if provided
+	if graphCtx.UserDetailsFn != nil {
+		details, err := userDetails(ctx, graphCtx, token)
+		if err == nil {
+			rootValue["details"] = details
+		}
+	}
+	return rootValue
+}
+
+// addRootCaller adds the authenticated caller of the request of ctx to the root value,
+// under "caller", with the user details from CallerDetailsFn in place of those of the
+// token, and returns it
+func addRootCaller(ctx context.Context, graphCtx *GraphContext, rootValue map[string]interface{}) map[string]interface{} {
+	caller, ok := CallerFromContext(ctx)
+	if !ok {
+		return rootValue
+	}
+
+	if rootValue == nil {
+		rootValue = make(map[string]interface{}, 2)
+	}
+	rootValue["caller"] = caller.ID
+	delete(rootValue, "details")
+	if graphCtx.CallerDetailsFn != nil {
+		if details, err := graphCtx.CallerDetailsFn(caller); err == nil {
+			rootValue["details"] = details
+		}
+	}
+	return rootValue
+}
+
+// requestExtensions returns the "extensions" object of a request: from the JSON
+// "extensions" query parameter, or from a JSON POST body (which is restored for later reads)
+func requestExtensions(r *http.Request) map[string]interface{} {
+	var extensions map[string]interface{}
+
+	if param := queryParam(r.URL.RawQuery, "extensions"); param != "" {
+		_ = json.Unmarshal([]byte(param), &extensions)
+		return extensions
+	}
+
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil
+	}
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil
+	}
+	// Restore body for request option parsing
+	r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+	var body struct {
+		Extensions map[string]interface{} `json:"extensions"`
+	}
+	if err := json.Unmarshal(bodyBytes, &body); err == nil {
+		extensions = body.Extensions
+	}
+	return extensions
+}
+
+// peekRequestOptions parses the GraphQL request options of r, restoring the body for later reads
+func peekRequestOptions(r *http.Request) *handler.RequestOptions {
+	if r.Method != http.MethodPost || r.Body == nil {
+		return handler.NewRequestOptions(r)
+	}
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		return &handler.RequestOptions{}
+	}
+	r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+	peek := r.Clone(r.Context())
+	peek.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	return handler.NewRequestOptions(peek)
+}
+
+// instrumentRequestState wraps the resolvers of schema with the features configured per
+// request: the mutation hook, the authorization policy, the error reporter, the mutation
+// audit log, cache hints and local date times
+func instrumentRequestState(schema *graphql.Schema, graphCtx *GraphContext) {
+	if graphCtx.AfterMutation != nil {
+		addInstrumentation(schema, emitMutationEvents)
+	}
+	if graphCtx.AuthorizationPolicy != nil {
+		addInstrumentation(schema, authorizeResolvers)
+	}
+	if graphCtx.ErrorReporter != nil {
+		addInstrumentation(schema, reportResolverErrors)
+	}
+	if graphCtx.MutationAudit != nil && graphCtx.MutationAudit.Sink != nil {
+		addInstrumentation(schema, auditMutations)
+	}
+	if graphCtx.CacheControl != nil {
+		addInstrumentation(schema, cacheControlResolvers)
+	}
+	if graphCtx.LocalDateTimes {
+		addInstrumentation(schema, localizeDateTimes)
+	}
+	if graphCtx.RequireTenant {
+		addInstrumentation(schema, requireTenantResolvers)
+	}
+}
+
+// withRequestState attaches the state read by the instrumented resolvers of a request
+func withRequestState(r *http.Request, graphCtx *GraphContext) *http.Request {
+	r = withRequestPrincipal(r)
+	r = withAfterMutation(r, graphCtx.AfterMutation)
+	r = withAuthorizationPolicy(r, graphCtx.AuthorizationPolicy)
+	r = withLocale(r, graphCtx)
+	r = withTenant(r, graphCtx)
+	r = withHealthSignal(r, graphCtx.HealthSignal)
+	r = withErrorReporter(r, graphCtx.ErrorReporter)
+	r = withInputDepth(r, graphCtx)
+	r = withDecodeBudget(r, graphCtx)
+	return withMutationAudit(r, graphCtx.MutationAudit)
+}
+
+// requestContextKey stores the incoming *http.Request in the resolver context
+type requestContextKey struct{}
+
+// RequestFromContext returns the HTTP request being served by NewHTTP, for example to
+// read headers inside a resolver
+func RequestFromContext(ctx context.Context) (*http.Request, bool) {
+	r, ok := ctx.Value(requestContextKey{}).(*http.Request)
+	return r, ok
+}
+
+// serveGraphQL executes a GraphQL request with the configured Executor and writes the
+// result. Browser requests for the GraphiQL or Playground page are served by h, or by
+// servePlayground when the playground is configured.
+func serveGraphQL(w http.ResponseWriter, r *http.Request, h *handler.Handler, schema *graphql.Schema, graphCtx *GraphContext) {
+	if (graphCtx.GraphiQL || graphCtx.Playground) && acceptsHTML(r) {
+		if graphCtx.Playground && graphCtx.PlaygroundConfig != nil {
+			servePlayground(w, r, schema, graphCtx.PlaygroundConfig)
+			return
+		}
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	codec := codecFor(graphCtx)
+	opts := requestOptions(r, codec)
+	logOperation(r.Context(), opts)
+	result := executorFor(graphCtx).Execute(ExecuteParams{
+		Context:       r.Context(),
+		Schema:        schema,
+		Query:         opts.Query,
+		OperationName: opts.OperationName,
+		Variables:     opts.Variables,
+		RootValue:     buildRootValue(r.Context(), graphCtx, r),
 	})
 
-	return h, nil
+	body, _ := encodeJSON(codec, result, graphCtx.Pretty)
+
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// acceptsHTML reports whether a request comes from a browser expecting an HTML page
+// rather than a JSON response (the ?raw parameter forces JSON)
+func acceptsHTML(r *http.Request) bool {
+	if _, raw := r.URL.Query()["raw"]; raw {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return !strings.Contains(accept, "application/json") && strings.Contains(accept, "text/html")
 }
 
 // NewHTTP creates a standard http.HandlerFunc with built-in validation and sanitization support.
@@ -242,6 +601,7 @@ func New(graphCtx GraphContext) (*handler.Handler, error) {
 //   - In DEBUG mode (DEBUG: true): Skips all validation and sanitization for easier development
 //   - In production (DEBUG: false): Enables validation and sanitization based on configuration
 //   - Panics during initialization if schema building fails (fail-fast approach)
+//   - Panics if one of WarmupOperations fails, after running them through the handler
 //
 // Security Features (when DEBUG: false):
 //   - EnableValidation: Validates query depth (max 10), aliases (max 4), complexity (max 200), and blocks introspection
@@ -277,10 +637,10 @@ func NewHTTP(graphCtx *GraphContext) http.HandlerFunc {
 		graphCtx = &GraphContext{DEBUG: true, Playground: true}
 	}
 
-	// Build handler (panic if schema building fails)
-	h, err := New(*graphCtx)
+	// Build handler (panic if the context is misconfigured or schema building fails)
+	h, err := newHandler(*graphCtx)
 	if err != nil {
-		panic("failed to build GraphQL schema: " + err.Error())
+		panic("failed to build GraphQL handler: " + err.Error())
 	}
 
 	// Get the built schema for validation
@@ -289,66 +649,358 @@ func NewHTTP(graphCtx *GraphContext) http.HandlerFunc {
 		panic("failed to build GraphQL schema: " + err.Error())
 	}
 
-	return func(w http.ResponseWriter, r *http.Request) {
+	if graphCtx.UsageCollector != nil {
+		graphCtx.UsageCollector.bind(schema)
+	}
+
+	// Time resolvers of operations that ask to be explained, or of every operation for
+	// the slow operation log
+	explainable := graphCtx.DEBUG || graphCtx.EnableExplain
+	if explainable || graphCtx.SlowQueries != nil {
+		addInstrumentation(schema, explainResolvers)
+	}
+
+	// Authorize and audit fields with the policy and audit log of each request
+	instrumentRequestState(schema, graphCtx)
+
+	idempotency := newIdempotencyGuard(graphCtx.Idempotency)
+	revocations := newRevocationCache(graphCtx.TokenRevokedFn)
+	streams := newStreamLimiter(graphCtx.Subscriptions.MaxSubscriptions)
+	codec := codecFor(graphCtx)
+	rateLimiter := newTenantRateLimiter(graphCtx.TenantPolicies)
+	accessLog := newAccessLogger(graphCtx.AccessLog)
+	versioner := newSchemaVersioner(graphCtx, schema)
+
+	serve := func(w http.ResponseWriter, r *http.Request) {
+		// Log every request once it is served, rejected ones included
+		if accessLog != nil {
+			var done func()
+			w, r, done = accessLog.begin(w, r)
+			defer done()
+		}
+
+		// Stamp responses with the schema version, and turn away clients requiring a newer one
+		if versioner != nil {
+			if err := versioner.stamp(w, r); err != nil {
+				writeSchemaVersionError(w, err)
+				return
+			}
+		}
+
+		// Authenticate server-to-server callers by the signature of the raw body
+		if graphCtx.RequestSignature != nil && graphCtx.RequestSignature.isSigned(r) {
+			keyID, err := VerifySignature(r, *graphCtx.RequestSignature)
+			if errors.Is(err, ErrSignatureBody) {
+				http.Error(w, "Signed request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Invalid request signature", http.StatusUnauthorized)
+				return
+			}
+			r = withCaller(r, Caller{Method: CallerSignature, ID: keyID})
+		} else if graphCtx.ClientCertAuth {
+			if caller, ok := requestCaller(graphCtx, r); ok {
+				r = withCaller(r, caller)
+			}
+		}
+
+		// Allow introspection to tooling holding a signed token, however it is blocked for others
+		if graphCtx.IntrospectionTokens != nil {
+			var err error
+			if r, err = allowIntrospection(r, graphCtx.IntrospectionTokens); err != nil {
+				http.Error(w, "Invalid introspection token", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// Expose the request, the response writer and the principal, hooks and policies to resolvers
+		r = withRequestState(r, graphCtx)
+		r = r.WithContext(context.WithValue(r.Context(), requestContextKey{}, r))
+		r = withResponseWriter(r, w)
+
+		// Reject revoked tokens, and ask clients with an expired token to refresh it
+		// rather than serving them anonymously
+		if revocations != nil {
+			r = withRevocations(r, revocations)
+		}
+		if err := authenticateRequest(r, graphCtx, revocations); err != nil {
+			writeUnauthenticated(w, err)
+			return
+		}
+
+		// Apply the limits and feature gates of the tenant's plan, and turn away tenants
+		// over their rate limit
+		r, err := withTenantPolicy(r, graphCtx.TenantPolicies)
+		if err != nil {
+			http.Error(w, "Failed to load tenant policy", http.StatusInternalServerError)
+			return
+		}
+		if err := rateLimiter.allow(r.Context()); err != nil {
+			writeRateLimited(w, err)
+			return
+		}
+
+		// Decode MessagePack bodies into JSON, so the checks below see the variables
+		if graphCtx.EnableMsgPack && r.Method == http.MethodPost && isMsgPackContentType(r.Header.Get("Content-Type")) {
+			if err := msgPackRequestToJSON(r); err != nil {
+				var budgetErr *DecodeBudgetError
+				if errors.As(err, &budgetErr) {
+					writeDecodeBudgetExceeded(w, budgetErr)
+					return
+				}
+				http.Error(w, "Failed to decode msgpack request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Turn away bodies that would take more memory to decode than allowed
+		budgetBuffer, budgetErr := checkDecodeBudget(r)
+		if budgetBuffer != nil {
+			defer putBuffer(budgetBuffer)
+		}
+		if budgetErr != nil {
+			writeDecodeBudgetExceeded(w, budgetErr)
+			return
+		}
+
+		// Turn away deeply nested variables before anything decodes them
+		depthBuffer, depthErr := checkVariablesDepth(r)
+		if depthBuffer != nil {
+			defer putBuffer(depthBuffer)
+		}
+		if depthErr != nil {
+			writeInputTooDeep(w, depthErr)
+			return
+		}
+
+		// Turn away GET requests with malformed variables, or executing a mutation
+		if rejectGetRequest(w, r, codec) {
+			return
+		}
+
+		// Stream results as Server-Sent Events when the client asks for them
+		if graphCtx.EnableSSE && acceptsEventStream(r) {
+			serveSSE(w, r, schema, graphCtx, streams)
+			return
+		}
+
+		// Describe the endpoint to clients opening it without a query
+		if isLandingRequest(r, graphCtx) {
+			serveLanding(w, r, graphCtx.LandingPage)
+			return
+		}
+
+		// Give the operation a deadline its fields can split (see BudgetMiddleware)
+		if graphCtx.RequestTimeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), graphCtx.RequestTimeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+
+		// Lower the accepted complexity and depth while the server is overloaded
+		if graphCtx.AdaptiveLimits != nil {
+			var done func()
+			r, done = graphCtx.AdaptiveLimits.begin(r)
+			defer done()
+		}
+
+		// Pass the response through the stages of the enabled features before writing it
+		pipeline := newResponsePipeline(w, codec, graphCtx.Pretty)
+		defer func() { pipeline.finish(r) }()
+		w = pipeline
+
+		// Answer polling clients that hold the current response with 304 Not Modified
+		if graphCtx.EnableETag {
+			pipeline.Use(HashPhase, tagResponse)
+		}
+
+		// Compress responses for clients accepting gzip
+		if graphCtx.EnableCompression {
+			pipeline.Use(CompressPhase, compressResponse)
+		}
+
+		// Answer in MessagePack when the client asks for it
+		if graphCtx.EnableMsgPack && acceptsMsgPack(r) {
+			pipeline.Use(EncodePhase, packResponse)
+		}
+
+		// Abort oversized responses before they reach the client
+		pipeline.maxBytes = graphCtx.MaxResponseBytes
+
+		// Localize error messages carrying a message key
+		if graphCtx.MessageCatalog != nil {
+			pipeline.Use(DecoratePhase, localizeResponse(graphCtx.MessageCatalog))
+		}
+
+		// Let the application attach metadata to every response
+		if graphCtx.ResponseDecorator != nil {
+			pipeline.Use(DecoratePhase, decorateResponse(r.Context(), graphCtx.ResponseDecorator))
+		}
+		if graphCtx.ResponsePipelineFn != nil {
+			graphCtx.ResponsePipelineFn(r, pipeline)
+		}
+
+		// Report the cost of the operation without executing it
+		if graphCtx.EnableEstimate && isEstimateRequest(r) {
+			serveEstimate(w, r, schema)
+			return
+		}
+
+		// Validate the operation and its variables without executing it
+		if graphCtx.EnableValidateOnly && isValidateOnlyRequest(r) {
+			serveValidateOnly(w, r, schema, graphCtx)
+			return
+		}
+
+		// Replay the response of a retried mutation instead of executing it again.
+		// Estimates and validate-only requests, which execute nothing, are neither
+		// replayed nor stored.
+		if idempotency != nil && idempotency.begin(pipeline, r, graphCtx) {
+			return
+		}
+
+		// Sample the fields selected by operations
+		if graphCtx.UsageCollector != nil && graphCtx.UsageCollector.sample() {
+			if opts := peekRequestOptions(r); opts.Query != "" {
+				graphCtx.UsageCollector.recordOperation(schema, opts.Query, opts.OperationName, clientName(r))
+			}
+		}
+
+		// Aggregate the cache hints of the operation into a Cache-Control header
+		if graphCtx.CacheControl != nil {
+			var cacheControl ResponseDecorator
+			r, cacheControl = cacheControlRequest(r, graphCtx.CacheControl, w.Header())
+			pipeline.Use(ExtensionsPhase, decorateResponse(r.Context(), cacheControl))
+		}
+
+		// Hand the client a replacement for its token
+		if graphCtx.TokenRotationFn != nil {
+			pipeline.Use(ExtensionsPhase, decorateResponse(r.Context(), rotateToken(graphCtx.TokenRotationFn)))
+		}
+
+		// Report the resolver call tree of the operation with its response
+		if explainable && isExplainRequest(r) {
+			var explain ResponseDecorator
+			r, explain = explainRequest(r)
+			pipeline.Use(ExtensionsPhase, decorateResponse(r.Context(), explain))
+		}
+
+		// List the fields that resolved to their declared default
+		if graphCtx.ReportDefaults {
+			var defaults ResponseDecorator
+			r, defaults = defaultsRequest(r)
+			pipeline.Use(ExtensionsPhase, decorateResponse(r.Context(), defaults))
+		}
+
+		// Record deprecated field usage, warn the client about it and turn away operations
+		// selecting fields past their sunset date
+		if graphCtx.DeprecationTracker != nil || graphCtx.DeprecationWarnings || graphCtx.SunsetPolicy != SunsetIgnore {
+			fields, warn := trackDeprecations(r, schema, graphCtx.DeprecationTracker, graphCtx.DeprecationWarnings)
+			if err := applySunsetPolicy(w.Header(), fields, graphCtx.SunsetPolicy, time.Now()); err != nil {
+				writeSunsetError(w, err)
+				return
+			}
+			if warn != nil {
+				pipeline.Use(ExtensionsPhase, decorateResponse(r.Context(), warn))
+			}
+		}
+
 		// Skip validation and sanitization in DEBUG mode
 		if graphCtx.DEBUG {
-			h.ServeHTTP(w, r)
+			serveGraphQL(w, r, h, schema, graphCtx)
 			return
 		}
 
-		// Extract query for validation
+		// Remove field suggestions from the errors of the response, validation errors
+		// included, if enabled
+		if graphCtx.EnableSanitization {
+			pipeline.Use(SanitizePhase, sanitizeResponse)
+		}
+
+		// Extract query and variables for validation
 		var query string
+		var variables map[string]interface{}
+		var batch []string
+		var batchVariables []map[string]interface{}
 		if r.Method == http.MethodPost {
-			// Read body
-			bodyBytes, err := io.ReadAll(r.Body)
-			if err != nil {
+			// Read body into a pooled buffer, released once the request is served
+			bodyBuffer := getBuffer()
+			defer putBuffer(bodyBuffer)
+			if _, err := bodyBuffer.ReadFrom(r.Body); err != nil {
 				http.Error(w, "Failed to read request body", http.StatusBadRequest)
 				return
 			}
+			bodyBytes := bodyBuffer.Bytes()
 
 			// Try to parse as form data
 			if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
-				r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+				r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 				if err := r.ParseForm(); err == nil {
 					query = r.PostForm.Get("query")
 				}
+			} else if trimmed := bytes.TrimSpace(bodyBytes); len(trimmed) > 0 && trimmed[0] == '[' {
+				// Batched request: a JSON array of operations
+				var requestBodies []map[string]interface{}
+				if err := codec.Unmarshal(trimmed, &requestBodies); err == nil {
+					batch = make([]string, 0, len(requestBodies))
+					batchVariables = make([]map[string]interface{}, 0, len(requestBodies))
+					for _, requestBody := range requestBodies {
+						q, _ := requestBody["query"].(string)
+						v, _ := requestBody["variables"].(map[string]interface{})
+						batch = append(batch, q)
+						batchVariables = append(batchVariables, v)
+					}
+				}
 			} else {
 				// Try to parse as JSON
 				var requestBody map[string]interface{}
-				if err := json.Unmarshal(bodyBytes, &requestBody); err == nil {
+				if err := codec.Unmarshal(bodyBytes, &requestBody); err == nil {
 					if q, ok := requestBody["query"].(string); ok {
 						query = q
 					}
+					variables, _ = requestBody["variables"].(map[string]interface{})
 				}
 			}
 
 			// Restore body for GraphQL handler
-			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 		} else if r.Method == http.MethodGet {
-			query = r.URL.Query().Get("query")
+			// Validated like POST requests, with their variables
+			opts, _ := getRequestOptions(r, codec)
+			query, variables = opts.Query, opts.Variables
 		}
 
 		// Validate query if enabled
-		if graphCtx.EnableValidation && query != "" {
-			if err := ValidateGraphQLQuery(query, schema); err != nil {
+		if graphCtx.EnableValidation && (query != "" || batch != nil) {
+			var err error
+			if batch != nil {
+				err = validateGraphQLBatch(batch, batchVariables, schema, graphCtx.RejectParseErrors, queryLimits(r.Context()))
+			} else {
+				var doc *ast.Document
+				doc, err = validateGraphQLQuery(query, variables, schema, graphCtx.RejectParseErrors, queryLimits(r.Context()))
+				// Execute the validated document rather than parsing and validating the
+				// query again. Schemas given in GraphContext.Schema may carry extensions
+				// hooking parse and validation, so their operations go through graphql.Do.
+				if doc != nil && graphCtx.Schema == nil {
+					r = r.WithContext(withValidatedDocument(r.Context(), query, schema, doc))
+				}
+			}
+			if err != nil {
+				body, _ := encodeJSON(codec, map[string]interface{}{"errors": validationErrors(err)}, graphCtx.Pretty)
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusBadRequest)
-				_ = json.NewEncoder(w).Encode(map[string]interface{}{
-					"errors": []map[string]interface{}{
-						{"message": err.Error()},
-					},
-				})
+				_, _ = w.Write(body)
 				return
 			}
 		}
 
-		// Wrap response writer for sanitization if enabled
-		if graphCtx.EnableSanitization {
-			wrapper := newResponseWriterWrapper(w)
-			h.ServeHTTP(wrapper, r)
-			wrapper.sanitizeAndWrite()
-		} else {
-			h.ServeHTTP(w, r)
-		}
+		serveGraphQL(w, r, h, schema, graphCtx)
+	}
+
+	// Fail fast when the warm-up operations show the schema or its resolvers are miswired
+	if err := Warmup(context.Background(), http.HandlerFunc(serve), graphCtx.WarmupOperations...); err != nil {
+		panic("GraphQL warm-up failed: " + err.Error())
 	}
+	return serve
 }
@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// HedgePolicy configures Hedge and HedgeMiddleware
+type HedgePolicy struct {
+	// Delay before each additional attempt is started while none has succeeded, e.g.
+	// the p95 latency of the backend. Default: 100ms
+	Delay time.Duration
+
+	// MaxAttempts is the number of attempts at most, the first one included. Default: 2
+	MaxAttempts int
+
+	// Observer, if set, receives the outcome of every hedged call, for metrics
+	Observer func(ctx context.Context, outcome HedgeOutcome)
+}
+
+// HedgeOutcome describes a hedged call once it returned
+type HedgeOutcome struct {
+	Attempts int           // Attempts started
+	Winner   int           // Attempt that succeeded, counting from 1; 0 when all failed
+	Duration time.Duration // Until the call returned
+	Err      error         // Error returned, nil on success
+}
+
+// Hedge calls call, and again after each Delay without a success, up to MaxAttempts
+// concurrent attempts, and returns the first success. An attempt that fails starts the
+// next one at once. The attempts still running are canceled through their context
+// once Hedge returns. When every attempt fails, the last error is returned; when ctx
+// is done first, its error.
+//
+// Only hedge idempotent calls, such as reads from a replicated backend: every attempt
+// may reach the backend.
+//
+// Example:
+//
+//	profile, err := graph.Hedge(ctx, graph.HedgePolicy{Delay: 30 * time.Millisecond},
+//	    func(ctx context.Context) (*Profile, error) {
+//	        return profiles.Get(ctx, id)
+//	    })
+func Hedge[T any](ctx context.Context, policy HedgePolicy, call func(ctx context.Context) (T, error)) (T, error) {
+	delay := policy.Delay
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 2
+	}
+
+	type attempt struct {
+		value T
+		err   error
+		index int
+	}
+
+	start := time.Now()
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so attempts finishing after Hedge returned do not block
+	results := make(chan attempt, maxAttempts)
+	started := 0
+	launch := func() {
+		started++
+		index := started
+		go func() {
+			value, err := call(ctx)
+			results <- attempt{value: value, err: err, index: index}
+		}()
+	}
+
+	outcome := func(winner int, err error) {
+		if policy.Observer != nil {
+			policy.Observer(parent, HedgeOutcome{Attempts: started, Winner: winner, Duration: time.Since(start), Err: err})
+		}
+	}
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var zero T
+	var lastErr error
+	failed := 0
+	for {
+		select {
+		case result := <-results:
+			if result.err == nil {
+				outcome(result.index, nil)
+				return result.value, nil
+			}
+			lastErr = result.err
+			failed++
+			if started < maxAttempts {
+				launch()
+				timer.Reset(delay)
+			} else if failed == started {
+				outcome(0, lastErr)
+				return zero, lastErr
+			}
+		case <-timer.C:
+			if started < maxAttempts {
+				launch()
+				timer.Reset(delay)
+			}
+		case <-parent.Done():
+			outcome(0, parent.Err())
+			return zero, parent.Err()
+		}
+	}
+}
+
+// HedgeMiddleware hedges the resolver of a read-only field with Hedge, so a slow
+// backend response is raced by another attempt after policy.Delay. Fields resolved for
+// mutations are never hedged. Hedged resolvers report their extra attempts in explain
+// mode.
+//
+// Example:
+//
+//	graph.NewResolver[Product]("product").
+//	    WithMiddleware(graph.HedgeMiddleware(graph.HedgePolicy{Delay: 50 * time.Millisecond})).
+//	    BuildQuery()
+func HedgeMiddleware(policy HedgePolicy) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			if operation, ok := p.Info.Operation.(*ast.OperationDefinition); ok && operation.Operation == ast.OperationTypeMutation {
+				return next(p)
+			}
+			ctx := p.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			hedged := policy
+			hedged.Observer = func(ctx context.Context, outcome HedgeOutcome) {
+				if outcome.Attempts > 1 {
+					markHedged(graphql.ResolveParams(p), outcome.Attempts-1)
+				}
+				if policy.Observer != nil {
+					policy.Observer(ctx, outcome)
+				}
+			}
+			return Hedge(ctx, hedged, func(ctx context.Context) (interface{}, error) {
+				attempt := p
+				attempt.Context = ctx
+				return next(attempt)
+			})
+		}
+	}
+}
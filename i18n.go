@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error is a resolver error carrying a message key and parameters, so the handler
+// can render it in the language of the request using GraphContext.MessageCatalog.
+// The key and params are exposed in the error's extensions.
+type Error struct {
+	Key            string
+	Params         map[string]interface{}
+	DefaultMessage string
+}
+
+// NewError creates a localizable error for a message key. Params fill {name}
+// placeholders in the catalog message.
+//
+// Example:
+//
+//	if len(args.Name) < 3 {
+//	    return nil, graph.NewError("validation.too_short", map[string]interface{}{
+//	        "field": "name",
+//	        "min":   3,
+//	    }).WithDefault("{field} must be at least {min} characters")
+//	}
+func NewError(key string, params map[string]interface{}) *Error {
+	return &Error{Key: key, Params: params}
+}
+
+// WithDefault sets the message template used when no catalog entry matches
+func (e *Error) WithDefault(message string) *Error {
+	e.DefaultMessage = message
+	return e
+}
+
+// Error renders the default message, or the key when there is none
+func (e *Error) Error() string {
+	if e.DefaultMessage == "" {
+		return e.Key
+	}
+	return formatMessage(e.DefaultMessage, e.Params)
+}
+
+// Extensions exposes the message key and params in the GraphQL error
+func (e *Error) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"key": e.Key}
+	if len(e.Params) > 0 {
+		extensions["params"] = e.Params
+	}
+	return extensions
+}
+
+// MessageCatalog resolves message templates by locale and key
+type MessageCatalog interface {
+	// Message returns the template for key in locale (e.g. "fr" or "pt-BR")
+	Message(locale, key string) (string, bool)
+}
+
+// MapCatalog is a MessageCatalog backed by a map of locale → key → template
+//
+// Example:
+//
+//	catalog := graph.MapCatalog{
+//	    "en": {"validation.too_short": "{field} must be at least {min} characters"},
+//	    "fr": {"validation.too_short": "{field} doit contenir au moins {min} caractères"},
+//	}
+type MapCatalog map[string]map[string]string
+
+// Message returns the template for key in locale
+func (c MapCatalog) Message(locale, key string) (string, bool) {
+	message, ok := c[locale][key]
+	return message, ok
+}
+
+// formatMessage replaces {name} placeholders in template with params
+func formatMessage(template string, params map[string]interface{}) string {
+	if len(params) == 0 {
+		return template
+	}
+
+	replacements := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// parseAcceptLanguage returns the locales of an Accept-Language header ordered by
+// preference. Region-specific tags are followed by their base language.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{locale: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	var locales []string
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if !seen[locale] {
+			seen[locale] = true
+			locales = append(locales, locale)
+		}
+	}
+	for _, tag := range tags {
+		add(tag.locale)
+		if base, _, found := strings.Cut(tag.locale, "-"); found {
+			add(base)
+		}
+	}
+	return locales
+}
+
+// localizeMessage resolves key for the first locale the catalog supports
+func localizeMessage(catalog MessageCatalog, locales []string, key string, params map[string]interface{}) (string, bool) {
+	for _, locale := range locales {
+		if template, ok := catalog.Message(locale, key); ok {
+			return formatMessage(template, params), true
+		}
+	}
+	return "", false
+}
+
+// localizeResponse returns the stage rewriting the messages of errors carrying a message
+// key, using the request's Accept-Language
+func localizeResponse(catalog MessageCatalog) ResponseStage {
+	return func(r *http.Request, response *Response) {
+		locales := parseAcceptLanguage(r.Header.Get("Accept-Language"))
+
+		var data map[string]interface{}
+		if len(locales) == 0 || json.Unmarshal(response.Body(), &data) != nil {
+			return
+		}
+		errs, ok := data["errors"].([]interface{})
+		if !ok {
+			return
+		}
+
+		localized := false
+		for _, errItem := range errs {
+			errMap, ok := errItem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			extensions, _ := errMap["extensions"].(map[string]interface{})
+			key, _ := extensions["key"].(string)
+			if key == "" {
+				continue
+			}
+			params, _ := extensions["params"].(map[string]interface{})
+			if message, ok := localizeMessage(catalog, locales, key, params); ok {
+				errMap["message"] = message
+				localized = true
+			}
+		}
+		if localized {
+			if localizedBody, err := json.Marshal(data); err == nil {
+				response.SetBody(localizedBody)
+			}
+		}
+	}
+}
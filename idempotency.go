@@ -0,0 +1,267 @@
+package graph
+
+import (
+	"container/heap"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// IdempotencyKeyHeader carries the key identifying retries of the same mutation, like
+// "idempotencyKey" in the request extensions
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentResponse is a stored mutation response, replayed for retries
+type IdempotentResponse struct {
+	Fingerprint string // Hash of the operation and variables the key was first used with
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyStore stores the responses of mutations sent with an idempotency key.
+// Implementations backed by Redis or a database let replicas share responses.
+type IdempotencyStore interface {
+	// Load returns the response stored for key, if it has not expired
+	Load(ctx context.Context, key string) (*IdempotentResponse, bool, error)
+	// Save stores the response for key until ttl elapses
+	Save(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error
+}
+
+// IdempotencyConfig configures idempotency keys for mutations. Keys are scoped to the
+// principal of the request, its token or Caller, so anonymous requests are not
+// deduplicated.
+//
+// Example:
+//
+//	graphCtx := &graph.GraphContext{
+//	    SchemaParams: params,
+//	    Idempotency:  &graph.IdempotencyConfig{TTL: time.Hour},
+//	}
+//
+// Clients then send the same Idempotency-Key header when retrying a mutation:
+//
+//	curl -H 'Idempotency-Key: 8e03978e-40d5-43e8-bc93-6894a57f9324' \
+//	     -d '{"query":"mutation { createOrder(sku: \"A1\") { id } }"}' ...
+type IdempotencyConfig struct {
+	Store IdempotencyStore // Default: in-memory store local to the handler
+	TTL   time.Duration    // How long responses are replayed, default 24 hours
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore, suitable for a single
+// instance. It is safe for concurrent use.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]memoryIdempotentResponse
+	expiries  idempotencyExpiries
+}
+
+type memoryIdempotentResponse struct {
+	response  *IdempotentResponse
+	expiresAt time.Time
+}
+
+// idempotencyExpiry is the time the response stored for key at that time expires
+type idempotencyExpiry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// idempotencyExpiries is a min-heap of expiries, so Save evicts expired responses
+// without scanning the store
+type idempotencyExpiries []idempotencyExpiry
+
+func (h idempotencyExpiries) Len() int            { return len(h) }
+func (h idempotencyExpiries) Less(i, j int) bool  { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h idempotencyExpiries) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *idempotencyExpiries) Push(x interface{}) { *h = append(*h, x.(idempotencyExpiry)) }
+func (h *idempotencyExpiries) Pop() interface{} {
+	old := *h
+	expiry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return expiry
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{responses: make(map[string]memoryIdempotentResponse)}
+}
+
+// Load returns the response stored for key
+func (s *MemoryIdempotencyStore) Load(ctx context.Context, key string) (*IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.responses[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(stored.expiresAt) {
+		delete(s.responses, key)
+		return nil, false, nil
+	}
+	return stored.response, true, nil
+}
+
+// Save stores the response for key, evicting expired responses
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for len(s.expiries) > 0 && now.After(s.expiries[0].expiresAt) {
+		expired := heap.Pop(&s.expiries).(idempotencyExpiry)
+		// Keys saved again since have a later expiry of their own
+		if stored, ok := s.responses[expired.key]; ok && stored.expiresAt.Equal(expired.expiresAt) {
+			delete(s.responses, expired.key)
+		}
+	}
+
+	expiresAt := now.Add(ttl)
+	s.responses[key] = memoryIdempotentResponse{response: response, expiresAt: expiresAt}
+	heap.Push(&s.expiries, idempotencyExpiry{key: key, expiresAt: expiresAt})
+	return nil
+}
+
+// idempotencyGuard replays the responses of retried mutations for a handler
+type idempotencyGuard struct {
+	store IdempotencyStore
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+// newIdempotencyGuard creates the guard of a handler, or nil when idempotency is disabled
+func newIdempotencyGuard(config *IdempotencyConfig) *idempotencyGuard {
+	if config == nil {
+		return nil
+	}
+	g := &idempotencyGuard{store: config.Store, ttl: config.TTL, inflight: make(map[string]chan struct{})}
+	if g.store == nil {
+		g.store = NewMemoryIdempotencyStore()
+	}
+	if g.ttl <= 0 {
+		g.ttl = 24 * time.Hour
+	}
+	return g
+}
+
+// begin replays the stored response of a retried mutation into pipeline and reports
+// true, or records the response of a first attempt once pipeline has attached its
+// extensions. Requests without a key or a principal, or not executing a mutation, pass
+// through.
+func (g *idempotencyGuard) begin(pipeline *responsePipeline, r *http.Request, graphCtx *GraphContext) bool {
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+	if idempotencyKey == "" {
+		idempotencyKey, _ = requestExtensions(r)["idempotencyKey"].(string)
+	}
+	if idempotencyKey == "" || r.Method != http.MethodPost {
+		return false
+	}
+
+	opts := peekRequestOptions(r)
+	op := selectOperation(opts.Query, opts.OperationName)
+	if op == nil || op.Operation != ast.OperationTypeMutation {
+		return false
+	}
+
+	// Keys are scoped to the principal, so clients cannot replay each other's responses.
+	// Anonymous clients have no scope of their own and are not deduplicated.
+	principalID := principalID(graphCtx, r)
+	if principalID == "" {
+		return false
+	}
+	principal := sha256.Sum256([]byte(principalID))
+	key := hex.EncodeToString(principal[:8]) + ":" + idempotencyKey
+	fingerprint := operationFingerprint(opts.Query, opts.OperationName, opts.Variables)
+
+	release := g.acquire(r.Context(), key)
+	if release == nil {
+		http.Error(pipeline, "Request canceled", http.StatusServiceUnavailable)
+		return true
+	}
+
+	stored, ok, err := g.store.Load(r.Context(), key)
+	if err == nil && ok {
+		release()
+		if stored.Fingerprint != fingerprint {
+			writeIdempotencyMismatch(pipeline)
+			return true
+		}
+		pipeline.replayed = true
+		pipeline.Header().Set("Content-Type", stored.ContentType)
+		pipeline.Header().Set("Idempotent-Replayed", "true")
+		pipeline.WriteHeader(stored.StatusCode)
+		_, _ = pipeline.Write(stored.Body)
+		return true
+	}
+
+	// The key is held until the response is written, so retries wait for it
+	pipeline.cleanups = append(pipeline.cleanups, release)
+	pipeline.record(func(response *Response) {
+		// Server errors are not stored, so the client's retry executes again
+		if response.StatusCode < http.StatusInternalServerError {
+			_ = g.store.Save(r.Context(), key, &IdempotentResponse{
+				Fingerprint: fingerprint,
+				StatusCode:  response.StatusCode,
+				ContentType: response.Header.Get("Content-Type"),
+				Body:        append([]byte(nil), response.Body()...),
+			}, g.ttl)
+		}
+	})
+	return false
+}
+
+// acquire waits until no other request with key is executing and marks key as
+// executing. It returns the func releasing key, or nil when ctx is done first.
+func (g *idempotencyGuard) acquire(ctx context.Context, key string) func() {
+	for {
+		g.mu.Lock()
+		running, ok := g.inflight[key]
+		if !ok {
+			done := make(chan struct{})
+			g.inflight[key] = done
+			g.mu.Unlock()
+
+			return func() {
+				g.mu.Lock()
+				delete(g.inflight, key)
+				g.mu.Unlock()
+				close(done)
+			}
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// operationFingerprint hashes an operation and its variables
+func operationFingerprint(query, operationName string, variables map[string]interface{}) string {
+	vars, _ := json.Marshal(variables)
+	sum := sha256.Sum256([]byte(query + "\x00" + operationName + "\x00" + string(vars)))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeIdempotencyMismatch rejects a key reused for a different operation
+func writeIdempotencyMismatch(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    "Idempotency-Key was already used for a different operation",
+			"extensions": map[string]interface{}{"code": "IDEMPOTENCY_KEY_REUSED"},
+		}},
+	})
+}
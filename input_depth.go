@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/graphql-go/graphql"
+)
+
+// InputDepthError reports variables or an input object argument nested deeper than
+// allowed
+type InputDepthError struct {
+	MaxDepth int
+	Argument string // Input object argument, or "" for variables
+}
+
+// Error describes the input that is nested too deeply
+func (e *InputDepthError) Error() string {
+	if e.Argument == "" {
+		return fmt.Sprintf("variables are nested deeper than the maximum input depth of %d", e.MaxDepth)
+	}
+	return fmt.Sprintf("argument %q is nested deeper than the maximum input depth of %d", e.Argument, e.MaxDepth)
+}
+
+// Extensions exposes the INPUT_TOO_DEEP code in the GraphQL error
+func (e *InputDepthError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "INPUT_TOO_DEEP", "maxDepth": e.MaxDepth}
+}
+
+type inputDepthContextKey struct{}
+
+// withInputDepth has input object resolvers enforce the MaxInputDepth of the request's
+// QueryCostLimits, when graphCtx enables validation
+func withInputDepth(r *http.Request, graphCtx *GraphContext) *http.Request {
+	if !graphCtx.EnableValidation {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), inputDepthContextKey{}, true))
+}
+
+// inputDepthLimit returns the input object nesting allowed in ctx, or 0 when it is not
+// limited
+func inputDepthLimit(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	if enabled, _ := ctx.Value(inputDepthContextKey{}).(bool); !enabled {
+		return 0
+	}
+	return queryLimits(ctx).MaxInputDepth
+}
+
+// checkVariablesDepth rejects requests whose variables nest input objects deeper than
+// the MaxInputDepth of the request. The body is scanned token by token, so a hostile
+// body is turned away before anything decodes it in full; it is read into a pooled
+// buffer, returned for the caller to release once the request is served, and restored
+// for later reads.
+func checkVariablesDepth(r *http.Request) (*bytes.Buffer, *InputDepthError) {
+	maxDepth := inputDepthLimit(r.Context())
+	if maxDepth <= 0 {
+		return nil, nil
+	}
+
+	if variables := queryParam(r.URL.RawQuery, "variables"); variables != "" {
+		if jsonDepthExceeds([]byte(variables), maxDepth, false) {
+			return nil, &InputDepthError{MaxDepth: maxDepth}
+		}
+	}
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil, nil
+	}
+
+	buf := getBuffer()
+	if _, err := buf.ReadFrom(r.Body); err != nil {
+		putBuffer(buf)
+		return nil, nil
+	}
+	body := buf.Bytes()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil || !jsonDepthExceeds([]byte(form.Get("variables")), maxDepth, false) {
+			return buf, nil
+		}
+		return buf, &InputDepthError{MaxDepth: maxDepth}
+	}
+	if jsonDepthExceeds(body, maxDepth, true) {
+		return buf, &InputDepthError{MaxDepth: maxDepth}
+	}
+	return buf, nil
+}
+
+// writeInputTooDeep rejects a request whose variables are nested too deeply
+func writeInputTooDeep(w http.ResponseWriter, err *InputDepthError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    err.Error(),
+			"extensions": err.Extensions(),
+		}},
+	})
+}
+
+// jsonDepthExceeds reports whether the variables in data nest input objects deeper
+// than maxDepth. Lists do not count as a level, as in QueryCostLimits. data is the
+// variables object itself, or, when inBody is set, a request body or batch of request
+// bodies holding it under "variables". Malformed JSON is left for the GraphQL handler
+// to report.
+func jsonDepthExceeds(data []byte, maxDepth int, inBody bool) bool {
+	type container struct {
+		object    bool
+		expectKey bool
+		depth     int // Objects nested in the variables, up to and including this one
+	}
+	var stack []container
+	base := -1 // Index in stack of the variables object
+	pendingVariables := !inBody
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			if base >= len(stack) {
+				base = -1
+			}
+			continue
+		}
+
+		if n := len(stack); n > 0 && stack[n-1].object {
+			if stack[n-1].expectKey {
+				stack[n-1].expectKey = false
+				// "variables" of the body, or of a body in a batch
+				topLevel := n == 1 || (n == 2 && !stack[0].object)
+				if key, _ := token.(string); inBody && base < 0 && topLevel && key == "variables" {
+					pendingVariables = true
+				}
+				continue
+			}
+			stack[n-1].expectKey = true
+		}
+
+		delim, ok := token.(json.Delim)
+		if !ok {
+			pendingVariables = false
+			continue
+		}
+		next := container{object: delim == '{', expectKey: delim == '{'}
+		if base >= 0 {
+			next.depth = stack[len(stack)-1].depth
+			if next.object {
+				next.depth++
+			}
+		}
+		stack = append(stack, next)
+		if pendingVariables {
+			// The variables object itself is not counted
+			base = len(stack) - 1
+			stack[base].depth = 0
+			pendingVariables = false
+		}
+		if next.depth > maxDepth {
+			return true
+		}
+	}
+}
+
+// limitInputObjectDepth rejects input object arguments nested deeper than the
+// MaxInputDepth of the request before they are decoded
+func limitInputObjectDepth(resolve graphql.FieldResolveFn, argName string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if maxDepth := inputDepthLimit(p.Context); maxDepth > 0 && valueDepthExceeds(p.Args[argName], 0, maxDepth) {
+			return nil, &InputDepthError{MaxDepth: maxDepth, Argument: argName}
+		}
+		return resolve(p)
+	}
+}
+
+// valueDepthExceeds reports whether value, nested in depth input objects, nests input
+// objects deeper than maxDepth
+func valueDepthExceeds(value interface{}, depth, maxDepth int) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if depth+1 > maxDepth {
+			return true
+		}
+		for _, field := range v {
+			if valueDepthExceeds(field, depth+1, maxDepth) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if valueDepthExceeds(item, depth, maxDepth) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// InstrumentResolvers wraps every field resolver of the built schema, including the
+// fields of nested object types, so tracing, metrics or authorization apply uniformly.
+// Wrappers run in the order given, outermost first. Fields without a resolver are
+// wrapped around the default property resolver.
+//
+// Example:
+//
+//	schema, err := graph.NewSchemaBuilder(params).
+//	    InstrumentResolvers(func(next graph.FieldResolveFn) graph.FieldResolveFn {
+//	        return func(p graph.ResolveParams) (interface{}, error) {
+//	            ctx, span := tracer.Start(p.Context, p.Info.ParentType.Name()+"."+p.Info.FieldName)
+//	            defer span.End()
+//	            p.Context = ctx
+//	            return next(p)
+//	        }
+//	    }).
+//	    Build()
+func (sb *SchemaBuilder) InstrumentResolvers(wrappers ...FieldMiddleware) *SchemaBuilder {
+	sb.instrumentation = append(sb.instrumentation, wrappers...)
+	return sb
+}
+
+// Object types are shared between schemas through the type registries, so each field
+// definition is wrapped once with a dispatcher that looks up the instrumentation of
+// the schema executing it, identified by its query root type.
+var (
+	schemaInstrumentations sync.Map // *graphql.Object → *schemaInstrumentation
+	instrumentedFields     = make(map[*graphql.FieldDefinition]bool)
+	instrumentedFieldsMu   sync.Mutex
+)
+
+// schemaInstrumentation holds the wrappers of one schema and the composed resolvers
+type schemaInstrumentation struct {
+	wrappers []FieldMiddleware
+	composed sync.Map // *graphql.FieldDefinition → graphql.FieldResolveFn
+}
+
+// instrumentSchema applies wrappers to every object field of schema
+func instrumentSchema(schema *graphql.Schema, wrappers []FieldMiddleware) {
+	schemaInstrumentations.Store(schema.QueryType(), &schemaInstrumentation{wrappers: wrappers})
+
+	instrumentedFieldsMu.Lock()
+	defer instrumentedFieldsMu.Unlock()
+
+	for name, t := range schema.TypeMap() {
+		object, ok := t.(*graphql.Object)
+		if !ok || strings.HasPrefix(name, "__") {
+			continue
+		}
+		for _, field := range object.Fields() {
+			if instrumentedFields[field] {
+				continue
+			}
+			instrumentedFields[field] = true
+			field.Resolve = dispatchInstrumented(field, field.Resolve)
+		}
+	}
+}
+
+// addInstrumentation wraps the resolvers of schema with wrappers, outside the wrappers
+// it is already instrumented with
+func addInstrumentation(schema *graphql.Schema, wrappers ...FieldMiddleware) {
+	existing, ok := schemaInstrumentations.Load(schema.QueryType())
+	if !ok {
+		instrumentSchema(schema, wrappers)
+		return
+	}
+
+	// Memoized schemas are shared by handlers; apply each wrapper once
+	current := existing.(*schemaInstrumentation).wrappers
+	var added []FieldMiddleware
+	for _, wrapper := range wrappers {
+		if !containsMiddleware(current, wrapper) {
+			added = append(added, wrapper)
+		}
+	}
+	if len(added) > 0 {
+		instrumentSchema(schema, append(added, current...))
+	}
+}
+
+// containsMiddleware reports whether wrappers includes the function wrapper
+func containsMiddleware(wrappers []FieldMiddleware, wrapper FieldMiddleware) bool {
+	for _, w := range wrappers {
+		if reflect.ValueOf(w).Pointer() == reflect.ValueOf(wrapper).Pointer() {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchInstrumented resolves a field through the instrumentation of the executing schema
+func dispatchInstrumented(field *graphql.FieldDefinition, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		instrumentation, ok := schemaInstrumentations.Load(p.Info.Schema.QueryType())
+		if !ok {
+			return resolve(p)
+		}
+		return instrumentation.(*schemaInstrumentation).resolver(field, resolve)(p)
+	}
+}
+
+// resolver returns resolve wrapped with the schema's wrappers, composing it once per field
+func (i *schemaInstrumentation) resolver(field *graphql.FieldDefinition, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if composed, ok := i.composed.Load(field); ok {
+		return composed.(graphql.FieldResolveFn)
+	}
+
+	next := FieldResolveFn(func(p ResolveParams) (interface{}, error) {
+		return resolve(graphql.ResolveParams(p))
+	})
+	for j := len(i.wrappers) - 1; j >= 0; j-- {
+		next = i.wrappers[j](next)
+	}
+
+	composed := graphql.FieldResolveFn(func(p graphql.ResolveParams) (interface{}, error) {
+		return next(ResolveParams(p))
+	})
+	i.composed.Store(field, composed)
+	return composed
+}
@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IntrospectionTokenHeader carries the introspection token of a request
+const IntrospectionTokenHeader = "X-Introspection-Token"
+
+// Errors returned by VerifyIntrospectionToken
+var (
+	ErrIntrospectionTokenInvalid = errors.New("introspection token invalid")
+	ErrIntrospectionTokenExpired = errors.New("introspection token expired")
+)
+
+// IntrospectionTokenConfig lets tooling pipelines, such as schema registries or client
+// code generators, introspect the schema while introspection is blocked for the public
+// by EnableValidation. Requests carrying a token issued with IssueIntrospectionToken
+// and the same secret may introspect until the token expires.
+//
+// Example:
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams:        params,
+//	    EnableValidation:    true,
+//	    IntrospectionTokens: &graph.IntrospectionTokenConfig{Secret: []byte(os.Getenv("INTROSPECTION_SECRET"))},
+//	})
+type IntrospectionTokenConfig struct {
+	Secret []byte           // HMAC key the tokens are signed with
+	Header string           // Default: X-Introspection-Token
+	Now    func() time.Time // Clock, default time.Now
+}
+
+// introspectionClaims is the payload of an introspection token
+type introspectionClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"` // Unix time
+}
+
+// introspectionTokenPurpose is signed with the payload, so tokens cannot be mistaken
+// for other HMAC-signed values sharing the secret
+const introspectionTokenPurpose = "graph-introspection."
+
+// IssueIntrospectionToken returns a token allowing introspection for ttl, signed with
+// secret. subject names the holder, e.g. the CI pipeline, and is returned by
+// VerifyIntrospectionToken. Keep ttl short, such as the length of a deploy.
+//
+// Example:
+//
+//	token := graph.IssueIntrospectionToken(secret, "codegen-ci", 15*time.Minute)
+//	// curl -H "X-Introspection-Token: $TOKEN" -d '{"query": "{ __schema { ... } }"}' ...
+func IssueIntrospectionToken(secret []byte, subject string, ttl time.Duration) string {
+	return issueIntrospectionToken(secret, subject, time.Now().Add(ttl))
+}
+
+// issueIntrospectionToken returns a token for subject expiring at expiresAt
+func issueIntrospectionToken(secret []byte, subject string, expiresAt time.Time) string {
+	payload, _ := json.Marshal(introspectionClaims{Subject: subject, ExpiresAt: expiresAt.Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(introspectionMAC(secret, encoded))
+}
+
+// VerifyIntrospectionToken checks the signature and expiry of token at now, and
+// returns its subject
+func VerifyIntrospectionToken(secret []byte, token string, now time.Time) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || len(secret) == 0 {
+		return "", ErrIntrospectionTokenInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, introspectionMAC(secret, encoded)) {
+		return "", ErrIntrospectionTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrIntrospectionTokenInvalid
+	}
+	var claims introspectionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", ErrIntrospectionTokenInvalid
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return "", ErrIntrospectionTokenExpired
+	}
+	return claims.Subject, nil
+}
+
+// introspectionMAC signs the encoded payload of a token
+func introspectionMAC(secret []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(introspectionTokenPurpose + encoded))
+	return mac.Sum(nil)
+}
+
+type introspectionAllowedContextKey struct{}
+
+// allowIntrospection verifies the introspection token of r, if any, and marks r as
+// allowed to introspect when it is valid
+func allowIntrospection(r *http.Request, config *IntrospectionTokenConfig) (*http.Request, error) {
+	header := config.Header
+	if header == "" {
+		header = IntrospectionTokenHeader
+	}
+	token := r.Header.Get(header)
+	if token == "" {
+		return r, nil
+	}
+	now := time.Now
+	if config.Now != nil {
+		now = config.Now
+	}
+	if _, err := VerifyIntrospectionToken(config.Secret, token, now()); err != nil {
+		return r, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), introspectionAllowedContextKey{}, true)), nil
+}
+
+// introspectionAllowed reports whether ctx carries a valid introspection token
+func introspectionAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(introspectionAllowedContextKey{}).(bool)
+	return allowed
+}
@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LambdaRequest is an AWS API Gateway proxy event. It covers REST APIs (payload
+// format 1.0), HTTP APIs (payload format 2.0) and Lambda function URLs, which use
+// the 2.0 format.
+type LambdaRequest struct {
+	Version                         string               `json:"version,omitempty"`
+	HTTPMethod                      string               `json:"httpMethod,omitempty"` // 1.0
+	Path                            string               `json:"path,omitempty"`       // 1.0
+	RawPath                         string               `json:"rawPath,omitempty"`    // 2.0
+	RawQueryString                  string               `json:"rawQueryString,omitempty"`
+	Headers                         map[string]string    `json:"headers,omitempty"`
+	MultiValueHeaders               map[string][]string  `json:"multiValueHeaders,omitempty"`
+	QueryStringParameters           map[string]string    `json:"queryStringParameters,omitempty"`
+	MultiValueQueryStringParameters map[string][]string  `json:"multiValueQueryStringParameters,omitempty"`
+	Cookies                         []string             `json:"cookies,omitempty"` // 2.0
+	Body                            string               `json:"body,omitempty"`
+	IsBase64Encoded                 bool                 `json:"isBase64Encoded,omitempty"`
+	RequestContext                  LambdaRequestContext `json:"requestContext"`
+}
+
+// LambdaRequestContext holds the request metadata of a LambdaRequest used by the handler
+type LambdaRequestContext struct {
+	HTTP struct {
+		Method   string `json:"method"`
+		Path     string `json:"path"`
+		SourceIP string `json:"sourceIp"`
+	} `json:"http"` // 2.0
+	Identity struct {
+		SourceIP string `json:"sourceIp"`
+	} `json:"identity"` // 1.0
+}
+
+// LambdaResponse is an AWS API Gateway proxy response. Binary bodies, such as
+// MessagePack responses, are base64 encoded.
+type LambdaResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// LambdaHandler creates an AWS Lambda handler serving GraphQL requests from API Gateway
+// or function URL events. The handler is built once, when LambdaHandler is called, and
+// reused by every invocation of a warm function; call it outside the Lambda handler
+// function so cold starts build the schema only once.
+//
+// Events are converted to HTTP requests: base64 bodies are decoded and header names are
+// canonicalized, so lower-cased headers such as "authorization" reach TokenExtractorFn
+// as they would over plain HTTP.
+//
+// The handler matches the signature expected by lambda.Start from
+// github.com/aws/aws-lambda-go, with no dependency on it. Google Cloud Functions call
+// http.HandlerFunc directly, so they can use NewHTTP as their entry point.
+//
+// Example:
+//
+//	var graphHandler = graph.LambdaHandler(&graph.GraphContext{
+//	    SchemaParams:     &graph.SchemaBuilderParams{QueryFields: queries},
+//	    EnableValidation: true,
+//	})
+//
+//	func main() {
+//	    lambda.Start(graphHandler)
+//	}
+func LambdaHandler(graphCtx *GraphContext) func(ctx context.Context, event LambdaRequest) (LambdaResponse, error) {
+	h := NewHTTP(graphCtx)
+
+	return func(ctx context.Context, event LambdaRequest) (LambdaResponse, error) {
+		r, err := event.httpRequest(ctx)
+		if err != nil {
+			return LambdaResponse{
+				StatusCode: http.StatusBadRequest,
+				Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+				Body:       "Failed to decode request body",
+			}, nil
+		}
+
+		w := &lambdaResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+		h.ServeHTTP(w, r)
+		return w.response(event.isV2()), nil
+	}
+}
+
+// isV2 reports whether the event uses payload format 2.0
+func (event LambdaRequest) isV2() bool {
+	return event.Version == "2.0"
+}
+
+// httpRequest converts the event to an HTTP request
+func (event LambdaRequest) httpRequest(ctx context.Context) (*http.Request, error) {
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = decoded
+	}
+
+	method, path := event.HTTPMethod, event.Path
+	if event.isV2() {
+		method, path = event.RequestContext.HTTP.Method, event.RawPath
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	target := &url.URL{Path: path, RawQuery: event.rawQuery()}
+	r, err := http.NewRequestWithContext(ctx, method, target.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Header.Add canonicalizes names, which API Gateway may send lower-cased
+	for name, values := range event.MultiValueHeaders {
+		for _, value := range values {
+			r.Header.Add(name, value)
+		}
+	}
+	for name, value := range event.Headers {
+		if _, ok := r.Header[http.CanonicalHeaderKey(name)]; !ok {
+			r.Header.Set(name, value)
+		}
+	}
+	if len(event.Cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+
+	r.RemoteAddr = event.RequestContext.HTTP.SourceIP
+	if r.RemoteAddr == "" {
+		r.RemoteAddr = event.RequestContext.Identity.SourceIP
+	}
+	r.Host = r.Header.Get("Host")
+	return r, nil
+}
+
+// rawQuery returns the encoded query string of the event
+func (event LambdaRequest) rawQuery() string {
+	if event.RawQueryString != "" {
+		return event.RawQueryString
+	}
+
+	query := url.Values{}
+	for name, values := range event.MultiValueQueryStringParameters {
+		query[name] = append(query[name], values...)
+	}
+	for name, value := range event.QueryStringParameters {
+		if _, ok := query[name]; !ok {
+			query.Set(name, value)
+		}
+	}
+	return query.Encode()
+}
+
+// lambdaResponseWriter captures a response to return it as a LambdaResponse
+type lambdaResponseWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+}
+
+func (w *lambdaResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *lambdaResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.statusCode = statusCode
+	w.wroteHeader = true
+}
+
+func (w *lambdaResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// response returns the captured response in the event's payload format
+func (w *lambdaResponseWriter) response(v2 bool) LambdaResponse {
+	response := LambdaResponse{
+		StatusCode: w.statusCode,
+		Headers:    make(map[string]string, len(w.header)),
+	}
+
+	for name, values := range w.header {
+		switch {
+		case v2 && name == "Set-Cookie":
+			response.Cookies = values
+		case len(values) > 1 && !v2:
+			if response.MultiValueHeaders == nil {
+				response.MultiValueHeaders = make(map[string][]string)
+			}
+			response.MultiValueHeaders[name] = values
+		default:
+			response.Headers[name] = strings.Join(values, ",")
+		}
+	}
+
+	if isTextContentType(w.header.Get("Content-Type")) {
+		response.Body = w.body.String()
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		response.IsBase64Encoded = true
+	}
+	return response
+}
+
+// isTextContentType reports whether a response body can be returned to API Gateway
+// as text
+func isTextContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		mediaType == "application/graphql-response+json" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/javascript" ||
+		mediaType == "application/xml"
+}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// LandingPageConfig answers GET requests without a query, typically someone opening the
+// endpoint in a browser, instead of executing an empty operation
+type LandingPageConfig struct {
+	// RedirectURL sends browsers (requests accepting text/html) to an IDE, such as a
+	// hosted explorer, with 302 Found. Other clients get the usage JSON.
+	RedirectURL string
+
+	// Description replaces the default message of the usage JSON
+	Description string
+
+	// Links are listed in the usage JSON by name, e.g. "docs" or "schema"
+	Links map[string]string
+}
+
+// defaultLandingDescription explains how to use the endpoint
+const defaultLandingDescription = "This is a GraphQL endpoint. Send queries as JSON with POST, " +
+	"or with GET in the query, variables and operationName parameters."
+
+// isLandingRequest reports whether r is a GET request without a query that the
+// playground does not serve
+func isLandingRequest(r *http.Request, graphCtx *GraphContext) bool {
+	if graphCtx.LandingPage == nil || r.Method != http.MethodGet || queryParam(r.URL.RawQuery, "query") != "" {
+		return false
+	}
+	return !((graphCtx.GraphiQL || graphCtx.Playground) && acceptsHTML(r))
+}
+
+// serveLanding redirects browsers to the configured IDE, or describes the endpoint
+func serveLanding(w http.ResponseWriter, r *http.Request, config *LandingPageConfig) {
+	if config.RedirectURL != "" && acceptsHTML(r) {
+		http.Redirect(w, r, config.RedirectURL, http.StatusFound)
+		return
+	}
+
+	description := config.Description
+	if description == "" {
+		description = defaultLandingDescription
+	}
+	usage := map[string]interface{}{
+		"message":  description,
+		"endpoint": r.URL.Path,
+		"example":  r.URL.Path + "?query=%7B__typename%7D",
+	}
+	if config.RedirectURL != "" {
+		usage["ide"] = config.RedirectURL
+	}
+	if len(config.Links) > 0 {
+		usage["links"] = config.Links
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(usage)
+}
@@ -0,0 +1,198 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+)
+
+// Rules reported in LintWarning.Rule
+const (
+	LintDeprecatedField    = "deprecatedField"
+	LintAnonymousOperation = "anonymousOperation"
+	LintUnusedVariable     = "unusedVariable"
+	LintOverFetching       = "overFetching"
+)
+
+// lintLimitWarningPercent is the share of a query limit from which LintQuery warns
+const lintLimitWarningPercent = 80
+
+// LintWarning is a problem LintQuery found in a valid query
+type LintWarning struct {
+	Rule      string                    `json:"rule"`
+	Message   string                    `json:"message"`
+	Locations []location.SourceLocation `json:"locations,omitempty"`
+}
+
+// LintQuery checks a client query against schema for problems that do not make it
+// invalid but should be fixed before it ships: deprecated fields, operations without a
+// name, variables that are never used, and a depth, alias count or complexity above
+// DefaultQueryLimits (rejected when the server validates queries) or over 80% of them.
+// It returns a *QueryValidationError when the query does not parse or validate.
+//
+// Client teams can run it in CI against the schema, directly or through LintHandler.
+//
+// Example:
+//
+//	warnings, err := graph.LintQuery(query, &schema)
+//	if err != nil {
+//	    t.Fatalf("invalid query: %v", err)
+//	}
+//	for _, warning := range warnings {
+//	    t.Errorf("%s: %s", warning.Rule, warning.Message)
+//	}
+func LintQuery(query string, schema *graphql.Schema) ([]LintWarning, error) {
+	doc, err := parseGraphQLQuery(query)
+	if err != nil {
+		return nil, &QueryValidationError{Errors: gqlerrors.FormatErrors(err)}
+	}
+	// Unused variables are reported as warnings rather than errors
+	if result := graphql.ValidateDocument(schema, doc, lintValidationRules()); !result.IsValid {
+		return nil, &QueryValidationError{Errors: result.Errors}
+	}
+
+	var warnings []LintWarning
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		name := ""
+		if operation.Name != nil {
+			name = operation.Name.Value
+		} else {
+			warnings = append(warnings, LintWarning{
+				Rule:      LintAnonymousOperation,
+				Message:   fmt.Sprintf("%s has no name: name operations so they can be identified in logs and metrics", operation.Operation),
+				Locations: lintLocations(doc, operation),
+			})
+		}
+		warnings = append(warnings, lintDeprecatedFields(schema, doc, name)...)
+	}
+
+	unused := graphql.ValidateDocument(schema, doc, []graphql.ValidationRuleFn{graphql.NoUnusedVariablesRule})
+	for _, err := range unused.Errors {
+		warnings = append(warnings, LintWarning{Rule: LintUnusedVariable, Message: err.Message, Locations: err.Locations})
+	}
+
+	return append(warnings, lintLimits(doc)...), nil
+}
+
+// lintValidationRules returns the specified validation rules, except unused variables
+func lintValidationRules() []graphql.ValidationRuleFn {
+	unused := reflect.ValueOf(graphql.NoUnusedVariablesRule).Pointer()
+	rules := make([]graphql.ValidationRuleFn, 0, len(graphql.SpecifiedRules))
+	for _, rule := range graphql.SpecifiedRules {
+		if reflect.ValueOf(rule).Pointer() != unused {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// lintDeprecatedFields reports the deprecated fields selected by an operation, once per
+// field with the location of every selection
+func lintDeprecatedFields(schema *graphql.Schema, doc *ast.Document, operationName string) []LintWarning {
+	var warnings []LintWarning
+	byField := make(map[string]int)
+	visitSelectedFields(schema, doc, operationName, func(parent graphql.Type, field *graphql.FieldDefinition, node *ast.Field) {
+		if field.DeprecationReason == "" {
+			return
+		}
+		coordinate := parent.Name() + "." + field.Name
+		i, ok := byField[coordinate]
+		if !ok {
+			i = len(warnings)
+			byField[coordinate] = i
+			warnings = append(warnings, LintWarning{
+				Rule:    LintDeprecatedField,
+				Message: fmt.Sprintf("%s is deprecated: %s", coordinate, field.DeprecationReason),
+			})
+		}
+		warnings[i].Locations = append(warnings[i].Locations, lintLocations(doc, node)...)
+	})
+	return warnings
+}
+
+// lintLimits reports the measures of doc above DefaultQueryLimits or close to them
+func lintLimits(doc *ast.Document) []LintWarning {
+	result := analyzeDocument(doc, nil, DefaultQueryLimits)
+	measures := []struct {
+		name         string
+		value, limit int
+	}{
+		{"depth", result.Depth, DefaultQueryLimits.MaxDepth},
+		{"alias count", result.AliasCount, DefaultQueryLimits.MaxAliases},
+		{"complexity", result.Complexity, DefaultQueryLimits.MaxComplexity},
+	}
+
+	var warnings []LintWarning
+	for _, measure := range measures {
+		switch {
+		case measure.limit <= 0:
+		case measure.value > measure.limit:
+			warnings = append(warnings, LintWarning{
+				Rule:    LintOverFetching,
+				Message: fmt.Sprintf("query %s %d exceeds the limit of %d: the server rejects it", measure.name, measure.value, measure.limit),
+			})
+		case measure.value*100 >= measure.limit*lintLimitWarningPercent:
+			warnings = append(warnings, LintWarning{
+				Rule:    LintOverFetching,
+				Message: fmt.Sprintf("query %s %d is %d%% of the limit of %d: select fewer fields", measure.name, measure.value, measure.value*100/measure.limit, measure.limit),
+			})
+		}
+	}
+	return warnings
+}
+
+// lintLocations returns the location of node in the query text
+func lintLocations(doc *ast.Document, node ast.Node) []location.SourceLocation {
+	if doc.Loc == nil || doc.Loc.Source == nil || node.GetLoc() == nil {
+		return nil
+	}
+	return []location.SourceLocation{location.GetLocation(doc.Loc.Source, node.GetLoc().Start)}
+}
+
+// LintHandler serves LintQuery over HTTP, so client teams can lint their queries in CI
+// without importing the schema: POST {"query": "..."} gets {"warnings": [...]}, or 400
+// with the errors of an invalid query.
+//
+// Example:
+//
+//	http.Handle("/graphql/lint", graph.LintHandler(&schema))
+//
+//	// curl -s -d '{"query": "{ user(id: 1) { name } }"}' https://api.example.com/graphql/lint
+func LintHandler(schema *graphql.Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Lint queries with POST", http.StatusMethodNotAllowed)
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Query == "" {
+			http.Error(w, `Expected a JSON body with a "query"`, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		warnings, err := LintQuery(body.Query, schema)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": validationErrors(err)})
+			return
+		}
+		if warnings == nil {
+			warnings = []LintWarning{}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"warnings": warnings})
+	})
+}
@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// LiveDirective marks a query as live: the client receives an updated result
+// whenever the data the query depends on changes. It is added to built schemas
+// when GraphContext.LivePubSub is set; add it to SchemaConfig.Directives when
+// providing a pre-built schema.
+//
+//	query @live { dashboard { activeUsers } }
+var LiveDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "live",
+	Description: "Re-executes the query and pushes the result whenever its dependencies change.",
+	Locations:   []string{graphql.DirectiveLocationQuery},
+})
+
+// liveDependenciesKey is the context key of the dependency collector of a live execution
+type liveDependenciesKey struct{}
+
+// liveDependencies collects the topics a live query depends on during one execution
+type liveDependencies struct {
+	mu     sync.Mutex
+	topics map[string]struct{}
+}
+
+func (d *liveDependencies) add(topics ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, topic := range topics {
+		d.topics[topic] = struct{}{}
+	}
+}
+
+func (d *liveDependencies) list() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	topics := make([]string, 0, len(d.topics))
+	for topic := range d.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// DependsOn marks the PubSub topics the current field depends on. When the field
+// is resolved as part of a live query, publishing to any of the topics re-executes
+// the query. Outside live queries it is a no-op.
+//
+// Example:
+//
+//	NewResolver[Stats]("stats").
+//		WithResolver(func(p graph.ResolveParams) (*Stats, error) {
+//			graph.DependsOn(p, "orders", "users")
+//			return statsService.Current(p.Context)
+//		}).BuildQuery()
+//
+//	// Wherever orders change
+//	_ = pubsub.Publish(ctx, "orders", nil)
+func DependsOn(p ResolveParams, topics ...string) {
+	if p.Context == nil {
+		return
+	}
+	if deps, ok := p.Context.Value(liveDependenciesKey{}).(*liveDependencies); ok {
+		deps.add(topics...)
+	}
+}
+
+// isLiveQuery reports whether the selected operation is a query marked @live
+func isLiveQuery(query, operationName string) bool {
+	op := selectOperation(query, operationName)
+	if op == nil || op.Operation != ast.OperationTypeQuery {
+		return false
+	}
+	for _, directive := range op.Directives {
+		if directive.Name != nil && directive.Name.Value == LiveDirective.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// executeLive executes a live query and re-executes it each time one of the topics
+// its resolvers depend on is published. Unchanged results are not sent again.
+// The channel is closed when the request context ends, or after the first result
+// if the query has no dependencies.
+func executeLive(executor Executor, params ExecuteParams, pubsub PubSub) chan *graphql.Result {
+	results := make(chan *graphql.Result)
+
+	go func() {
+		defer close(results)
+
+		ctx := params.Context
+		var previous []byte
+		for {
+			deps := &liveDependencies{topics: make(map[string]struct{})}
+			execParams := params
+			execParams.Context = context.WithValue(ctx, liveDependenciesKey{}, deps)
+			result := executor.Execute(execParams)
+
+			if encoded, err := json.Marshal(result); err != nil || !bytes.Equal(encoded, previous) {
+				previous = encoded
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			topics := deps.list()
+			if len(topics) == 0 {
+				return
+			}
+
+			if !waitForInvalidation(ctx, pubsub, topics) {
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// waitForInvalidation blocks until one of the topics is published, returning false
+// if ctx ends first or a subscription fails
+func waitForInvalidation(ctx context.Context, pubsub PubSub, topics []string) bool {
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	invalidated := make(chan struct{}, 1)
+	for _, topic := range topics {
+		events, err := pubsub.Subscribe(subCtx, topic)
+		if err != nil {
+			return false
+		}
+		go func() {
+			for range events {
+				select {
+				case invalidated <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+
+	select {
+	case <-invalidated:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// DefaultTimezoneHeader names the header carrying the client's IANA time zone, such as
+// "Europe/Paris", when GraphContext.TimezoneHeader is empty
+const DefaultTimezoneHeader = "X-Timezone"
+
+// requestLocale holds the locale preferences of a request
+type requestLocale struct {
+	locales  []string
+	location *time.Location
+
+	// localDateTimes serializes DateTime values in location (see GraphContext.LocalDateTimes)
+	localDateTimes bool
+}
+
+type localeContextKey struct{}
+
+// withLocale parses the Accept-Language and time zone headers of r into its context
+func withLocale(r *http.Request, graphCtx *GraphContext) *http.Request {
+	timezoneHeader := graphCtx.TimezoneHeader
+	if timezoneHeader == "" {
+		timezoneHeader = DefaultTimezoneHeader
+	}
+	locale := &requestLocale{
+		locales:        parseAcceptLanguage(r.Header.Get("Accept-Language")),
+		location:       loadLocation(r.Header.Get(timezoneHeader)),
+		localDateTimes: graphCtx.LocalDateTimes,
+	}
+	return r.WithContext(context.WithValue(r.Context(), localeContextKey{}, locale))
+}
+
+// Locale returns the locale the client of the request being served prefers, from its
+// Accept-Language header, or "" when it did not say
+//
+// Example:
+//
+//	switch graph.Locale(p.Context) {
+//	case "fr-FR", "fr":
+//	    return product.NameFR, nil
+//	}
+func Locale(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeContextKey{}).(*requestLocale); ok && len(locale.locales) > 0 {
+		return locale.locales[0]
+	}
+	return ""
+}
+
+// Location returns the time zone of the client of the request being served, from the
+// X-Timezone header (see GraphContext.TimezoneHeader), or UTC when it did not send a
+// valid one
+func Location(ctx context.Context) *time.Location {
+	if locale, ok := ctx.Value(localeContextKey{}).(*requestLocale); ok {
+		return locale.location
+	}
+	return time.UTC
+}
+
+// locations caches the time zones loaded by loadLocation, which reads the zone database
+var locations sync.Map // name → *time.Location
+
+// loadLocation returns the time zone named name, or UTC for unknown names
+func loadLocation(name string) *time.Location {
+	if name == "" || len(name) > 64 {
+		return time.UTC
+	}
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location)
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(name, location)
+	return location
+}
+
+// localDateTime is a time serialized by DateTime in its own location rather than UTC
+type localDateTime struct {
+	time.Time
+}
+
+// localizeDateTimes converts the values of DateTime fields to the time zone of the
+// request, so they serialize as the client's wall-clock time. Whether to convert is
+// read from the context, so handlers sharing a memoized schema can differ.
+func localizeDateTimes(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		output := p.Info.ReturnType
+		if nonNull, ok := output.(*graphql.NonNull); ok {
+			output = nonNull.OfType
+		}
+		list, isList := output.(*graphql.List)
+		if isList {
+			output = list.OfType
+			if nonNull, ok := output.(*graphql.NonNull); ok {
+				output = nonNull.OfType
+			}
+		}
+		if output != DateTime || p.Context == nil {
+			return next(p)
+		}
+		locale, ok := p.Context.Value(localeContextKey{}).(*requestLocale)
+		if !ok || !locale.localDateTimes {
+			return next(p)
+		}
+
+		value, err := next(p)
+		if err != nil {
+			return value, err
+		}
+		return inLocation(value, locale.location), nil
+	}
+}
+
+// inLocation converts times, and lists of times, to location
+func inLocation(value interface{}, location *time.Location) interface{} {
+	switch value := value.(type) {
+	case time.Time:
+		return localDateTime{value.In(location)}
+	case *time.Time:
+		if value != nil {
+			return localDateTime{value.In(location)}
+		}
+	case []time.Time:
+		converted := make([]interface{}, len(value))
+		for i, t := range value {
+			converted[i] = localDateTime{t.In(location)}
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, v := range value {
+			converted[i] = inLocation(v, location)
+		}
+		return converted
+	}
+	return value
+}
@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// FieldLookahead tells a resolver how a field below it will be requested, so it can
+// prefetch or join the data up front. Occurrences of the field under several aliases
+// and through fragments are all taken into account.
+type FieldLookahead struct {
+	p     ResolveParams
+	nodes []*ast.Field
+}
+
+// Lookahead returns the lookahead of the field being resolved. Use Field to look at
+// the children that will be requested.
+//
+// Example:
+//
+//	func(p graph.ResolveParams) (*[]Post, error) {
+//	    comments := graph.Lookahead(p).Field("comments")
+//	    if comments.IsSelected() {
+//	        limit, _ := comments.Args()["limit"].(int)
+//	        return loadPostsWithComments(p.Context, limit) // one JOIN instead of N queries
+//	    }
+//	    return loadPosts(p.Context)
+//	}
+func Lookahead(p ResolveParams) FieldLookahead {
+	return FieldLookahead{p: p, nodes: p.Info.FieldASTs}
+}
+
+// Field returns the lookahead of a child field. Nested fields are addressed with dots,
+// e.g. "edges.node.author".
+func (l FieldLookahead) Field(path string) FieldLookahead {
+	current := l
+	for _, name := range strings.Split(path, ".") {
+		var children []*ast.Field
+		for _, node := range current.nodes {
+			children = append(children, collectFieldNodes(l.p, node.SelectionSet, name, nil)...)
+		}
+		current = FieldLookahead{p: l.p, nodes: children}
+	}
+	return current
+}
+
+// IsSelected reports whether the field will be requested
+func (l FieldLookahead) IsSelected() bool {
+	return len(l.nodes) > 0
+}
+
+// Aliases returns the response keys the field is requested under
+func (l FieldLookahead) Aliases() []string {
+	keys := make([]string, 0, len(l.nodes))
+	for _, node := range l.nodes {
+		if node.Alias != nil {
+			keys = append(keys, node.Alias.Value)
+		} else {
+			keys = append(keys, node.Name.Value)
+		}
+	}
+	return keys
+}
+
+// Args returns the arguments of the field's first occurrence, with variables replaced
+// by their values. Literals are returned as written: integers as int, floats as
+// float64 and enum values as strings.
+func (l FieldLookahead) Args() map[string]interface{} {
+	if len(l.nodes) == 0 {
+		return map[string]interface{}{}
+	}
+	return l.arguments(l.nodes[0])
+}
+
+// AllArgs returns the arguments of every occurrence of the field, in the order of Aliases
+func (l FieldLookahead) AllArgs() []map[string]interface{} {
+	all := make([]map[string]interface{}, 0, len(l.nodes))
+	for _, node := range l.nodes {
+		all = append(all, l.arguments(node))
+	}
+	return all
+}
+
+// Selection returns the sub-fields requested on the field, merged across occurrences
+func (l FieldLookahead) Selection() Selection {
+	selection := Selection{}
+	for _, node := range l.nodes {
+		collectSelection(l.p, node.SelectionSet, selection)
+	}
+	return selection
+}
+
+// arguments resolves the arguments of a field node
+func (l FieldLookahead) arguments(node *ast.Field) map[string]interface{} {
+	args := make(map[string]interface{}, len(node.Arguments))
+	for _, arg := range node.Arguments {
+		args[arg.Name.Value] = astValue(l.p, arg.Value)
+	}
+	return args
+}
+
+// collectFieldNodes returns the fields named name in selectionSet, following
+// fragments and honoring @skip and @include
+func collectFieldNodes(p ResolveParams, selectionSet *ast.SelectionSet, name string, nodes []*ast.Field) []*ast.Field {
+	if selectionSet == nil {
+		return nodes
+	}
+
+	for _, node := range selectionSet.Selections {
+		switch sel := node.(type) {
+		case *ast.Field:
+			if sel.Name.Value == name && shouldIncludeNode(p, sel.Directives) {
+				nodes = append(nodes, sel)
+			}
+		case *ast.InlineFragment:
+			if shouldIncludeNode(p, sel.Directives) {
+				nodes = collectFieldNodes(p, sel.SelectionSet, name, nodes)
+			}
+		case *ast.FragmentSpread:
+			if !shouldIncludeNode(p, sel.Directives) {
+				continue
+			}
+			if fragment, ok := p.Info.Fragments[sel.Name.Value].(*ast.FragmentDefinition); ok {
+				nodes = collectFieldNodes(p, fragment.SelectionSet, name, nodes)
+			}
+		}
+	}
+	return nodes
+}
+
+// astValue converts an argument value to Go, replacing variables by their values
+func astValue(p ResolveParams, value ast.Value) interface{} {
+	switch v := value.(type) {
+	case *ast.Variable:
+		return p.Info.VariableValues[v.Name.Value]
+	case *ast.IntValue:
+		if n, err := strconv.Atoi(v.Value); err == nil {
+			return n
+		}
+		return v.Value
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+		return v.Value
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.ListValue:
+		list := make([]interface{}, 0, len(v.Values))
+		for _, item := range v.Values {
+			list = append(list, astValue(p, item))
+		}
+		return list
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			object[field.Name.Value] = astValue(p, field.Value)
+		}
+		return object
+	}
+	return nil
+}
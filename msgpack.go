@@ -0,0 +1,535 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MsgPackContentType is the media type used for MessagePack request and response bodies.
+const MsgPackContentType = "application/msgpack"
+
+// maxMsgPackDepth bounds the nesting of maps and arrays UnmarshalMsgPack decodes, so a
+// hostile body cannot exhaust the stack. Request variables are held to the much lower
+// input depth limit once decoded.
+const maxMsgPackDepth = 1000
+
+var errMsgPackTooDeep = errors.New("msgpack: maps and arrays nested too deeply")
+
+// msgPackContentTypes lists the media types accepted as MessagePack
+var msgPackContentTypes = []string{
+	MsgPackContentType,
+	"application/x-msgpack",
+	"application/vnd.msgpack",
+}
+
+// isMsgPackContentType reports whether a Content-Type header value denotes MessagePack
+func isMsgPackContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range msgPackContentTypes {
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsMsgPack reports whether the request's Accept header asks for MessagePack
+func acceptsMsgPack(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if isMsgPackContentType(strings.TrimSpace(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalMsgPack encodes a value as MessagePack.
+//
+// Natively supported values are nil, bool, integers, floats, string, []byte,
+// []interface{} and map[string]interface{}. Any other value is first converted
+// through its JSON representation, so structs honour their json tags.
+//
+// Example:
+//
+//	body, err := graph.MarshalMsgPack(map[string]interface{}{
+//	    "query":     "query($id: Int!) { user(id: $id) { name } }",
+//	    "variables": map[string]interface{}{"id": 1},
+//	})
+func MarshalMsgPack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeMsgPack(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgPack decodes MessagePack data into generic Go values.
+// Maps decode to map[string]interface{}, arrays to []interface{},
+// integers to int64 and floats to float64.
+//
+// Example:
+//
+//	value, err := graph.UnmarshalMsgPack(resp)
+//	result := value.(map[string]interface{})
+func UnmarshalMsgPack(data []byte) (interface{}, error) {
+	r := bytes.NewReader(data)
+	v, err := decodeMsgPack(r, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("msgpack: %d trailing bytes", r.Len())
+	}
+	return v, nil
+}
+
+func encodeMsgPack(buf *bytes.Buffer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if val {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int:
+		encodeMsgPackInt(buf, int64(val))
+	case int8:
+		encodeMsgPackInt(buf, int64(val))
+	case int16:
+		encodeMsgPackInt(buf, int64(val))
+	case int32:
+		encodeMsgPackInt(buf, int64(val))
+	case int64:
+		encodeMsgPackInt(buf, val)
+	case uint:
+		encodeMsgPackUint(buf, uint64(val))
+	case uint8:
+		encodeMsgPackUint(buf, uint64(val))
+	case uint16:
+		encodeMsgPackUint(buf, uint64(val))
+	case uint32:
+		encodeMsgPackUint(buf, uint64(val))
+	case uint64:
+		encodeMsgPackUint(buf, val)
+	case float32:
+		encodeMsgPackFloat(buf, float64(val))
+	case float64:
+		encodeMsgPackFloat(buf, val)
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			encodeMsgPackInt(buf, i)
+		} else if f, err := val.Float64(); err == nil {
+			encodeMsgPackFloat(buf, f)
+		} else {
+			return fmt.Errorf("msgpack: invalid number %q", val)
+		}
+	case string:
+		encodeMsgPackString(buf, val)
+	case []byte:
+		writeMsgPackHeader(buf, len(val), 0, -1, 0xc4, 0xc5, 0xc6)
+		buf.Write(val)
+	case []interface{}:
+		writeMsgPackHeader(buf, len(val), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range val {
+			if err := encodeMsgPack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeMsgPackHeader(buf, len(val), 0x80, 15, 0, 0xde, 0xdf)
+		for key, item := range val {
+			encodeMsgPackString(buf, key)
+			if err := encodeMsgPack(buf, item); err != nil {
+				return err
+			}
+		}
+	default:
+		// For complex types, convert through JSON
+		jsonBytes, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("msgpack: failed to marshal value: %w", err)
+		}
+		var generic interface{}
+		decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+		decoder.UseNumber()
+		if err := decoder.Decode(&generic); err != nil {
+			return fmt.Errorf("msgpack: failed to unmarshal value: %w", err)
+		}
+		return encodeMsgPack(buf, generic)
+	}
+	return nil
+}
+
+func encodeMsgPackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0:
+		encodeMsgPackUint(buf, uint64(i))
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func encodeMsgPackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		_ = binary.Write(buf, binary.BigEndian, uint16(u))
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		_ = binary.Write(buf, binary.BigEndian, uint32(u))
+	default:
+		buf.WriteByte(0xcf)
+		_ = binary.Write(buf, binary.BigEndian, u)
+	}
+}
+
+func encodeMsgPackFloat(buf *bytes.Buffer, f float64) {
+	// JSON numbers decode as float64; keep integral values compact
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		encodeMsgPackInt(buf, int64(f))
+		return
+	}
+	buf.WriteByte(0xcb)
+	_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+}
+
+func encodeMsgPackString(buf *bytes.Buffer, s string) {
+	writeMsgPackHeader(buf, len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	buf.WriteString(s)
+}
+
+// writeMsgPackHeader writes a length-prefixed header choosing the smallest format.
+// fixMax is the largest length the fix format can hold (-1 if the family has none),
+// and a zero code8 means the family has no 8-bit length format.
+func writeMsgPackHeader(buf *bytes.Buffer, n int, fix byte, fixMax int, code8, code16, code32 byte) {
+	switch {
+	case n <= fixMax:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(code8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+// decodeMsgPack decodes the next value of r. A non-nil budget is charged with the
+// memory of each value before it is allocated, failing with a DecodeBudgetError once
+// it is exceeded.
+func decodeMsgPack(r *bytes.Reader, depth int, budget *decodeBudget) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: unexpected end of data")
+	}
+
+	switch {
+	case c <= 0x7f, c >= 0xe0, c >= 0xca && c <= 0xd3:
+		if err := chargeMsgPack(budget, decodedNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return decodeMsgPackMap(r, int(c&0x0f), depth, budget)
+	case c&0xf0 == 0x90:
+		return decodeMsgPackArray(r, int(c&0x0f), depth, budget)
+	case c&0xe0 == 0xa0:
+		return readMsgPackString(r, int(c&0x1f), budget)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgPackLength(r, c-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		if err := chargeMsgPack(budget, decodedArray+int64(n)); err != nil {
+			return nil, err
+		}
+		b, err := readMsgPackBytes(r, n)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case 0xca:
+		var f float32
+		if err := binary.Read(r, binary.BigEndian, &f); err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		return float64(f), nil
+	case 0xcb:
+		var f float64
+		if err := binary.Read(r, binary.BigEndian, &f); err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		return f, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		var u uint64
+		switch c {
+		case 0xcc:
+			var v uint8
+			err = binary.Read(r, binary.BigEndian, &v)
+			u = uint64(v)
+		case 0xcd:
+			var v uint16
+			err = binary.Read(r, binary.BigEndian, &v)
+			u = uint64(v)
+		case 0xce:
+			var v uint32
+			err = binary.Read(r, binary.BigEndian, &v)
+			u = uint64(v)
+		default:
+			err = binary.Read(r, binary.BigEndian, &u)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		if u > math.MaxInt64 {
+			return float64(u), nil
+		}
+		return int64(u), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		var i int64
+		switch c {
+		case 0xd0:
+			var v int8
+			err = binary.Read(r, binary.BigEndian, &v)
+			i = int64(v)
+		case 0xd1:
+			var v int16
+			err = binary.Read(r, binary.BigEndian, &v)
+			i = int64(v)
+		case 0xd2:
+			var v int32
+			err = binary.Read(r, binary.BigEndian, &v)
+			i = int64(v)
+		default:
+			err = binary.Read(r, binary.BigEndian, &i)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		return i, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgPackLength(r, c-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgPackString(r, n, budget)
+	case 0xdc, 0xdd:
+		n, err := readMsgPackLength(r, c-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgPackArray(r, n, depth, budget)
+	case 0xde, 0xdf:
+		n, err := readMsgPackLength(r, c-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgPackMap(r, n, depth, budget)
+	}
+
+	return nil, fmt.Errorf("msgpack: unsupported format byte 0x%02x", c)
+}
+
+// readMsgPackLength reads a 1, 2 or 4 byte length for size class 0, 1 or 2
+func readMsgPackLength(r *bytes.Reader, sizeClass byte) (int, error) {
+	var n int
+	var err error
+	switch sizeClass {
+	case 0:
+		var v uint8
+		err = binary.Read(r, binary.BigEndian, &v)
+		n = int(v)
+	case 1:
+		var v uint16
+		err = binary.Read(r, binary.BigEndian, &v)
+		n = int(v)
+	default:
+		var v uint32
+		err = binary.Read(r, binary.BigEndian, &v)
+		n = int(v)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("msgpack: %w", err)
+	}
+	return n, nil
+}
+
+func readMsgPackBytes(r *bytes.Reader, n int) ([]byte, error) {
+	if n > r.Len() {
+		return nil, fmt.Errorf("msgpack: length %d exceeds remaining data", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return b, nil
+}
+
+func readMsgPackString(r *bytes.Reader, n int, budget *decodeBudget) (string, error) {
+	if err := chargeMsgPack(budget, decodedString+int64(n)); err != nil {
+		return "", err
+	}
+	b, err := readMsgPackBytes(r, n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func decodeMsgPackArray(r *bytes.Reader, n, depth int, budget *decodeBudget) ([]interface{}, error) {
+	if depth >= maxMsgPackDepth {
+		return nil, errMsgPackTooDeep
+	}
+	if n > r.Len() {
+		return nil, fmt.Errorf("msgpack: array length %d exceeds remaining data", n)
+	}
+	if err := chargeMsgPack(budget, decodedArray+int64(n)*decodedValueSize); err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		item, err := decodeMsgPack(r, depth+1, budget)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func decodeMsgPackMap(r *bytes.Reader, n, depth int, budget *decodeBudget) (map[string]interface{}, error) {
+	if depth >= maxMsgPackDepth {
+		return nil, errMsgPackTooDeep
+	}
+	if n > r.Len() {
+		return nil, fmt.Errorf("msgpack: map length %d exceeds remaining data", n)
+	}
+	if err := chargeMsgPack(budget, decodedObject+int64(n)*decodedEntry); err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := decodeMsgPack(r, depth+1, budget)
+		if err != nil {
+			return nil, err
+		}
+		keyStr, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key %v is not a string", key)
+		}
+		value, err := decodeMsgPack(r, depth+1, budget)
+		if err != nil {
+			return nil, err
+		}
+		m[keyStr] = value
+	}
+	return m, nil
+}
+
+// chargeMsgPack charges n bytes to the decode budget, if any
+func chargeMsgPack(budget *decodeBudget, n int64) error {
+	if budget != nil && !budget.charge(n) {
+		return &DecodeBudgetError{Budget: budget.limit}
+	}
+	return nil
+}
+
+// msgPackRequestToJSON rewrites a MessagePack request body as JSON so the
+// GraphQL handler and validation can consume it unchanged. With a decode budget, the
+// body is read no further than the budget and the decoded values are charged to it
+// as they are decoded, returning a DecodeBudgetError once it is exceeded.
+func msgPackRequestToJSON(r *http.Request) error {
+	body := io.Reader(r.Body)
+	budget := decodeBudgetFrom(r.Context())
+	if budget != nil {
+		// One byte past the budget tells bodies over it from bodies that fill it
+		body = io.LimitReader(body, budget.limit+1)
+	}
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read request body")
+	}
+	if err := chargeMsgPack(budget, int64(len(bodyBytes))); err != nil {
+		return err
+	}
+
+	reader := bytes.NewReader(bodyBytes)
+	value, err := decodeMsgPack(reader, 0, budget)
+	if err != nil {
+		return err
+	}
+	if reader.Len() != 0 {
+		return fmt.Errorf("msgpack: %d trailing bytes", reader.Len())
+	}
+
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to convert msgpack body: %w", err)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(jsonBytes))
+	r.ContentLength = int64(len(jsonBytes))
+	r.Header.Set("Content-Type", "application/json")
+	return nil
+}
+
+// packResponse converts a JSON response to MessagePack. Non-JSON bodies (e.g. the
+// playground HTML) are left unchanged.
+func packResponse(r *http.Request, response *Response) {
+	var data interface{}
+	if err := json.Unmarshal(response.Body(), &data); err != nil {
+		return
+	}
+	if packed, err := MarshalMsgPack(data); err == nil {
+		response.Header.Set("Content-Type", MsgPackContentType)
+		response.SetBody(packed)
+	}
+}
@@ -0,0 +1,229 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// MutationAuditEntry records one mutation field executed by a request
+type MutationAuditEntry struct {
+	Time          time.Time              `json:"time"`
+	Duration      time.Duration          `json:"duration"` // Nanoseconds in JSON
+	OperationName string                 `json:"operationName,omitempty"`
+	Field         string                 `json:"field"`               // Mutation field, e.g. "createUser"
+	Principal     interface{}            `json:"principal,omitempty"` // User details from UserDetailsFn
+	Arguments     map[string]interface{} `json:"arguments,omitempty"` // Redacted per MutationAuditConfig
+	Status        string                 `json:"status"`              // "success" or "error"
+	Error         string                 `json:"error,omitempty"`
+}
+
+// Statuses of a MutationAuditEntry
+const (
+	AuditStatusSuccess = "success"
+	AuditStatusError   = "error"
+)
+
+// MutationAuditSink receives an entry for every mutation field executed
+type MutationAuditSink func(ctx context.Context, entry MutationAuditEntry)
+
+// DefaultRedactedArguments lists the argument names redacted from mutation audit
+// entries when MutationAuditConfig.RedactArguments is nil
+var DefaultRedactedArguments = []string{"password", "token", "secret", "creditCard"}
+
+// MutationAuditConfig configures the mutation audit log.
+//
+// Example:
+//
+//	graphCtx := &graph.GraphContext{
+//	    SchemaParams: params,
+//	    MutationAudit: &graph.MutationAuditConfig{
+//	        Sink:            graph.WriterAuditSink(auditFile),
+//	        RedactArguments: []string{"password", "ssn"},
+//	    },
+//	}
+type MutationAuditConfig struct {
+	Sink MutationAuditSink
+
+	// RedactArguments lists argument names, at any depth of the input and case
+	// insensitive, whose values are replaced with RedactedString. Nil uses
+	// DefaultRedactedArguments; an empty slice redacts nothing.
+	RedactArguments []string
+}
+
+// redactArguments returns a copy of args with the configured arguments redacted
+func (c *MutationAuditConfig) redactArguments(args map[string]interface{}) map[string]interface{} {
+	names := c.RedactArguments
+	if names == nil {
+		names = DefaultRedactedArguments
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return redactValue(args, names).(map[string]interface{})
+}
+
+// redactValue copies an input value, redacting the values of the named keys
+func redactValue(value interface{}, names []string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			if isRedactedArgument(key, names) {
+				copied[key] = RedactedString
+			} else {
+				copied[key] = redactValue(v, names)
+			}
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, v := range value {
+			copied[i] = redactValue(v, names)
+		}
+		return copied
+	}
+	return value
+}
+
+// isRedactedArgument reports whether an argument name is one of names
+func isRedactedArgument(name string, names []string) bool {
+	for _, n := range names {
+		if strings.EqualFold(name, n) {
+			return true
+		}
+	}
+	return false
+}
+
+type mutationAuditContextKey struct{}
+
+// withMutationAudit attaches the audit config applied to the mutations of a request
+func withMutationAudit(r *http.Request, config *MutationAuditConfig) *http.Request {
+	if config == nil || config.Sink == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), mutationAuditContextKey{}, config))
+}
+
+// auditMutations records the root fields of mutations to the audit sink of the request
+func auditMutations(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		if p.Context == nil {
+			return next(p)
+		}
+		config, ok := p.Context.Value(mutationAuditContextKey{}).(*MutationAuditConfig)
+		if !ok {
+			return next(p)
+		}
+		operation, ok := rootMutationField(p)
+		if !ok {
+			return next(p)
+		}
+
+		entry := MutationAuditEntry{
+			Time:      time.Now(),
+			Field:     p.Info.FieldName,
+			Arguments: config.redactArguments(p.Args),
+		}
+		if operation.Name != nil {
+			entry.OperationName = operation.Name.Value
+		}
+		if principal, ok := principalFromContext(p.Context); ok {
+			principal.mu.Lock()
+			entry.Principal = principal.details
+			principal.mu.Unlock()
+		}
+
+		finish := func(value interface{}, err error) (interface{}, error) {
+			entry.Duration = time.Since(entry.Time)
+			entry.Status = AuditStatusSuccess
+			if err != nil {
+				entry.Status = AuditStatusError
+				entry.Error = err.Error()
+			}
+			config.Sink(p.Context, entry)
+			return value, err
+		}
+
+		value, err := next(p)
+		if thunk, ok := value.(func() (interface{}, error)); ok && err == nil {
+			return func() (interface{}, error) {
+				return finish(thunk())
+			}, nil
+		}
+		return finish(value, err)
+	}
+}
+
+// rootMutationField returns the operation of p when p resolves a root field of a mutation
+func rootMutationField(p ResolveParams) (*ast.OperationDefinition, bool) {
+	operation, ok := p.Info.Operation.(*ast.OperationDefinition)
+	if !ok || operation.Operation != ast.OperationTypeMutation || p.Info.Path == nil || p.Info.Path.Prev != nil {
+		return nil, false
+	}
+	return operation, true
+}
+
+// WriterAuditSink writes entries to w as JSON lines. Writes are serialized, so w may
+// be a file shared by concurrent requests.
+func WriterAuditSink(w io.Writer) MutationAuditSink {
+	var mu sync.Mutex
+	return func(ctx context.Context, entry MutationAuditEntry) {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(line, '\n'))
+	}
+}
+
+// ChannelAuditSink sends entries to ch for processing by the application. Entries are
+// dropped rather than delaying the mutation when ch is full.
+func ChannelAuditSink(ch chan<- MutationAuditEntry) MutationAuditSink {
+	return func(ctx context.Context, entry MutationAuditEntry) {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// HTTPAuditSink posts entries as JSON to url, such as a log collector endpoint. Entries
+// are posted in the background, so the mutation does not wait for the collector.
+// A nil client uses http.DefaultClient.
+func HTTPAuditSink(url string, client *http.Client) MutationAuditSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, entry MutationAuditEntry) {
+		body, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			if err != nil {
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+	}
+}
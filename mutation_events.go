@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+)
+
+// MutationEvent describes a mutation field that succeeded, for publishing domain events
+type MutationEvent struct {
+	OperationName string                 // Name of the operation, empty when anonymous
+	Field         string                 // Mutation field, e.g. "createOrder"
+	Arguments     map[string]interface{} // Arguments of the field
+	Result        interface{}            // Value returned by the resolver, e.g. *Order
+	Principal     interface{}            // User details from UserDetailsFn
+}
+
+// AfterMutationFn receives an event for every root mutation field whose resolver
+// succeeded. It is called once the resolver (including its deferred work) returned
+// without error, so changes committed by the resolver are visible; failed fields
+// emit nothing.
+//
+// Example:
+//
+//	graphCtx := &graph.GraphContext{
+//	    SchemaParams: params,
+//	    AfterMutation: func(ctx context.Context, event graph.MutationEvent) {
+//	        if event.Field == "createOrder" {
+//	            outbox.Publish(ctx, "order.created", event.Result)
+//	        }
+//	    },
+//	}
+type AfterMutationFn func(ctx context.Context, event MutationEvent)
+
+type afterMutationContextKey struct{}
+
+// withAfterMutation attaches the hook receiving the successful mutations of a request
+func withAfterMutation(r *http.Request, hook AfterMutationFn) *http.Request {
+	if hook == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), afterMutationContextKey{}, hook))
+}
+
+// emitMutationEvents passes the root mutation fields that succeeded to the hook of the
+// request
+func emitMutationEvents(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		if p.Context == nil {
+			return next(p)
+		}
+		hook, ok := p.Context.Value(afterMutationContextKey{}).(AfterMutationFn)
+		if !ok {
+			return next(p)
+		}
+		operation, ok := rootMutationField(p)
+		if !ok {
+			return next(p)
+		}
+
+		emit := func(value interface{}, err error) (interface{}, error) {
+			if err != nil {
+				return value, err
+			}
+
+			event := MutationEvent{Field: p.Info.FieldName, Arguments: p.Args, Result: value}
+			if operation.Name != nil {
+				event.OperationName = operation.Name.Value
+			}
+			if principal, ok := principalFromContext(p.Context); ok {
+				principal.mu.Lock()
+				event.Principal = principal.details
+				principal.mu.Unlock()
+			}
+			hook(p.Context, event)
+			return value, nil
+		}
+
+		value, err := next(p)
+		if thunk, ok := value.(func() (interface{}, error)); ok && err == nil {
+			return func() (interface{}, error) {
+				return emit(thunk())
+			}, nil
+		}
+		return emit(value, err)
+	}
+}
@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// FieldNamingStrategy maps a Go struct field to its GraphQL field name.
+// Returning "-" excludes the field from the schema.
+type FieldNamingStrategy func(field reflect.StructField) string
+
+var (
+	fieldNamingStrategy   FieldNamingStrategy = NamingJSONTag
+	fieldNamingStrategyMu sync.RWMutex
+)
+
+// SetFieldNamingStrategy sets the strategy used by the reflection layer to name
+// fields of generated object types, input types and arguments, and to map
+// arguments back onto structs.
+//
+// Types are cached in global registries once generated, so the strategy should be
+// set once at startup, before any resolvers are built. Fields tagged json:"-" are
+// always excluded by the built-in strategies.
+//
+// Example:
+//
+//	func init() {
+//	    graph.SetFieldNamingStrategy(graph.NamingSnakeCase)
+//	}
+//
+//	// Or a custom callback
+//	graph.SetFieldNamingStrategy(func(field reflect.StructField) string {
+//	    return "x_" + strings.ToLower(field.Name)
+//	})
+func SetFieldNamingStrategy(strategy FieldNamingStrategy) {
+	if strategy == nil {
+		strategy = NamingJSONTag
+	}
+	fieldNamingStrategyMu.Lock()
+	defer fieldNamingStrategyMu.Unlock()
+	fieldNamingStrategy = strategy
+}
+
+// currentFieldNamingStrategy returns the configured naming strategy
+func currentFieldNamingStrategy() FieldNamingStrategy {
+	fieldNamingStrategyMu.RLock()
+	defer fieldNamingStrategyMu.RUnlock()
+	return fieldNamingStrategy
+}
+
+// NamingJSONTag names fields from the json tag, then the graphql tag, and
+// finally the Go field name with its first letter lowercased. This is the default.
+func NamingJSONTag(field reflect.StructField) string {
+	if name := jsonTagName(field); name != "" {
+		return name
+	}
+
+	if name := graphqlTagName(field); name != "" {
+		return name
+	}
+
+	return toCamelCase(field.Name)
+}
+
+// NamingCamelCase names fields by converting the Go field name to camelCase,
+// lowercasing leading acronyms (UserID → userID, HTTPServer → httpServer).
+// An explicit name in the graphql tag takes precedence.
+func NamingCamelCase(field reflect.StructField) string {
+	if jsonTagName(field) == "-" {
+		return "-"
+	}
+
+	if name := graphqlTagName(field); name != "" {
+		return name
+	}
+
+	return lowerCamelCase(field.Name)
+}
+
+// NamingSnakeCase names fields by converting the Go field name to snake_case
+// (UserID → user_id, HTTPServer → http_server).
+// An explicit name in the graphql tag takes precedence.
+func NamingSnakeCase(field reflect.StructField) string {
+	if jsonTagName(field) == "-" {
+		return "-"
+	}
+
+	if name := graphqlTagName(field); name != "" {
+		return name
+	}
+
+	return toSnakeCase(field.Name)
+}
+
+// FieldName returns the GraphQL name of a struct field as generated in the schema,
+// or "-" when the field is excluded. It lets packages building on the schema, such as
+// sqlgraph, map selections back onto struct fields.
+func FieldName(field reflect.StructField) string {
+	return getFieldName(field)
+}
+
+// jsonTagName returns the name part of a field's json tag
+func jsonTagName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// graphqlTagName returns the bare field name from a field's graphql tag, if any
+func graphqlTagName(field reflect.StructField) string {
+	return parseGraphQLTag(field).name
+}
+
+// lowerCamelCase lowercases the leading word of a Go identifier, treating a
+// leading run of capitals as one acronym (ID → id, HTTPServer → httpServer)
+func lowerCamelCase(name string) string {
+	runes := []rune(name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		// Keep the last capital of an acronym when it starts the next word
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// toSnakeCase converts a Go identifier to snake_case (UserID → user_id)
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])))
+			if startsWord {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,277 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// NullPropagationPolicy decides what happens when a non-null field cannot be resolved
+type NullPropagationPolicy int
+
+const (
+	// PropagateNulls follows the GraphQL specification: the error nulls the nearest
+	// nullable parent, discarding the sibling fields resolved with it
+	PropagateNulls NullPropagationPolicy = iota
+	// MaskNulls nulls only the failing field and reports the error for it, so clients
+	// preferring partial data keep the rest of the parent object
+	MaskNulls
+)
+
+// maskingExecutor executes operations against a copy of the schema whose fields are
+// nullable, so errors no longer propagate to parents. Fields that were non-null
+// report an error when they resolve to null, as they would under PropagateNulls.
+type maskingExecutor struct {
+	Executor
+}
+
+// Execute runs a query or mutation with masked nulls
+func (e maskingExecutor) Execute(params ExecuteParams) *graphql.Result {
+	params.Schema = maskedSchema(params.Schema)
+	return e.Executor.Execute(params)
+}
+
+// Subscribe runs a subscription with masked nulls
+func (e maskingExecutor) Subscribe(params ExecuteParams) chan *graphql.Result {
+	params.Schema = maskedSchema(params.Schema)
+	return e.Executor.Subscribe(params)
+}
+
+// maskedSchemas caches the nullable copy of each schema, identified by its query root type
+var maskedSchemas sync.Map // *graphql.Object → *graphql.Schema
+
+// maskedSchema returns the nullable copy of schema. The schema itself is returned
+// when it cannot be copied, keeping the specification behavior.
+func maskedSchema(schema *graphql.Schema) *graphql.Schema {
+	if schema == nil || schema.QueryType() == nil {
+		return schema
+	}
+	if masked, ok := maskedSchemas.Load(schema.QueryType()); ok {
+		return masked.(*graphql.Schema)
+	}
+
+	masked, err := newMaskedSchema(schema)
+	if err != nil {
+		return schema
+	}
+
+	// Resolvers instrumented for the schema dispatch on the query root type
+	if instrumentation, ok := schemaInstrumentations.Load(schema.QueryType()); ok {
+		schemaInstrumentations.Store(masked.QueryType(), instrumentation)
+	}
+
+	actual, _ := maskedSchemas.LoadOrStore(schema.QueryType(), masked)
+	return actual.(*graphql.Schema)
+}
+
+// schemaCopier copies the output types of a schema with nullable fields. Input types,
+// scalars and enums are shared with the original schema.
+type schemaCopier struct {
+	objects    map[*graphql.Object]*graphql.Object
+	interfaces map[*graphql.Interface]*graphql.Interface
+	unions     map[*graphql.Union]*graphql.Union
+}
+
+// newMaskedSchema builds the nullable copy of schema
+func newMaskedSchema(schema *graphql.Schema) (*graphql.Schema, error) {
+	c := &schemaCopier{
+		objects:    make(map[*graphql.Object]*graphql.Object),
+		interfaces: make(map[*graphql.Interface]*graphql.Interface),
+		unions:     make(map[*graphql.Union]*graphql.Union),
+	}
+
+	config := graphql.SchemaConfig{
+		Query:      c.object(schema.QueryType()),
+		Directives: schema.Directives(),
+	}
+	if mutation := schema.MutationType(); mutation != nil {
+		config.Mutation = c.object(mutation)
+	}
+	if subscription := schema.SubscriptionType(); subscription != nil {
+		config.Subscription = c.object(subscription)
+	}
+	for name, t := range schema.TypeMap() {
+		// Introspection types are added by graphql.NewSchema
+		if !strings.HasPrefix(name, "__") {
+			config.Types = append(config.Types, c.output(t).(graphql.Type))
+		}
+	}
+
+	masked, err := newSchema(config)
+	if err != nil {
+		return nil, err
+	}
+	return &masked, nil
+}
+
+// output returns the copy of an output type, keeping list item types as they are
+func (c *schemaCopier) output(t graphql.Type) graphql.Output {
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return graphql.NewNonNull(c.output(t.OfType))
+	case *graphql.List:
+		return graphql.NewList(c.output(t.OfType))
+	case *graphql.Object:
+		return c.object(t)
+	case *graphql.Interface:
+		return c.iface(t)
+	case *graphql.Union:
+		return c.union(t)
+	}
+	return t.(graphql.Output)
+}
+
+// object returns the copy of an object type
+func (c *schemaCopier) object(object *graphql.Object) *graphql.Object {
+	if copied, ok := c.objects[object]; ok {
+		return copied
+	}
+
+	copied := graphql.NewObject(graphql.ObjectConfig{
+		Name:        object.Name(),
+		Description: object.Description(),
+		IsTypeOf:    object.IsTypeOf,
+		Interfaces: (graphql.InterfacesThunk)(func() []*graphql.Interface {
+			interfaces := make([]*graphql.Interface, 0, len(object.Interfaces()))
+			for _, iface := range object.Interfaces() {
+				interfaces = append(interfaces, c.iface(iface))
+			}
+			return interfaces
+		}),
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return c.fields(object.Name(), object.Fields(), true)
+		}),
+	})
+	c.objects[object] = copied
+	return copied
+}
+
+// iface returns the copy of an interface type
+func (c *schemaCopier) iface(iface *graphql.Interface) *graphql.Interface {
+	if copied, ok := c.interfaces[iface]; ok {
+		return copied
+	}
+
+	copied := graphql.NewInterface(graphql.InterfaceConfig{
+		Name:        iface.Name(),
+		Description: iface.Description(),
+		ResolveType: c.resolveType(iface.ResolveType),
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return c.fields(iface.Name(), iface.Fields(), false)
+		}),
+	})
+	c.interfaces[iface] = copied
+	return copied
+}
+
+// union returns the copy of a union type
+func (c *schemaCopier) union(union *graphql.Union) *graphql.Union {
+	if copied, ok := c.unions[union]; ok {
+		return copied
+	}
+
+	types := make([]*graphql.Object, 0, len(union.Types()))
+	for _, object := range union.Types() {
+		types = append(types, c.object(object))
+	}
+
+	copied := graphql.NewUnion(graphql.UnionConfig{
+		Name:        union.Name(),
+		Description: union.Description(),
+		Types:       types,
+		ResolveType: c.resolveType(union.ResolveType),
+	})
+	c.unions[union] = copied
+	return copied
+}
+
+// resolveType maps the objects returned by an abstract type's resolver to their copies
+func (c *schemaCopier) resolveType(resolveType graphql.ResolveTypeFn) graphql.ResolveTypeFn {
+	if resolveType == nil {
+		return nil
+	}
+	return func(p graphql.ResolveTypeParams) *graphql.Object {
+		object := resolveType(p)
+		if object == nil {
+			return nil
+		}
+		return c.object(object)
+	}
+}
+
+// fields copies field definitions, dropping the outer non-null of their types. The
+// resolvers of object fields that were non-null report null results as errors.
+func (c *schemaCopier) fields(parent string, definitions graphql.FieldDefinitionMap, resolvable bool) graphql.Fields {
+	fields := make(graphql.Fields, len(definitions))
+	for name, def := range definitions {
+		fieldType := c.output(def.Type)
+		resolve := def.Resolve
+		if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+			fieldType = nonNull.OfType.(graphql.Output)
+			if resolvable {
+				resolve = rejectNull(parent+"."+name, resolve)
+			}
+		}
+
+		args := make(graphql.FieldConfigArgument, len(def.Args))
+		for _, arg := range def.Args {
+			args[arg.Name()] = &graphql.ArgumentConfig{
+				Type:         arg.Type,
+				DefaultValue: arg.DefaultValue,
+				Description:  arg.Description(),
+			}
+		}
+
+		fields[name] = &graphql.Field{
+			Name:              def.Name,
+			Description:       def.Description,
+			Type:              fieldType,
+			Args:              args,
+			Resolve:           resolve,
+			Subscribe:         def.Subscribe,
+			DeprecationReason: def.DeprecationReason,
+		}
+	}
+	return fields
+}
+
+// rejectNull wraps the resolver of a formerly non-null field so a null result is
+// reported as an error on the field, including results of deferred resolvers
+func rejectNull(coordinate string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+
+	check := func(value interface{}, err error) (interface{}, error) {
+		if err == nil && isNullValue(value) {
+			return nil, fmt.Errorf("Cannot return null for non-nullable field %s.", coordinate)
+		}
+		return value, err
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		value, err := resolve(p)
+		if thunk, ok := value.(func() (interface{}, error)); ok && err == nil {
+			return func() (interface{}, error) {
+				return check(thunk())
+			}, nil
+		}
+		return check(value, err)
+	}
+}
+
+// isNullValue reports whether a resolved value is null
+func isNullValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// PaginationStyle selects the arguments generated for paginated resolvers
+type PaginationStyle int
+
+const (
+	// CursorPagination generates first, after, last and before
+	CursorPagination PaginationStyle = iota
+	// OffsetPagination generates page and limit
+	OffsetPagination
+)
+
+// Pagination defaults
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// PaginationConfig configures the arguments of a paginated resolver
+type PaginationConfig struct {
+	// Style selects cursor (first/after/last/before) or offset (page/limit) arguments.
+	// Default: CursorPagination
+	Style PaginationStyle
+
+	// DefaultLimit is the page size when the client sends none. Default: DefaultPageLimit
+	DefaultLimit int
+
+	// MaxLimit rejects larger page sizes with a PAGINATION_LIMIT_EXCEEDED error.
+	// Default: MaxPageLimit
+	MaxLimit int
+
+	// Descriptions overrides the descriptions of the generated arguments, by name
+	Descriptions map[string]string
+}
+
+// PaginationLimitError reports a page size over PaginationConfig.MaxLimit, or a
+// negative page size or page number
+type PaginationLimitError struct {
+	Argument string
+	Value    int
+	Max      int
+}
+
+// Error describes the rejected argument
+func (e *PaginationLimitError) Error() string {
+	if e.Value < 0 || (e.Argument == "page" && e.Value < 1) {
+		return fmt.Sprintf("argument %q must be positive, got %d", e.Argument, e.Value)
+	}
+	return fmt.Sprintf("argument %q must be at most %d, got %d", e.Argument, e.Max, e.Value)
+}
+
+// Extensions exposes the PAGINATION_LIMIT_EXCEEDED code in the GraphQL error
+func (e *PaginationLimitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "PAGINATION_LIMIT_EXCEEDED", "max": e.Max}
+}
+
+// withDefaults fills the unset settings of c with the package defaults
+func (c PaginationConfig) withDefaults() PaginationConfig {
+	if c.DefaultLimit <= 0 {
+		c.DefaultLimit = DefaultPageLimit
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = MaxPageLimit
+	}
+	if c.DefaultLimit > c.MaxLimit {
+		c.DefaultLimit = c.MaxLimit
+	}
+	return c
+}
+
+// limitArguments returns the names of the page size arguments of the style
+func (c PaginationConfig) limitArguments() []string {
+	if c.Style == OffsetPagination {
+		return []string{"limit"}
+	}
+	return []string{"first", "last"}
+}
+
+// arguments returns the pagination arguments of the style, with their defaults and
+// descriptions
+func (c PaginationConfig) arguments() graphql.FieldConfigArgument {
+	var args graphql.FieldConfigArgument
+	if c.Style == OffsetPagination {
+		args = graphql.FieldConfigArgument{
+			"page": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: 1,
+				Description:  "Page number, starting at 1",
+			},
+			"limit": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: c.DefaultLimit,
+				Description:  fmt.Sprintf("Number of items per page, at most %d", c.MaxLimit),
+			},
+		}
+	} else {
+		args = graphql.FieldConfigArgument{
+			"first": &graphql.ArgumentConfig{
+				Type:        graphql.Int,
+				Description: fmt.Sprintf("Number of items to fetch, at most %d (default %d)", c.MaxLimit, c.DefaultLimit),
+			},
+			"after": &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "Cursor to start after",
+			},
+			"last": &graphql.ArgumentConfig{
+				Type:        graphql.Int,
+				Description: fmt.Sprintf("Number of items to fetch from the end, at most %d", c.MaxLimit),
+			},
+			"before": &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "Cursor to start before",
+			},
+		}
+	}
+
+	for name, description := range c.Descriptions {
+		if arg, ok := args[name]; ok {
+			arg.Description = description
+		}
+	}
+	return args
+}
+
+// withPaginationArgs adds the pagination arguments of config to args, keeping the
+// arguments args already declares
+func withPaginationArgs(args graphql.FieldConfigArgument, config PaginationConfig) graphql.FieldConfigArgument {
+	merged := config.arguments()
+	for name, arg := range args {
+		merged[name] = arg
+	}
+	return merged
+}
+
+// limitPagination rejects page sizes over the maximum of config, and negative page
+// sizes or page numbers. Cursor requests sending neither first nor last get first set to
+// the default limit.
+func limitPagination(resolve graphql.FieldResolveFn, config PaginationConfig) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		for _, name := range config.limitArguments() {
+			value, ok := p.Args[name].(int)
+			if ok && (value < 0 || value > config.MaxLimit) {
+				return nil, &PaginationLimitError{Argument: name, Value: value, Max: config.MaxLimit}
+			}
+		}
+		if page, ok := p.Args["page"].(int); ok && config.Style == OffsetPagination && page < 1 {
+			return nil, &PaginationLimitError{Argument: "page", Value: page, Max: config.MaxLimit}
+		}
+
+		if config.Style == CursorPagination && p.Args["first"] == nil && p.Args["last"] == nil {
+			args := make(map[string]interface{}, len(p.Args)+1)
+			for name, value := range p.Args {
+				args[name] = value
+			}
+			args["first"] = config.DefaultLimit
+			p.Args = args
+		}
+		return resolve(p)
+	}
+}
+
+// paginatedResult exposes a PaginatedResponse of any item type to its connection type
+type paginatedResult interface {
+	paginatedItems() interface{}
+	paginatedTotalCount() (interface{}, error)
+	paginatedPageInfo() PageInfo
+}
+
+var paginatedResultType = reflect.TypeOf((*paginatedResult)(nil)).Elem()
+
+func (r PaginatedResponse[T]) paginatedItems() interface{} { return r.Items }
+
+func (r PaginatedResponse[T]) paginatedTotalCount() (interface{}, error) { return r.TotalCount, nil }
+
+func (r PaginatedResponse[T]) paginatedPageInfo() PageInfo { return r.PageInfo }
+
+// asPaginatedResult returns the page a paginated resolver returned, by value or pointer
+func asPaginatedResult(source interface{}) (paginatedResult, bool) {
+	page, ok := source.(paginatedResult)
+	if !ok {
+		return nil, false
+	}
+	if v := reflect.ValueOf(source); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	return page, true
+}
+
+// countedPage is a page whose total is counted separately from its items, the first
+// time the client reads it
+type countedPage struct {
+	paginatedResult
+	once  sync.Once
+	count func() (interface{}, error)
+	total interface{}
+	err   error
+}
+
+func (c *countedPage) paginatedTotalCount() (interface{}, error) {
+	c.once.Do(func() {
+		if c.count != nil {
+			c.total, c.err = c.count()
+		}
+	})
+	return c.total, c.err
+}
+
+// countTotal defers the total count of the pages resolve returns to counter, which
+// runs only when totalCount is selected, with the arguments of the paginated field. A
+// nil counter leaves totalCount null.
+func countTotal(resolve graphql.FieldResolveFn, counter func(p ResolveParams) (int, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := resolve(p)
+		page, ok := asPaginatedResult(result)
+		if err != nil || !ok {
+			return result, err
+		}
+
+		counted := &countedPage{paginatedResult: page}
+		if counter != nil {
+			counted.count = func() (interface{}, error) {
+				total, err := counter(ResolveParams(p))
+				if err != nil {
+					return nil, err
+				}
+				return total, nil
+			}
+		}
+		return counted, nil
+	}
+}
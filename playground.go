@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// PlaygroundConfig customizes the GraphQL Playground page served when Playground is
+// enabled, so developers opening it get working requests out of the box
+//
+// Example:
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams: params,
+//	    DEBUG:        true,
+//	    Playground:   true,
+//	    PlaygroundConfig: &graph.PlaygroundConfig{
+//	        Headers:     map[string]string{"Authorization": "Bearer <token>"},
+//	        ExampleTabs: true,
+//	    },
+//	})
+type PlaygroundConfig struct {
+	// Endpoint queried by the playground, e.g. the public URL of the API behind a proxy
+	// rewriting paths. Default: the path of the page request
+	Endpoint string
+
+	// SubscriptionEndpoint is the WebSocket endpoint of subscriptions. Default: none
+	SubscriptionEndpoint string
+
+	// Headers are sent with the requests of every tab, e.g. an Authorization placeholder
+	Headers map[string]string
+
+	// HeadersFn returns headers added to Headers for the page request, e.g. the token of
+	// the developer's session so queries are authenticated. The headers are written into
+	// the page: only use it in development.
+	HeadersFn func(r *http.Request) map[string]string
+
+	// Tabs opened when the playground loads. Tabs without an endpoint or headers get
+	// Endpoint and Headers.
+	Tabs []PlaygroundTab
+
+	// ExampleTabs opens a tab per root field of the schema after Tabs, with the
+	// operations of GenerateExampleQueries
+	ExampleTabs bool
+
+	// AssetsURL is the base URL of the graphql-playground-react build, e.g. a mirror on
+	// the intranet. Default: the jsDelivr CDN
+	AssetsURL string
+
+	// Offline serves a lightweight IDE embedded in the binary instead of GraphQL
+	// Playground, for air-gapped deployments without access to the assets. It supports
+	// the same endpoint, headers and tabs, and browses the schema by introspection.
+	Offline bool
+}
+
+// playgroundCDN serves the GraphQL Playground assets
+const playgroundCDN = "//cdn.jsdelivr.net/npm/graphql-playground-react/build"
+
+//go:embed playground/offline.html
+var playgroundFiles embed.FS
+
+// offlinePlaygroundTemplate is the embedded IDE of offline mode, initialized with the
+// same options as GraphQL Playground
+var offlinePlaygroundTemplate = template.Must(template.ParseFS(playgroundFiles, "playground/offline.html"))
+
+// playgroundTemplate is the GraphQL Playground page, initialized with the options of a
+// PlaygroundConfig
+var playgroundTemplate = template.Must(template.New("playground").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset=utf-8/>
+  <meta name="viewport" content="user-scalable=no, initial-scale=1.0, minimum-scale=1.0, maximum-scale=1.0, minimal-ui">
+  <title>GraphQL Playground</title>
+  <link rel="stylesheet" href="{{ .Assets }}/static/css/index.css" />
+  <link rel="shortcut icon" href="{{ .Assets }}/favicon.png" />
+  <script src="{{ .Assets }}/static/js/middleware.js"></script>
+</head>
+<body>
+  <div id="root"></div>
+  <script>window.addEventListener('load', function () {
+    GraphQLPlayground.init(document.getElementById('root'), {{ .Options }})
+  })</script>
+</body>
+</html>
+`))
+
+// playgroundOptions returns the options of GraphQLPlayground.init for a page request
+func playgroundOptions(r *http.Request, schema *graphql.Schema, config *PlaygroundConfig) map[string]interface{} {
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = r.URL.Path
+	}
+
+	headers := make(map[string]string, len(config.Headers))
+	for name, value := range config.Headers {
+		headers[name] = value
+	}
+	if config.HeadersFn != nil {
+		for name, value := range config.HeadersFn(r) {
+			headers[name] = value
+		}
+	}
+
+	tabs := append([]PlaygroundTab(nil), config.Tabs...)
+	if config.ExampleTabs {
+		tabs = append(tabs, PlaygroundTabs(endpoint, GenerateExampleQueries(schema))...)
+	}
+	for i := range tabs {
+		if tabs[i].Endpoint == "" {
+			tabs[i].Endpoint = endpoint
+		}
+		if tabs[i].Headers == nil && len(headers) > 0 {
+			tabs[i].Headers = headers
+		}
+	}
+
+	options := map[string]interface{}{
+		"endpoint": endpoint,
+		"setTitle": true,
+	}
+	if config.SubscriptionEndpoint != "" {
+		options["subscriptionEndpoint"] = config.SubscriptionEndpoint
+	}
+	if len(headers) > 0 {
+		options["headers"] = headers
+	}
+	if len(tabs) > 0 {
+		options["tabs"] = tabs
+	}
+	return options
+}
+
+// servePlayground writes the GraphQL Playground page configured by config, or the
+// embedded IDE in offline mode
+func servePlayground(w http.ResponseWriter, r *http.Request, schema *graphql.Schema, config *PlaygroundConfig) {
+	page := playgroundTemplate
+	if config.Offline {
+		page = offlinePlaygroundTemplate
+	}
+	assets := config.AssetsURL
+	if assets == "" {
+		assets = playgroundCDN
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = page.Execute(w, map[string]interface{}{
+		"Assets":  strings.TrimSuffix(assets, "/"),
+		"Options": playgroundOptions(r, schema, config),
+	})
+}
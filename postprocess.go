@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/graphql-go/graphql"
+)
+
+// PostProcessFn transforms the resolved value of a field, for example to convert
+// currencies, format values for a locale or redact data. Returning an error fails the field.
+type PostProcessFn func(ctx context.Context, result interface{}) (interface{}, error)
+
+// PostProcessHook transforms the resolved value of every root field and generated
+// object field. It runs after any per-field post-processors; use p.Info to decide
+// which fields to transform.
+type PostProcessHook func(p ResolveParams, result interface{}) (interface{}, error)
+
+// postProcessHook holds the global PostProcessHook
+var postProcessHook atomic.Value
+
+// SetPostProcessHook sets the global hook applied to resolved field values.
+// Passing nil removes it. The hook is read at resolve time, so it also applies to
+// schemas built before it was set.
+//
+// Example:
+//
+//	graph.SetPostProcessHook(func(p graph.ResolveParams, result interface{}) (interface{}, error) {
+//	    if s, ok := result.(string); ok && p.Info.FieldName == "email" {
+//	        return maskEmail(s), nil
+//	    }
+//	    return result, nil
+//	})
+func SetPostProcessHook(hook PostProcessHook) {
+	postProcessHook.Store(hook)
+}
+
+// currentPostProcessHook returns the global hook, or nil if none is set
+func currentPostProcessHook() PostProcessHook {
+	hook, _ := postProcessHook.Load().(PostProcessHook)
+	return hook
+}
+
+// WithPostProcess adds a post-processor for the resolver's result. Post-processors
+// run in the order they are added, after the resolver and before any middleware.
+//
+// Example:
+//
+//	NewResolver[Price]("price").
+//		WithPostProcess(func(ctx context.Context, result interface{}) (interface{}, error) {
+//			return convertCurrency(ctx, result.(*Price))
+//		}).
+//		WithResolver(...).
+//		BuildQuery()
+func (r *UnifiedResolver[T]) WithPostProcess(fn PostProcessFn) *UnifiedResolver[T] {
+	r.postProcessors = append(r.postProcessors, fn)
+	return r
+}
+
+// WithFieldPostProcess adds a post-processor for a field of the resolver's object type
+//
+// Example:
+//
+//	NewResolver[User]("user").
+//		WithFieldPostProcess("email", func(ctx context.Context, result interface{}) (interface{}, error) {
+//			return strings.ToLower(result.(string)), nil
+//		})
+func (r *UnifiedResolver[T]) WithFieldPostProcess(fieldName string, fn PostProcessFn) *UnifiedResolver[T] {
+	if r.fieldPostProcessors == nil {
+		r.fieldPostProcessors = make(map[string][]PostProcessFn)
+	}
+	r.fieldPostProcessors[fieldName] = append(r.fieldPostProcessors[fieldName], fn)
+	return r
+}
+
+// WithPostProcess adds a post-processor for the resolver's result
+func (r *TypedArgsResolver[T, A]) WithPostProcess(fn PostProcessFn) *TypedArgsResolver[T, A] {
+	r.base.WithPostProcess(fn)
+	return r
+}
+
+// chainPostProcess applies post-processors to the result of resolve
+func chainPostProcess(resolve graphql.FieldResolveFn, fns []PostProcessFn) graphql.FieldResolveFn {
+	if len(fns) == 0 {
+		return resolve
+	}
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := resolve(p)
+		if err != nil {
+			return result, err
+		}
+		for _, fn := range fns {
+			if result, err = fn(p.Context, result); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+}
+
+// withPostProcessHook applies the global PostProcessHook to the result of resolve
+func withPostProcessHook(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := resolve(p)
+		if err != nil {
+			return result, err
+		}
+		if hook := currentPostProcessHook(); hook != nil {
+			return hook(ResolveParams(p), result)
+		}
+		return result, nil
+	}
+}
+
+// wrapFieldsWithPostProcessHook applies the global PostProcessHook to every field.
+// Fields are copied so definitions shared with other types are left untouched.
+func wrapFieldsWithPostProcessHook(fields graphql.Fields) graphql.Fields {
+	for name, field := range fields {
+		wrapped := *field
+		wrapped.Resolve = withPostProcessHook(field.Resolve)
+		fields[name] = &wrapped
+	}
+	return fields
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+)
+
+// UserDetails adapts a user details fetcher returning a typed principal to
+// GraphContext.UserDetailsFn, so resolvers read it back with Principal[T] without a
+// JSON round-trip through GetRootInfo.
+//
+// Example:
+//
+//	graphCtx := &graph.GraphContext{
+//	    SchemaParams: params,
+//	    UserDetailsFn: graph.UserDetails(func(token string) (*User, error) {
+//	        return users.ByToken(token)
+//	    }),
+//	}
+func UserDetails[T any](fn func(token string) (T, error)) func(token string) (interface{}, error) {
+	return func(token string) (interface{}, error) {
+		details, err := fn(token)
+		if err != nil {
+			return nil, err
+		}
+		return details, nil
+	}
+}
+
+// Principal returns the user details of the principal resolving a field as a T, and
+// false for anonymous requests or details of another type. Details stored as a *T
+// are also returned as a T.
+//
+// Example:
+//
+//	user, ok := graph.Principal[*User](p)
+//	if !ok {
+//	    return nil, fmt.Errorf("authentication required")
+//	}
+func Principal[T any](p ResolveParams) (T, bool) {
+	if _, ok := principalFromContext(p.Context); ok {
+		return PrincipalFromContext[T](p.Context)
+	}
+	if root, ok := p.Info.RootValue.(map[string]interface{}); ok {
+		return principalAs[T](root["details"])
+	}
+	var zero T
+	return zero, false
+}
+
+// PrincipalFromContext returns the user details of the principal of the request being
+// served by NewHTTP as a T, for code that only has the context, such as data loaders
+func PrincipalFromContext[T any](ctx context.Context) (T, bool) {
+	principal, ok := principalFromContext(ctx)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	principal.mu.Lock()
+	details := principal.details
+	principal.mu.Unlock()
+	return principalAs[T](details)
+}
+
+// principalAs converts user details to a T
+func principalAs[T any](details interface{}) (T, bool) {
+	switch details := details.(type) {
+	case T:
+		return details, true
+	case *T:
+		if details != nil {
+			return *details, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Ways a Caller is authenticated
+const (
+	CallerSignature  = "signature"   // Verified request signature (RequestSignature)
+	CallerClientCert = "client-cert" // Verified TLS client certificate (ClientCertAuth)
+)
+
+// Caller is a service authenticated by the transport rather than by a token: the key of
+// a verified request signature, or a verified TLS client certificate. Its ID is kept apart from the token the
+// client sends, so a request cannot pass for a service by sending its name as a
+// Bearer token.
+type Caller struct {
+	Method string // How the caller was authenticated, e.g. CallerSignature
+	ID     string // Key ID of the signature, or ClientIdentity.Name of the certificate
+}
+
+type callerContextKey struct{}
+
+// withCaller records the authenticated caller of a request
+func withCaller(r *http.Request, caller Caller) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), callerContextKey{}, caller))
+}
+
+// CallerFromContext returns the authenticated caller of the request being served, in
+// RootObjectFn, resolvers and permission middleware. Its user details are those
+// returned by GraphContext.CallerDetailsFn.
+//
+// Example:
+//
+//	if caller, ok := graph.CallerFromContext(p.Context); ok && caller.ID == "billing" {
+//	    // trusted service
+//	}
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	if ctx == nil {
+		return Caller{}, false
+	}
+	caller, ok := ctx.Value(callerContextKey{}).(Caller)
+	return caller, ok
+}
+
+// principalID identifies the principal of a request, its caller or else its token, for
+// state kept per principal. It is empty for anonymous requests.
+func principalID(graphCtx *GraphContext, r *http.Request) string {
+	if caller, ok := requestCaller(graphCtx, r); ok {
+		return "caller:" + caller.Method + ":" + caller.ID
+	}
+	if token := requestToken(graphCtx, r); token != "" {
+		return "token:" + token
+	}
+	return ""
+}
@@ -0,0 +1,238 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// PubSub is the event source for subscription resolvers. Resolvers subscribe to a
+// topic for the lifetime of a subscription and mutations publish to it.
+//
+// Use NewInMemoryPubSub for a single instance, or NewBrokerPubSub to fan events out
+// across instances through Redis, NATS or another message broker.
+type PubSub interface {
+	// Publish delivers payload to every current subscriber of topic
+	Publish(ctx context.Context, topic string, payload interface{}) error
+
+	// Subscribe returns a channel of payloads published to topic.
+	// The channel is closed when ctx is cancelled.
+	Subscribe(ctx context.Context, topic string) (<-chan interface{}, error)
+}
+
+// pubSubBufferSize is the number of undelivered events buffered per subscriber.
+// Events published to a subscriber with a full buffer are dropped.
+const pubSubBufferSize = 64
+
+// InMemoryPubSub is a PubSub that delivers events within the current process
+type InMemoryPubSub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan interface{}]struct{}
+}
+
+// NewInMemoryPubSub creates an in-process PubSub.
+//
+// Example:
+//
+//	pubsub := graph.NewInMemoryPubSub()
+//
+//	graph.NewResolver[Message]("messageAdded").
+//		WithSubscriber(func(p graph.ResolveParams) (<-chan Message, error) {
+//			return graph.SubscribeTopic[Message](p.Context, pubsub, "messages")
+//		}).BuildSubscription()
+//
+//	// In a mutation
+//	_ = pubsub.Publish(ctx, "messages", message)
+func NewInMemoryPubSub() *InMemoryPubSub {
+	return &InMemoryPubSub{
+		subscribers: make(map[string]map[chan interface{}]struct{}),
+	}
+}
+
+// Publish delivers payload to every current subscriber of topic without blocking.
+// Subscribers whose buffer is full miss the event.
+func (ps *InMemoryPubSub) Publish(ctx context.Context, topic string, payload interface{}) error {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	for ch := range ps.subscribers[topic] {
+		select {
+		case ch <- payload:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel of payloads published to topic until ctx is cancelled
+func (ps *InMemoryPubSub) Subscribe(ctx context.Context, topic string) (<-chan interface{}, error) {
+	ch := make(chan interface{}, pubSubBufferSize)
+
+	ps.mu.Lock()
+	if ps.subscribers[topic] == nil {
+		ps.subscribers[topic] = make(map[chan interface{}]struct{})
+	}
+	ps.subscribers[topic][ch] = struct{}{}
+	ps.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		// Remove and close under the write lock so Publish never sends on a closed channel
+		ps.mu.Lock()
+		delete(ps.subscribers[topic], ch)
+		if len(ps.subscribers[topic]) == 0 {
+			delete(ps.subscribers, topic)
+		}
+		close(ch)
+		ps.mu.Unlock()
+	}()
+
+	return ch, nil
+}
+
+// Broker is the transport behind NewBrokerPubSub. Adapters for Redis, NATS or other
+// message brokers implement it by mapping topics to channels or subjects.
+//
+// Example (NATS):
+//
+//	type natsBroker struct{ conn *nats.Conn }
+//
+//	func (b natsBroker) Publish(ctx context.Context, topic string, data []byte) error {
+//	    return b.conn.Publish(topic, data)
+//	}
+//
+//	func (b natsBroker) Subscribe(ctx context.Context, topic string, handler func([]byte)) (func() error, error) {
+//	    sub, err := b.conn.Subscribe(topic, func(m *nats.Msg) { handler(m.Data) })
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return sub.Unsubscribe, nil
+//	}
+type Broker interface {
+	// Publish sends an encoded event to topic
+	Publish(ctx context.Context, topic string, data []byte) error
+
+	// Subscribe calls handler for every encoded event sent to topic until
+	// the returned unsubscribe function is called
+	Subscribe(ctx context.Context, topic string, handler func(data []byte)) (unsubscribe func() error, err error)
+}
+
+// brokerPubSub is a PubSub that exchanges JSON-encoded events through a Broker
+type brokerPubSub struct {
+	broker Broker
+}
+
+// NewBrokerPubSub creates a PubSub backed by an external message broker.
+// Payloads are encoded as JSON and delivered to subscribers as json.RawMessage;
+// SubscribeTopic decodes them into the subscription's type.
+func NewBrokerPubSub(broker Broker) PubSub {
+	return &brokerPubSub{broker: broker}
+}
+
+// Publish encodes payload as JSON and sends it to the broker
+func (ps *brokerPubSub) Publish(ctx context.Context, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return ps.broker.Publish(ctx, topic, data)
+}
+
+// Subscribe returns a channel of json.RawMessage events sent to topic until ctx is cancelled
+func (ps *brokerPubSub) Subscribe(ctx context.Context, topic string) (<-chan interface{}, error) {
+	ch := make(chan interface{}, pubSubBufferSize)
+
+	var mu sync.Mutex
+	closed := false
+
+	unsubscribe, err := ps.broker.Subscribe(ctx, topic, func(data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- json.RawMessage(append([]byte(nil), data...)):
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = unsubscribe()
+
+		mu.Lock()
+		closed = true
+		close(ch)
+		mu.Unlock()
+	}()
+
+	return ch, nil
+}
+
+// SubscribeTopic subscribes to topic and converts each payload to T, for use in
+// WithSubscriber. Payloads that are already T (or *T) are passed through; encoded
+// payloads from a broker are decoded from JSON. Payloads that cannot be converted are skipped.
+//
+// Example:
+//
+//	graph.NewResolver[Message]("messageAdded").
+//		WithSubscriber(func(p graph.ResolveParams) (<-chan Message, error) {
+//			return graph.SubscribeTopic[Message](p.Context, pubsub, "messages")
+//		}).BuildSubscription()
+func SubscribeTopic[T any](ctx context.Context, ps PubSub, topic string) (<-chan T, error) {
+	events, err := ps.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for payload := range events {
+			value, err := convertPayload[T](payload)
+			if err != nil {
+				continue
+			}
+			select {
+			case out <- value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// convertPayload converts a published payload to T
+func convertPayload[T any](payload interface{}) (T, error) {
+	var value T
+
+	switch v := payload.(type) {
+	case T:
+		return v, nil
+	case *T:
+		if v != nil {
+			return *v, nil
+		}
+		return value, nil
+	case json.RawMessage:
+		err := json.Unmarshal(v, &value)
+		return value, err
+	case []byte:
+		err := json.Unmarshal(v, &value)
+		return value, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
@@ -0,0 +1,170 @@
+package graph
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// RecordedOperation is an operation executed in DEBUG mode
+type RecordedOperation struct {
+	StartedAt     time.Time                  `json:"startedAt"`
+	Duration      time.Duration              `json:"duration"` // Nanoseconds in JSON
+	Client        string                     `json:"client"`
+	OperationName string                     `json:"operationName,omitempty"`
+	Query         string                     `json:"query"`
+	Variables     map[string]interface{}     `json:"variables,omitempty"`
+	Errors        []gqlerrors.FormattedError `json:"errors,omitempty"`
+}
+
+// Recorder keeps the most recent operations executed in DEBUG mode in memory, so
+// developers can inspect what clients sent without external tooling. It is safe for
+// concurrent use. Nothing is recorded when GraphContext.DEBUG is false.
+//
+// Example:
+//
+//	recorder := graph.NewRecorder(100)
+//
+//	http.Handle("/graphql", graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams: params,
+//	    DEBUG:        true,
+//	    Recorder:     recorder,
+//	}))
+//	http.Handle("/graphql/recordings", recorder) // JSON, or an HTML page in a browser
+type Recorder struct {
+	mu         sync.Mutex
+	operations []RecordedOperation
+	next       int
+	full       bool
+}
+
+// NewRecorder creates a Recorder keeping the last size operations (default 100)
+func NewRecorder(size int) *Recorder {
+	if size <= 0 {
+		size = 100
+	}
+	return &Recorder{operations: make([]RecordedOperation, size)}
+}
+
+// Record stores an operation, replacing the oldest one when the buffer is full
+func (rec *Recorder) Record(operation RecordedOperation) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.operations[rec.next] = operation
+	rec.next = (rec.next + 1) % len(rec.operations)
+	if rec.next == 0 {
+		rec.full = true
+	}
+}
+
+// Operations returns the recorded operations, newest first
+func (rec *Recorder) Operations() []RecordedOperation {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	count := rec.next
+	if rec.full {
+		count = len(rec.operations)
+	}
+
+	operations := make([]RecordedOperation, 0, count)
+	for i := 1; i <= count; i++ {
+		operations = append(operations, rec.operations[(rec.next-i+len(rec.operations))%len(rec.operations)])
+	}
+	return operations
+}
+
+// Reset clears the recorded operations
+func (rec *Recorder) Reset() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.operations = make([]RecordedOperation, len(rec.operations))
+	rec.next = 0
+	rec.full = false
+}
+
+// ServeHTTP writes the recorded operations as JSON, or as an HTML page for browsers
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operations := rec.Operations()
+
+	if accept := r.Header.Get("Accept"); strings.Contains(accept, "text/html") && !strings.Contains(accept, "application/json") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = recorderPage.Execute(w, operations)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"operations": operations})
+}
+
+// recorderPage renders the recorded operations for a browser
+var recorderPage = template.Must(template.New("recorder").Funcs(template.FuncMap{
+	"json": func(v interface{}) string {
+		b, _ := json.MarshalIndent(v, "", "  ")
+		return string(b)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GraphQL Recordings</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+details { border: 1px solid #ddd; border-radius: 4px; margin-bottom: .5em; padding: .5em; }
+summary { cursor: pointer; }
+pre { background: #f6f8fa; padding: .5em; overflow: auto; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Recent operations</h1>
+{{range .}}
+<details>
+<summary>{{.StartedAt.Format "15:04:05.000"}} · {{if .OperationName}}{{.OperationName}}{{else}}anonymous{{end}} · {{.Client}} · {{.Duration}}{{if .Errors}} · <span class="error">{{len .Errors}} error(s)</span>{{end}}</summary>
+<pre>{{.Query}}</pre>
+{{if .Variables}}<pre>{{json .Variables}}</pre>{{end}}
+{{if .Errors}}<pre class="error">{{json .Errors}}</pre>{{end}}
+</details>
+{{else}}
+<p>No operations recorded yet.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// recordingExecutor records the operations it executes
+type recordingExecutor struct {
+	Executor
+	recorder *Recorder
+}
+
+// Execute runs a query or mutation and records it
+func (e recordingExecutor) Execute(params ExecuteParams) *graphql.Result {
+	start := time.Now()
+	result := e.Executor.Execute(params)
+
+	client := unknownClient
+	if params.Context != nil {
+		if r, ok := RequestFromContext(params.Context); ok {
+			client = clientName(r)
+		}
+	}
+
+	e.recorder.Record(RecordedOperation{
+		StartedAt:     start,
+		Duration:      time.Since(start),
+		Client:        client,
+		OperationName: params.OperationName,
+		Query:         params.Query,
+		Variables:     params.Variables,
+		Errors:        result.Errors,
+	})
+	return result
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// RedactedString replaces string values of sensitive fields the principal may not see
+const RedactedString = "[REDACTED]"
+
+// Sensitivity classifies the data of a field for redaction and auditing
+type Sensitivity struct {
+	// Category of the data, such as "pii" or "financial"
+	Category string
+}
+
+// Sensitive classifies a field's data under category.
+//
+// Example:
+//
+//	NewResolver[User]("user").
+//		WithSensitiveField("email", graph.Sensitive("pii")).
+//		WithSensitiveField("ssn", graph.Sensitive("pii"))
+func Sensitive(category string) Sensitivity {
+	return Sensitivity{Category: category}
+}
+
+// RedactionPolicy reports whether the principal of a request may see data of the
+// given category unmasked
+type RedactionPolicy func(p ResolveParams, category string) bool
+
+// AuditEntry records a sensitive value returned unmasked
+type AuditEntry struct {
+	Time     time.Time
+	Category string
+	Type     string        // Parent object type
+	Field    string        // Field name
+	Path     []interface{} // Response path
+	Details  interface{}   // User details from the root value, if any
+}
+
+// AuditLogger receives an entry each time a sensitive value is returned unmasked
+type AuditLogger func(ctx context.Context, entry AuditEntry)
+
+var (
+	redactionPolicy RedactionPolicy
+	auditLogger     AuditLogger
+	redactionMu     sync.RWMutex
+)
+
+// SetRedactionPolicy sets the policy deciding who sees sensitive fields unmasked.
+// Without a policy every sensitive field is masked.
+//
+// Example:
+//
+//	graph.SetRedactionPolicy(graph.ScopeRedactionPolicy(func(p graph.ResolveParams) []string {
+//	    var user User
+//	    if err := graph.GetRootInfo(p, "details", &user); err != nil {
+//	        return nil
+//	    }
+//	    return user.Scopes
+//	}))
+func SetRedactionPolicy(policy RedactionPolicy) {
+	redactionMu.Lock()
+	defer redactionMu.Unlock()
+	redactionPolicy = policy
+}
+
+// SetAuditLogger sets the logger receiving an entry whenever a sensitive value is returned unmasked
+func SetAuditLogger(logger AuditLogger) {
+	redactionMu.Lock()
+	defer redactionMu.Unlock()
+	auditLogger = logger
+}
+
+// ScopeRedactionPolicy allows principals to see a category unmasked when the scopes
+// returned by scopes include the category name
+func ScopeRedactionPolicy(scopes func(p ResolveParams) []string) RedactionPolicy {
+	return func(p ResolveParams, category string) bool {
+		for _, scope := range scopes(p) {
+			if scope == category {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WithSensitiveField marks a field of the resolver's object type as sensitive.
+// The value is masked for principals the RedactionPolicy rejects, and audited when
+// returned unmasked.
+func (r *UnifiedResolver[T]) WithSensitiveField(fieldName string, sensitivity Sensitivity) *UnifiedResolver[T] {
+	if r.sensitiveFields == nil {
+		r.sensitiveFields = make(map[string]Sensitivity)
+	}
+	r.sensitiveFields[fieldName] = sensitivity
+	return r
+}
+
+// redactField masks or audits the result of resolve according to sensitivity
+func redactField(resolve graphql.FieldResolveFn, sensitivity Sensitivity) graphql.FieldResolveFn {
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := resolve(p)
+		if err != nil || result == nil {
+			return result, err
+		}
+
+		redactionMu.RLock()
+		policy, logger := redactionPolicy, auditLogger
+		redactionMu.RUnlock()
+
+		if policy == nil || !policy(ResolveParams(p), sensitivity.Category) {
+			return maskValue(result), nil
+		}
+
+		if logger != nil {
+			entry := AuditEntry{
+				Time:     time.Now(),
+				Category: sensitivity.Category,
+				Field:    p.Info.FieldName,
+			}
+			if p.Info.ParentType != nil {
+				entry.Type = p.Info.ParentType.Name()
+			}
+			if p.Info.Path != nil {
+				entry.Path = p.Info.Path.AsArray()
+			}
+			if root, ok := p.Info.RootValue.(map[string]interface{}); ok {
+				entry.Details = root["details"]
+			}
+			logger(p.Context, entry)
+		}
+
+		return result, nil
+	}
+}
+
+// maskValue returns RedactedString for strings and the zero value for other types
+func maskValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		return RedactedString
+	}
+	return reflect.Zero(v.Type()).Interface()
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Global type registries, shared by every schema built in the process so a Go type
+// maps to one GraphQL type
+var (
+	// typeRegistry holds the object types of resolvers (NewResolver[T])
+	typeRegistry = &namedTypes[*graphql.Object]{}
+	// objectTypeRegistry holds the object types generated for nested struct fields
+	objectTypeRegistry = &namedTypes[*graphql.Object]{}
+	// inputTypeRegistry holds the input object types of arguments
+	inputTypeRegistry = &namedTypes[*graphql.InputObject]{}
+)
+
+// namedTypes is a registry of GraphQL types by name, safe for concurrent use.
+// Lookups read a sync.Map without locking, so parallel schema builds don't contend
+// once their types exist. Creations are serialized, so a type is created once per
+// name: a create function must not create types in the same registry itself, which
+// field thunks avoid by running after it returns.
+type namedTypes[T any] struct {
+	types sync.Map // name → T
+	mu    sync.Mutex
+}
+
+// load returns the type registered under name
+func (r *namedTypes[T]) load(name string) (T, bool) {
+	if t, ok := r.types.Load(name); ok {
+		return t.(T), true
+	}
+	var zero T
+	return zero, false
+}
+
+// loadOrStore registers t under name unless a type is registered already, and returns
+// the registered type
+func (r *namedTypes[T]) loadOrStore(name string, t T) T {
+	actual, _ := r.types.LoadOrStore(name, t)
+	return actual.(T)
+}
+
+// loadOrCreate returns the type registered under name, or registers the one create
+// returns
+func (r *namedTypes[T]) loadOrCreate(name string, create func() T) T {
+	if t, ok := r.load(name); ok {
+		return t
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Double-check in case another goroutine created it
+	if t, ok := r.load(name); ok {
+		return t
+	}
+	return r.loadOrStore(name, create())
+}
+
+// schemaBuildMu serializes schema construction. graphql-go defines the fields of a type
+// the first time a schema reaches it, without locking, and the registered types are
+// shared by every schema; once a schema is built its types are only read.
+var schemaBuildMu sync.Mutex
+
+// newSchema is graphql.NewSchema, safe to call while other schemas are built
+func newSchema(config graphql.SchemaConfig) (graphql.Schema, error) {
+	schemaBuildMu.Lock()
+	defer schemaBuildMu.Unlock()
+	return graphql.NewSchema(config)
+}
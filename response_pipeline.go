@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ResponsePhase orders the stages of a ResponsePipeline. Phases run in the order below,
+// and the stages of a phase in the order they were added.
+type ResponsePhase int
+
+const (
+	// SanitizePhase rewrites error messages (EnableSanitization)
+	SanitizePhase ResponsePhase = iota
+
+	// ExtensionsPhase attaches what execution measured to the extensions (deprecation
+	// warnings, explain trees, rotated tokens, cache hints). Responses are stored for
+	// idempotent replay after this phase.
+	ExtensionsPhase
+
+	// DecoratePhase edits the result for the client (localization, ResponseDecorator).
+	// It is the first phase replayed idempotent responses go through.
+	DecoratePhase
+
+	// EncodePhase enforces MaxResponseBytes and changes the media type (MessagePack).
+	// Responses stored for idempotent replay are limited as they are stored.
+	EncodePhase
+
+	// HashPhase computes validators over the encoded body (ETag)
+	HashPhase
+
+	// CompressPhase applies a content coding to the body (EnableCompression)
+	CompressPhase
+
+	responsePhaseCount
+)
+
+// Response is a serialized response buffered by NewHTTP, as passed to the stages of its
+// ResponsePipeline. Stages edit the body as bytes with Body and SetBody, or as a GraphQL
+// result with Result; edits to the result are encoded once a stage asks for the body.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+
+	body   []byte
+	result *graphql.Result // Decoded body, when a stage asked for it
+	codec  Codec
+	pretty bool
+}
+
+// Body returns the serialized body, including the edits made to Result
+func (r *Response) Body() []byte {
+	if r.result == nil {
+		return r.body
+	}
+
+	if encoded, err := encodeJSON(r.codec, r.result, r.pretty); err == nil {
+		r.body = encoded
+	}
+	r.result = nil
+	return r.body
+}
+
+// SetBody replaces the body, discarding unread edits made to Result
+func (r *Response) SetBody(body []byte) {
+	r.body = body
+	r.result = nil
+}
+
+// Result decodes the body as a GraphQL result, keeping numbers exact, for stages editing
+// its data, errors or extensions. It reports false for other bodies, such as the
+// playground page.
+func (r *Response) Result() (*graphql.Result, bool) {
+	if r.result != nil {
+		return r.result, true
+	}
+	result, ok := decodeResult(r.body)
+	if !ok {
+		return nil, false
+	}
+	r.result = result
+	return result, true
+}
+
+// ResponseStage transforms a buffered response. r is the request being answered.
+type ResponseStage func(r *http.Request, response *Response)
+
+// ResponsePipeline is the chain of stages the serialized response of a request passes
+// through before it is written, so features rewriting the body (sanitization,
+// decoration, ETags, compression) each see the output of the previous ones. Requests
+// that no stage touches are written without buffering.
+type ResponsePipeline interface {
+	// Use adds stage to phase, after the stages already added to it
+	Use(phase ResponsePhase, stage ResponseStage)
+}
+
+// DecorateResponse adapts a ResponseDecorator to a ResponseStage
+//
+// Example:
+//
+//	ResponsePipelineFn: func(r *http.Request, pipeline graph.ResponsePipeline) {
+//	    pipeline.Use(graph.DecoratePhase, graph.DecorateResponse(addServiceInfo))
+//	}
+func DecorateResponse(decorate ResponseDecorator) ResponseStage {
+	return decorateResponse(nil, decorate)
+}
+
+// decorateResponse adapts decorate to a stage called with ctx, or with the context of the
+// request when ctx is nil. Bodies that are not GraphQL responses are left unchanged.
+func decorateResponse(ctx context.Context, decorate ResponseDecorator) ResponseStage {
+	return func(r *http.Request, response *Response) {
+		result, ok := response.Result()
+		if !ok {
+			return
+		}
+		if ctx == nil {
+			decorate(r.Context(), result)
+			return
+		}
+		decorate(ctx, result)
+	}
+}
+
+// responsePipeline buffers a response for its stages, and writes the result once the
+// request is served
+type responsePipeline struct {
+	http.ResponseWriter
+	stages [responsePhaseCount][]ResponseStage
+	codec  Codec
+	pretty bool
+
+	// maxBytes caps the buffered body (see limitResponse)
+	maxBytes int
+
+	// recorders receive the response after ExtensionsPhase (see idempotencyGuard)
+	recorders []func(response *Response)
+	// replayed responses start at DecoratePhase
+	replayed bool
+	// cleanups run once the response is written, even when nothing was
+	cleanups []func()
+
+	// capture buffers the response, decided on the first write: nil when nothing
+	// processes it
+	capture *responseWriterWrapper
+	started bool
+}
+
+func newResponsePipeline(w http.ResponseWriter, codec Codec, pretty bool) *responsePipeline {
+	return &responsePipeline{ResponseWriter: w, codec: codec, pretty: pretty}
+}
+
+// Use adds stage to phase
+func (p *responsePipeline) Use(phase ResponsePhase, stage ResponseStage) {
+	if phase < 0 || phase >= responsePhaseCount {
+		return
+	}
+	p.stages[phase] = append(p.stages[phase], stage)
+}
+
+// record passes the response to recorder after ExtensionsPhase
+func (p *responsePipeline) record(recorder func(response *Response)) {
+	p.recorders = append(p.recorders, recorder)
+}
+
+// start decides whether the response is buffered, once stages can no longer be added
+func (p *responsePipeline) start() {
+	if p.started {
+		return
+	}
+	p.started = true
+
+	buffered := p.maxBytes > 0 || len(p.recorders) > 0
+	for _, stages := range p.stages {
+		buffered = buffered || len(stages) > 0
+	}
+	if buffered {
+		p.capture = newResponseWriterWrapper(p.ResponseWriter)
+		p.capture.maxBytes = p.maxBytes
+	}
+}
+
+func (p *responsePipeline) Write(b []byte) (int, error) {
+	p.start()
+	if p.capture == nil {
+		return p.ResponseWriter.Write(b)
+	}
+	return p.capture.Write(b)
+}
+
+func (p *responsePipeline) WriteHeader(statusCode int) {
+	p.start()
+	if p.capture == nil {
+		p.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	p.capture.WriteHeader(statusCode)
+}
+
+// finish runs the stages over the buffered response of r and writes it out
+func (p *responsePipeline) finish(r *http.Request) {
+	for _, cleanup := range p.cleanups {
+		defer cleanup()
+	}
+	if p.capture == nil {
+		return
+	}
+	defer p.capture.release()
+
+	response := &Response{
+		StatusCode: p.capture.statusCode,
+		Header:     p.Header(),
+		body:       p.capture.body.Bytes(),
+		codec:      p.codec,
+		pretty:     p.pretty,
+	}
+	if p.capture.overflow {
+		response.SetBody(nil)
+	}
+
+	first := SanitizePhase
+	if p.replayed {
+		first = DecoratePhase
+	}
+	overflow := p.capture.overflow
+	for phase := first; phase < responsePhaseCount; phase++ {
+		if phase == DecoratePhase && !p.replayed {
+			// Oversized responses are replaced before they are recorded, so replays
+			// answer the RESPONSE_TOO_LARGE error rather than an empty body
+			if p.maxBytes > 0 && len(p.recorders) > 0 {
+				limitResponse(response, p.maxBytes, overflow)
+				overflow = false
+			}
+			for _, recorder := range p.recorders {
+				recorder(response)
+			}
+		}
+		if phase == EncodePhase && p.maxBytes > 0 {
+			limitResponse(response, p.maxBytes, overflow)
+		}
+		for _, stage := range p.stages[phase] {
+			stage(r, response)
+		}
+	}
+
+	body := response.Body()
+	p.ResponseWriter.WriteHeader(response.StatusCode)
+	_, _ = p.ResponseWriter.Write(body)
+}
@@ -0,0 +1,599 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// RESTRoute maps an HTTP endpoint to a GraphQL operation. Path parameters (":id" or
+// "{id}"), query parameters and JSON body properties are passed as the operation's
+// variables of the same name. The response body is the value of the operation's
+// first root field.
+type RESTRoute struct {
+	Method        string // HTTP method, defaults to GET
+	Path          string // e.g. "/api/users/:id"
+	Query         string // GraphQL operation, e.g. "query User($id: Int!) { user(id: $id) { id name } }"
+	OperationName string // Operation to execute when Query holds several
+	Summary       string // Short description for the OpenAPI document
+}
+
+// RESTBridge serves selected GraphQL operations as REST endpoints, so legacy REST
+// consumers can be served from the same schema and resolvers.
+//
+// Example:
+//
+//	bridge, err := graph.NewRESTBridge(graphCtx,
+//	    graph.RESTRoute{
+//	        Method: http.MethodGet,
+//	        Path:   "/api/users/:id",
+//	        Query:  `query User($id: Int!) { user(id: $id) { id name email } }`,
+//	    },
+//	    graph.RESTRoute{
+//	        Method: http.MethodPost,
+//	        Path:   "/api/users",
+//	        Query:  `mutation CreateUser($name: String!, $email: String!) { createUser(name: $name, email: $email) { id } }`,
+//	    },
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	http.Handle("/api/", bridge)
+//	http.Handle("/openapi.json", bridge.OpenAPIHandler("Users API", "1.0.0"))
+type RESTBridge struct {
+	graphCtx    *GraphContext
+	schema      *graphql.Schema
+	routes      []*restRoute
+	revocations *revocationCache
+}
+
+// restRoute is a RESTRoute with its parsed operation
+type restRoute struct {
+	RESTRoute
+	segments  []string
+	operation *ast.OperationDefinition
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]*ast.VariableDefinition
+}
+
+// NewRESTBridge creates a bridge for routes, executing them against the schema of graphCtx.
+// Every route's operation is validated against the schema up front.
+func NewRESTBridge(graphCtx *GraphContext, routes ...RESTRoute) (*RESTBridge, error) {
+	if graphCtx == nil {
+		graphCtx = &GraphContext{}
+	}
+
+	schema, err := buildSchemaFromContext(graphCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instrumentRequestState(schema, graphCtx)
+
+	bridge := &RESTBridge{graphCtx: graphCtx, schema: schema, revocations: newRevocationCache(graphCtx.TokenRevokedFn)}
+	for _, route := range routes {
+		if route.Method == "" {
+			route.Method = http.MethodGet
+		}
+		route.Method = strings.ToUpper(route.Method)
+
+		parsed, err := parseRESTRoute(schema, route)
+		if err != nil {
+			return nil, fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
+		}
+		bridge.routes = append(bridge.routes, parsed)
+	}
+
+	return bridge, nil
+}
+
+// parseRESTRoute parses and validates the operation of a route
+func parseRESTRoute(schema *graphql.Schema, route RESTRoute) (*restRoute, error) {
+	doc, err := parseGraphQLQuery(route.Query)
+	if err != nil {
+		return nil, err
+	}
+	if result := graphql.ValidateDocument(schema, doc, nil); !result.IsValid {
+		return nil, fmt.Errorf("invalid operation: %s", result.Errors[0].Message)
+	}
+
+	parsed := &restRoute{
+		RESTRoute: route,
+		segments:  splitPath(route.Path),
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: make(map[string]*ast.VariableDefinition),
+	}
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			parsed.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if parsed.operation == nil && (route.OperationName == "" || (def.Name != nil && def.Name.Value == route.OperationName)) {
+				parsed.operation = def
+			}
+		}
+	}
+	if parsed.operation == nil {
+		return nil, fmt.Errorf("operation %q not found", route.OperationName)
+	}
+
+	for _, def := range parsed.operation.VariableDefinitions {
+		parsed.variables[def.Variable.Name.Value] = def
+	}
+	for _, segment := range parsed.segments {
+		if name, ok := pathParam(segment); ok && parsed.variables[name] == nil {
+			return nil, fmt.Errorf("path parameter %q has no matching variable", name)
+		}
+	}
+
+	return parsed, nil
+}
+
+// splitPath splits a URL path into its non-empty segments
+func splitPath(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// pathParam returns the parameter name of a ":name" or "{name}" path segment
+func pathParam(segment string) (string, bool) {
+	if strings.HasPrefix(segment, ":") {
+		return segment[1:], true
+	}
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// match returns the path parameters of path if it matches the route's pattern
+func (route *restRoute) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(route.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range route.segments {
+		if name, ok := pathParam(segment); ok {
+			params[name] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// ServeHTTP executes the operation of the route matching the request
+func (b *RESTBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+
+	pathMatched := false
+	for _, route := range b.routes {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if route.Method != r.Method {
+			continue
+		}
+
+		b.serveRoute(w, r, route, params)
+		return
+	}
+
+	if pathMatched {
+		writeRESTError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeRESTError(w, http.StatusNotFound, "not found")
+}
+
+// serveRoute executes a route's operation with variables taken from the request
+func (b *RESTBridge) serveRoute(w http.ResponseWriter, r *http.Request, route *restRoute, params map[string]string) {
+	variables, err := route.requestVariables(r, params)
+	if err != nil {
+		writeRESTError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	r = withRequestState(r, b.graphCtx)
+	r = withResponseWriter(r, w)
+	if b.revocations != nil {
+		r = withRevocations(r, b.revocations)
+	}
+	if err := authenticateRequest(r, b.graphCtx, b.revocations); err != nil {
+		writeUnauthenticated(w, err)
+		return
+	}
+
+	result := executorFor(b.graphCtx).Execute(ExecuteParams{
+		Context:       r.Context(),
+		Schema:        b.schema,
+		Query:         route.Query,
+		OperationName: route.OperationName,
+		Variables:     variables,
+		RootValue:     buildRootValue(r.Context(), b.graphCtx, r),
+	})
+
+	if len(result.Errors) > 0 {
+		status := http.StatusInternalServerError
+		if result.Data == nil {
+			// Variables were rejected before execution
+			status = http.StatusBadRequest
+		}
+
+		errs := make([]map[string]interface{}, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			message := e.Message
+			if !b.graphCtx.DEBUG && b.graphCtx.EnableSanitization {
+				message = sanitizeErrorMessage(message)
+			}
+			errs = append(errs, map[string]interface{}{"message": message})
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": errs})
+		return
+	}
+
+	var body interface{}
+	if data, ok := result.Data.(map[string]interface{}); ok {
+		body = data[route.responseKey()]
+	}
+	if body == nil {
+		writeRESTError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// responseKey returns the response key of the operation's first root field
+func (route *restRoute) responseKey() string {
+	for _, selection := range route.operation.SelectionSet.Selections {
+		if field, ok := selection.(*ast.Field); ok {
+			if field.Alias != nil {
+				return field.Alias.Value
+			}
+			return field.Name.Value
+		}
+	}
+	return ""
+}
+
+// requestVariables collects the operation's variables from the path parameters,
+// the query string and a JSON body, in increasing order of precedence
+func (route *restRoute) requestVariables(r *http.Request, params map[string]string) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+
+	query := r.URL.Query()
+	for name, def := range route.variables {
+		if values, ok := query[name]; ok {
+			value, err := coerceRESTValue(def.Type, values)
+			if err != nil {
+				return nil, fmt.Errorf("query parameter %q: %w", name, err)
+			}
+			variables[name] = value
+		}
+	}
+
+	for name, raw := range params {
+		value, err := coerceRESTValue(route.variables[name].Type, []string{raw})
+		if err != nil {
+			return nil, fmt.Errorf("path parameter %q: %w", name, err)
+		}
+		variables[name] = value
+	}
+
+	if r.Body != nil && r.ContentLength != 0 && r.Method != http.MethodGet {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		for name := range route.variables {
+			if value, ok := body[name]; ok {
+				variables[name] = value
+			}
+		}
+	}
+
+	return variables, nil
+}
+
+// coerceRESTValue converts string parameters to the GraphQL variable type
+func coerceRESTValue(t ast.Type, values []string) (interface{}, error) {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return coerceRESTValue(t.Type, values)
+	case *ast.List:
+		list := make([]interface{}, 0, len(values))
+		for _, raw := range values {
+			for _, part := range strings.Split(raw, ",") {
+				value, err := coerceRESTValue(t.Type, []string{part})
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+		}
+		return list, nil
+	case *ast.Named:
+		raw := values[len(values)-1]
+		switch t.Name.Value {
+		case "Int":
+			return strconv.Atoi(raw)
+		case "Float":
+			return strconv.ParseFloat(raw, 64)
+		case "Boolean":
+			return strconv.ParseBool(raw)
+		case "String", "ID":
+			return raw, nil
+		}
+		// Input objects and custom scalars may be passed as JSON
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return raw, nil
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported type")
+}
+
+// writeRESTError writes a REST error response
+func writeRESTError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{"message": message}},
+	})
+}
+
+// OpenAPIHandler serves the bridge's OpenAPI document as JSON
+func (b *RESTBridge) OpenAPIHandler(title, version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(b.OpenAPI(title, version))
+	}
+}
+
+// OpenAPI returns an OpenAPI 3.0 document describing the bridge's routes. Parameter
+// and response schemas are derived from the operations' variables and selections.
+func (b *RESTBridge) OpenAPI(title, version string) map[string]interface{} {
+	paths := make(map[string]interface{})
+
+	for _, route := range b.routes {
+		operation := map[string]interface{}{
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Successful response",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": route.responseSchema(b.schema)},
+					},
+				},
+				"400": map[string]interface{}{"description": "Invalid parameters"},
+				"404": map[string]interface{}{"description": "Not found"},
+			},
+		}
+		if route.Summary != "" {
+			operation["summary"] = route.Summary
+		}
+		if route.operation.Name != nil {
+			operation["operationId"] = route.operation.Name.Value
+		}
+
+		pathParams := make(map[string]bool)
+		for _, segment := range route.segments {
+			if name, ok := pathParam(segment); ok {
+				pathParams[name] = true
+			}
+		}
+
+		names := make([]string, 0, len(route.variables))
+		for name := range route.variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var parameters []interface{}
+		bodyProperties := make(map[string]interface{})
+		var bodyRequired []string
+		hasBody := route.Method == http.MethodPost || route.Method == http.MethodPut || route.Method == http.MethodPatch
+
+		for _, name := range names {
+			def := route.variables[name]
+			_, required := def.Type.(*ast.NonNull)
+
+			switch {
+			case pathParams[name]:
+				parameters = append(parameters, map[string]interface{}{
+					"name": name, "in": "path", "required": true, "schema": astTypeSchema(b.schema, def.Type),
+				})
+			case hasBody:
+				bodyProperties[name] = astTypeSchema(b.schema, def.Type)
+				if required {
+					bodyRequired = append(bodyRequired, name)
+				}
+			default:
+				parameters = append(parameters, map[string]interface{}{
+					"name": name, "in": "query", "required": required, "schema": astTypeSchema(b.schema, def.Type),
+				})
+			}
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if len(bodyProperties) > 0 {
+			bodySchema := map[string]interface{}{"type": "object", "properties": bodyProperties}
+			if len(bodyRequired) > 0 {
+				bodySchema["required"] = bodyRequired
+			}
+			operation["requestBody"] = map[string]interface{}{
+				"required": len(bodyRequired) > 0,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": bodySchema},
+				},
+			}
+		}
+
+		path := openAPIPath(route.segments)
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+	}
+}
+
+// openAPIPath formats path segments with {name} parameters
+func openAPIPath(segments []string) string {
+	formatted := make([]string, len(segments))
+	for i, segment := range segments {
+		if name, ok := pathParam(segment); ok {
+			segment = "{" + name + "}"
+		}
+		formatted[i] = segment
+	}
+	return "/" + strings.Join(formatted, "/")
+}
+
+// astTypeSchema returns the JSON schema of a variable type
+func astTypeSchema(schema *graphql.Schema, t ast.Type) map[string]interface{} {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return astTypeSchema(schema, t.Type)
+	case *ast.List:
+		return map[string]interface{}{"type": "array", "items": astTypeSchema(schema, t.Type)}
+	case *ast.Named:
+		return graphQLTypeSchema(schema.Type(t.Name.Value))
+	}
+	return map[string]interface{}{}
+}
+
+// graphQLTypeSchema returns the JSON schema of a scalar, enum or input type
+func graphQLTypeSchema(t graphql.Type) map[string]interface{} {
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return graphQLTypeSchema(t.OfType)
+	case *graphql.List:
+		return map[string]interface{}{"type": "array", "items": graphQLTypeSchema(t.OfType)}
+	case *graphql.Enum:
+		values := make([]interface{}, 0, len(t.Values()))
+		for _, value := range t.Values() {
+			values = append(values, value.Name)
+		}
+		return map[string]interface{}{"type": "string", "enum": values}
+	case *graphql.InputObject:
+		properties := make(map[string]interface{})
+		for name, field := range t.Fields() {
+			properties[name] = graphQLTypeSchema(field.Type)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	case *graphql.Scalar:
+		switch t.Name() {
+		case "Int":
+			return map[string]interface{}{"type": "integer"}
+		case "Float":
+			return map[string]interface{}{"type": "number"}
+		case "Boolean":
+			return map[string]interface{}{"type": "boolean"}
+		case "String", "ID":
+			return map[string]interface{}{"type": "string"}
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// responseSchema returns the JSON schema of the route's response: the selection of
+// the operation's first root field
+func (route *restRoute) responseSchema(schema *graphql.Schema) map[string]interface{} {
+	var root *graphql.Object
+	switch route.operation.Operation {
+	case ast.OperationTypeMutation:
+		root = schema.MutationType()
+	default:
+		root = schema.QueryType()
+	}
+
+	for _, selection := range route.operation.SelectionSet.Selections {
+		if field, ok := selection.(*ast.Field); ok && root != nil {
+			if def := root.Fields()[field.Name.Value]; def != nil {
+				return route.selectionSchema(schema, def.Type, field.SelectionSet)
+			}
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// selectionSchema returns the JSON schema of a value of type t with the given selections
+func (route *restRoute) selectionSchema(schema *graphql.Schema, t graphql.Type, selectionSet *ast.SelectionSet) map[string]interface{} {
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return route.selectionSchema(schema, t.OfType, selectionSet)
+	case *graphql.List:
+		return map[string]interface{}{"type": "array", "items": route.selectionSchema(schema, t.OfType, selectionSet)}
+	case *graphql.Object, *graphql.Interface:
+		properties := make(map[string]interface{})
+		route.collectProperties(schema, t, selectionSet, properties)
+		return map[string]interface{}{"type": "object", "properties": properties}
+	case *graphql.Union:
+		return map[string]interface{}{"type": "object"}
+	}
+	return graphQLTypeSchema(t)
+}
+
+// collectProperties adds the schemas of the fields selected on parent to properties
+func (route *restRoute) collectProperties(schema *graphql.Schema, parent graphql.Type, selectionSet *ast.SelectionSet, properties map[string]interface{}) {
+	if selectionSet == nil {
+		return
+	}
+
+	for _, selection := range selectionSet.Selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			key := sel.Name.Value
+			if sel.Alias != nil {
+				key = sel.Alias.Value
+			}
+			if sel.Name.Value == "__typename" {
+				properties[key] = map[string]interface{}{"type": "string"}
+				continue
+			}
+			if def := schemaField(parent, sel.Name.Value); def != nil {
+				properties[key] = route.selectionSchema(schema, def.Type, sel.SelectionSet)
+			}
+		case *ast.InlineFragment:
+			fragmentType := parent
+			if sel.TypeCondition != nil {
+				fragmentType = schema.Type(sel.TypeCondition.Name.Value)
+			}
+			route.collectProperties(schema, fragmentType, sel.SelectionSet, properties)
+		case *ast.FragmentSpread:
+			if fragment, ok := route.fragments[sel.Name.Value]; ok {
+				route.collectProperties(schema, schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet, properties)
+			}
+		}
+	}
+}
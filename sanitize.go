@@ -0,0 +1,264 @@
+package graph
+
+import (
+	"html"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/graphql-go/graphql"
+)
+
+// InputSanitizer rewrites a string argument before resolvers see it
+type InputSanitizer func(string) string
+
+// TrimSpace removes leading and trailing white space
+func TrimSpace(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// NormalizeUnicode replaces invalid UTF-8 with U+FFFD, removes invisible format
+// characters (zero-width spaces, byte order marks, bidirectional overrides) and turns
+// every kind of space separator into an ASCII space. It does not compose or decompose
+// characters; wrap norm.NFC.String from golang.org/x/text in an InputSanitizer for that.
+func NormalizeUnicode(s string) string {
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "\uFFFD")
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.Cf, r):
+			return -1
+		case unicode.Is(unicode.Zs, r):
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// StripControlCharacters removes control characters other than tab, newline and
+// carriage return
+func StripControlCharacters(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// EscapeHTML escapes <, >, &, ' and ", so the value can be rendered in HTML verbatim
+func EscapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// StripHTML removes HTML tags and comments, keeping the text between them. It is meant
+// for plain-text fields, not as an HTML sanitizer for rich text.
+func StripHTML(s string) string {
+	if !strings.ContainsRune(s, '<') {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '<')
+		if start < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:start])
+		s = s[start:]
+
+		end := ">"
+		if strings.HasPrefix(s, "<!--") {
+			end = "-->"
+		}
+		stop := strings.Index(s, end)
+		if stop < 0 {
+			break
+		}
+		s = s[stop+len(end):]
+	}
+	return b.String()
+}
+
+// sanitizerTags maps the names accepted in `sanitize` struct tags to sanitizers
+var sanitizerTags = map[string]InputSanitizer{
+	"trim":      TrimSpace,
+	"normalize": NormalizeUnicode,
+	"control":   StripControlCharacters,
+	"html":      EscapeHTML,
+	"striphtml": StripHTML,
+}
+
+// WithInputSanitizer sanitizes the string values of an argument before the resolver,
+// and its middleware, run. sanitizers apply in order. Nested input fields are addressed
+// with dots, e.g. "input.email"; lists are sanitized element by element.
+//
+// Struct fields of input objects and argument structs can declare the same with a
+// `sanitize` tag listing trim, normalize, control, html and striphtml.
+//
+// Example:
+//
+//	type CreateUserInput struct {
+//	    Name  string `json:"name" sanitize:"trim,normalize,control"`
+//	    Email string `json:"email" sanitize:"trim"`
+//	}
+//
+//	NewResolver[User]("createUser").
+//	    WithInputObject(CreateUserInput{}).
+//	    WithInputSanitizer("input.bio", graph.TrimSpace, graph.StripHTML).
+//	    AsMutation()
+func (r *UnifiedResolver[T]) WithInputSanitizer(argPath string, sanitizers ...InputSanitizer) *UnifiedResolver[T] {
+	if r.inputSanitizers == nil {
+		r.inputSanitizers = make(map[string][]InputSanitizer)
+	}
+	r.inputSanitizers[argPath] = append(r.inputSanitizers[argPath], sanitizers...)
+	return r
+}
+
+// WithInputSanitizer sanitizes the string values of an argument before the resolver
+// runs (see UnifiedResolver.WithInputSanitizer)
+func (r *TypedArgsResolver[T, A]) WithInputSanitizer(argPath string, sanitizers ...InputSanitizer) *TypedArgsResolver[T, A] {
+	r.base.WithInputSanitizer(argPath, sanitizers...)
+	return r
+}
+
+// addTaggedSanitizers registers the sanitizers declared by the `sanitize` tags of the
+// fields of t, with prefix leading their argument paths
+func (r *UnifiedResolver[T]) addTaggedSanitizers(t reflect.Type, prefix string) {
+	collectTaggedSanitizers(t, prefix, func(path string, sanitizers []InputSanitizer) {
+		r.WithInputSanitizer(path, sanitizers...)
+	}, make(map[reflect.Type]bool))
+}
+
+// collectTaggedSanitizers walks the fields of t, and of the input objects nested in
+// it, calling add for each field with a `sanitize` tag
+func collectTaggedSanitizers(t reflect.Type, prefix string, add func(string, []InputSanitizer), visiting map[reflect.Type]bool) {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for _, field := range collectStructFields(t) {
+		path := getFieldName(field.StructField)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if tag := field.Tag.Get("sanitize"); tag != "" {
+			var sanitizers []InputSanitizer
+			for _, name := range strings.Split(tag, ",") {
+				if sanitizer, ok := sanitizerTags[strings.TrimSpace(name)]; ok {
+					sanitizers = append(sanitizers, sanitizer)
+				}
+			}
+			if len(sanitizers) > 0 {
+				add(path, sanitizers)
+			}
+		}
+		collectTaggedSanitizers(field.Type, path, add, visiting)
+	}
+}
+
+// sanitizeArgs applies the sanitizers registered by argument path to the arguments of
+// resolve. The argument maps are copied, never modified in place.
+func sanitizeArgs(resolve graphql.FieldResolveFn, sanitizers map[string][]InputSanitizer) graphql.FieldResolveFn {
+	paths := make(map[string][]string, len(sanitizers))
+	for path := range sanitizers {
+		paths[path] = strings.Split(path, ".")
+	}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		var args interface{} = p.Args
+		for path, segments := range paths {
+			args = sanitizeAt(args, segments, sanitizers[path])
+		}
+		p.Args, _ = args.(map[string]interface{})
+		return resolve(p)
+	}
+}
+
+// sanitizeAt applies sanitizers to the strings found at path in value
+func sanitizeAt(value interface{}, path []string, sanitizers []InputSanitizer) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		sanitized := make([]interface{}, len(v))
+		for i, item := range v {
+			sanitized[i] = sanitizeAt(item, path, sanitizers)
+		}
+		return sanitized
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return v
+		}
+		field, ok := v[path[0]]
+		if !ok {
+			return v
+		}
+		sanitized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			sanitized[key] = item
+		}
+		sanitized[path[0]] = sanitizeAt(field, path[1:], sanitizers)
+		return sanitized
+	case string:
+		if len(path) > 0 {
+			return v
+		}
+		for _, sanitize := range sanitizers {
+			v = sanitize(v)
+		}
+		return v
+	}
+	return value
+}
+
+// SanitizeInputs returns middleware applying sanitizers to every string argument of
+// a field, at any depth. Use WithInputSanitizer or `sanitize` tags to target single
+// arguments.
+//
+// Example:
+//
+//	NewResolver[Post]("createPost").
+//	    WithMiddleware(graph.SanitizeInputs(graph.TrimSpace, graph.StripControlCharacters))
+func SanitizeInputs(sanitizers ...InputSanitizer) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			args, _ := sanitizeAll(p.Args, sanitizers).(map[string]interface{})
+			p.Args = args
+			return next(p)
+		}
+	}
+}
+
+// sanitizeAll applies sanitizers to every string in value
+func sanitizeAll(value interface{}, sanitizers []InputSanitizer) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		sanitized := make([]interface{}, len(v))
+		for i, item := range v {
+			sanitized[i] = sanitizeAll(item, sanitizers)
+		}
+		return sanitized
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		sanitized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			sanitized[key] = sanitizeAll(item, sanitizers)
+		}
+		return sanitized
+	case string:
+		for _, sanitize := range sanitizers {
+			v = sanitize(v)
+		}
+		return v
+	}
+	return value
+}
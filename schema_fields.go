@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// visitSelectedFields calls visit for every schema field selected by the operation
+// named operationName (or the first operation), following fragments. parent is the
+// object or interface type declaring the field, and node its selection. Selections that
+// do not match the schema are skipped.
+func visitSelectedFields(schema *graphql.Schema, doc *ast.Document, operationName string, visit func(parent graphql.Type, field *graphql.FieldDefinition, node *ast.Field)) {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operation == nil && (operationName == "" || (def.Name != nil && def.Name.Value == operationName)) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return
+	}
+
+	var root *graphql.Object
+	switch operation.Operation {
+	case ast.OperationTypeMutation:
+		root = schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = schema.SubscriptionType()
+	default:
+		root = schema.QueryType()
+	}
+	if root == nil {
+		return
+	}
+
+	var walk func(parent graphql.Type, selectionSet *ast.SelectionSet, spread map[string]bool)
+	walk = func(parent graphql.Type, selectionSet *ast.SelectionSet, spread map[string]bool) {
+		if selectionSet == nil {
+			return
+		}
+
+		for _, selection := range selectionSet.Selections {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				field := schemaField(parent, sel.Name.Value)
+				if field == nil {
+					continue
+				}
+				visit(parent, field, sel)
+				fieldType, _ := graphql.GetNamed(field.Type).(graphql.Type)
+				walk(fieldType, sel.SelectionSet, spread)
+
+			case *ast.InlineFragment:
+				fragmentType := parent
+				if sel.TypeCondition != nil {
+					fragmentType = schema.Type(sel.TypeCondition.Name.Value)
+				}
+				walk(fragmentType, sel.SelectionSet, spread)
+
+			case *ast.FragmentSpread:
+				name := sel.Name.Value
+				fragment, ok := fragments[name]
+				if !ok || spread[name] {
+					continue
+				}
+				spread[name] = true
+				walk(schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet, spread)
+				delete(spread, name)
+			}
+		}
+	}
+
+	walk(root, operation.SelectionSet, make(map[string]bool))
+}
+
+// schemaField returns the definition of a field of an object or interface type
+func schemaField(parent graphql.Type, name string) *graphql.FieldDefinition {
+	switch t := parent.(type) {
+	case *graphql.Object:
+		return t.Fields()[name]
+	case *graphql.Interface:
+		return t.Fields()[name]
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// SchemaVersionHeader carries the version of the schema on every response
+const SchemaVersionHeader = "X-Schema-Version"
+
+// MinSchemaVersionHeader lets clients require a minimum schema version: requests whose
+// minimum is newer than the schema served are rejected with SCHEMA_VERSION_TOO_OLD
+const MinSchemaVersionHeader = "X-Schema-Min-Version"
+
+// SchemaHash returns a short hash of the SDL of schema, suitable as a schema version
+func SchemaHash(schema *graphql.Schema) string {
+	sum := sha256.Sum256([]byte(PrintSchema(schema)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// CompareVersions orders dot separated versions such as "2.14.0", segment by segment:
+// numeric segments by value, others alphabetically. A leading "v" is ignored and
+// missing segments count as 0, so "v2.1" and "2.1.0" are the same version.
+func CompareVersions(a, b string) int {
+	left := strings.Split(strings.TrimPrefix(strings.TrimPrefix(a, "v"), "V"), ".")
+	right := strings.Split(strings.TrimPrefix(strings.TrimPrefix(b, "v"), "V"), ".")
+	for i := 0; i < max(len(left), len(right)); i++ {
+		x, y := "0", "0"
+		if i < len(left) {
+			x = left[i]
+		}
+		if i < len(right) {
+			y = right[i]
+		}
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil && xn != yn:
+			if xn < yn {
+				return -1
+			}
+			return 1
+		case (xErr != nil || yErr != nil) && x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
+
+// SchemaVersionError rejects a request requiring a newer schema than the one served
+type SchemaVersionError struct {
+	Version string // Version served
+	Minimum string // Version required by the client
+}
+
+// Error describes both versions
+func (e *SchemaVersionError) Error() string {
+	return "schema version " + e.Version + " is older than the required version " + e.Minimum
+}
+
+// Extensions exposes the SCHEMA_VERSION_TOO_OLD code and both versions in the GraphQL
+// error
+func (e *SchemaVersionError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":           "SCHEMA_VERSION_TOO_OLD",
+		"schemaVersion":  e.Version,
+		"minimumVersion": e.Minimum,
+	}
+}
+
+// schemaVersioner stamps responses with the schema version and checks the minimum
+// version of requests
+type schemaVersioner struct {
+	version string
+	compare func(a, b string) int
+}
+
+// newSchemaVersioner returns the versioner of the schema of graphCtx, or nil when
+// EnableSchemaVersion is not set
+func newSchemaVersioner(graphCtx *GraphContext, schema *graphql.Schema) *schemaVersioner {
+	if !graphCtx.EnableSchemaVersion {
+		return nil
+	}
+	if graphCtx.SchemaVersion != "" {
+		return &schemaVersioner{version: graphCtx.SchemaVersion, compare: CompareVersions}
+	}
+	// Hashes have no order: only the same hash satisfies a minimum
+	return &schemaVersioner{
+		version: SchemaHash(schema),
+		compare: func(a, b string) int {
+			if a == b {
+				return 0
+			}
+			return -1
+		},
+	}
+}
+
+// stamp sets the version header of the response to r, and returns an error when r
+// requires a newer version
+func (v *schemaVersioner) stamp(w http.ResponseWriter, r *http.Request) *SchemaVersionError {
+	w.Header().Set(SchemaVersionHeader, v.version)
+
+	minimum := strings.TrimSpace(r.Header.Get(MinSchemaVersionHeader))
+	if minimum == "" || v.compare(v.version, minimum) >= 0 {
+		return nil
+	}
+	return &SchemaVersionError{Version: v.version, Minimum: minimum}
+}
+
+// writeSchemaVersionError rejects a request requiring a newer schema version with 412
+// Precondition Failed
+func writeSchemaVersionError(w http.ResponseWriter, err *SchemaVersionError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    err.Error(),
+			"extensions": err.Extensions(),
+		}},
+	})
+}
@@ -0,0 +1,165 @@
+package graph
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// SkipType is returned by SchemaVisitor.Type to skip the fields, arguments and enum
+// values of the type being visited. The walk continues with the next type.
+var SkipType = errors.New("skip this type")
+
+// SchemaVisitor walks a built schema with callbacks, for tooling such as documentation
+// generators, lint rules and instrumentation that needs no SDL parsing. Every callback
+// is optional. Types, fields, arguments and values are visited sorted by name, so walks
+// are stable across builds; a callback returning an error other than SkipType stops
+// the walk with that error.
+//
+// Example:
+//
+//	// Lint: every field of the API must be described
+//	var missing []string
+//	err := graph.SchemaVisitor{
+//	    Field: func(parent graphql.Type, field *graphql.FieldDefinition) error {
+//	        if field.Description == "" {
+//	            missing = append(missing, parent.Name()+"."+field.Name)
+//	        }
+//	        return nil
+//	    },
+//	}.Walk(schema)
+type SchemaVisitor struct {
+	// Directive is called for each directive, after the types
+	Directive func(directive *graphql.Directive) error
+
+	// Type is called for each named type before its members
+	Type func(t graphql.Type) error
+
+	// Field is called for each field of an object or interface type
+	Field func(parent graphql.Type, field *graphql.FieldDefinition) error
+
+	// Argument is called for each argument of a field, after the field
+	Argument func(parent graphql.Type, field *graphql.FieldDefinition, arg *graphql.Argument) error
+
+	// InputField is called for each field of an input object type
+	InputField func(parent *graphql.InputObject, field *graphql.InputObjectField) error
+
+	// EnumValue is called for each value of an enum type
+	EnumValue func(parent *graphql.Enum, value *graphql.EnumValueDefinition) error
+
+	// IncludeBuiltIns also visits the introspection types, the built-in scalars and
+	// the specified directives, which PrintSchema omits
+	IncludeBuiltIns bool
+}
+
+// Walk visits the types of schema, their members, then the directives
+func (v SchemaVisitor) Walk(schema *graphql.Schema) error {
+	typeMap := schema.TypeMap()
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		if v.IncludeBuiltIns || (!strings.HasPrefix(name, "__") && !isBuiltInScalar(name)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := v.walkType(typeMap[name]); err != nil {
+			return err
+		}
+	}
+
+	if v.Directive == nil {
+		return nil
+	}
+	directives := append([]*graphql.Directive(nil), schema.Directives()...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, directive := range directives {
+		if !v.IncludeBuiltIns && isSpecifiedDirective(directive) {
+			continue
+		}
+		if err := v.Directive(directive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkType visits a named type and its members
+func (v SchemaVisitor) walkType(t graphql.Type) error {
+	if v.Type != nil {
+		if err := v.Type(t); err != nil {
+			if errors.Is(err, SkipType) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	switch t := t.(type) {
+	case *graphql.Object:
+		return v.walkFields(t, t.Fields())
+	case *graphql.Interface:
+		return v.walkFields(t, t.Fields())
+	case *graphql.InputObject:
+		if v.InputField == nil {
+			return nil
+		}
+		fields := t.Fields()
+		for _, name := range sortedKeys(fields) {
+			if err := v.InputField(t, fields[name]); err != nil {
+				return err
+			}
+		}
+	case *graphql.Enum:
+		if v.EnumValue == nil {
+			return nil
+		}
+		values := append([]*graphql.EnumValueDefinition(nil), t.Values()...)
+		sort.Slice(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+		for _, value := range values {
+			if err := v.EnumValue(t, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkFields visits the fields of an object or interface type and their arguments
+func (v SchemaVisitor) walkFields(parent graphql.Type, fields graphql.FieldDefinitionMap) error {
+	if v.Field == nil && v.Argument == nil {
+		return nil
+	}
+	for _, name := range sortedKeys(fields) {
+		field := fields[name]
+		if v.Field != nil {
+			if err := v.Field(parent, field); err != nil {
+				return err
+			}
+		}
+		if v.Argument == nil {
+			continue
+		}
+		args := append([]*graphql.Argument(nil), field.Args...)
+		sort.Slice(args, func(i, j int) bool { return args[i].Name() < args[j].Name() })
+		for _, arg := range args {
+			if err := v.Argument(parent, field, arg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
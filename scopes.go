@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ScopedPrincipal is implemented by user details exposing the scopes granted to them.
+// Details returned by UserDetailsFn that implement it, or JWT claims maps with a
+// "scope", "scopes" or "scp" claim, provide the scopes checked by RequireScopes and
+// HasScope. Other details need GraphContext.ScopesFn.
+type ScopedPrincipal interface {
+	Scopes() []string
+}
+
+// ForbiddenError is returned when the principal lacks the scopes a field requires, or
+// when an AuthorizationPolicy denies it
+type ForbiddenError struct {
+	MissingScopes []string
+	Field         string // Schema coordinate of a field denied by the policy
+}
+
+// Error lists the missing scopes, or names the denied field
+func (e *ForbiddenError) Error() string {
+	if len(e.MissingScopes) == 0 {
+		return "forbidden: not authorized to access " + e.Field
+	}
+	return "forbidden: missing scopes " + strings.Join(e.MissingScopes, ", ")
+}
+
+// Extensions exposes the FORBIDDEN code and the missing scopes in the GraphQL error
+func (e *ForbiddenError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": "FORBIDDEN"}
+	if len(e.MissingScopes) > 0 {
+		extensions["missingScopes"] = e.MissingScopes
+	}
+	return extensions
+}
+
+// requestPrincipal holds the authentication result of a request, so resolvers can
+// read it from their context as well as from the root value
+type requestPrincipal struct {
+	mu      sync.Mutex
+	token   string
+	details interface{}
+	scopes  []string
+
+	// fetched caches the user details fetched before execution (see authenticateRequest)
+	fetched *fetchedDetails
+}
+
+type principalContextKey struct{}
+
+// withRequestPrincipal attaches an empty principal to a request, filled in when its root
+// value is built
+func withRequestPrincipal(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalContextKey{}, &requestPrincipal{}))
+}
+
+// principalFromContext returns the principal of the request being served
+func principalFromContext(ctx context.Context) (*requestPrincipal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	principal, ok := ctx.Value(principalContextKey{}).(*requestPrincipal)
+	return principal, ok
+}
+
+// recordPrincipal stores the token and details of a root value in the principal of ctx
+func recordPrincipal(ctx context.Context, graphCtx *GraphContext, rootValue map[string]interface{}) {
+	principal, ok := principalFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	principal.mu.Lock()
+	defer principal.mu.Unlock()
+	principal.token, _ = rootValue["token"].(string)
+	principal.details = rootValue["details"]
+	principal.scopes = principalScopes(graphCtx.ScopesFn, principal.details)
+}
+
+// principalScopes returns the scopes granted to user details
+func principalScopes(scopesFn func(details interface{}) []string, details interface{}) []string {
+	if details == nil {
+		return nil
+	}
+	if scopesFn != nil {
+		return scopesFn(details)
+	}
+
+	switch details := details.(type) {
+	case ScopedPrincipal:
+		return details.Scopes()
+	case map[string]interface{}:
+		for _, claim := range []string{"scope", "scopes", "scp"} {
+			if scopes := claimScopes(details[claim]); scopes != nil {
+				return scopes
+			}
+		}
+	}
+	return nil
+}
+
+// claimScopes reads a scope claim: a space-separated string (RFC 8693) or a list
+func claimScopes(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []string:
+		return claim
+	case []interface{}:
+		scopes := make([]string, 0, len(claim))
+		for _, scope := range claim {
+			if s, ok := scope.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
+
+// ScopesFromContext returns the scopes granted to the principal of the request being
+// served by NewHTTP
+func ScopesFromContext(ctx context.Context) []string {
+	principal, ok := principalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	principal.mu.Lock()
+	defer principal.mu.Unlock()
+	return principal.scopes
+}
+
+// HasScope reports whether the principal of the request being served by NewHTTP was
+// granted scope, for checks inside resolvers.
+//
+// Example:
+//
+//	if !graph.HasScope(p.Context, "users:email") {
+//	    user.Email = ""
+//	}
+func HasScope(ctx context.Context, scope string) bool {
+	return containsString(ScopesFromContext(ctx), scope)
+}
+
+// RequireScopes is a permission middleware allowing only principals granted every one
+// of scopes. Other principals get a FORBIDDEN error listing the missing scopes.
+//
+// Scopes come from the request context under NewHTTP, or from the "details" of the root
+// value when the schema is executed directly.
+//
+// Example:
+//
+//	graph.NewResolver[User]("users").
+//	    WithPermission(graph.RequireScopes("users:read")).
+//	    BuildListQuery()
+func RequireScopes(scopes ...string) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			granted := scopesOf(p)
+
+			var missing []string
+			for _, scope := range scopes {
+				if !containsString(granted, scope) {
+					missing = append(missing, scope)
+				}
+			}
+			if len(missing) > 0 {
+				return nil, &ForbiddenError{MissingScopes: missing}
+			}
+			return next(p)
+		}
+	}
+}
+
+// scopesOf returns the scopes granted to the principal resolving a field
+func scopesOf(p ResolveParams) []string {
+	if _, ok := principalFromContext(p.Context); ok {
+		return ScopesFromContext(p.Context)
+	}
+	if root, ok := p.Info.RootValue.(map[string]interface{}); ok {
+		return principalScopes(nil, root["details"])
+	}
+	return nil
+}
+
+// containsString reports whether values includes value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
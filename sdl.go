@@ -0,0 +1,322 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// PrintSchema returns the schema in the GraphQL schema definition language (SDL), as
+// gateways and schema registries expect it. Types, fields, arguments and enum values
+// are sorted by name so the output is stable across builds. Introspection types,
+// built-in scalars and the specified directives are omitted.
+func PrintSchema(schema *graphql.Schema) string {
+	var blocks []string
+
+	if definition := printSchemaDefinition(schema); definition != "" {
+		blocks = append(blocks, definition)
+	}
+
+	directives := append([]*graphql.Directive(nil), schema.Directives()...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, directive := range directives {
+		if !isSpecifiedDirective(directive) {
+			blocks = append(blocks, printDirective(directive))
+		}
+	}
+
+	typeMap := schema.TypeMap()
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		if !strings.HasPrefix(name, "__") && !isBuiltInScalar(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if block := printType(typeMap[name]); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// printSchemaDefinition prints the schema block when root types use non-default names
+func printSchemaDefinition(schema *graphql.Schema) string {
+	roots := []struct {
+		operation string
+		object    *graphql.Object
+	}{
+		{"query", schema.QueryType()},
+		{"mutation", schema.MutationType()},
+		{"subscription", schema.SubscriptionType()},
+	}
+
+	conventional := true
+	var lines []string
+	for _, root := range roots {
+		if root.object == nil {
+			continue
+		}
+		if root.object.Name() != strings.ToUpper(root.operation[:1])+root.operation[1:] {
+			conventional = false
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", root.operation, root.object.Name()))
+	}
+	if conventional {
+		return ""
+	}
+	return "schema {\n" + strings.Join(lines, "\n") + "\n}"
+}
+
+// isSpecifiedDirective reports whether a directive is one of @include, @skip and @deprecated
+func isSpecifiedDirective(directive *graphql.Directive) bool {
+	for _, specified := range graphql.SpecifiedDirectives {
+		if directive.Name == specified.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuiltInScalar reports whether name is a scalar every GraphQL schema has
+func isBuiltInScalar(name string) bool {
+	switch name {
+	case "String", "Int", "Float", "Boolean", "ID":
+		return true
+	}
+	return false
+}
+
+// printDirective prints a directive definition
+func printDirective(directive *graphql.Directive) string {
+	return printDescription(directive.Description, "") +
+		"directive @" + directive.Name + printArgs(directive.Args, "") +
+		" on " + strings.Join(directive.Locations, " | ")
+}
+
+// printType prints the definition of a named type
+func printType(t graphql.Type) string {
+	switch t := t.(type) {
+	case *graphql.Scalar:
+		return printDescription(t.Description(), "") + "scalar " + t.Name()
+	case *graphql.Object:
+		return printDescription(t.Description(), "") + "type " + t.Name() +
+			printImplements(t.Interfaces()) + printFields(t.Fields())
+	case *graphql.Interface:
+		return printDescription(t.Description(), "") + "interface " + t.Name() + printFields(t.Fields())
+	case *graphql.Union:
+		members := make([]string, 0, len(t.Types()))
+		for _, object := range t.Types() {
+			members = append(members, object.Name())
+		}
+		sort.Strings(members)
+		return printDescription(t.Description(), "") + "union " + t.Name() + " = " + strings.Join(members, " | ")
+	case *graphql.Enum:
+		return printDescription(t.Description(), "") + "enum " + t.Name() + printEnumValues(t.Values())
+	case *graphql.InputObject:
+		return printDescription(t.Description(), "") + "input " + t.Name() + printInputFields(t.Fields())
+	}
+	return ""
+}
+
+// printImplements prints the interfaces clause of an object type
+func printImplements(interfaces []*graphql.Interface) string {
+	if len(interfaces) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(interfaces))
+	for _, iface := range interfaces {
+		names = append(names, iface.Name())
+	}
+	sort.Strings(names)
+	return " implements " + strings.Join(names, " & ")
+}
+
+// printFields prints the fields block of an object or interface type
+func printFields(fields graphql.FieldDefinitionMap) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		field := fields[name]
+		lines = append(lines, printDescription(field.Description, "  ")+
+			"  "+name+printArgs(field.Args, "  ")+": "+field.Type.String()+
+			printDeprecated(field.DeprecationReason))
+	}
+	return printBlock(lines)
+}
+
+// printArgs prints the arguments of a field or directive, one per line when any has
+// a description
+func printArgs(args []*graphql.Argument, indent string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	sorted := append([]*graphql.Argument(nil), args...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+
+	described := false
+	printed := make([]string, 0, len(sorted))
+	for _, arg := range sorted {
+		described = described || arg.Description() != ""
+		printed = append(printed, arg.Name()+": "+arg.Type.String()+printDefault(arg.DefaultValue, arg.Type))
+	}
+	if !described {
+		return "(" + strings.Join(printed, ", ") + ")"
+	}
+
+	lines := make([]string, len(sorted))
+	for i, arg := range sorted {
+		lines[i] = printDescription(arg.Description(), indent+"  ") + indent + "  " + printed[i]
+	}
+	return "(\n" + strings.Join(lines, "\n") + "\n" + indent + ")"
+}
+
+// printInputFields prints the fields block of an input object type
+func printInputFields(fields graphql.InputObjectFieldMap) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		field := fields[name]
+		lines = append(lines, printDescription(field.Description(), "  ")+
+			"  "+name+": "+field.Type.String()+printDefault(field.DefaultValue, field.Type))
+	}
+	return printBlock(lines)
+}
+
+// printEnumValues prints the values block of an enum type
+func printEnumValues(values []*graphql.EnumValueDefinition) string {
+	sorted := append([]*graphql.EnumValueDefinition(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	lines := make([]string, 0, len(sorted))
+	for _, value := range sorted {
+		lines = append(lines, printDescription(value.Description, "  ")+
+			"  "+value.Name+printDeprecated(value.DeprecationReason))
+	}
+	return printBlock(lines)
+}
+
+// printBlock wraps lines in braces, omitting the block when it is empty
+func printBlock(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return " {\n" + strings.Join(lines, "\n") + "\n}"
+}
+
+// printDeprecated prints the @deprecated directive of a field or enum value
+func printDeprecated(reason string) string {
+	switch reason {
+	case "":
+		return ""
+	case graphql.DefaultDeprecationReason:
+		return " @deprecated"
+	}
+	return " @deprecated(reason: " + printString(reason) + ")"
+}
+
+// printDescription prints a description above a definition, as a block string when it
+// spans several lines
+func printDescription(description, indent string) string {
+	if description == "" {
+		return ""
+	}
+	if !strings.Contains(description, "\n") {
+		return indent + printString(description) + "\n"
+	}
+
+	escaped := strings.ReplaceAll(description, `"""`, `\"""`)
+	lines := strings.Split(escaped, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return indent + `"""` + "\n" + strings.Join(lines, "\n") + "\n" + indent + `"""` + "\n"
+}
+
+// printString prints a GraphQL string literal
+func printString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// printDefault prints the default value clause of an argument or input field
+func printDefault(value interface{}, t graphql.Type) string {
+	if value == nil {
+		return ""
+	}
+	return " = " + printValue(value, t)
+}
+
+// printValue prints a Go value as a GraphQL literal of type t
+func printValue(value interface{}, t graphql.Type) string {
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+	}
+	if value == nil {
+		return "null"
+	}
+
+	switch t := t.(type) {
+	case *graphql.Enum:
+		for _, enumValue := range t.Values() {
+			if reflect.DeepEqual(enumValue.Value, value) {
+				return enumValue.Name
+			}
+		}
+	case *graphql.List:
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return printValue(value, t.OfType)
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = printValue(v.Index(i).Interface(), t.OfType)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *graphql.InputObject:
+		if fields, ok := value.(map[string]interface{}); ok {
+			names := make([]string, 0, len(fields))
+			for name := range fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			printed := make([]string, 0, len(names))
+			for _, name := range names {
+				var fieldType graphql.Type = graphql.String
+				if field, ok := t.Fields()[name]; ok {
+					fieldType = field.Type
+				}
+				printed = append(printed, name+": "+printValue(fields[name], fieldType))
+			}
+			return "{" + strings.Join(printed, ", ") + "}"
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		return printString(value)
+	case fmt.Stringer:
+		return printString(value.String())
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Selection is the tree of sub-fields requested on a field, keyed by field name.
+// Fields selected through fragments and under several aliases are merged; leaf
+// fields map to an empty Selection.
+type Selection map[string]Selection
+
+// SelectedFields returns the sub-fields requested on the field being resolved,
+// following fragments and honoring @skip and @include. Resolvers can use it for
+// column projection or to take cheaper code paths.
+//
+// Example:
+//
+//	func(p graph.ResolveParams) (*User, error) {
+//	    fields := graph.SelectedFields(p)
+//	    if fields.Has("posts.comments") {
+//	        return loadUserWithComments(p.Context, id)
+//	    }
+//	    return loadUser(p.Context, id, fields.Names())
+//	}
+func SelectedFields(p ResolveParams) Selection {
+	selection := Selection{}
+	for _, field := range p.Info.FieldASTs {
+		collectSelection(p, field.SelectionSet, selection)
+	}
+	return selection
+}
+
+// collectSelection merges the fields of selectionSet into selection
+func collectSelection(p ResolveParams, selectionSet *ast.SelectionSet, selection Selection) {
+	if selectionSet == nil {
+		return
+	}
+
+	for _, node := range selectionSet.Selections {
+		switch sel := node.(type) {
+		case *ast.Field:
+			if !shouldIncludeNode(p, sel.Directives) {
+				continue
+			}
+			child, ok := selection[sel.Name.Value]
+			if !ok {
+				child = Selection{}
+				selection[sel.Name.Value] = child
+			}
+			collectSelection(p, sel.SelectionSet, child)
+
+		case *ast.InlineFragment:
+			if shouldIncludeNode(p, sel.Directives) {
+				collectSelection(p, sel.SelectionSet, selection)
+			}
+
+		case *ast.FragmentSpread:
+			if !shouldIncludeNode(p, sel.Directives) {
+				continue
+			}
+			if fragment, ok := p.Info.Fragments[sel.Name.Value].(*ast.FragmentDefinition); ok {
+				collectSelection(p, fragment.SelectionSet, selection)
+			}
+		}
+	}
+}
+
+// shouldIncludeNode evaluates the @skip and @include directives of a selection
+func shouldIncludeNode(p ResolveParams, directives []*ast.Directive) bool {
+	return includeNode(directives, p.Info.VariableValues)
+}
+
+// includeNode evaluates the @skip and @include directives of a selection against
+// variable values. Like the executor, a condition that is not a boolean, such as a
+// variable without a value, leaves the selection included.
+func includeNode(directives []*ast.Directive, variables map[string]interface{}) bool {
+	for _, directive := range directives {
+		name := directive.Name.Value
+		if name != "skip" && name != "include" {
+			continue
+		}
+		for _, arg := range directive.Arguments {
+			if arg.Name.Value != "if" {
+				continue
+			}
+			condition, ok := directiveCondition(arg.Value, variables)
+			if !ok {
+				continue
+			}
+			if (name == "skip" && condition) || (name == "include" && !condition) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// directiveCondition returns the boolean value of a directive argument, and whether it is known
+func directiveCondition(value ast.Value, variables map[string]interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case *ast.BooleanValue:
+		return v.Value, true
+	case *ast.Variable:
+		condition, ok := variables[v.Name.Value].(bool)
+		return condition, ok
+	}
+	return false, false
+}
+
+// Has reports whether a field is selected. Nested fields are addressed with dots,
+// e.g. "posts.author.name".
+func (s Selection) Has(path string) bool {
+	current := s
+	for _, name := range strings.Split(path, ".") {
+		next, ok := current[name]
+		if !ok {
+			return false
+		}
+		current = next
+	}
+	return true
+}
+
+// Get returns the sub-selection at a dotted path, or nil when it is not selected
+func (s Selection) Get(path string) Selection {
+	current := s
+	for _, name := range strings.Split(path, ".") {
+		next, ok := current[name]
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// Names returns the selected field names at this level, sorted, excluding __typename
+func (s Selection) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		if name != "__typename" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,304 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SentryOptions configures a SentryReporter
+type SentryOptions struct {
+	// DSN of the Sentry project, e.g. https://public@o0.ingest.sentry.io/42
+	DSN string
+
+	// Environment, Release and ServerName tag every event. ServerName defaults to the
+	// hostname.
+	Environment string
+	Release     string
+	ServerName  string
+
+	// HTTPClient sends the events. Default: a client with a 10 second timeout
+	HTTPClient *http.Client
+
+	// QueueSize is the number of events waiting to be sent; events reported while the
+	// queue is full are dropped. Default: 100
+	QueueSize int
+}
+
+// SentryReporter is an ErrorReporter sending events to Sentry (or a compatible service
+// such as GlitchTip) over its HTTP API, without the Sentry SDK. Events carry the
+// GraphQL operation, field and path as tags and in a "graphql" context, the panic stack
+// and the method and URL of the request. They are sent in the background; call Close
+// on shutdown to deliver the events still queued.
+//
+// Example:
+//
+//	reporter, err := graph.NewSentryReporter(graph.SentryOptions{
+//	    DSN:         os.Getenv("SENTRY_DSN"),
+//	    Environment: "production",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer reporter.Close(context.Background())
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams:  params,
+//	    ErrorReporter: reporter,
+//	})
+type SentryReporter struct {
+	endpoint string
+	auth     string
+	dsn      string
+	options  SentryOptions
+	client   *http.Client
+
+	mu     sync.RWMutex
+	queue  chan []byte
+	closed bool
+	done   chan struct{}
+}
+
+// NewSentryReporter creates a reporter sending events to the project of options.DSN
+func NewSentryReporter(options SentryOptions) (*SentryReporter, error) {
+	dsn, err := url.Parse(options.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	key := ""
+	if dsn.User != nil {
+		key = dsn.User.Username()
+	}
+	slash := strings.LastIndex(dsn.Path, "/")
+	if key == "" || dsn.Host == "" || slash < 0 || dsn.Path[slash+1:] == "" {
+		return nil, errors.New("invalid Sentry DSN: expected scheme://key@host/project")
+	}
+	project := dsn.Path[slash+1:]
+
+	if options.ServerName == "" {
+		options.ServerName, _ = os.Hostname()
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 100
+	}
+	client := options.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	reporter := &SentryReporter{
+		endpoint: dsn.Scheme + "://" + dsn.Host + dsn.Path[:slash] + "/api/" + project + "/envelope/",
+		auth:     "Sentry sentry_version=7, sentry_client=go-graph/1.0, sentry_key=" + key,
+		dsn:      options.DSN,
+		options:  options,
+		client:   client,
+		queue:    make(chan []byte, options.QueueSize),
+		done:     make(chan struct{}),
+	}
+	go reporter.send()
+	return reporter, nil
+}
+
+// Report queues an event for report, dropping it when the queue is full or the
+// reporter is closed
+func (s *SentryReporter) Report(ctx context.Context, report ErrorReport) {
+	envelope, err := s.envelope(ctx, report)
+	if err != nil {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- envelope:
+	default:
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are sent, or ctx is done
+func (s *SentryReporter) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send posts the queued envelopes until the reporter is closed
+func (s *SentryReporter) send() {
+	defer close(s.done)
+	for envelope := range s.queue {
+		req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(envelope))
+		if err != nil {
+			continue
+		}
+		req.Header.Set("Content-Type", "application/x-sentry-envelope")
+		req.Header.Set("X-Sentry-Auth", s.auth)
+		resp, err := s.client.Do(req)
+		if err != nil {
+			continue
+		}
+		_ = resp.Body.Close()
+	}
+}
+
+// sentryFrame is a frame of a Sentry stack trace
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// envelope encodes report as a Sentry envelope holding one event
+func (s *SentryReporter) envelope(ctx context.Context, report ErrorReport) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	eventID := hex.EncodeToString(id)
+	now := time.Now().UTC()
+
+	exception := map[string]interface{}{
+		"type":  fmt.Sprintf("%T", report.Err),
+		"value": report.Err.Error(),
+	}
+	if report.Panic {
+		exception["type"] = "panic"
+		exception["mechanism"] = map[string]interface{}{"type": "graphql", "handled": false}
+	}
+	if len(report.Stack) > 0 {
+		// Sentry lists frames outermost first
+		frames := make([]sentryFrame, 0, len(report.Stack))
+		for i := len(report.Stack) - 1; i >= 0; i-- {
+			frame := report.Stack[i]
+			module, function := splitFunctionName(frame.Function)
+			frames = append(frames, sentryFrame{
+				Function: function,
+				Module:   module,
+				Filename: frame.File[strings.LastIndex(frame.File, "/")+1:],
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    isAppModule(module),
+			})
+		}
+		exception["stacktrace"] = map[string]interface{}{"frames": frames}
+	}
+
+	transaction := report.OperationName
+	if transaction == "" {
+		transaction = report.Field
+	}
+	event := map[string]interface{}{
+		"event_id":    eventID,
+		"timestamp":   now.Format(time.RFC3339Nano),
+		"level":       "error",
+		"platform":    "go",
+		"logger":      "graphql",
+		"transaction": transaction,
+		"server_name": s.options.ServerName,
+		"tags": map[string]string{
+			"graphql.operation": report.OperationName,
+			"graphql.field":     report.Field,
+			"graphql.panic":     strconv.FormatBool(report.Panic),
+		},
+		"contexts": map[string]interface{}{
+			"graphql": map[string]interface{}{
+				"operation": report.OperationName,
+				"field":     report.Field,
+				"path":      report.Path,
+			},
+		},
+		"exception": map[string]interface{}{"values": []interface{}{exception}},
+	}
+	if s.options.Environment != "" {
+		event["environment"] = s.options.Environment
+	}
+	if s.options.Release != "" {
+		event["release"] = s.options.Release
+	}
+	if r, ok := RequestFromContext(ctx); ok {
+		// The query string is left out: it may carry variables
+		event["request"] = map[string]interface{}{
+			"method": r.Method,
+			"url":    (&url.URL{Scheme: requestScheme(r), Host: r.Host, Path: r.URL.Path}).String(),
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	header, _ := json.Marshal(map[string]string{
+		"event_id": eventID,
+		"sent_at":  now.Format(time.RFC3339Nano),
+		"dsn":      s.dsn,
+	})
+	item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(payload)})
+
+	var envelope bytes.Buffer
+	envelope.Grow(len(header) + len(item) + len(payload) + 3)
+	envelope.Write(header)
+	envelope.WriteByte('\n')
+	envelope.Write(item)
+	envelope.WriteByte('\n')
+	envelope.Write(payload)
+	envelope.WriteByte('\n')
+	return envelope.Bytes(), nil
+}
+
+// splitFunctionName splits a qualified Go function name into its package path and its
+// name, e.g. "example.com/app/users.(*Store).Get" into "example.com/app/users" and
+// "(*Store).Get"
+func splitFunctionName(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// isAppModule reports whether frames of module belong to the application rather than
+// to the runtime, the GraphQL executor or this package
+func isAppModule(module string) bool {
+	for _, prefix := range []string{"runtime", "net/http", "github.com/graphql-go/", "github.com/paulmanoni/go-graph"} {
+		if strings.HasPrefix(module, prefix) {
+			return false
+		}
+	}
+	return module != ""
+}
+
+// requestScheme returns the scheme a request was received with
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
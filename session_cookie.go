@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultAuthCookieName is the name of the session cookie when CookieOptions.Name is empty
+const DefaultAuthCookieName = "session"
+
+// CookieOptions configures the session cookie of browser apps. The cookie is always
+// HttpOnly, so scripts cannot read the token.
+type CookieOptions struct {
+	Name   string        // Default: DefaultAuthCookieName
+	Path   string        // Default: "/"
+	Domain string        // Default: the host of the request
+	MaxAge time.Duration // Default: 0, a session cookie deleted when the browser closes
+
+	// Insecure allows the cookie over plain HTTP, for local development. By default the
+	// cookie is Secure.
+	Insecure bool
+
+	// SameSite defaults to http.SameSiteLaxMode, which keeps the cookie off cross-site
+	// POST requests and so protects mutations against CSRF
+	SameSite http.SameSite
+}
+
+// withDefaults fills in the defaults of unset options
+func (o CookieOptions) withDefaults() CookieOptions {
+	if o.Name == "" {
+		o.Name = DefaultAuthCookieName
+	}
+	if o.Path == "" {
+		o.Path = "/"
+	}
+	if o.SameSite == 0 {
+		o.SameSite = http.SameSiteLaxMode
+	}
+	return o
+}
+
+// cookie returns the session cookie holding value
+func (o CookieOptions) cookie(value string) *http.Cookie {
+	o = o.withDefaults()
+	cookie := &http.Cookie{
+		Name:     o.Name,
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		HttpOnly: true,
+		Secure:   !o.Insecure,
+		SameSite: o.SameSite,
+	}
+	if o.MaxAge > 0 {
+		cookie.MaxAge = int(o.MaxAge / time.Second)
+		cookie.Expires = time.Now().Add(o.MaxAge)
+	}
+	return cookie
+}
+
+// SetAuthCookie stores token in the session cookie of the response, typically from a
+// login mutation. Read it back with CookieTokenExtractor.
+//
+// Example:
+//
+//	Resolve: func(p graph.ResolveParams) (interface{}, error) {
+//	    token, err := auth.Login(p.Args["email"].(string), p.Args["password"].(string))
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    w, _ := graph.ResponseWriterFromContext(p.Context)
+//	    graph.SetAuthCookie(w, token, graph.CookieOptions{MaxAge: 24 * time.Hour})
+//	    return true, nil
+//	}
+func SetAuthCookie(w http.ResponseWriter, token string, opts CookieOptions) {
+	http.SetCookie(w, opts.cookie(token))
+}
+
+// ClearAuthCookie deletes the session cookie, typically from a logout mutation. opts
+// must name the same cookie, path and domain as when it was set.
+func ClearAuthCookie(w http.ResponseWriter, opts CookieOptions) {
+	cookie := opts.cookie("")
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0)
+	http.SetCookie(w, cookie)
+}
+
+// CookieTokenExtractor returns a TokenExtractorFn reading the token from the session
+// cookie set by SetAuthCookie with the same options.
+//
+// Example:
+//
+//	graphCtx := &graph.GraphContext{
+//	    SchemaParams:     params,
+//	    TokenExtractorFn: graph.CookieTokenExtractor(graph.CookieOptions{}),
+//	}
+func CookieTokenExtractor(opts CookieOptions) func(*http.Request) string {
+	name := opts.withDefaults().Name
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// responseWriterContextKey stores the http.ResponseWriter in the resolver context
+type responseWriterContextKey struct{}
+
+// ResponseWriterFromContext returns the writer of the response being served by NewHTTP,
+// so resolvers can set headers and cookies, e.g. with SetAuthCookie. Headers must be
+// set while resolving, before the response is written.
+func ResponseWriterFromContext(ctx context.Context) (http.ResponseWriter, bool) {
+	w, ok := ctx.Value(responseWriterContextKey{}).(http.ResponseWriter)
+	return w, ok
+}
+
+// withResponseWriter exposes w to the resolvers of r
+func withResponseWriter(r *http.Request, w http.ResponseWriter) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), responseWriterContextKey{}, w))
+}
@@ -0,0 +1,209 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// SlowQueryConfig configures the slow operation log
+//
+// Example:
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams: params,
+//	    SlowQueries: &graph.SlowQueryConfig{
+//	        Threshold:         500 * time.Millisecond,
+//	        ResolverThreshold: 200 * time.Millisecond,
+//	    },
+//	})
+type SlowQueryConfig struct {
+	// Threshold logs operations taking longer in total. Default: 1s
+	Threshold time.Duration
+
+	// ResolverThreshold logs operations with a single resolver taking longer, whatever
+	// their total duration. Default: 0 (disabled)
+	ResolverThreshold time.Duration
+
+	// Logger receives one JSON line per slow operation. Default: log.Default()
+	Logger Logger
+
+	// RedactVariables lists variable names, at any depth and case insensitive, whose
+	// values are replaced with RedactedString. Nil uses DefaultRedactedArguments.
+	RedactVariables []string
+
+	// MaxResolvers caps the fields listed in the timing breakdown, which lists the
+	// fields with the longest total duration first. Default: 20
+	MaxResolvers int
+}
+
+// SlowOperation is an operation written to the slow operation log
+type SlowOperation struct {
+	StartedAt     time.Time              `json:"startedAt"`
+	Duration      time.Duration          `json:"duration"` // Nanoseconds in JSON
+	Reason        string                 `json:"reason"`   // "operation" or "resolver": the threshold exceeded
+	Client        string                 `json:"client"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"` // Redacted per SlowQueryConfig
+	Depth         int                    `json:"depth"`
+	Complexity    int                    `json:"complexity"`
+	Errors        int                    `json:"errors,omitempty"`
+	Resolvers     []ResolverTiming       `json:"resolvers"`
+}
+
+// ResolverTiming aggregates the calls of a field in a slow operation
+type ResolverTiming struct {
+	Field string        `json:"field"` // Schema coordinate, e.g. "Post.author"
+	Calls int           `json:"calls"`
+	Total time.Duration `json:"total"` // Nanoseconds in JSON
+	Max   time.Duration `json:"max"`   // Nanoseconds in JSON
+}
+
+// slowQueryExecutor times the operations it executes and logs the slow ones
+type slowQueryExecutor struct {
+	Executor
+	config *SlowQueryConfig
+	logger Logger
+}
+
+// newSlowQueryExecutor wraps executor with the slow operation log of config
+func newSlowQueryExecutor(executor Executor, config *SlowQueryConfig) slowQueryExecutor {
+	logger := config.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	return slowQueryExecutor{Executor: executor, config: config, logger: logger}
+}
+
+// Execute runs a query or mutation, timing its resolvers, and logs it when slow
+func (e slowQueryExecutor) Execute(params ExecuteParams) *graphql.Result {
+	ctx := params.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Explained operations share their trace
+	trace := explainTraceFrom(ctx)
+	if trace == nil {
+		trace = newExplainTrace()
+		params.Context = context.WithValue(ctx, explainContextKey{}, trace)
+	}
+
+	start := time.Now()
+	result := e.Executor.Execute(params)
+	duration := time.Since(start)
+
+	threshold := e.config.Threshold
+	if threshold <= 0 {
+		threshold = time.Second
+	}
+	if duration <= threshold && e.config.ResolverThreshold <= 0 {
+		return result
+	}
+
+	resolvers := aggregateResolverTimings(trace)
+	reason := ""
+	if duration > threshold {
+		reason = "operation"
+	} else {
+		for _, timing := range resolvers {
+			if timing.Max > e.config.ResolverThreshold {
+				reason = "resolver"
+				break
+			}
+		}
+	}
+	if reason == "" {
+		return result
+	}
+
+	e.log(params, result, SlowOperation{
+		StartedAt: start,
+		Duration:  duration,
+		Reason:    reason,
+		Resolvers: resolvers,
+	})
+	return result
+}
+
+// log completes operation with the request, its cost and its redacted variables, and
+// writes it as a JSON line
+func (e slowQueryExecutor) log(params ExecuteParams, result *graphql.Result, operation SlowOperation) {
+	operation.Client = unknownClient
+	if r, ok := RequestFromContext(params.Context); ok {
+		operation.Client = clientName(r)
+	}
+	operation.OperationName = params.OperationName
+	operation.Query = params.Query
+	operation.Errors = len(result.Errors)
+	if len(params.Variables) > 0 {
+		names := e.config.RedactVariables
+		if names == nil {
+			names = DefaultRedactedArguments
+		}
+		operation.Variables = redactValue(params.Variables, names).(map[string]interface{})
+	}
+	if doc, err := parseGraphQLQuery(params.Query); err == nil {
+		operation.Depth = calculateQueryDepth(doc, 0, nil)
+		operation.Complexity = calculateQueryComplexity(doc, 1, nil)
+		if operation.OperationName == "" {
+			for _, definition := range doc.Definitions {
+				if op, ok := definition.(*ast.OperationDefinition); ok && op.Name != nil {
+					operation.OperationName = op.Name.Value
+					break
+				}
+			}
+		}
+	}
+
+	maxResolvers := e.config.MaxResolvers
+	if maxResolvers <= 0 {
+		maxResolvers = 20
+	}
+	if len(operation.Resolvers) > maxResolvers {
+		operation.Resolvers = operation.Resolvers[:maxResolvers]
+	}
+
+	var line bytes.Buffer
+	encoder := json.NewEncoder(&line)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(operation); err == nil {
+		e.logger.Printf("slow GraphQL operation: %s", bytes.TrimSuffix(line.Bytes(), []byte("\n")))
+	}
+}
+
+// aggregateResolverTimings sums the resolver calls of trace by field, by decreasing
+// total duration
+func aggregateResolverTimings(trace *explainTrace) []ResolverTiming {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	byField := make(map[string]*ResolverTiming)
+	var timings []*ResolverTiming
+	for _, field := range trace.fields {
+		timing, ok := byField[field.Field]
+		if !ok {
+			timing = &ResolverTiming{Field: field.Field}
+			byField[field.Field] = timing
+			timings = append(timings, timing)
+		}
+		timing.Calls++
+		timing.Total += field.Duration
+		if field.Duration > timing.Max {
+			timing.Max = field.Duration
+		}
+	}
+
+	sorted := make([]ResolverTiming, len(timings))
+	for i, timing := range timings {
+		sorted[i] = *timing
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Total > sorted[j].Total })
+	return sorted
+}
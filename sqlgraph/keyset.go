@@ -0,0 +1,298 @@
+package sqlgraph
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/paulmanoni/go-graph"
+)
+
+// ErrInvalidCursor is returned for cursors that were not encoded by EncodeCursor, or
+// do not hold the sort keys of the keyset they are used with
+var ErrInvalidCursor = errors.New("sqlgraph: invalid cursor")
+
+// SortKey is a column rows are ordered by
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// Asc orders rows by column, ascending
+func Asc(column string) SortKey {
+	return SortKey{Column: column}
+}
+
+// Desc orders rows by column, descending
+func Desc(column string) SortKey {
+	return SortKey{Column: column, Descending: true}
+}
+
+// EncodeCursor encodes the sort key values of a row as an opaque cursor
+func EncodeCursor(values ...interface{}) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes the sort key values of a cursor into dest, pointers to values
+// of the types the cursor was encoded from
+func DecodeCursor(cursor string, dest ...interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != len(dest) {
+		return ErrInvalidCursor
+	}
+	for i, value := range values {
+		if err := json.Unmarshal(value, dest[i]); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+	return nil
+}
+
+// KeysetPredicate returns the condition selecting the rows after values in the order of
+// keys, or before them when before is set, with ? placeholders bound to the returned
+// arguments. Keys sharing one direction compare as a row value, (a, b) > (?, ?); mixed
+// directions expand to (a > ?) OR (a = ? AND b < ?). Sort keys must not be NULL.
+func KeysetPredicate(keys []SortKey, values []interface{}, before bool) (string, []interface{}) {
+	uniform := true
+	for _, key := range keys[1:] {
+		uniform = uniform && key.Descending == keys[0].Descending
+	}
+
+	if uniform {
+		columns := make([]string, len(keys))
+		placeholders := make([]string, len(keys))
+		for i, key := range keys {
+			columns[i] = key.Column
+			placeholders[i] = "?"
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), keysetOperator(keys[0], before),
+			strings.Join(placeholders, ", ")), values
+	}
+
+	var terms []string
+	var args []interface{}
+	for i, key := range keys {
+		var conditions []string
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		conditions = append(conditions, key.Column+" "+keysetOperator(key, before)+" ?")
+		args = append(args, values[i])
+		terms = append(terms, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return "(" + strings.Join(terms, " OR ") + ")", args
+}
+
+// keysetOperator returns the comparison selecting the rows after a key, or before it
+func keysetOperator(key SortKey, before bool) string {
+	if key.Descending != before {
+		return "<"
+	}
+	return ">"
+}
+
+// Keyset pages through the rows of a table in the order of sort keys, selecting each
+// page with a predicate on the keys of the last row seen instead of an OFFSET scan.
+// Back the sort keys with an index. It is safe for concurrent use.
+type Keyset[T any] struct {
+	table   *Table[T]
+	keys    []SortKey
+	columns []column // Columns of the sort keys
+}
+
+// Keyset pages through the table in the order of keys. The primary key is appended
+// as the last sort key, so rows with equal keys keep a stable order, unless keys
+// already include it. It panics when a key is not a column of the table.
+//
+// Example:
+//
+//	posts := sqlgraph.NewTable[Post](db, "posts")
+//	recent := posts.Keyset(sqlgraph.Desc("published_at"))
+//
+//	graph.NewResolver[graph.PaginatedResponse[Post]]("posts").
+//	    AsPaginated().
+//	    WithResolver(recent.PageResolver("author_id = ?", "authorId")).
+//	    BuildQuery()
+func (t *Table[T]) Keyset(keys ...SortKey) *Keyset[T] {
+	k := &Keyset[T]{table: t}
+	hasKey := false
+	for _, key := range keys {
+		hasKey = hasKey || key.Column == t.key
+	}
+	if !hasKey && t.key != "" {
+		keys = append(keys, Asc(t.key))
+	}
+
+	for _, key := range keys {
+		c, ok := t.column(key.Column)
+		if !ok {
+			panic(fmt.Sprintf("sqlgraph: sort key %q is not a column of %s", key.Column, t.name))
+		}
+		k.keys = append(k.keys, key)
+		k.columns = append(k.columns, c)
+	}
+	if len(k.keys) == 0 {
+		panic(fmt.Sprintf("sqlgraph: keyset of %s has no sort keys", t.name))
+	}
+	return k
+}
+
+// Cursor returns the cursor of row, from the values of its sort keys
+func (k *Keyset[T]) Cursor(row T) (string, error) {
+	v := reflect.ValueOf(&row).Elem()
+	values := make([]interface{}, len(k.columns))
+	for i, c := range k.columns {
+		values[i] = fieldByIndex(v, c.index).Interface()
+	}
+	return EncodeCursor(values...)
+}
+
+// Predicate returns the condition selecting the rows after cursor, or before it when
+// before is set, with ? placeholders bound to the returned arguments
+func (k *Keyset[T]) Predicate(cursor string, before bool) (string, []interface{}, error) {
+	var zero T
+	typ := reflect.TypeOf(zero)
+	dest := make([]interface{}, len(k.columns))
+	for i, c := range k.columns {
+		dest[i] = reflect.New(typ.FieldByIndex(c.index).Type).Interface()
+	}
+	if err := DecodeCursor(cursor, dest...); err != nil {
+		return "", nil, err
+	}
+
+	values := make([]interface{}, len(dest))
+	for i, d := range dest {
+		values[i] = reflect.ValueOf(d).Elem().Interface()
+	}
+	predicate, args := KeysetPredicate(k.keys, values, before)
+	return predicate, args, nil
+}
+
+// OrderBy returns the ORDER BY clause of the keyset, reversed when reverse is set
+func (k *Keyset[T]) OrderBy(reverse bool) string {
+	terms := make([]string, len(k.keys))
+	for i, key := range k.keys {
+		direction := "ASC"
+		if key.Descending != reverse {
+			direction = "DESC"
+		}
+		terms[i] = key.Column + " " + direction
+	}
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
+
+// PageResolver returns a resolver of a paginated field loading pages of the rows
+// matching where, with ? placeholders bound to the arguments argNames in order. An
+// empty where pages through every row.
+//
+// Pages follow the first/after and last/before arguments of AsPaginated. Each page
+// selects one row past its size to tell whether another page follows; the cursors
+// of its first and last rows are the start and end cursors of its PageInfo. The
+// total count is left to WithTotalCount. Soft deleted rows are left out unless the
+// includeDeleted argument is true.
+func (k *Keyset[T]) PageResolver(where string, argNames ...string) func(p graph.ResolveParams) (*graph.PaginatedResponse[T], error) {
+	return func(p graph.ResolveParams) (*graph.PaginatedResponse[T], error) {
+		var conditions []string
+		var args []interface{}
+		if where != "" {
+			conditions = append(conditions, "("+where+")")
+			for _, name := range argNames {
+				args = append(args, p.Args[name])
+			}
+		}
+
+		if condition := k.table.deletedCondition(p); condition != "" {
+			conditions = append(conditions, condition)
+		}
+
+		after, _ := p.Args["after"].(string)
+		before, _ := p.Args["before"].(string)
+		for _, bound := range []struct {
+			cursor string
+			before bool
+		}{{after, false}, {before, true}} {
+			if bound.cursor == "" {
+				continue
+			}
+			predicate, values, err := k.Predicate(bound.cursor, bound.before)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, predicate)
+			args = append(args, values...)
+		}
+
+		// last without first pages backwards from the end
+		limit, forward := graph.DefaultPageLimit, true
+		if first, ok := p.Args["first"].(int); ok {
+			limit = first
+		} else if last, ok := p.Args["last"].(int); ok {
+			limit, forward = last, false
+		}
+
+		query := k.OrderBy(!forward) + " LIMIT ?"
+		if len(conditions) > 0 {
+			query = strings.Join(conditions, " AND ") + " " + query
+		}
+		rows, err := k.table.Query(p.Context, k.selectColumns(p), query, append(args, limit+1)...)
+		if err != nil {
+			return nil, err
+		}
+
+		more := len(rows) > limit
+		if more {
+			rows = rows[:limit]
+		}
+		if !forward {
+			for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+				rows[i], rows[j] = rows[j], rows[i]
+			}
+		}
+
+		page := &graph.PaginatedResponse[T]{Items: rows}
+		if forward {
+			page.PageInfo.HasNextPage = more
+			page.PageInfo.HasPreviousPage = after != ""
+		} else {
+			page.PageInfo.HasNextPage = before != ""
+			page.PageInfo.HasPreviousPage = more
+		}
+		if len(rows) > 0 {
+			if page.PageInfo.StartCursor, err = k.Cursor(rows[0]); err != nil {
+				return nil, err
+			}
+			if page.PageInfo.EndCursor, err = k.Cursor(rows[len(rows)-1]); err != nil {
+				return nil, err
+			}
+		}
+		return page, nil
+	}
+}
+
+// selectColumns returns the columns of the item fields the query requests, with the
+// sort keys the cursors are made of
+func (k *Keyset[T]) selectColumns(p graph.ResolveParams) []string {
+	columns := k.table.columnsFor(graph.SelectedFields(p).Get("items"))
+	for _, c := range k.columns {
+		found := false
+		for _, name := range columns {
+			found = found || name == c.name
+		}
+		if !found {
+			columns = append(columns, c.name)
+		}
+	}
+	return columns
+}
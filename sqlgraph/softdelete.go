@@ -0,0 +1,190 @@
+package sqlgraph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/paulmanoni/go-graph"
+)
+
+// IncludeDeletedArg is the argument of queries over soft deleting tables that includes
+// the soft deleted rows when true
+const IncludeDeletedArg = "includeDeleted"
+
+// IncludeDeletedArgs returns args with the includeDeleted argument, for the queries of
+// soft deleting tables
+//
+// Example:
+//
+//	graph.NewResolver[[]User]("users").
+//	    AsList().
+//	    WithArgs(sqlgraph.IncludeDeletedArgs(nil)).
+//	    WithResolver(users.ListResolver("")).
+//	    BuildQuery()
+func IncludeDeletedArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	merged := graphql.FieldConfigArgument{
+		IncludeDeletedArg: &graphql.ArgumentConfig{
+			Type:         graphql.Boolean,
+			DefaultValue: false,
+			Description:  "Include deleted items",
+		},
+	}
+	for name, arg := range args {
+		merged[name] = arg
+	}
+	return merged
+}
+
+// clauseTail matches the clauses following the condition of a where
+var clauseTail = regexp.MustCompile(`(?i)\b(GROUP\s+BY|ORDER\s+BY|LIMIT)\b`)
+
+// withoutDeleted adds the condition leaving out soft deleted rows to where, unless the
+// table doesn't soft delete or the query includes deleted rows
+func (t *Table[T]) withoutDeleted(p graph.ResolveParams, where string) string {
+	return withCondition(where, t.deletedCondition(p))
+}
+
+// withCondition adds condition to where, ahead of its GROUP BY, ORDER BY and LIMIT
+// clauses, so its placeholders come first
+func withCondition(where, condition string) string {
+	if condition == "" {
+		return where
+	}
+
+	filter, tail := where, ""
+	if loc := clauseTail.FindStringIndex(where); loc != nil {
+		filter, tail = where[:loc[0]], where[loc[0]:]
+	}
+	if strings.TrimSpace(filter) != "" {
+		condition += " AND (" + strings.TrimSpace(filter) + ")"
+	}
+	return strings.TrimSpace(condition + " " + tail)
+}
+
+// deletedCondition returns the condition leaving out soft deleted rows, or "" when
+// they are included
+func (t *Table[T]) deletedCondition(p graph.ResolveParams) string {
+	if t.deletedAt == "" {
+		return ""
+	}
+	if include, _ := p.Args[IncludeDeletedArg].(bool); include {
+		return ""
+	}
+	return t.deletedAt + " IS NULL"
+}
+
+// DeleteResolver returns a resolver of a delete mutation, deleting the row whose primary
+// key equals the argument argName and resolving to it. Soft deleting tables set the
+// deleted time of the row instead. It resolves to nil when no row matches, or the row
+// is deleted already.
+//
+// Example:
+//
+//	graph.NewResolver[User]("deleteUser").
+//	    WithArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}}).
+//	    WithResolver(users.DeleteResolver("id")). // UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL
+//	    BuildMutation()
+func (t *Table[T]) DeleteResolver(argName string) func(p graph.ResolveParams) (*T, error) {
+	return func(p graph.ResolveParams) (*T, error) {
+		key, err := t.keyArg(p, argName)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.deletedAt == "" {
+			rows, err := t.Query(p.Context, t.Columns(p), t.key+" = ?", key)
+			if err != nil || len(rows) == 0 {
+				return nil, err
+			}
+			result, err := t.exec(p.Context, "DELETE FROM "+t.name+" WHERE "+t.key+" = ?", key)
+			if n, _ := rowsAffected(result, err); n == 0 {
+				return nil, err
+			}
+			return &rows[0], nil
+		}
+
+		result, err := t.exec(p.Context,
+			"UPDATE "+t.name+" SET "+t.deletedAt+" = ? WHERE "+t.key+" = ? AND "+t.deletedAt+" IS NULL",
+			time.Now().UTC(), key)
+		return t.updated(p, result, err, key)
+	}
+}
+
+// RestoreResolver returns a resolver of a restore mutation, clearing the deleted time
+// of the soft deleted row whose primary key equals the argument argName and resolving
+// to it. It resolves to nil when no deleted row matches.
+func (t *Table[T]) RestoreResolver(argName string) func(p graph.ResolveParams) (*T, error) {
+	return func(p graph.ResolveParams) (*T, error) {
+		if t.deletedAt == "" {
+			return nil, fmt.Errorf("sqlgraph: table %s does not soft delete", t.name)
+		}
+		key, err := t.keyArg(p, argName)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := t.exec(p.Context,
+			"UPDATE "+t.name+" SET "+t.deletedAt+" = NULL WHERE "+t.key+" = ? AND "+t.deletedAt+" IS NOT NULL",
+			key)
+		return t.updated(p, result, err, key)
+	}
+}
+
+// updated loads the row an update changed, or resolves to nil when it changed none
+func (t *Table[T]) updated(p graph.ResolveParams, result sql.Result, err error, key interface{}) (*T, error) {
+	if n, err := rowsAffected(result, err); n == 0 || err != nil {
+		return nil, err
+	}
+	rows, err := t.Query(p.Context, t.Columns(p), t.key+" = ?", key)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// rowsAffected returns the rows a statement changed
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// keyArg returns the primary key value of the argument argName
+func (t *Table[T]) keyArg(p graph.ResolveParams, argName string) (interface{}, error) {
+	if t.key == "" {
+		return nil, fmt.Errorf("sqlgraph: table %s has no primary key", t.name)
+	}
+	key, ok := p.Args[argName]
+	if !ok {
+		return nil, fmt.Errorf("sqlgraph: missing argument %q", argName)
+	}
+	return key, nil
+}
+
+// exec runs a statement with ? placeholders bound to args. The statement is prepared
+// once and reused. The WHERE clause ending the statement of a table with a tenant
+// column is restricted to the tenant of ctx.
+func (t *Table[T]) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t.tenant != "" {
+		tenant, err := tenantOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND " + t.tenant + " = ?"
+		args = append(args, tenant)
+	}
+	stmt, err := t.prepare(ctx, t.rebind(query))
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
@@ -0,0 +1,402 @@
+// Package sqlgraph binds go-graph resolvers to database/sql tables.
+//
+// Struct fields are mapped to columns with the db tag, SELECT statements include
+// only the columns of the fields a query requests, and prepared statements are
+// cached per table. Tables with a deleted_at column soft delete their rows.
+//
+// Example:
+//
+//	type User struct {
+//	    ID        int       `json:"id" db:"id,pk"`
+//	    Name      string    `json:"name"`                // column "name"
+//	    CreatedAt time.Time `json:"createdAt" db:"created_at"`
+//	    Posts     []Post    `json:"posts"`               // not a column
+//	}
+//
+//	users := sqlgraph.NewTable[User](db, "users")
+//
+//	graph.NewResolver[User]("user").
+//	    WithArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}}).
+//	    WithResolver(users.FindResolver("id")).
+//	    BuildQuery()
+//
+//	graph.NewResolver[[]User]("activeUsers").
+//	    AsList().
+//	    WithResolver(users.ListResolver("active = TRUE ORDER BY name")).
+//	    BuildQuery()
+//
+// Paginated fields page through large tables by keyset, with cursors holding the sort
+// keys of the last row seen instead of an offset:
+//
+//	graph.NewResolver[graph.PaginatedResponse[User]]("users").
+//	    AsPaginated().
+//	    WithResolver(users.Keyset(sqlgraph.Desc("created_at")).PageResolver("")).
+//	    BuildQuery()
+package sqlgraph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paulmanoni/go-graph"
+)
+
+// PlaceholderStyle is the bind parameter syntax of a database driver
+type PlaceholderStyle int
+
+const (
+	// Question uses ? placeholders (MySQL, SQLite)
+	Question PlaceholderStyle = iota
+	// Dollar uses $1, $2, ... placeholders (PostgreSQL)
+	Dollar
+)
+
+// column maps a struct field to a table column
+type column struct {
+	name    string // Column name
+	field   string // GraphQL field name
+	index   []int  // Struct field index
+	options string // Options of the db tag
+}
+
+// Table binds struct type T to a database table. It is safe for concurrent use.
+type Table[T any] struct {
+	db          *sql.DB
+	name        string
+	columns     []column
+	key         string // Primary key column
+	deletedAt   string // Soft delete column
+	tenant      string // Tenant column
+	placeholder PlaceholderStyle
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// NewTable maps the fields of T to the columns of table name.
+//
+// Columns are named by the db tag, or the snake_case Go field name. Fields tagged
+// db:"-", fields excluded from the schema, and struct or slice fields without a db
+// tag (relations) are not columns. The primary key is tagged db:"name,pk", or is
+// the "id" column.
+//
+// Rows are soft deleted when T has a column tagged db:"name,softdelete", or a
+// "deleted_at" column: a nullable time set when the row is deleted (see DeleteResolver).
+//
+// Tables shared by tenants have a column tagged db:"name,tenant". Their statements only
+// reach the rows of the tenant of the context, graph.TenantID, and fail with a
+// TENANT_REQUIRED error without one.
+func NewTable[T any](db *sql.DB, name string) *Table[T] {
+	t := &Table[T]{db: db, name: name, stmts: make(map[string]*sql.Stmt)}
+
+	var zero T
+	collectColumns(reflect.TypeOf(zero), nil, &t.columns, &t.key)
+	if t.key == "" {
+		for _, c := range t.columns {
+			if c.name == "id" {
+				t.key = c.name
+				break
+			}
+		}
+	}
+	for _, c := range t.columns {
+		if hasOption(c.options, "softdelete") || (t.deletedAt == "" && c.name == "deleted_at") {
+			t.deletedAt = c.name
+		}
+		if hasOption(c.options, "tenant") {
+			t.tenant = c.name
+		}
+	}
+
+	return t
+}
+
+// collectColumns adds the columns of struct type typ, flattening embedded structs
+func collectColumns(typ reflect.Type, parent []int, columns *[]column, key *string) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		index := append(append([]int{}, parent...), i)
+
+		tag, hasTag := field.Tag.Lookup("db")
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && !hasTag {
+			collectColumns(field.Type, index, columns, key)
+			continue
+		}
+
+		fieldName := graph.FieldName(field)
+		if fieldName == "-" {
+			continue
+		}
+		if !hasTag && isRelation(field.Type) {
+			continue
+		}
+
+		if name == "" {
+			name = graph.NamingSnakeCase(reflect.StructField{Name: field.Name})
+		}
+		*columns = append(*columns, column{name: name, field: fieldName, index: index, options: options})
+		if hasOption(options, "pk") {
+			*key = name
+		}
+	}
+}
+
+// hasOption reports whether the comma-separated db tag options include option
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isRelation reports whether a field type holds related objects rather than a column value
+func isRelation(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		return typ != reflect.TypeOf(time.Time{}) && !reflect.PointerTo(typ).Implements(reflect.TypeOf((*sql.Scanner)(nil)).Elem())
+	case reflect.Slice:
+		return typ.Elem().Kind() != reflect.Uint8
+	case reflect.Map:
+		return true
+	}
+	return false
+}
+
+// WithPlaceholders sets the placeholder style of generated statements (default Question)
+func (t *Table[T]) WithPlaceholders(style PlaceholderStyle) *Table[T] {
+	t.placeholder = style
+	return t
+}
+
+// Columns returns the columns of the fields requested by the query, always including
+// the primary key. Without a selection (e.g. outside a resolver) every column is returned.
+func (t *Table[T]) Columns(p graph.ResolveParams) []string {
+	return t.columnsFor(graph.SelectedFields(p))
+}
+
+// columnsFor returns the columns of the requested fields, always including the primary key
+func (t *Table[T]) columnsFor(requested graph.Selection) []string {
+	var columns []string
+	for _, c := range t.columns {
+		if _, ok := requested[c.field]; ok || len(requested) == 0 || c.name == t.key {
+			columns = append(columns, c.name)
+		}
+	}
+	if len(columns) == 0 {
+		for _, c := range t.columns {
+			columns = append(columns, c.name)
+		}
+	}
+	return columns
+}
+
+// column returns the column named name
+func (t *Table[T]) column(name string) (column, bool) {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// FindResolver returns a resolver loading the row whose primary key equals the
+// argument argName. It resolves to nil when no row matches, or the row is soft deleted
+// and the includeDeleted argument is not true.
+func (t *Table[T]) FindResolver(argName string) func(p graph.ResolveParams) (*T, error) {
+	return func(p graph.ResolveParams) (*T, error) {
+		key, err := t.keyArg(p, argName)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := t.Query(p.Context, t.Columns(p), t.withoutDeleted(p, t.key+" = ?"), key)
+		if err != nil || len(rows) == 0 {
+			return nil, err
+		}
+		return &rows[0], nil
+	}
+}
+
+// ListResolver returns a resolver loading the rows matching where, with ? placeholders
+// bound to the arguments argNames in order. An empty where loads every row. Soft
+// deleted rows are left out unless the includeDeleted argument is true.
+//
+// Example:
+//
+//	users.ListResolver("team_id = ? AND active = ? ORDER BY name", "teamId", "active")
+func (t *Table[T]) ListResolver(where string, argNames ...string) func(p graph.ResolveParams) (*[]T, error) {
+	return func(p graph.ResolveParams) (*[]T, error) {
+		args := make([]interface{}, len(argNames))
+		for i, name := range argNames {
+			args[i] = p.Args[name]
+		}
+
+		rows, err := t.Query(p.Context, t.Columns(p), t.withoutDeleted(p, where), args...)
+		if err != nil {
+			return nil, err
+		}
+		return &rows, nil
+	}
+}
+
+// Query selects columns from the rows matching where, with ? placeholders bound to
+// args. The statement is prepared once and reused. Tables with a tenant column only
+// select the rows of the tenant of ctx.
+func (t *Table[T]) Query(ctx context.Context, columns []string, where string, args ...interface{}) ([]T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t.tenant != "" {
+		tenant, err := tenantOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		where = withCondition(where, t.tenant+" = ?")
+		args = append([]interface{}{tenant}, args...)
+	}
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + t.name
+	if where != "" {
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(where)), "ORDER BY") {
+			query += " " + where
+		} else {
+			query += " WHERE " + where
+		}
+	}
+
+	stmt, err := t.prepare(ctx, t.rebind(query))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byName := make(map[string]*column, len(t.columns))
+	for i := range t.columns {
+		byName[t.columns[i].name] = &t.columns[i]
+	}
+
+	var results []T
+	for rows.Next() {
+		var item T
+		value := reflect.ValueOf(&item).Elem()
+		dest := make([]interface{}, len(columns))
+		for i, name := range columns {
+			c, ok := byName[name]
+			if !ok {
+				var ignored interface{}
+				dest[i] = &ignored
+				continue
+			}
+			dest[i] = fieldByIndex(value, c.index).Addr().Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		results = append(results, item)
+	}
+	return results, rows.Err()
+}
+
+// tenantOf returns the tenant of ctx, or a TenantRequiredError when it has none
+func tenantOf(ctx context.Context) (string, error) {
+	tenant, ok := graph.TenantID(ctx)
+	if !ok {
+		return "", &graph.TenantRequiredError{}
+	}
+	return tenant, nil
+}
+
+// fieldByIndex returns the struct field at index, allocating nil embedded pointers
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// rebind rewrites ? placeholders for the table's placeholder style
+func (t *Table[T]) rebind(query string) string {
+	if t.placeholder != Dollar {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// prepare returns the cached prepared statement for query
+func (t *Table[T]) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if stmt, ok := t.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := t.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	t.stmts[query] = stmt
+	return stmt, nil
+}
+
+// Close closes the cached prepared statements
+func (t *Table[T]) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for query, stmt := range t.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(t.stmts, query)
+	}
+	return errors.Join(errs...)
+}
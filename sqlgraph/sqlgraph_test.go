@@ -0,0 +1,712 @@
+package sqlgraph
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/paulmanoni/go-graph"
+)
+
+// fakeDB is an in-memory database/sql driver recording prepared statements
+type fakeDB struct {
+	mu       sync.Mutex
+	prepared []string
+	rows     []map[string]driver.Value
+	// query, when set, selects the rows of a query in place of the filter column
+	query func(query string, args []driver.Value) []map[string]driver.Value
+	// exec, when set, runs statements and returns the rows they changed
+	exec func(query string, args []driver.Value) int64
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.prepared = append(c.db.prepared, query)
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.db.exec == nil {
+		return nil, errors.New("not supported")
+	}
+	return driver.RowsAffected(s.db.exec(s.query, args)), nil
+}
+
+// Query returns the selected columns of the rows whose filter column equals the first
+// argument, or of the rows the query hook selects
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	selectList := strings.TrimPrefix(s.query[:strings.Index(s.query, " FROM ")], "SELECT ")
+	columns := strings.Split(selectList, ", ")
+
+	matches := s.db.rows
+	if s.db.query != nil {
+		matches = s.db.query(s.query, args)
+	} else if i := strings.Index(s.query, " WHERE "); i >= 0 && len(args) > 0 {
+		filter := strings.Fields(s.query[i+len(" WHERE "):])[0]
+		matches = nil
+		for _, row := range s.db.rows {
+			if row[filter] == args[0] {
+				matches = append(matches, row)
+			}
+		}
+	}
+
+	var rows [][]driver.Value
+	for _, row := range matches {
+		values := make([]driver.Value, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		rows = append(rows, values)
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type SQLUser struct {
+	ID     int      `json:"id" db:"id,pk"`
+	Name   string   `json:"name"`
+	Email  string   `json:"email" db:"email_address"`
+	Secret string   `json:"-"`
+	Teams  []string `json:"teams"`
+}
+
+func TestTable(t *testing.T) {
+	fake := &fakeDB{rows: []map[string]driver.Value{
+		{"id": int64(1), "name": "Ada", "email_address": "ada@example.com"},
+		{"id": int64(2), "name": "Grace", "email_address": "grace@example.com"},
+	}}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	users := NewTable[SQLUser](db, "users")
+	defer users.Close()
+	postgresUsers := NewTable[SQLUser](db, "users").WithPlaceholders(Dollar)
+	defer postgresUsers.Close()
+
+	schema, err := graph.NewSchemaBuilder(graph.SchemaBuilderParams{
+		QueryFields: []graph.QueryField{
+			graph.NewResolver[SQLUser]("sqlUser").
+				WithArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}}).
+				WithResolver(users.FindResolver("id")).
+				BuildQuery(),
+			graph.NewResolver[[]SQLUser]("sqlUsersNamed").
+				AsList().
+				WithArgs(graphql.FieldConfigArgument{"name": {Type: graphql.NewNonNull(graphql.String)}}).
+				WithResolver(postgresUsers.ListResolver("name = ?", "name")).
+				BuildQuery(),
+		},
+	}).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		want         string
+		wantPrepared string
+	}{
+		{
+			name:         "only requested columns",
+			query:        "{ sqlUser(id: 1) { name } }",
+			want:         `{"sqlUser":{"name":"Ada"}}`,
+			wantPrepared: "SELECT id, name FROM users WHERE id = ?",
+		},
+		{
+			name:         "fragment and tagged column",
+			query:        "{ sqlUser(id: 2) { ...F } } fragment F on SQLUser { email }",
+			want:         `{"sqlUser":{"email":"grace@example.com"}}`,
+			wantPrepared: "SELECT id, email_address FROM users WHERE id = ?",
+		},
+		{
+			name:         "no matching row",
+			query:        "{ sqlUser(id: 9) { name } }",
+			want:         `{"sqlUser":null}`,
+			wantPrepared: "SELECT id, name FROM users WHERE id = ?",
+		},
+		{
+			name:         "dollar placeholders",
+			query:        `{ sqlUsersNamed(name: "Grace") { id name } }`,
+			want:         `{"sqlUsersNamed":[{"id":"2","name":"Grace"}]}`,
+			wantPrepared: "SELECT id, name FROM users WHERE name = $1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: tt.query})
+			if len(result.Errors) > 0 {
+				t.Fatalf("Errors = %v", result.Errors)
+			}
+			if got := mustJSON(t, result.Data); got != tt.want {
+				t.Errorf("Data = %s, want %s", got, tt.want)
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			count := 0
+			for _, query := range fake.prepared {
+				if query == tt.wantPrepared {
+					count++
+				}
+			}
+			if count != 1 {
+				t.Errorf("Prepared %v, want %q exactly once", fake.prepared, tt.wantPrepared)
+			}
+		})
+	}
+
+	t.Run("columns without selection", func(t *testing.T) {
+		want := []string{"id", "name", "email_address"}
+		if got := users.Columns(graph.ResolveParams{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("Columns() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestCursor(t *testing.T) {
+	cursor, err := EncodeCursor("2024-05-01", 42)
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+
+	var day string
+	var id int
+	if err := DecodeCursor(cursor, &day, &id); err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if day != "2024-05-01" || id != 42 {
+		t.Errorf("DecodeCursor() = %q, %d, want %q, %d", day, id, "2024-05-01", 42)
+	}
+
+	for _, invalid := range []string{"not base64!", "bm90IGpzb24", cursor[:len(cursor)-4]} {
+		if err := DecodeCursor(invalid, &day, &id); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", invalid, err)
+		}
+	}
+	if err := DecodeCursor(cursor, &id); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("DecodeCursor() with one destination error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestKeysetPredicate(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []SortKey
+		before   bool
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "ascending row value",
+			keys:     []SortKey{Asc("created_at"), Asc("id")},
+			want:     "(created_at, id) > (?, ?)",
+			wantArgs: []interface{}{"t", 1},
+		},
+		{
+			name:     "descending before",
+			keys:     []SortKey{Desc("created_at"), Desc("id")},
+			before:   true,
+			want:     "(created_at, id) > (?, ?)",
+			wantArgs: []interface{}{"t", 1},
+		},
+		{
+			name:     "descending after",
+			keys:     []SortKey{Desc("created_at"), Desc("id")},
+			want:     "(created_at, id) < (?, ?)",
+			wantArgs: []interface{}{"t", 1},
+		},
+		{
+			name:     "mixed directions",
+			keys:     []SortKey{Desc("created_at"), Asc("id")},
+			want:     "((created_at < ?) OR (created_at = ? AND id > ?))",
+			wantArgs: []interface{}{"t", "t", 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := KeysetPredicate(tt.keys, []interface{}{"t", 1}, tt.before)
+			if got != tt.want {
+				t.Errorf("KeysetPredicate() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("KeysetPredicate() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	fake := &fakeDB{}
+	for i, name := range []string{"Ada", "Grace", "Edsger", "Barbara", "Alan"} {
+		fake.rows = append(fake.rows, map[string]driver.Value{"id": int64(i + 1), "name": name})
+	}
+	// Pages through rows by id, as the keyset on the primary key orders them
+	fake.query = func(query string, args []driver.Value) []map[string]driver.Value {
+		limit := int(args[len(args)-1].(int64))
+		var rows []map[string]driver.Value
+		for _, row := range fake.rows {
+			id := row["id"].(int64)
+			switch {
+			case strings.Contains(query, "(id) > (?)") && id <= args[0].(int64):
+			case strings.Contains(query, "(id) < (?)") && id >= args[0].(int64):
+			default:
+				rows = append(rows, row)
+			}
+		}
+		if strings.Contains(query, "ORDER BY id DESC") {
+			for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+				rows[i], rows[j] = rows[j], rows[i]
+			}
+		}
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+		return rows
+	}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	users := NewTable[SQLUser](db, "users")
+	defer users.Close()
+	byID := users.Keyset()
+
+	if got, want := byID.OrderBy(false), "ORDER BY id ASC"; got != want {
+		t.Errorf("OrderBy(false) = %q, want %q", got, want)
+	}
+	if got, want := users.Keyset(Desc("name")).OrderBy(true), "ORDER BY name ASC, id DESC"; got != want {
+		t.Errorf("OrderBy(true) = %q, want %q", got, want)
+	}
+
+	schema, err := graph.NewSchemaBuilder(graph.SchemaBuilderParams{
+		QueryFields: []graph.QueryField{
+			graph.NewResolver[graph.PaginatedResponse[SQLUser]]("sqlUsersPage").
+				AsPaginated().
+				WithResolver(byID.PageResolver("")).
+				BuildQuery(),
+		},
+	}).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	cursor := func(id int) string {
+		c, err := byID.Cursor(SQLUser{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		want         string
+		wantPrepared string
+	}{
+		{
+			name:         "first page",
+			query:        "{ sqlUsersPage(first: 2) { items { name } pageInfo { hasNextPage hasPreviousPage endCursor } } }",
+			want:         `{"sqlUsersPage":{"items":[{"name":"Ada"},{"name":"Grace"}],"pageInfo":{"endCursor":"` + cursor(2) + `","hasNextPage":true,"hasPreviousPage":false}}}`,
+			wantPrepared: "SELECT id, name FROM users ORDER BY id ASC LIMIT ?",
+		},
+		{
+			name:         "after cursor",
+			query:        `{ sqlUsersPage(first: 2, after: "` + cursor(4) + `") { items { name } pageInfo { hasNextPage hasPreviousPage } } }`,
+			want:         `{"sqlUsersPage":{"items":[{"name":"Alan"}],"pageInfo":{"hasNextPage":false,"hasPreviousPage":true}}}`,
+			wantPrepared: "SELECT id, name FROM users WHERE (id) > (?) ORDER BY id ASC LIMIT ?",
+		},
+		{
+			name:         "last before cursor",
+			query:        `{ sqlUsersPage(last: 2, before: "` + cursor(4) + `") { items { name } pageInfo { hasNextPage hasPreviousPage startCursor } } }`,
+			want:         `{"sqlUsersPage":{"items":[{"name":"Grace"},{"name":"Edsger"}],"pageInfo":{"hasNextPage":true,"hasPreviousPage":true,"startCursor":"` + cursor(2) + `"}}}`,
+			wantPrepared: "SELECT id, name FROM users WHERE (id) < (?) ORDER BY id DESC LIMIT ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: tt.query})
+			if len(result.Errors) > 0 {
+				t.Fatalf("Errors = %v", result.Errors)
+			}
+			if got := mustJSON(t, result.Data); got != tt.want {
+				t.Errorf("Data = %s, want %s", got, tt.want)
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			found := false
+			for _, query := range fake.prepared {
+				found = found || query == tt.wantPrepared
+			}
+			if !found {
+				t.Errorf("Prepared %v, want %q", fake.prepared, tt.wantPrepared)
+			}
+		})
+	}
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ sqlUsersPage(after: "bogus") { items { name } } }`})
+		if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "invalid cursor") {
+			t.Errorf("Errors = %v, want an invalid cursor error", result.Errors)
+		}
+	})
+}
+
+type SoftUser struct {
+	ID        int        `json:"id" db:"id,pk"`
+	Name      string     `json:"name"`
+	DeletedAt *time.Time `json:"deletedAt"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fake := &fakeDB{rows: []map[string]driver.Value{
+		{"id": int64(1), "name": "Ada", "deleted_at": nil},
+		{"id": int64(2), "name": "Grace", "deleted_at": deletedAt},
+	}}
+	// Selects rows by the id and deleted_at conditions of the query
+	fake.query = func(query string, args []driver.Value) []map[string]driver.Value {
+		var rows []map[string]driver.Value
+		for _, row := range fake.rows {
+			switch {
+			case strings.Contains(query, "deleted_at IS NULL") && row["deleted_at"] != nil:
+			case strings.Contains(query, "id = ?") && row["id"] != args[0]:
+			default:
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+	fake.exec = func(query string, args []driver.Value) int64 {
+		id := args[len(args)-1]
+		for i, row := range fake.rows {
+			switch {
+			case row["id"] != id:
+			case strings.HasPrefix(query, "DELETE"):
+				fake.rows = append(fake.rows[:i], fake.rows[i+1:]...)
+				return 1
+			case strings.Contains(query, "deleted_at = NULL") && row["deleted_at"] != nil:
+				row["deleted_at"] = nil
+				return 1
+			case strings.Contains(query, "deleted_at = ?") && row["deleted_at"] == nil:
+				row["deleted_at"] = deletedAt
+				return 1
+			}
+		}
+		return 0
+	}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	users := NewTable[SoftUser](db, "users")
+	defer users.Close()
+	hardUsers := NewTable[SQLUser](db, "users")
+	defer hardUsers.Close()
+
+	idArgs := graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}}
+	schema, err := graph.NewSchemaBuilder(graph.SchemaBuilderParams{
+		QueryFields: []graph.QueryField{
+			graph.NewResolver[[]SoftUser]("softUsers").
+				AsList().
+				WithArgs(IncludeDeletedArgs(nil)).
+				WithResolver(users.ListResolver("")).
+				BuildQuery(),
+			graph.NewResolver[SoftUser]("softUser").
+				WithArgs(IncludeDeletedArgs(idArgs)).
+				WithResolver(users.FindResolver("id")).
+				BuildQuery(),
+		},
+		MutationFields: []graph.MutationField{
+			graph.NewResolver[SoftUser]("deleteSoftUser").
+				WithArgs(idArgs).
+				WithResolver(users.DeleteResolver("id")).
+				BuildMutation(),
+			graph.NewResolver[SoftUser]("restoreSoftUser").
+				WithArgs(idArgs).
+				WithResolver(users.RestoreResolver("id")).
+				BuildMutation(),
+			graph.NewResolver[SQLUser]("deleteSQLUser").
+				WithArgs(idArgs).
+				WithResolver(hardUsers.DeleteResolver("id")).
+				BuildMutation(),
+		},
+	}).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	steps := []struct {
+		name         string
+		query        string
+		want         string
+		wantPrepared string
+	}{
+		{
+			name:         "lists rows not deleted",
+			query:        "{ softUsers { name } }",
+			want:         `{"softUsers":[{"name":"Ada"}]}`,
+			wantPrepared: "SELECT id, name FROM users WHERE deleted_at IS NULL",
+		},
+		{
+			name:  "lists deleted rows on request",
+			query: "{ softUsers(includeDeleted: true) { name deletedAt } }",
+			want:  `{"softUsers":[{"deletedAt":null,"name":"Ada"},{"deletedAt":"2024-05-01T12:00","name":"Grace"}]}`,
+		},
+		{
+			name:         "finds rows not deleted",
+			query:        "{ softUser(id: 2) { name } }",
+			want:         `{"softUser":null}`,
+			wantPrepared: "SELECT id, name FROM users WHERE deleted_at IS NULL AND (id = ?)",
+		},
+		{
+			name:         "deletes by setting deleted_at",
+			query:        "mutation { deleteSoftUser(id: 1) { name deletedAt } }",
+			want:         `{"deleteSoftUser":{"deletedAt":"2024-05-01T12:00","name":"Ada"}}`,
+			wantPrepared: "UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL",
+		},
+		{
+			name:  "deletes once",
+			query: "mutation { deleteSoftUser(id: 1) { name } }",
+			want:  `{"deleteSoftUser":null}`,
+		},
+		{
+			name:         "restores deleted rows",
+			query:        "mutation { restoreSoftUser(id: 2) { name deletedAt } }",
+			want:         `{"restoreSoftUser":{"deletedAt":null,"name":"Grace"}}`,
+			wantPrepared: "UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL",
+		},
+		{
+			name:  "lists restored rows",
+			query: "{ softUsers { name } }",
+			want:  `{"softUsers":[{"name":"Grace"}]}`,
+		},
+		{
+			name:         "deletes rows of tables without deleted_at",
+			query:        "mutation { deleteSQLUser(id: 2) { name } }",
+			want:         `{"deleteSQLUser":{"name":"Grace"}}`,
+			wantPrepared: "DELETE FROM users WHERE id = ?",
+		},
+	}
+
+	// Steps run in order against the same rows
+	for _, step := range steps {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: step.query})
+		if len(result.Errors) > 0 {
+			t.Fatalf("%s: Errors = %v", step.name, result.Errors)
+		}
+		if got := mustJSON(t, result.Data); got != step.want {
+			t.Errorf("%s: Data = %s, want %s", step.name, got, step.want)
+		}
+		if step.wantPrepared == "" {
+			continue
+		}
+		fake.mu.Lock()
+		found := false
+		for _, query := range fake.prepared {
+			found = found || query == step.wantPrepared
+		}
+		fake.mu.Unlock()
+		if !found {
+			t.Errorf("%s: Prepared %v, want %q", step.name, fake.prepared, step.wantPrepared)
+		}
+	}
+
+	t.Run("keeps clauses after the condition", func(t *testing.T) {
+		tests := map[string]string{
+			"":                                   "deleted_at IS NULL",
+			"ORDER BY name":                      "deleted_at IS NULL ORDER BY name",
+			"team_id = ? OR admin = TRUE":        "deleted_at IS NULL AND (team_id = ? OR admin = TRUE)",
+			"team_id = ? order by name limit 10": "deleted_at IS NULL AND (team_id = ?) order by name limit 10",
+		}
+		for where, want := range tests {
+			if got := users.withoutDeleted(graph.ResolveParams{}, where); got != want {
+				t.Errorf("withoutDeleted(%q) = %q, want %q", where, got, want)
+			}
+		}
+	})
+
+	t.Run("restore requires a soft delete column", func(t *testing.T) {
+		if _, err := hardUsers.RestoreResolver("id")(graph.ResolveParams{Args: map[string]interface{}{"id": 1}}); err == nil {
+			t.Error("RestoreResolver() error = nil, want an error for a table without deleted_at")
+		}
+	})
+}
+
+type TenantDoc struct {
+	ID       int    `json:"id" db:"id,pk"`
+	TenantID string `json:"tenantId" db:"tenant_id,tenant"`
+	Title    string `json:"title"`
+}
+
+func TestTenantScoping(t *testing.T) {
+	fake := &fakeDB{rows: []map[string]driver.Value{
+		{"id": int64(1), "tenant_id": "acme", "title": "Roadmap"},
+		{"id": int64(2), "tenant_id": "globex", "title": "Budget"},
+		{"id": int64(3), "tenant_id": "acme", "title": "Hiring"},
+	}}
+	// Selects rows by the tenant_id and id conditions of the query
+	fake.query = func(query string, args []driver.Value) []map[string]driver.Value {
+		var rows []map[string]driver.Value
+		for _, row := range fake.rows {
+			switch {
+			case row["tenant_id"] != args[0]:
+			case strings.Contains(query, "(id = ?)") && row["id"] != args[1]:
+			default:
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+	fake.exec = func(query string, args []driver.Value) int64 {
+		for i, row := range fake.rows {
+			if row["id"] == args[0] && row["tenant_id"] == args[1] {
+				fake.rows = append(fake.rows[:i], fake.rows[i+1:]...)
+				return 1
+			}
+		}
+		return 0
+	}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	docs := NewTable[TenantDoc](db, "docs")
+	defer docs.Close()
+
+	idArgs := graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}}
+	schema, err := graph.NewSchemaBuilder(graph.SchemaBuilderParams{
+		QueryFields: []graph.QueryField{
+			graph.NewResolver[[]TenantDoc]("tenantDocs").
+				AsList().
+				WithResolver(docs.ListResolver("ORDER BY title")).
+				BuildQuery(),
+			graph.NewResolver[TenantDoc]("tenantDoc").
+				WithArgs(idArgs).
+				WithResolver(docs.FindResolver("id")).
+				BuildQuery(),
+		},
+		MutationFields: []graph.MutationField{
+			graph.NewResolver[TenantDoc]("deleteTenantDoc").
+				WithArgs(idArgs).
+				WithResolver(docs.DeleteResolver("id")).
+				BuildMutation(),
+		},
+	}).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	acme := graph.WithTenant(context.Background(), "acme")
+	steps := []struct {
+		name         string
+		query        string
+		want         string
+		wantPrepared string
+	}{
+		{
+			name:         "lists the rows of the tenant",
+			query:        "{ tenantDocs { title } }",
+			want:         `{"tenantDocs":[{"title":"Roadmap"},{"title":"Hiring"}]}`,
+			wantPrepared: "SELECT id, title FROM docs WHERE tenant_id = ? ORDER BY title",
+		},
+		{
+			name:         "finds rows of other tenants",
+			query:        "{ tenantDoc(id: 2) { title } }",
+			want:         `{"tenantDoc":null}`,
+			wantPrepared: "SELECT id, title FROM docs WHERE tenant_id = ? AND (id = ?)",
+		},
+		{
+			name:         "deletes rows of other tenants",
+			query:        "mutation { deleteTenantDoc(id: 2) { title } }",
+			want:         `{"deleteTenantDoc":null}`,
+			wantPrepared: "SELECT id, title FROM docs WHERE tenant_id = ? AND (id = ?)",
+		},
+		{
+			name:         "deletes rows of the tenant",
+			query:        "mutation { deleteTenantDoc(id: 3) { title } }",
+			want:         `{"deleteTenantDoc":{"title":"Hiring"}}`,
+			wantPrepared: "DELETE FROM docs WHERE id = ? AND tenant_id = ?",
+		},
+	}
+
+	for _, step := range steps {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: step.query, Context: acme})
+		if len(result.Errors) > 0 {
+			t.Fatalf("%s: Errors = %v", step.name, result.Errors)
+		}
+		if got := mustJSON(t, result.Data); got != step.want {
+			t.Errorf("%s: Data = %s, want %s", step.name, got, step.want)
+		}
+		fake.mu.Lock()
+		found := false
+		for _, query := range fake.prepared {
+			found = found || query == step.wantPrepared
+		}
+		fake.mu.Unlock()
+		if !found {
+			t.Errorf("%s: Prepared %v, want %q", step.name, fake.prepared, step.wantPrepared)
+		}
+	}
+	if len(fake.rows) != 2 {
+		t.Errorf("rows = %v, want the rows of globex and acme left", fake.rows)
+	}
+
+	t.Run("requires a tenant", func(t *testing.T) {
+		_, err := docs.Query(context.Background(), []string{"id"}, "")
+		var required *graph.TenantRequiredError
+		if !errors.As(err, &required) {
+			t.Errorf("Query() error = %v, want a TenantRequiredError", err)
+		}
+	})
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
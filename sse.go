@@ -0,0 +1,325 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// EventStreamContentType is the media type of Server-Sent Events responses
+const EventStreamContentType = "text/event-stream"
+
+// acceptsEventStream reports whether the request asks for a Server-Sent Events response
+func acceptsEventStream(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if strings.EqualFold(mediaType, EventStreamContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveSSE executes a request and streams its results as Server-Sent Events,
+// following the "distinct connections" mode of the GraphQL over SSE protocol:
+// each result is sent as a "next" event and the stream ends with a "complete" event.
+//
+// Subscriptions emit one result per event until the event channel closes or the
+// client disconnects. Queries and mutations emit a single result, followed by one
+// incremental payload per item of the lists they select with @stream when
+// GraphContext.EnableStreaming is set.
+func serveSSE(w http.ResponseWriter, r *http.Request, schema *graphql.Schema, graphCtx *GraphContext, streams *streamLimiter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// The extensions object doubles as the connection init payload
+	payload := requestExtensions(r)
+	codec := codecFor(graphCtx)
+	opts := requestOptions(r, codec)
+	logOperation(r.Context(), opts)
+
+	// Reject invalid operations before the stream starts
+	if !graphCtx.DEBUG && graphCtx.EnableValidation && opts.Query != "" {
+		if _, err := validateGraphQLQuery(opts.Query, opts.Variables, schema, graphCtx.RejectParseErrors, queryLimits(r.Context())); err != nil {
+			writeSSEError(w, http.StatusBadRequest, err.Error(), "")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	rootValue := buildRootValue(ctx, graphCtx, r)
+	if _, ok := rootValue["token"]; !ok {
+		tokenFn := graphCtx.Subscriptions.ConnectionTokenFn
+		if tokenFn == nil {
+			tokenFn = defaultConnectionToken
+		}
+		// The payload token is checked like a request token, since NewHTTP only saw the headers
+		token := tokenFn(payload)
+		revocations, _ := ctx.Value(revocationContextKey{}).(*revocationCache)
+		if err := authenticateToken(ctx, graphCtx, revocations, token); err != nil {
+			writeUnauthenticated(w, err)
+			return
+		}
+		rootValue = addRootToken(ctx, graphCtx, rootValue, token)
+		recordPrincipal(ctx, graphCtx, rootValue)
+	}
+
+	params := ExecuteParams{
+		Context:       ctx,
+		Schema:        schema,
+		Query:         opts.Query,
+		OperationName: opts.OperationName,
+		Variables:     opts.Variables,
+		RootValue:     rootValue,
+	}
+	executor := executorFor(graphCtx)
+
+	subscription := isSubscriptionOperation(opts.Query, opts.OperationName)
+	live := !subscription && graphCtx.LivePubSub != nil && isLiveQuery(opts.Query, opts.OperationName)
+
+	// Long-lived streams count towards the client's subscription limit
+	if subscription || live {
+		client := streamClientKey(r, rootValue)
+		if !streams.acquire(client) {
+			writeSSEError(w, http.StatusTooManyRequests,
+				fmt.Sprintf("maximum of %d concurrent subscriptions exceeded", streams.max), "TOO_MANY_SUBSCRIPTIONS")
+			return
+		}
+		defer streams.release(client)
+	}
+
+	var results chan *graphql.Result
+	var lists *listStreams
+	switch {
+	case subscription:
+		results = executor.Subscribe(params)
+	case live:
+		results = executeLive(executor, params, graphCtx.LivePubSub)
+	default:
+		if graphCtx.EnableStreaming {
+			// Lists selected with @stream are sent item by item after the result
+			params, lists = withListStreams(params)
+			defer lists.stop()
+		}
+		results = make(chan *graphql.Result, 1)
+		results <- executor.Execute(params)
+		close(results)
+	}
+
+	// Keep draining after the stream ends so the executor never blocks on send
+	defer func() {
+		cancel()
+		go func() {
+			for range results {
+			}
+		}()
+	}()
+
+	w.Header().Set("Content-Type", EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering in nginx-style proxies
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sanitizeErrors := func(errors []gqlerrors.FormattedError) {
+		if !graphCtx.DEBUG && graphCtx.EnableSanitization {
+			for i := range errors {
+				errors[i].Message = sanitizeErrorMessage(errors[i].Message)
+			}
+		}
+	}
+	writeNext := func(payload interface{}) {
+		data, err := codec.Marshal(payload)
+		if err != nil {
+			return
+		}
+		_, _ = fmt.Fprintf(w, "event: next\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	var keepAlive <-chan time.Time
+	if graphCtx.Subscriptions.KeepAlive > 0 {
+		ticker := time.NewTicker(graphCtx.Subscriptions.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	var expired <-chan time.Time
+	if graphCtx.Subscriptions.MaxLifetime > 0 {
+		timer := time.NewTimer(graphCtx.Subscriptions.MaxLifetime)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+stream:
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				break stream
+			}
+			sanitizeErrors(result.Errors)
+
+			if graphCtx.ResponseDecorator != nil {
+				graphCtx.ResponseDecorator(r.Context(), result)
+			}
+
+			if !lists.pending() {
+				writeNext(result)
+				continue
+			}
+			writeNext(incrementalResult{Result: result, HasNext: true})
+			lists.serve(ctx, func(payload incrementalResult) {
+				for _, items := range payload.Incremental {
+					sanitizeErrors(items.Errors)
+				}
+				writeNext(payload)
+			})
+
+		case <-keepAlive:
+			// Comment lines are ignored by clients but keep proxies from timing out
+			_, _ = fmt.Fprint(w, ":\n\n")
+			flusher.Flush()
+
+		case <-expired:
+			break stream
+
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	_, _ = fmt.Fprint(w, "event: complete\ndata:\n\n")
+	flusher.Flush()
+}
+
+// writeSSEError writes a JSON error response for a stream rejected before it started
+func writeSSEError(w http.ResponseWriter, status int, message, code string) {
+	graphErr := map[string]interface{}{"message": message}
+	if code != "" {
+		graphErr["extensions"] = map[string]interface{}{"code": code}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{graphErr},
+	})
+}
+
+// defaultConnectionToken reads a token from the "token", "authToken" or
+// Bearer "Authorization" entries of a connection init payload
+func defaultConnectionToken(payload map[string]interface{}) string {
+	for _, key := range []string{"token", "authToken"} {
+		if token, ok := payload[key].(string); ok && token != "" {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	if auth, ok := payload["Authorization"].(string); ok {
+		const bearerPrefix = "Bearer "
+		if len(auth) > len(bearerPrefix) && strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+			return strings.TrimSpace(auth[len(bearerPrefix):])
+		}
+	}
+	return ""
+}
+
+// streamClientKey identifies the client of a stream: its verified caller, its token once
+// UserDetailsFn accepted it, or else its remote address. Unverified tokens are not
+// used, or a client could open each stream with a made-up token to evade the limit.
+func streamClientKey(r *http.Request, rootValue map[string]interface{}) string {
+	if caller, ok := CallerFromContext(r.Context()); ok {
+		return "caller:" + caller.Method + ":" + caller.ID
+	}
+	if token, ok := rootValue["token"].(string); ok {
+		if _, authenticated := rootValue["details"]; authenticated {
+			return "token:" + token
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+// streamLimiter caps the number of concurrent long-lived streams per client
+type streamLimiter struct {
+	max    int
+	mu     sync.Mutex
+	active map[string]int
+}
+
+func newStreamLimiter(max int) *streamLimiter {
+	return &streamLimiter{max: max, active: make(map[string]int)}
+}
+
+// acquire reserves a stream for client, reporting false when the client is at the limit
+func (l *streamLimiter) acquire(client string) bool {
+	if l == nil || l.max <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[client] >= l.max {
+		return false
+	}
+	l.active[client]++
+	return true
+}
+
+// release frees a stream reserved by acquire
+func (l *streamLimiter) release(client string) {
+	if l == nil || l.max <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[client]--; l.active[client] <= 0 {
+		delete(l.active, client)
+	}
+}
+
+// isSubscriptionOperation reports whether the operation selected by operationName
+// (or the only operation in the document) is a subscription
+func isSubscriptionOperation(query, operationName string) bool {
+	op := selectOperation(query, operationName)
+	return op != nil && op.Operation == ast.OperationTypeSubscription
+}
+
+// selectOperation returns the operation selected by operationName, or the first
+// operation in the document when operationName is empty
+func selectOperation(query, operationName string) *ast.OperationDefinition {
+	doc, err := parseGraphQLQuery(query)
+	if err != nil {
+		return nil
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+			return op
+		}
+	}
+	return nil
+}
@@ -0,0 +1,489 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// StreamDirective streams the items of a list field: the initial result holds the
+// first initialCount items, and the others follow one incremental payload each as the
+// resolver produces them. It is added to built schemas when GraphContext.EnableStreaming
+// is set; add it to SchemaConfig.Directives when providing a pre-built schema.
+//
+//	query { auditLog(since: "2024-01-01T00:00") @stream(initialCount: 20) { id action } }
+var StreamDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "stream",
+	Description: "Sends the items of a list past initialCount incrementally, as they are produced.",
+	Locations:   []string{graphql.DirectiveLocationField},
+	Args: graphql.FieldConfigArgument{
+		"initialCount": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 0,
+			Description:  "Number of items sent in the initial result.",
+		},
+		"if": &graphql.ArgumentConfig{
+			Type:         graphql.Boolean,
+			DefaultValue: true,
+			Description:  "Streams the list when true.",
+		},
+	},
+})
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// listIterator pulls the items of a list one at a time
+type listIterator struct {
+	next func(ctx context.Context) (item interface{}, ok bool, err error)
+	stop func()
+}
+
+// newListIterator returns an iterator over list: a slice or an array, an iter.Seq, an
+// iter.Seq2 of items and errors, a channel, or a pointer to one of them. It reports
+// false for other values.
+func newListIterator(list interface{}) (*listIterator, bool) {
+	v := reflect.ValueOf(list)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		i := 0
+		return &listIterator{
+			next: func(context.Context) (interface{}, bool, error) {
+				if i >= v.Len() {
+					return nil, false, nil
+				}
+				i++
+				return v.Index(i - 1).Interface(), true, nil
+			},
+			stop: func() {},
+		}, true
+	case reflect.Chan:
+		if v.Type().ChanDir()&reflect.RecvDir == 0 {
+			return nil, false
+		}
+		return &listIterator{
+			next: func(ctx context.Context) (interface{}, bool, error) {
+				chosen, item, ok := reflect.Select([]reflect.SelectCase{
+					{Dir: reflect.SelectRecv, Chan: v},
+					{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+				})
+				if chosen == 1 {
+					return nil, false, ctx.Err()
+				}
+				if !ok {
+					return nil, false, nil
+				}
+				return item.Interface(), true, nil
+			},
+			stop: func() {},
+		}, true
+	case reflect.Func:
+		return pullSequence(v)
+	}
+	return nil, false
+}
+
+// pullSequence returns an iterator over an iter.Seq, or an iter.Seq2 whose second
+// values are errors
+func pullSequence(seq reflect.Value) (*listIterator, bool) {
+	t := seq.Type()
+	if t.NumIn() != 1 || t.NumOut() != 0 || seq.IsNil() {
+		return nil, false
+	}
+	yield := t.In(0)
+	if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0) != reflect.TypeOf(true) {
+		return nil, false
+	}
+
+	switch {
+	case yield.NumIn() == 1:
+		next, stop := iter.Pull(func(y func(interface{}) bool) {
+			seq.Call([]reflect.Value{reflect.MakeFunc(yield, func(args []reflect.Value) []reflect.Value {
+				return []reflect.Value{reflect.ValueOf(y(args[0].Interface()))}
+			})})
+		})
+		return &listIterator{
+			next: func(context.Context) (interface{}, bool, error) {
+				item, ok := next()
+				return item, ok, nil
+			},
+			stop: stop,
+		}, true
+	case yield.NumIn() == 2 && yield.In(1) == errorType:
+		next, stop := iter.Pull2(func(y func(interface{}, error) bool) {
+			seq.Call([]reflect.Value{reflect.MakeFunc(yield, func(args []reflect.Value) []reflect.Value {
+				err, _ := args[1].Interface().(error)
+				return []reflect.Value{reflect.ValueOf(y(args[0].Interface(), err))}
+			})})
+		})
+		return &listIterator{
+			next: func(context.Context) (interface{}, bool, error) {
+				item, err, ok := next()
+				if !ok || err != nil {
+					return nil, false, err
+				}
+				return item, true, nil
+			},
+			stop: stop,
+		}, true
+	}
+	return nil, false
+}
+
+// take pulls up to n items, or every item when n is negative, and reports whether the
+// iterator has no items left
+func (it *listIterator) take(ctx context.Context, n int) ([]interface{}, bool, error) {
+	items := []interface{}{}
+	for n < 0 || len(items) < n {
+		item, ok, err := it.next(ctx)
+		if err != nil {
+			return nil, true, err
+		}
+		if !ok {
+			return items, true, nil
+		}
+		items = append(items, item)
+	}
+	return items, false, nil
+}
+
+// isLazyList reports whether a resolver result produces its items on demand: an
+// iterator or a channel
+func isLazyList(result interface{}) bool {
+	v := reflect.ValueOf(result)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Func || v.Kind() == reflect.Chan
+}
+
+// resolveListItems lets the resolver of a list field return an iterator or a channel.
+// When the field is streamed with @stream, the items past initialCount stay in the
+// iterator and are sent once the initial result is out; otherwise they are collected
+// into the list.
+func resolveListItems(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := resolve(p)
+		if err != nil || result == nil {
+			return result, err
+		}
+
+		streams := listStreamsFrom(p.Context)
+		initialCount, streamed, err := streamArguments(p)
+		if err != nil {
+			return nil, err
+		}
+		if streams == nil || !streamed {
+			if !isLazyList(result) {
+				return result, nil
+			}
+			initialCount = -1
+		}
+
+		items, ok := newListIterator(result)
+		if !ok {
+			return result, nil
+		}
+		initial, done, err := items.take(p.Context, initialCount)
+		if done {
+			items.stop()
+			return initial, err
+		}
+		streams.add(&listStream{info: p.Info, items: items, sent: len(initial)})
+		return initial, nil
+	}
+}
+
+// streamArguments returns the initialCount of the @stream directive of a field, and
+// whether the field is streamed
+func streamArguments(p graphql.ResolveParams) (int, bool, error) {
+	for _, field := range p.Info.FieldASTs {
+		for _, directive := range field.Directives {
+			if directive.Name == nil || directive.Name.Value != StreamDirective.Name {
+				continue
+			}
+
+			initialCount, stream := 0, true
+			for _, arg := range directive.Arguments {
+				switch value := astValue(ResolveParams(p), arg.Value); arg.Name.Value {
+				case "initialCount":
+					if n, ok := value.(int); ok {
+						initialCount = n
+					}
+				case "if":
+					if b, ok := value.(bool); ok {
+						stream = b
+					}
+				}
+			}
+			if initialCount < 0 {
+				return 0, false, errors.New("@stream initialCount must be non-negative")
+			}
+			return initialCount, stream, nil
+		}
+	}
+	return 0, false, nil
+}
+
+type listStreamsContextKey struct{}
+
+// listStreams collects the lists of an execution whose items are streamed after the
+// initial result
+type listStreams struct {
+	params  ExecuteParams // The execution, whose context streams no lists
+	mu      sync.Mutex
+	streams []*listStream
+}
+
+// listStream is a list whose remaining items are still in its iterator
+type listStream struct {
+	info  graphql.ResolveInfo
+	items *listIterator
+	sent  int // Items sent in the initial result
+}
+
+// withListStreams returns params with a context in which @stream fields are streamed
+// after the initial result, and the streams collecting them
+func withListStreams(params ExecuteParams) (ExecuteParams, *listStreams) {
+	streams := &listStreams{params: params}
+	params.Context = context.WithValue(params.Context, listStreamsContextKey{}, streams)
+	return params, streams
+}
+
+// listStreamsFrom returns the streams of the execution, or nil when lists are not
+// streamed
+func listStreamsFrom(ctx context.Context) *listStreams {
+	if ctx == nil {
+		return nil
+	}
+	streams, _ := ctx.Value(listStreamsContextKey{}).(*listStreams)
+	return streams
+}
+
+func (s *listStreams) add(stream *listStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams = append(s.streams, stream)
+}
+
+// pending reports whether items remain to be streamed
+func (s *listStreams) pending() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams) > 0
+}
+
+// stop releases the iterators of the lists not streamed yet
+func (s *listStreams) stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stream := range s.streams {
+		stream.items.stop()
+	}
+	s.streams = nil
+}
+
+// incrementalResult is a result of an execution streaming lists: the initial result,
+// or items of a streamed list
+type incrementalResult struct {
+	*graphql.Result
+	Incremental []incrementalItems `json:"incremental,omitempty"`
+	HasNext     bool               `json:"hasNext"`
+}
+
+// incrementalItems are items of the list at the path of their first item. Items is
+// null when the list failed.
+type incrementalItems struct {
+	Items  []interface{}              `json:"items"`
+	Path   []interface{}              `json:"path"`
+	Errors []gqlerrors.FormattedError `json:"errors,omitempty"`
+}
+
+// serve streams the remaining items of every list, one payload per item, then sends
+// the final payload. It stops early when ctx ends.
+func (s *listStreams) serve(ctx context.Context, send func(incrementalResult)) {
+	for {
+		s.mu.Lock()
+		if len(s.streams) == 0 {
+			s.mu.Unlock()
+			break
+		}
+		stream := s.streams[0]
+		s.streams = s.streams[1:]
+		s.mu.Unlock()
+
+		s.serveList(ctx, stream, send)
+		if ctx.Err() != nil {
+			s.stop()
+			return
+		}
+	}
+	send(incrementalResult{HasNext: false})
+}
+
+// serveList completes the remaining items of a list against the selection of its field
+func (s *listStreams) serveList(ctx context.Context, stream *listStream, send func(incrementalResult)) {
+	defer stream.items.stop()
+
+	path := stream.info.Path.AsArray()
+	itemPath := func(index int) []interface{} {
+		return append(path[:len(path):len(path)], index)
+	}
+	fail := func(index int, err error) {
+		send(incrementalResult{
+			Incremental: []incrementalItems{{Path: itemPath(index), Errors: gqlerrors.FormatErrors(err)}},
+			HasNext:     true,
+		})
+	}
+
+	schema, err := streamItemSchema(stream.info)
+	if err != nil {
+		fail(stream.sent, err)
+		return
+	}
+	doc := streamItemDocument(stream.info)
+
+	for index := stream.sent; ctx.Err() == nil; index++ {
+		item, ok, err := stream.items.next(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				fail(index, err)
+			}
+			return
+		}
+		if !ok {
+			return
+		}
+
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:  *schema,
+			Root:    s.params.RootValue,
+			AST:     doc,
+			Args:    s.params.Variables,
+			Context: context.WithValue(s.params.Context, streamItemContextKey{}, item),
+		})
+
+		items := incrementalItems{Items: []interface{}{nil}, Path: itemPath(index)}
+		if data, ok := result.Data.(map[string]interface{}); ok {
+			items.Items[0] = data["item"]
+		}
+		for _, itemErr := range result.Errors {
+			// Errors are reported from the list field instead of the item root
+			if len(itemErr.Path) > 0 {
+				itemErr.Path = append(itemPath(index), itemErr.Path[1:]...)
+			}
+			items.Errors = append(items.Errors, itemErr)
+		}
+		send(incrementalResult{Incremental: []incrementalItems{items}, HasNext: true})
+	}
+}
+
+type streamItemContextKey struct{}
+
+// streamItemSchemas caches the schemas completing streamed items, by query root type
+// and item type
+var streamItemSchemas sync.Map // streamItemSchemaKey → *graphql.Schema
+
+type streamItemSchemaKey struct {
+	query    *graphql.Object
+	itemType graphql.Type
+}
+
+// streamItemSchema returns the schema completing the items of a streamed list field:
+// the types of the executing schema under a query root whose "item" field resolves to
+// the item being streamed
+func streamItemSchema(info graphql.ResolveInfo) (*graphql.Schema, error) {
+	listType := info.ReturnType
+	if nonNull, ok := listType.(*graphql.NonNull); ok {
+		listType = nonNull.OfType
+	}
+	list, ok := listType.(*graphql.List)
+	if !ok {
+		return nil, fmt.Errorf("@stream on %s.%s, which is not a list", info.ParentType.Name(), info.FieldName)
+	}
+
+	schema := info.Schema
+	key := streamItemSchemaKey{query: schema.QueryType(), itemType: list.OfType}
+	itemSchema, ok := streamItemSchemas.Load(key)
+	if !ok {
+		config := graphql.SchemaConfig{
+			Query: graphql.NewObject(graphql.ObjectConfig{
+				Name: "_StreamItem",
+				Fields: graphql.Fields{
+					"item": &graphql.Field{
+						Type: list.OfType,
+						Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+							return p.Context.Value(streamItemContextKey{}), nil
+						},
+					},
+				},
+			}),
+			Directives: schema.Directives(),
+		}
+		for name, t := range schema.TypeMap() {
+			// Introspection types are added by graphql.NewSchema
+			if !strings.HasPrefix(name, "__") {
+				config.Types = append(config.Types, t)
+			}
+		}
+
+		built, err := newSchema(config)
+		if err != nil {
+			return nil, err
+		}
+		itemSchema, _ = streamItemSchemas.LoadOrStore(key, &built)
+	}
+
+	// Resolvers instrumented for the schema dispatch on the query root type
+	if instrumentation, ok := schemaInstrumentations.Load(schema.QueryType()); ok {
+		schemaInstrumentations.Store(itemSchema.(*graphql.Schema).QueryType(), instrumentation)
+	}
+	return itemSchema.(*graphql.Schema), nil
+}
+
+// streamItemDocument returns the operation completing one item of a streamed list: the
+// selection of the list field on the "item" root field, with the variables and
+// fragments of the executing operation
+func streamItemDocument(info graphql.ResolveInfo) *ast.Document {
+	item := ast.NewField(&ast.Field{Name: ast.NewName(&ast.Name{Value: "item"})})
+	var selections []ast.Selection
+	for _, field := range info.FieldASTs {
+		if field.SelectionSet != nil {
+			selections = append(selections, field.SelectionSet.Selections...)
+		}
+	}
+	if len(selections) > 0 {
+		item.SelectionSet = ast.NewSelectionSet(&ast.SelectionSet{Selections: selections})
+	}
+
+	op := ast.NewOperationDefinition(&ast.OperationDefinition{
+		Operation:    ast.OperationTypeQuery,
+		SelectionSet: ast.NewSelectionSet(&ast.SelectionSet{Selections: []ast.Selection{item}}),
+	})
+	if operation, ok := info.Operation.(*ast.OperationDefinition); ok {
+		op.VariableDefinitions = operation.VariableDefinitions
+	}
+
+	definitions := []ast.Node{op}
+	for _, fragment := range info.Fragments {
+		definitions = append(definitions, fragment)
+	}
+	return ast.NewDocument(&ast.Document{Definitions: definitions})
+}
@@ -0,0 +1,131 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// SunsetPolicy decides how operations selecting deprecated fields are treated once the
+// sunset date of the fields, declared in their deprecation reason, has passed
+type SunsetPolicy int
+
+const (
+	// SunsetIgnore leaves deprecated fields working past their sunset date and sets no
+	// headers
+	SunsetIgnore SunsetPolicy = iota
+	// SunsetWarn sets the Deprecation and Sunset headers (RFC 8594) on the responses of
+	// operations selecting deprecated fields, which keep working past their sunset date
+	SunsetWarn
+	// SunsetEnforce sets the headers like SunsetWarn, and rejects the operations
+	// selecting a field past its sunset date with a FIELD_SUNSET error
+	SunsetEnforce
+)
+
+// sunsetPattern finds the sunset date in a deprecation reason, e.g.
+// "Use fullName (sunset: 2025-06-30)"
+var sunsetPattern = regexp.MustCompile(`(?i)\bsunset:?\s*(\d{4}-\d{2}-\d{2}(?:T\d{2}:\d{2}:\d{2}(?:Z|[+-]\d{2}:\d{2}))?)`)
+
+// SunsetReason appends the sunset date to a deprecation reason, in the form read back by
+// NewHTTP's SunsetPolicy. Struct fields can use the sunset option of the graphql tag
+// instead.
+//
+// Example:
+//
+//	"name": &graphql.Field{
+//	    Type:              graphql.String,
+//	    DeprecationReason: graph.SunsetReason("Use fullName", time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)),
+//	}
+func SunsetReason(reason string, sunset time.Time) string {
+	date := sunset.UTC().Format(time.RFC3339)
+	if sunset.UTC().Equal(sunset.UTC().Truncate(24 * time.Hour)) {
+		date = sunset.UTC().Format(time.DateOnly)
+	}
+	if reason == "" {
+		return "Sunset: " + date
+	}
+	return reason + " (sunset: " + date + ")"
+}
+
+// parseSunset returns the sunset date of a deprecation reason. A date without a time is
+// the start of the day, UTC.
+func parseSunset(reason string) (time.Time, bool) {
+	match := sunsetPattern.FindStringSubmatch(reason)
+	if match == nil {
+		return time.Time{}, false
+	}
+	if sunset, err := time.Parse(time.RFC3339, match[1]); err == nil {
+		return sunset, true
+	}
+	sunset, err := time.Parse(time.DateOnly, match[1])
+	return sunset, err == nil
+}
+
+// SunsetError rejects an operation selecting a field past its sunset date
+type SunsetError struct {
+	Field  string // Schema coordinate, e.g. "User.name"
+	Reason string
+	Sunset time.Time
+}
+
+// Error names the field and when it was removed
+func (e *SunsetError) Error() string {
+	return "field " + e.Field + " was removed on " + e.Sunset.UTC().Format(time.RFC3339) + ": " + e.Reason
+}
+
+// Extensions exposes the FIELD_SUNSET code, the field and its sunset date in the GraphQL
+// error
+func (e *SunsetError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   "FIELD_SUNSET",
+		"field":  e.Field,
+		"sunset": e.Sunset.UTC().Format(time.RFC3339),
+	}
+}
+
+// applySunsetPolicy sets the Deprecation and Sunset headers for the deprecated fields an
+// operation selects. Under SunsetEnforce, it returns the error rejecting the operation
+// when one of them is past its sunset date at now.
+func applySunsetPolicy(header http.Header, fields []deprecationWarning, policy SunsetPolicy, now time.Time) *SunsetError {
+	if policy == SunsetIgnore || len(fields) == 0 {
+		return nil
+	}
+
+	// The schema does not record when fields were deprecated, only that they are
+	header.Set("Deprecation", "true")
+
+	var earliest time.Time
+	var expired *SunsetError
+	for _, field := range fields {
+		if field.sunset.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || field.sunset.Before(earliest) {
+			earliest = field.sunset
+		}
+		if expired == nil && !now.Before(field.sunset) {
+			expired = &SunsetError{Field: field.Field, Reason: field.Reason, Sunset: field.sunset}
+		}
+	}
+	if !earliest.IsZero() {
+		header.Set("Sunset", earliest.UTC().Format(http.TimeFormat))
+	}
+
+	if policy != SunsetEnforce {
+		return nil
+	}
+	return expired
+}
+
+// writeSunsetError rejects an operation selecting a field past its sunset date
+func writeSunsetError(w http.ResponseWriter, err *SunsetError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    err.Error(),
+			"extensions": err.Extensions(),
+		}},
+	})
+}
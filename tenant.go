@@ -0,0 +1,194 @@
+package graph
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TenantExtractorFn returns the tenant of a request, or "" when it has none
+type TenantExtractorFn func(r *http.Request) string
+
+// TenantPrincipal is implemented by user details that belong to a tenant, for
+// TenantFromClaim
+type TenantPrincipal interface {
+	TenantID() string
+}
+
+// TenantRequiredError fails the fields of a request without a tenant, when
+// GraphContext.RequireTenant is set or the field uses RequireTenant
+type TenantRequiredError struct{}
+
+// Error explains the missing tenant
+func (e *TenantRequiredError) Error() string {
+	return "tenant required: the request does not identify a tenant"
+}
+
+// Extensions exposes the TENANT_REQUIRED code in the GraphQL error
+func (e *TenantRequiredError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "TENANT_REQUIRED"}
+}
+
+// TenantFromHeader reads the tenant from the request header name, such as "X-Tenant-ID".
+// Clients choose their header values: use it behind a gateway that sets the header
+// from an authenticated source.
+func TenantFromHeader(name string) TenantExtractorFn {
+	return func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(name))
+	}
+}
+
+// TenantFromSubdomain reads the tenant from the subdomain of baseDomain the request was
+// sent to: "acme" for acme.example.com with baseDomain "example.com". Hosts outside
+// baseDomain, and nested subdomains, have no tenant.
+func TenantFromSubdomain(baseDomain string) TenantExtractorFn {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return func(r *http.Request) string {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		tenant := strings.TrimSuffix(host, suffix)
+		if strings.Contains(tenant, ".") {
+			return ""
+		}
+		return tenant
+	}
+}
+
+// TenantFromClaim reads the tenant from the user details UserDetailsFn returned for the
+// request token: the claim of claims maps, or TenantID of details implementing
+// TenantPrincipal. Requests without valid credentials have no tenant.
+func TenantFromClaim(claim string) TenantExtractorFn {
+	return func(r *http.Request) string {
+		switch details := principalDetails(r.Context()).(type) {
+		case TenantPrincipal:
+			return details.TenantID()
+		case map[string]interface{}:
+			tenant, _ := details[claim].(string)
+			return tenant
+		}
+		return ""
+	}
+}
+
+// principalDetails returns the user details of the request of ctx: those of its root
+// value, or those fetched before execution when the root value isn't built yet
+func principalDetails(ctx context.Context) interface{} {
+	principal, ok := principalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	principal.mu.Lock()
+	defer principal.mu.Unlock()
+	if principal.details != nil {
+		return principal.details
+	}
+	if principal.fetched != nil && principal.fetched.err == nil {
+		return principal.fetched.details
+	}
+	return nil
+}
+
+// requestTenant holds the tenant of a request, extracted on first use since claims are
+// only known once the user details are fetched
+type requestTenant struct {
+	mu      sync.Mutex
+	id      string
+	r       *http.Request
+	extract TenantExtractorFn
+
+	// required fails the root fields of the request without a tenant
+	required bool
+}
+
+type tenantContextKey struct{}
+
+// withTenant attaches the tenant extractor of graphCtx to a request
+func withTenant(r *http.Request, graphCtx *GraphContext) *http.Request {
+	if graphCtx.TenantExtractorFn == nil && !graphCtx.RequireTenant {
+		return r
+	}
+	tenant := &requestTenant{extract: graphCtx.TenantExtractorFn, required: graphCtx.RequireTenant}
+	r = r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, tenant))
+	tenant.r = r
+	return r
+}
+
+// WithTenant returns a copy of ctx belonging to tenantID, for work done outside a
+// request, such as background jobs and tests
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, &requestTenant{id: tenantID})
+}
+
+// TenantID returns the tenant of the request being served, from
+// GraphContext.TenantExtractorFn, or of a context from WithTenant
+//
+// Example:
+//
+//	tenant, ok := graph.TenantID(p.Context)
+//	if !ok {
+//	    return nil, &graph.TenantRequiredError{}
+//	}
+//	return invoiceService.List(p.Context, tenant)
+func TenantID(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok := ctx.Value(tenantContextKey{}).(*requestTenant)
+	if !ok {
+		return "", false
+	}
+
+	tenant.mu.Lock()
+	defer tenant.mu.Unlock()
+	// Empty results are not kept: claims may not be known yet
+	if tenant.id == "" && tenant.extract != nil {
+		tenant.id = tenant.extract(tenant.r)
+	}
+	return tenant.id, tenant.id != ""
+}
+
+// RequireTenant is a permission middleware failing the field with a TENANT_REQUIRED
+// error when the request has no tenant.
+//
+// Example:
+//
+//	graph.NewResolver[Invoice]("invoices").
+//	    WithPermission(graph.RequireTenant()).
+//	    BuildListQuery()
+func RequireTenant() FieldMiddleware {
+	return requireTenant
+}
+
+// requireTenant fails fields resolved without a tenant
+func requireTenant(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		if _, ok := TenantID(p.Context); !ok {
+			return nil, &TenantRequiredError{}
+		}
+		return next(p)
+	}
+}
+
+// requireTenantResolvers fails the root fields of requests that require a tenant and
+// have none. The requirement is read from the context so handlers sharing a memoized
+// schema can differ.
+func requireTenantResolvers(next FieldResolveFn) FieldResolveFn {
+	guarded := requireTenant(next)
+	return func(p ResolveParams) (interface{}, error) {
+		if p.Context == nil || p.Info.Path == nil || p.Info.Path.Prev != nil {
+			return next(p)
+		}
+		if tenant, ok := p.Context.Value(tenantContextKey{}).(*requestTenant); ok && tenant.required {
+			return guarded(p)
+		}
+		return next(p)
+	}
+}
@@ -0,0 +1,277 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TenantPolicy is the plan of a tenant: the limits its operations are validated
+// against, how many requests it may send, and the features it may use
+type TenantPolicy struct {
+	// Limits replace DefaultQueryLimits for the tenant when EnableValidation is set.
+	// Zero fields keep their default.
+	Limits QueryCostLimits
+
+	// RateLimit is the number of requests the tenant may send per RateWindow
+	// (default one minute). 0 means unlimited.
+	RateLimit  int
+	RateWindow time.Duration
+
+	// Features are the feature gates enabled for the tenant (see RequireFeature)
+	Features []string
+}
+
+// TenantPolicyProvider returns the policy of tenants, so limits and feature gates can be
+// kept per plan in a database or configuration service. TenantPolicy is called once per
+// request that has a tenant, after authentication; an error fails the request with 500.
+type TenantPolicyProvider interface {
+	TenantPolicy(ctx context.Context, tenantID string) (TenantPolicy, error)
+}
+
+// TenantPolicyFunc adapts a function to a TenantPolicyProvider
+//
+// Example:
+//
+//	plans := map[string]graph.TenantPolicy{
+//	    "free": {RateLimit: 60, Limits: graph.QueryCostLimits{MaxComplexity: 50}},
+//	    "pro":  {RateLimit: 1000, Features: []string{"exports"}},
+//	}
+//
+//	handler := graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams:      params,
+//	    TenantExtractorFn: graph.TenantFromClaim("org_id"),
+//	    TenantPolicies: graph.TenantPolicyFunc(func(ctx context.Context, tenant string) (graph.TenantPolicy, error) {
+//	        plan, err := billing.PlanOf(ctx, tenant)
+//	        return plans[plan], err
+//	    }),
+//	})
+type TenantPolicyFunc func(ctx context.Context, tenantID string) (TenantPolicy, error)
+
+// TenantPolicy calls f
+func (f TenantPolicyFunc) TenantPolicy(ctx context.Context, tenantID string) (TenantPolicy, error) {
+	return f(ctx, tenantID)
+}
+
+// RateLimitedError rejects requests of a tenant that used up its TenantPolicy.RateLimit
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+// Error explains when the tenant may send requests again
+func (e *RateLimitedError) Error() string {
+	return "rate limit exceeded: retry after " + strconv.Itoa(e.retryAfterSeconds()) + "s"
+}
+
+// Extensions exposes the RATE_LIMITED code and the seconds to wait in the GraphQL error
+func (e *RateLimitedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "RATE_LIMITED", "retryAfter": e.retryAfterSeconds()}
+}
+
+// retryAfterSeconds rounds RetryAfter up to whole seconds, as sent in Retry-After
+func (e *RateLimitedError) retryAfterSeconds() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// FeatureDisabledError is returned when the tenant of a request lacks the features a
+// field requires
+type FeatureDisabledError struct {
+	MissingFeatures []string
+}
+
+// Error lists the missing features
+func (e *FeatureDisabledError) Error() string {
+	return "feature not enabled: " + strings.Join(e.MissingFeatures, ", ")
+}
+
+// Extensions exposes the FEATURE_DISABLED code and the missing features in the GraphQL error
+func (e *FeatureDisabledError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "FEATURE_DISABLED", "missingFeatures": e.MissingFeatures}
+}
+
+type tenantPolicyContextKey struct{}
+
+// withTenantPolicy attaches the policy of the tenant of a request. Requests without a
+// tenant keep the defaults.
+func withTenantPolicy(r *http.Request, provider TenantPolicyProvider) (*http.Request, error) {
+	if provider == nil {
+		return r, nil
+	}
+	tenant, ok := TenantID(r.Context())
+	if !ok {
+		return r, nil
+	}
+	policy, err := provider.TenantPolicy(r.Context(), tenant)
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), tenantPolicyContextKey{}, &policy)), nil
+}
+
+// tenantPolicyFromContext returns the policy of the tenant of the request being served
+func tenantPolicyFromContext(ctx context.Context) (*TenantPolicy, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	policy, ok := ctx.Value(tenantPolicyContextKey{}).(*TenantPolicy)
+	return policy, ok
+}
+
+// queryLimits returns the limits operations of the request of ctx are validated against:
+// those of its tenant's policy, defaulting to DefaultQueryLimits, lowered while the
+// server is overloaded (see AdaptiveLimiter), allowing introspection to requests with
+// an introspection token
+func queryLimits(ctx context.Context) QueryCostLimits {
+	if ctx == nil {
+		return DefaultQueryLimits
+	}
+	limits := scaleLimits(ctx, tenantLimits(ctx))
+	if introspectionAllowed(ctx) {
+		limits.AllowIntrospection = true
+	}
+	return limits
+}
+
+// tenantLimits returns the limits of the tenant's policy, defaulting to DefaultQueryLimits
+func tenantLimits(ctx context.Context) QueryCostLimits {
+	limits := DefaultQueryLimits
+	policy, ok := tenantPolicyFromContext(ctx)
+	if !ok {
+		return limits
+	}
+
+	override := func(limit *int, value int) {
+		if value > 0 {
+			*limit = value
+		}
+	}
+	override(&limits.MaxDepth, policy.Limits.MaxDepth)
+	override(&limits.MaxAliases, policy.Limits.MaxAliases)
+	override(&limits.MaxComplexity, policy.Limits.MaxComplexity)
+	override(&limits.MaxOperations, policy.Limits.MaxOperations)
+	override(&limits.MaxVariables, policy.Limits.MaxVariables)
+	override(&limits.MaxStringLength, policy.Limits.MaxStringLength)
+	override(&limits.MaxListLength, policy.Limits.MaxListLength)
+	override(&limits.MaxInputDepth, policy.Limits.MaxInputDepth)
+	if policy.Limits.AllowIntrospection {
+		limits.AllowIntrospection = true
+	}
+	return limits
+}
+
+// HasFeature reports whether the tenant of the request being served by NewHTTP has
+// feature enabled by its TenantPolicy, for checks inside resolvers.
+//
+// Example:
+//
+//	if graph.HasFeature(p.Context, "audit-log") {
+//	    report.Entries = auditLog.For(report.ID)
+//	}
+func HasFeature(ctx context.Context, feature string) bool {
+	policy, ok := tenantPolicyFromContext(ctx)
+	return ok && containsString(policy.Features, feature)
+}
+
+// RequireFeature is a permission middleware allowing only tenants whose TenantPolicy
+// enables every one of features. Other requests get a FEATURE_DISABLED error listing
+// the missing features.
+//
+// Example:
+//
+//	graph.NewResolver[Report]("exportReport").
+//	    WithPermission(graph.RequireFeature("exports")).
+//	    BuildMutation()
+func RequireFeature(features ...string) FieldMiddleware {
+	return func(next FieldResolveFn) FieldResolveFn {
+		return func(p ResolveParams) (interface{}, error) {
+			var missing []string
+			for _, feature := range features {
+				if !HasFeature(p.Context, feature) {
+					missing = append(missing, feature)
+				}
+			}
+			if len(missing) > 0 {
+				return nil, &FeatureDisabledError{MissingFeatures: missing}
+			}
+			return next(p)
+		}
+	}
+}
+
+// tenantRateLimiter counts the requests of each tenant in fixed windows
+type tenantRateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// rateWindow is the current window of a tenant
+type rateWindow struct {
+	start time.Time
+	ends  time.Time
+	count int
+}
+
+func newTenantRateLimiter(provider TenantPolicyProvider) *tenantRateLimiter {
+	if provider == nil {
+		return nil
+	}
+	return &tenantRateLimiter{windows: make(map[string]*rateWindow), now: time.Now}
+}
+
+// allow counts the request of ctx against the rate limit of its tenant's policy,
+// returning a RATE_LIMITED error when the tenant is over it
+func (l *tenantRateLimiter) allow(ctx context.Context) *RateLimitedError {
+	policy, ok := tenantPolicyFromContext(ctx)
+	if l == nil || !ok || policy.RateLimit <= 0 {
+		return nil
+	}
+	tenant, _ := TenantID(ctx)
+	period := policy.RateWindow
+	if period <= 0 {
+		period = time.Minute
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	// Forget the windows of tenants that stopped sending requests
+	if now.After(l.nextSweep) {
+		for id, window := range l.windows {
+			if !now.Before(window.ends) {
+				delete(l.windows, id)
+			}
+		}
+		l.nextSweep = now.Add(time.Minute)
+	}
+
+	window, ok := l.windows[tenant]
+	if !ok || !now.Before(window.ends) || !window.start.Add(period).Equal(window.ends) {
+		window = &rateWindow{start: now, ends: now.Add(period)}
+		l.windows[tenant] = window
+	}
+	if window.count >= policy.RateLimit {
+		return &RateLimitedError{RetryAfter: window.ends.Sub(now)}
+	}
+	window.count++
+	return nil
+}
+
+// writeRateLimited answers a request over its tenant's rate limit with 429
+func writeRateLimited(w http.ResponseWriter, err *RateLimitedError) {
+	w.Header().Set("Retry-After", strconv.Itoa(err.retryAfterSeconds()))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    err.Error(),
+			"extensions": err.Extensions(),
+		}},
+	})
+}
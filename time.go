@@ -12,7 +12,7 @@ import (
 const SpringShortLayout = "2006-01-02T15:04"
 
 // serializeDateTime converts time.Time to Spring Boot compatible string format.
-// Always returns time in UTC.
+// Returns time in UTC, unless converted to the request's time zone.
 func serializeDateTime(value interface{}) interface{} {
 	if t, ok := value.(time.Time); ok {
 		// always UTC to match Spring Boot style
@@ -21,6 +21,10 @@ func serializeDateTime(value interface{}) interface{} {
 	if t, ok := value.(*time.Time); ok && t != nil {
 		return t.UTC().Format(SpringShortLayout)
 	}
+	if t, ok := value.(localDateTime); ok {
+		// converted to the client's time zone (see GraphContext.LocalDateTimes)
+		return t.Format(SpringShortLayout)
+	}
 	return nil
 }
 
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ErrTokenExpired is returned (or wrapped) by UserDetailsFn for expired tokens. NewHTTP
+// then answers 401 with an UNAUTHENTICATED error and a WWW-Authenticate header, so
+// clients know to refresh their token and retry, instead of executing the operation
+// anonymously.
+//
+// Example:
+//
+//	UserDetailsFn: func(token string) (interface{}, error) {
+//	    claims, err := jwt.Parse(token)
+//	    if errors.Is(err, jwt.ErrTokenExpired) {
+//	        return nil, fmt.Errorf("%w: %v", graph.ErrTokenExpired, err)
+//	    }
+//	    return claims, err
+//	}
+var ErrTokenExpired = errors.New("token expired")
+
+// UnauthenticatedError reports a request whose credentials were rejected
+type UnauthenticatedError struct {
+	Reason string
+}
+
+// Error returns the reason the credentials were rejected
+func (e *UnauthenticatedError) Error() string {
+	return "unauthenticated: " + e.Reason
+}
+
+// Extensions exposes the UNAUTHENTICATED code in the GraphQL error
+func (e *UnauthenticatedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "UNAUTHENTICATED"}
+}
+
+// TokenRotationFn mints a replacement for the token of an authenticated request, e.g.
+// when it is about to expire. A non-empty token is returned to the client in the
+// "rotatedToken" response extension; an empty token or an error leaves the response
+// unchanged.
+type TokenRotationFn func(ctx context.Context, token string, details interface{}) (string, error)
+
+// authenticateRequest rejects revoked tokens and fetches the user details of the request
+// token ahead of execution, caching them in the request principal for its root value.
+// It returns ErrTokenRevoked or ErrTokenExpired when the request must be rejected.
+func authenticateRequest(r *http.Request, graphCtx *GraphContext, revocations *revocationCache) error {
+	if graphCtx.UserDetailsFn == nil && revocations == nil {
+		return nil
+	}
+	return authenticateToken(r.Context(), graphCtx, revocations, requestToken(graphCtx, r))
+}
+
+// authenticateToken is authenticateRequest for a token obtained elsewhere than the
+// request headers, such as the connection init payload of a stream
+func authenticateToken(ctx context.Context, graphCtx *GraphContext, revocations *revocationCache, token string) error {
+	if token == "" {
+		return nil
+	}
+	if revocations != nil && revocations.isRevoked(token) {
+		return ErrTokenRevoked
+	}
+
+	principal, ok := principalFromContext(ctx)
+	if graphCtx.UserDetailsFn == nil || !ok {
+		return nil
+	}
+	details, err := graphCtx.UserDetailsFn(token)
+	principal.mu.Lock()
+	principal.fetched = &fetchedDetails{token: token, details: details, err: err}
+	principal.mu.Unlock()
+	if errors.Is(err, ErrTokenExpired) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+// fetchedDetails are the user details fetched for a token by authenticateRequest
+type fetchedDetails struct {
+	token   string
+	details interface{}
+	err     error
+}
+
+// userDetails returns the user details of token, reusing those fetched by
+// authenticateRequest for the request of ctx
+func userDetails(ctx context.Context, graphCtx *GraphContext, token string) (interface{}, error) {
+	if principal, ok := principalFromContext(ctx); ok {
+		principal.mu.Lock()
+		fetched := principal.fetched
+		principal.mu.Unlock()
+		if fetched != nil && fetched.token == token {
+			return fetched.details, fetched.err
+		}
+	}
+	return graphCtx.UserDetailsFn(token)
+}
+
+// writeUnauthenticated rejects a request whose token expired or was revoked
+func writeUnauthenticated(w http.ResponseWriter, reason error) {
+	err := &UnauthenticatedError{Reason: reason.Error()}
+	description := "The access token expired"
+	if errors.Is(reason, ErrTokenRevoked) {
+		description = "The access token was revoked"
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+description+`"`)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    err.Error(),
+			"extensions": err.Extensions(),
+		}},
+	})
+}
+
+// rotateToken returns the decorator attaching the token minted by rotate for the
+// principal of the request to the response extensions
+func rotateToken(rotate TokenRotationFn) ResponseDecorator {
+	return func(ctx context.Context, response *graphql.Result) {
+		principal, ok := principalFromContext(ctx)
+		if !ok {
+			return
+		}
+		principal.mu.Lock()
+		token, details := principal.token, principal.details
+		principal.mu.Unlock()
+		if token == "" {
+			return
+		}
+
+		rotated, err := rotate(ctx, token, details)
+		if err != nil || rotated == "" {
+			return
+		}
+		if response.Extensions == nil {
+			response.Extensions = make(map[string]interface{})
+		}
+		response.Extensions["rotatedToken"] = rotated
+	}
+}
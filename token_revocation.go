@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrTokenRevoked is the reason requests with a token reported by TokenRevokedFn, or
+// passed to RevokeToken, are rejected
+var ErrTokenRevoked = errors.New("token revoked")
+
+const (
+	// revocationCacheTTL is how long TokenRevokedFn results are reused
+	revocationCacheTTL = 10 * time.Second
+	// revocationCacheSize bounds the tokens whose results are cached
+	revocationCacheSize = 1024
+)
+
+// revocationCache caches TokenRevokedFn results for a handler, so the revocation
+// store is not queried on every request
+type revocationCache struct {
+	revoked func(token string) bool
+
+	mu      sync.Mutex
+	entries map[string]revocationEntry
+}
+
+type revocationEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// newRevocationCache creates the cache of a handler, or nil when revocation is disabled
+func newRevocationCache(revoked func(token string) bool) *revocationCache {
+	if revoked == nil {
+		return nil
+	}
+	return &revocationCache{revoked: revoked, entries: make(map[string]revocationEntry)}
+}
+
+// isRevoked reports whether token was revoked, from the cache when fresh
+func (c *revocationCache) isRevoked(token string) bool {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[token]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked
+	}
+
+	revoked := c.revoked(token)
+	c.store(token, revoked, now)
+	return revoked
+}
+
+// store caches the result for token, evicting expired entries, or every entry when the
+// cache is full
+func (c *revocationCache) store(token string, revoked bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= revocationCacheSize {
+		for t, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, t)
+			}
+		}
+		if len(c.entries) >= revocationCacheSize {
+			c.entries = make(map[string]revocationEntry)
+		}
+	}
+	c.entries[token] = revocationEntry{revoked: revoked, expiresAt: now.Add(revocationCacheTTL)}
+}
+
+type revocationContextKey struct{}
+
+// withRevocations exposes the revocation cache of the handler to RevokeToken
+func withRevocations(r *http.Request, revocations *revocationCache) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), revocationContextKey{}, revocations))
+}
+
+// RevokeToken rejects token in the handler serving ctx at once, typically from a logout
+// mutation. The revocation must also be recorded where TokenRevokedFn looks for it, which
+// other instances and this one rely on once the cached result expires. It is a no-op
+// without TokenRevokedFn.
+//
+// Example:
+//
+//	Resolve: func(p graph.ResolveParams) (interface{}, error) {
+//	    token, err := graph.GetRootString(p, "token")
+//	    if err != nil {
+//	        return false, nil
+//	    }
+//	    if err := denyList.Add(p.Context, token); err != nil {
+//	        return nil, err
+//	    }
+//	    graph.RevokeToken(p.Context, token)
+//	    return true, nil
+//	}
+func RevokeToken(ctx context.Context, token string) {
+	revocations, ok := ctx.Value(revocationContextKey{}).(*revocationCache)
+	if !ok || token == "" {
+		return
+	}
+	revocations.store(token, true, time.Now())
+}
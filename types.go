@@ -2,7 +2,9 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/graphql-go/graphql"
 )
@@ -21,7 +23,12 @@ import (
 // Authentication:
 //   - TokenExtractorFn: Extract tokens from requests (defaults to Bearer token extraction)
 //   - UserDetailsFn: Fetch user details from the extracted token
+//   - RequestSignature: Authenticate HMAC-signed server-to-server requests
+//   - IntrospectionTokens: Allow introspection to requests with a short-lived signed token
+//   - ClientCertAuth: Authenticate requests by their verified TLS client certificate
 //   - RootObjectFn: Custom root object setup for advanced use cases
+//   - TenantExtractorFn: Identify the tenant of requests, from a header, subdomain or claim
+//   - TenantPolicies: Per-tenant limits, rate limits and feature gates
 //
 // Example Development Setup:
 //
@@ -63,12 +70,21 @@ type GraphContext struct {
 	// Playground: Enable GraphQL Playground interface
 	Playground bool
 
+	// PlaygroundConfig: Endpoint, default headers and tabs of the Playground page
+	// Default: nil (the page queries the request path, with no headers or tabs)
+	// When set: the playground queries Endpoint, sends Headers (and those of HeadersFn)
+	// with every tab, and opens Tabs and, with ExampleTabs, an example operation per root
+	// field; Offline serves an embedded IDE needing no CDN
+	PlaygroundConfig *PlaygroundConfig
+
 	// DEBUG mode skips validation and sanitization for easier development
 	// Default: false (validation enabled)
 	DEBUG bool
 
 	// RootObjectFn: Custom function to set up root object for each request
-	// Called before token extraction and user details fetching
+	// Called after token extraction, which TokenFromContext(ctx) returns, and before user
+	// details fetching. The returned values are merged into the root value; "token" and
+	// "details" take precedence over them unless KeepRootObjectValues is set.
 	RootObjectFn func(ctx context.Context, r *http.Request) map[string]interface{}
 
 	// TokenExtractorFn: Custom token extraction from request
@@ -82,13 +98,437 @@ type GraphContext struct {
 
 	// EnableValidation: Enable query validation (depth, complexity, introspection checks)
 	// Default: false (validation disabled)
-	// When enabled: Max depth=10, Max aliases=4, Max complexity=200, Introspection blocked
+	// When enabled: Max depth=10, Max aliases=4, Max complexity=200, Introspection blocked;
+	// variables nested deeper than MaxInputDepth are rejected before they are decoded
 	EnableValidation bool
 
 	// EnableSanitization: Enable response sanitization (removes field suggestions from errors)
 	// Default: false (sanitization disabled)
 	// Prevents information disclosure by removing "Did you mean X?" suggestions
 	EnableSanitization bool
+
+	// EnableMsgPack: Enable MessagePack content negotiation
+	// Default: false (JSON only)
+	// When enabled: request bodies sent with Content-Type application/msgpack are decoded,
+	// and responses are encoded as MessagePack when the Accept header asks for it
+	EnableMsgPack bool
+
+	// MaxResponseBytes: Maximum size of a serialized response body in bytes
+	// Default: 0 (unlimited)
+	// Responses exceeding the limit are discarded and replaced with a RESPONSE_TOO_LARGE error,
+	// protecting memory against queries that pass complexity checks but return huge payloads.
+	// The limit applies to the serialized body: the result is still built and encoded in
+	// full before it is measured, so it bounds what is buffered and sent, not what
+	// execution allocates; pair it with complexity limits for that.
+	MaxResponseBytes int
+
+	// LandingPage: Answer GET requests without a query
+	// Default: nil (they are executed, failing with a "Must provide an operation" error)
+	// When set: browsers are redirected to LandingPage.RedirectURL, if any, and other
+	// clients get JSON describing how to query the endpoint; the playground, when
+	// enabled, is still served to browsers
+	LandingPage *LandingPageConfig
+
+	// AccessLog: Log every request served by NewHTTP
+	// Default: nil (no access log)
+	// When set: one line per request, in the Apache combined or JSON format, records the
+	// method, path, operation name, status, response bytes, latency and client name
+	AccessLog *AccessLogConfig
+
+	// EnableSSE: Enable the Server-Sent Events transport (GraphQL over SSE, distinct connections mode)
+	// Default: false (SSE disabled)
+	// When enabled: requests with Accept: text/event-stream are streamed as "next" events
+	// followed by a "complete" event; subscriptions emit one event per published result
+	EnableSSE bool
+
+	// LivePubSub: Enable live queries (@live) using this PubSub for invalidation
+	// Default: nil (live queries disabled)
+	// When set: the @live directive is added to built schemas, and "query @live" requests served
+	// over SSE are re-executed and pushed whenever a topic passed to DependsOn is published
+	LivePubSub PubSub
+
+	// Subscriptions: Connection settings for the subscription transport (SSE)
+	// Default: zero value (no keepalive, unlimited lifetime and subscriptions)
+	// See SubscriptionConfig for the individual settings
+	Subscriptions SubscriptionConfig
+
+	// EnableEstimate: Enable the complexity pre-flight endpoint
+	// Default: false (estimates disabled)
+	// When enabled: POST requests to a path ending in /estimate, or with "estimate": true in their
+	// extensions, are parsed, validated and measured without executing, returning the computed
+	// cost and limits in extensions.cost
+	EnableEstimate bool
+
+	// EnableValidateOnly: Enable dry-run requests
+	// Default: false (dry runs disabled)
+	// When enabled: requests with "validateOnly": true in their extensions, or the
+	// X-GraphQL-Validate-Only: true header, are validated against the schema (including
+	// argument and variable types) and answered with the errors without invoking any resolvers
+	EnableValidateOnly bool
+
+	// MessageCatalog: Localizes errors created with NewError
+	// Default: nil (messages are returned as rendered by the resolver)
+	// When set: error messages are resolved from the catalog by message key, using the first
+	// locale in the request's Accept-Language header that the catalog supports
+	MessageCatalog MessageCatalog
+
+	// ResponseDecorator: Hook invoked with every response before it is serialized
+	// Default: nil
+	// When set: it can edit the result, for example to attach service version, region or
+	// deprecation notices to the extensions. It also runs for each Server-Sent Events result.
+	ResponseDecorator ResponseDecorator
+
+	// DeprecationTracker: Records operations selecting deprecated fields, by field and client
+	// Default: nil (no tracking)
+	// When set: each deprecated field an operation selects is counted for the client named by
+	// the X-GraphQL-Client-Name (or apollographql-client-name) header; read the counts with
+	// Report or serve them with the tracker's ServeHTTP
+	DeprecationTracker *DeprecationTracker
+
+	// DeprecationWarnings: Warn clients about the deprecated fields they select
+	// Default: false
+	// When enabled: responses list the deprecated fields and their reasons in extensions.deprecations
+	DeprecationWarnings bool
+
+	// SunsetPolicy: Treatment of deprecated fields with a sunset date in their reason
+	// Default: SunsetIgnore (fields keep working, no headers)
+	// When set: SunsetWarn sets the Deprecation and Sunset (RFC 8594) headers on responses
+	// to operations selecting deprecated fields; SunsetEnforce also rejects operations
+	// selecting a field past its sunset date with FIELD_SUNSET. Declare the date with
+	// SunsetReason or the sunset option of the graphql tag.
+	SunsetPolicy SunsetPolicy
+
+	// UsageCollector: Samples the fields operations select, with client attribution
+	// Default: nil (no usage analytics)
+	// When set: sampled operations are recorded; use Report or the collector's ServeHTTP to
+	// list hot fields and fields that were never queried
+	UsageCollector *UsageCollector
+
+	// Executor: Execution backend for operations served over HTTP, SSE and the REST bridge
+	// Default: nil (GraphQLGoExecutor, backed by github.com/graphql-go/graphql)
+	// When set: every operation is executed through it, e.g. to swap or decorate the engine
+	Executor Executor
+
+	// Codec: JSON codec of request bodies and responses
+	// Default: nil (StdCodec, backed by encoding/json)
+	// When set: JSON request bodies are decoded, and results and Server-Sent Events
+	// payloads encoded, with it, e.g. jsoniter.ConfigCompatibleWithStandardLibrary
+	Codec Codec
+
+	// RejectParseErrors: Reject malformed queries at the validation step
+	// Default: false (parse errors are reported by the GraphQL handler)
+	// When enabled with EnableValidation: queries that fail to parse are rejected with
+	// 400 Bad Request and the line and column of the syntax error, before execution
+	RejectParseErrors bool
+
+	// NullPropagation: What happens when a non-null field resolves to an error or null
+	// Default: PropagateNulls (the nearest nullable parent becomes null, per the GraphQL spec)
+	// When set to MaskNulls: only the failing field becomes null, with an error entry
+	// for it, so clients keep the partial data of its parent
+	NullPropagation NullPropagationPolicy
+
+	// Recorder: In-memory buffer of recent operations for debugging
+	// Default: nil (nothing recorded)
+	// When set with DEBUG: queries and mutations are recorded with their variables,
+	// duration and errors; mount the Recorder as a handler to inspect them
+	Recorder *Recorder
+
+	// SlowQueries: Log operations slower than a threshold
+	// Default: nil (no slow operation log)
+	// When set: operations exceeding SlowQueries.Threshold in total, or with a resolver
+	// exceeding SlowQueries.ResolverThreshold, are logged as JSON with their query,
+	// redacted variables, cost and per-field timings; resolvers of every operation are timed
+	SlowQueries *SlowQueryConfig
+
+	// ReportDefaults: List the fields that resolved to their declared default
+	// Default: false (defaults are substituted silently)
+	// When enabled: fields resolved by WithDefault, WithFieldDefault or DefaultValue
+	// because their resolver failed or returned null are listed, with the reason, in
+	// extensions.defaults
+	ReportDefaults bool
+
+	// EnableExplain: Allow explain requests outside DEBUG mode
+	// Default: false (explain is available in DEBUG mode only)
+	// When enabled: requests with the X-GraphQL-Explain: true header or "explain": true
+	// in the extensions get the resolver call tree, with timings and cache hits, in
+	// extensions.explain
+	EnableExplain bool
+
+	// SchemaVersion: Identifies the schema built from SchemaParams
+	// Default: "" (schemas are memoized by SchemaParams pointer alone)
+	// When set: handlers built from the same SchemaParams and SchemaVersion share one
+	// schema; change it to rebuild after modifying SchemaParams in place
+	SchemaVersion string
+
+	// EnableSchemaVersion: Tell clients which schema version serves them
+	// Default: false (no version header)
+	// When enabled: every response carries the X-Schema-Version header, with SchemaVersion
+	// or, when empty, SchemaHash of the schema; requests whose X-Schema-Min-Version header
+	// names a newer version (see CompareVersions) are rejected with 412 and
+	// SCHEMA_VERSION_TOO_OLD. With hash versions, any other hash counts as newer.
+	EnableSchemaVersion bool
+
+	// WarmupOperations: Operations executed in-process when NewHTTP builds the handler
+	// Default: nil (no warm-up)
+	// When set: each operation runs through the whole handler, in order, before NewHTTP
+	// returns; one answered with an error makes NewHTTP panic, so a miswired schema fails
+	// at startup rather than on the first request. Also primes caches and connection pools.
+	WarmupOperations []WarmupOperation
+
+	// RequestSignature: Verify HMAC-signed requests from server-to-server callers
+	// Default: nil (signature headers are ignored)
+	// When set: requests with a signature header are verified over their method, path,
+	// query and raw body before anything else; valid ones are authenticated as a Caller
+	// identified by the signing key ID, and invalid ones get 401
+	RequestSignature *SignatureConfig
+
+	// CallerDetailsFn: Returns the user details of an authenticated Caller
+	// Default: nil (callers have no user details)
+	// When set: requests from a caller authenticated by the transport, with a verified
+	// RequestSignature or client certificate (ClientCertAuth), get these details in place of those UserDetailsFn returns for
+	// their token; an error leaves them without details
+	CallerDetailsFn func(caller Caller) (interface{}, error)
+
+	// IntrospectionTokens: Let tooling pipelines introspect while introspection is blocked
+	// Default: nil (EnableValidation rejects every introspection query)
+	// When set: requests with a valid token from IssueIntrospectionToken in the
+	// X-Introspection-Token header may query __schema and __type until it expires;
+	// invalid or expired tokens get 401
+	IntrospectionTokens *IntrospectionTokenConfig
+
+	// ClientCertAuth: Authenticate requests without a token by their TLS client certificate
+	// Default: false (client certificates are only exposed via ClientIdentityFromContext)
+	// When enabled: requests where TokenExtractorFn finds no token are authenticated as a
+	// Caller named after their verified client certificate (URI, DNS or email SAN, else
+	// CN), with user details from CallerDetailsFn; the name is never taken as a token
+	ClientCertAuth bool
+
+	// ScopesFn: Returns the scopes granted to the user details from UserDetailsFn
+	// Default: nil (details implementing ScopedPrincipal, or claims maps with a "scope",
+	// "scopes" or "scp" claim, provide their scopes)
+	// When set: decides the scopes checked by RequireScopes and HasScope
+	ScopesFn func(details interface{}) []string
+
+	// TenantExtractorFn: Identifies the tenant of a request (see TenantFromHeader,
+	// TenantFromSubdomain and TenantFromClaim)
+	// Default: nil (requests have no tenant)
+	// When set: TenantID(ctx) returns the tenant of the request in resolvers, and
+	// sqlgraph tables with a tenant column scope their statements to it
+	TenantExtractorFn TenantExtractorFn
+
+	// RequireTenant: Reject operations of requests without a tenant
+	// Default: false
+	// When enabled: the root fields of requests TenantExtractorFn finds no tenant for
+	// fail with a TENANT_REQUIRED error
+	RequireTenant bool
+
+	// TenantPolicies: Returns the policy of the plan of each tenant
+	// Default: nil (every tenant gets DefaultQueryLimits, no rate limit and no features)
+	// When set: requests with a tenant are validated against the limits of its policy,
+	// rejected with 429 and a RATE_LIMITED error over its rate limit, and may resolve
+	// fields guarded by RequireFeature only with the features it enables
+	TenantPolicies TenantPolicyProvider
+
+	// RequestTimeout: Deadline of each request, Server-Sent Events streams excepted
+	// Default: 0 (no deadline beyond the client's)
+	// When set: resolvers see the deadline in their context, where RemainingBudget reads
+	// it and BudgetMiddleware gives fields a share of it
+	RequestTimeout time.Duration
+
+	// AdaptiveLimits: Lowers the complexity and depth limits under load
+	// Default: nil (limits are fixed)
+	// When set: while the p99 latency or the requests in flight exceed the thresholds of
+	// the AdaptiveLimiter, MaxComplexity and MaxDepth are scaled down, and restored
+	// once load recedes (limits are checked when EnableValidation is set)
+	AdaptiveLimits *AdaptiveLimiter
+
+	// HealthSignal: Reports whether the dependencies of sheddable fields are up
+	// Default: nil (sheddable fields always resolve)
+	// When set: fields marked with Shed(dependency) resolve to null with a DEGRADED error,
+	// without calling their resolver, while the signal reports their dependency down
+	// (see DegradationSwitch)
+	HealthSignal HealthSignal
+
+	// ErrorReporter: Receives the unexpected errors and panics of resolvers
+	// Default: nil (errors are only returned to the client)
+	// When set: errors without GraphQL extensions and resolver panics are reported with
+	// the operation name, field, response path and panic stack (see SentryReporter)
+	ErrorReporter ErrorReporter
+
+	// AuthorizationPolicy: Decides per field whether it may be resolved
+	// Default: nil (no policy)
+	// When set: the policy is asked before every object field is resolved, with the
+	// principal, operation, field path and arguments; denied fields resolve to null with
+	// a FORBIDDEN error
+	AuthorizationPolicy AuthorizationPolicy
+
+	// MutationAudit: Record every mutation field to an audit sink
+	// Default: nil (mutations are not audited)
+	// When set: each root mutation field is recorded with the operation name, principal,
+	// redacted arguments, status and time (see WriterAuditSink, ChannelAuditSink and
+	// HTTPAuditSink)
+	MutationAudit *MutationAuditConfig
+
+	// Idempotency: Replay the responses of mutations retried with the same idempotency key
+	// Default: nil (every request executes)
+	// When set: the first response of a mutation sent with an Idempotency-Key header (or
+	// "idempotencyKey" extension) is stored per key and principal, and replayed for
+	// retries within the TTL; reusing a key for another operation gets 422
+	Idempotency *IdempotencyConfig
+
+	// AfterMutation: Receives every root mutation field that succeeded
+	// Default: nil (no events)
+	// When set: called with the operation name, arguments and result of each mutation
+	// field once its resolver returned without error, to publish domain events
+	AfterMutation AfterMutationFn
+
+	// CacheControl: Computes the cache policy of query responses from field hints
+	// Default: nil (no Cache-Control header)
+	// When set: @cacheControl is declared, and the lowest max age of the hinted fields
+	// (private if any field is) is returned as a Cache-Control header and the
+	// "cacheControl" extension. Mutations and responses with errors are "no-store".
+	CacheControl *CacheControlConfig
+
+	// EnableETag: Tags query responses with a strong ETag and honors If-None-Match
+	// Default: false
+	// When enabled: GET responses, and POST responses made cacheable by CacheControl,
+	// carry an ETag computed over the serialized body; requests whose If-None-Match
+	// lists it get 304 Not Modified without a body
+	EnableETag bool
+
+	// EnableCompression: Compresses responses with gzip for clients accepting it
+	// Default: false
+	// When enabled: response bodies of 1 KiB or more are gzipped when the Accept-Encoding
+	// header allows it, after the ETag is computed (the ETag is then weak)
+	EnableCompression bool
+
+	// ResponsePipelineFn: Adds stages to the response pipeline of each request
+	// Default: nil (the built-in stages of the enabled features only)
+	// When set: called with each request and its pipeline, after the built-in stages of
+	// DecoratePhase are added; stages run phase by phase, so a stage added to DecoratePhase
+	// sees sanitized errors and its edits are covered by the ETag and compression
+	ResponsePipelineFn func(r *http.Request, pipeline ResponsePipeline)
+
+	// KeepRootObjectValues: Precedence of the values returned by RootObjectFn
+	// Default: false (the extracted "token" and fetched "details" replace them)
+	// When enabled: "token" and "details" returned by RootObjectFn are kept, e.g. to
+	// expose details resolved by the application instead of UserDetailsFn
+	KeepRootObjectValues bool
+
+	// TokenRotationFn: Mints replacement tokens for authenticated requests
+	// Default: nil (tokens are never rotated)
+	// When set: a non-empty token it returns is sent to the client in the "rotatedToken"
+	// response extension. Independently, UserDetailsFn errors wrapping ErrTokenExpired
+	// are answered with 401, an UNAUTHENTICATED error and a WWW-Authenticate header.
+	TokenRotationFn TokenRotationFn
+
+	// TokenRevokedFn: Reports tokens revoked by logout or a deny list
+	// Default: nil (tokens are not checked)
+	// When set: called before UserDetailsFn, with results cached for a few seconds;
+	// revoked tokens are answered with 401 and an UNAUTHENTICATED error. RevokeToken
+	// rejects a token at once, without waiting for its cached result to expire. Tokens
+	// of SSE connection payloads and RESTBridge requests are checked too.
+	TokenRevokedFn func(token string) bool
+
+	// TimezoneHeader: Header carrying the client's IANA time zone, returned by Location(ctx)
+	// Default: "" (DefaultTimezoneHeader, X-Timezone)
+	TimezoneHeader string
+
+	// LocalDateTimes: Serialize DateTime values in the client's time zone
+	// Default: false (DateTime values are serialized in UTC)
+	// When enabled: values of DateTime fields are converted to Location(ctx) before
+	// serialization; DateTime inputs are still parsed as UTC
+	LocalDateTimes bool
+
+	// DecodeBudget: Bytes of memory each request may spend on its body, its variables
+	// and its input object arguments once decoded
+	// Default: 0 (no budget)
+	// When set: the body is read into a pooled buffer, no further than the budget, and
+	// the memory decoding it allocates is estimated before anything decodes it;
+	// requests over budget are rejected with 413 Payload Too Large, and input object
+	// arguments over the rest of the budget fail with a DECODE_BUDGET_EXCEEDED error
+	DecodeBudget int64
+
+	// EnableStreaming: Stream the lists selected with @stream over Server-Sent Events
+	// Default: false (@stream not declared)
+	// When enabled: the @stream directive is added to built schemas; queries served over
+	// SSE send the first initialCount items of a streamed list in their result, then one
+	// incremental payload per item as its resolver produces it (see WithIterator)
+	EnableStreaming bool
+}
+
+// Validate reports settings of the context that conflict or are silently ignored.
+// New and NewHTTP call it, so misconfigured handlers fail at startup; the returned
+// error lists every problem found.
+func (c *GraphContext) Validate() error {
+	var problems []error
+	if c.Schema != nil && c.SchemaParams != nil {
+		problems = append(problems, errors.New("graph: Schema and SchemaParams are both set; SchemaParams would be ignored, set only one"))
+	}
+	if c.DEBUG && c.EnableValidation {
+		problems = append(problems, errors.New("graph: EnableValidation has no effect with DEBUG true, which skips validation; disable DEBUG to validate queries"))
+	}
+	if c.DEBUG && c.EnableSanitization {
+		problems = append(problems, errors.New("graph: EnableSanitization has no effect with DEBUG true, which skips sanitization; disable DEBUG to sanitize errors"))
+	}
+	if c.Playground && !c.DEBUG && c.EnableSanitization {
+		problems = append(problems, errors.New("graph: Playground is enabled in production mode (DEBUG false, EnableSanitization true); disable Playground or enable DEBUG"))
+	}
+	return errors.Join(problems...)
+}
+
+// validateForNew reports the settings New cannot enforce, because they rely on the
+// request state only NewHTTP attaches: ignoring them would let requests through that
+// they are meant to reject
+func (c *GraphContext) validateForNew() error {
+	var problems []error
+	requireNewHTTP := func(set bool, name string) {
+		if set {
+			problems = append(problems, errors.New("graph: "+name+" is only enforced by NewHTTP; New would ignore it, use NewHTTP"))
+		}
+	}
+	requireNewHTTP(c.AuthorizationPolicy != nil, "AuthorizationPolicy")
+	requireNewHTTP(c.TokenRevokedFn != nil, "TokenRevokedFn")
+	requireNewHTTP(c.TenantPolicies != nil, "TenantPolicies")
+	requireNewHTTP(c.RequireTenant, "RequireTenant")
+	requireNewHTTP(c.MutationAudit != nil, "MutationAudit")
+	return errors.Join(problems...)
+}
+
+// SubscriptionConfig configures long-lived connections of the subscription transport:
+// subscriptions and live queries served over Server-Sent Events.
+//
+// Example:
+//
+//	ctx := &graph.GraphContext{
+//	    EnableSSE: true,
+//	    Subscriptions: graph.SubscriptionConfig{
+//	        KeepAlive:        15 * time.Second,
+//	        MaxLifetime:      time.Hour,
+//	        MaxSubscriptions: 5,
+//	    },
+//	}
+type SubscriptionConfig struct {
+	// ConnectionTokenFn: Extracts the token from the connection init payload
+	// (the "extensions" object of the request) when TokenExtractorFn finds none,
+	// for clients such as EventSource that cannot set headers
+	// Default: reads "token", "authToken" or a Bearer "Authorization" entry
+	ConnectionTokenFn func(payload map[string]interface{}) string
+
+	// KeepAlive: Interval between keepalive pings (SSE comments) on idle streams
+	// Default: 0 (no keepalive)
+	KeepAlive time.Duration
+
+	// MaxLifetime: Maximum duration of a stream before the server completes it
+	// Default: 0 (unlimited)
+	MaxLifetime time.Duration
+
+	// MaxSubscriptions: Maximum concurrent streams per client, identified by its
+	// signature or certificate caller, by its token once UserDetailsFn accepted it, or
+	// else by remote address
+	// Default: 0 (unlimited)
+	// Streams beyond the limit are rejected with 429 Too Many Requests
+	MaxSubscriptions int
 }
 
 type ResolveParams graphql.ResolveParams
@@ -0,0 +1,412 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Upstream defaults
+const (
+	defaultUpstreamTimeout      = 10 * time.Second
+	defaultUpstreamRetryBackoff = 100 * time.Millisecond
+)
+
+// RESTUpstream describes a REST/JSON endpoint used to resolve a field.
+//
+// URL placeholders like {id} are filled from the field's arguments, then from the
+// fields of the parent object (for nested fields).
+type RESTUpstream struct {
+	Method string // HTTP method, defaults to GET
+	URL    string // e.g. "https://users.internal/api/users/{id}"
+
+	// Headers are sent with every request
+	Headers map[string]string
+	// ForwardHeaders are copied from the incoming GraphQL request, e.g. "Authorization"
+	ForwardHeaders []string
+
+	// ResultPath selects the value to decode from the response body, e.g. "$.data.user"
+	// or "$.items[0]". Empty uses the whole body.
+	ResultPath string
+
+	Timeout      time.Duration // Per attempt, defaults to 10s
+	Retries      int           // Extra attempts after network errors, 429 and 5xx responses
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each retry (default 100ms)
+
+	Client *http.Client // Defaults to http.DefaultClient
+}
+
+// UpstreamError is returned when an upstream responds with a non-2xx status
+type UpstreamError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("upstream %s responded with status %d", e.URL, e.StatusCode)
+}
+
+// UpstreamResolver resolves a field by calling a REST upstream and decoding the value at
+// ResultPath into T. Non-GET requests send the field's arguments as a JSON body.
+//
+// Example:
+//
+//	graph.NewResolver[User]("user").
+//	    WithArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}}).
+//	    WithResolver(graph.UpstreamResolver[User](graph.RESTUpstream{
+//	        URL:            "https://users.internal/api/users/{id}",
+//	        ForwardHeaders: []string{"Authorization"},
+//	        ResultPath:     "$.data",
+//	        Retries:        2,
+//	    })).
+//	    BuildQuery()
+func UpstreamResolver[T any](upstream RESTUpstream) func(p ResolveParams) (*T, error) {
+	return func(p ResolveParams) (*T, error) {
+		target, err := upstream.expandURL(func(name string) (interface{}, bool) {
+			return placeholderValue(p, name)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var body []byte
+		if upstream.method() != http.MethodGet && len(p.Args) > 0 {
+			if body, err = json.Marshal(p.Args); err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := upstream.fetch(p.Context, target, body)
+		if err != nil || value == nil {
+			return nil, err
+		}
+
+		var result T
+		if err := decodeUpstreamValue(value, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+}
+
+// UpstreamBatchResolver resolves a field of many parent objects with a single upstream
+// call per request. The URL's {keys} placeholder receives the comma-separated values of
+// the parents' key field, and each item of the resulting list is matched back to a
+// parent by its match field.
+//
+// Example:
+//
+//	// GET https://users.internal/api/users?ids=1,2,3 → [{"id": 1, ...}, ...]
+//	graph.NewResolver[Post]("posts").
+//	    WithFieldResolver("author", graph.UpstreamBatchResolver[User](graph.RESTUpstream{
+//	        URL: "https://users.internal/api/users?ids={keys}",
+//	    }, "authorId", "id")).
+//	    ...
+func UpstreamBatchResolver[T any](upstream RESTUpstream, keyField, matchField string) graphql.FieldResolveFn {
+	loader := &upstreamLoader{batches: make(map[context.Context]*upstreamBatch)}
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		key, ok := placeholderValue(ResolveParams(p), keyField)
+		if !ok || key == nil {
+			return nil, nil
+		}
+		keyString := fmt.Sprint(key)
+
+		batch := loader.add(p.Context, keyString)
+		return func() (interface{}, error) {
+			values, err := batch.load(func(keys []string) ([]interface{}, error) {
+				target, err := upstream.expandURL(func(name string) (interface{}, bool) {
+					if name == "keys" {
+						return strings.Join(keys, ","), true
+					}
+					return placeholderValue(ResolveParams(p), name)
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := upstream.fetch(p.Context, target, nil)
+				if err != nil {
+					return nil, err
+				}
+				items, ok := value.([]interface{})
+				if !ok && value != nil {
+					return nil, fmt.Errorf("upstream %s: expected a list of results", target)
+				}
+				return items, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range values {
+				fields, ok := item.(map[string]interface{})
+				if !ok || fmt.Sprint(fields[matchField]) != keyString {
+					continue
+				}
+				var result T
+				if err := decodeUpstreamValue(item, &result); err != nil {
+					return nil, err
+				}
+				return &result, nil
+			}
+			return nil, nil
+		}, nil
+	}
+}
+
+// upstreamLoader groups the keys requested during one execution into batches
+type upstreamLoader struct {
+	mu      sync.Mutex
+	batches map[context.Context]*upstreamBatch
+}
+
+// upstreamBatch is the set of keys resolved by a single upstream call
+type upstreamBatch struct {
+	loader *upstreamLoader
+	ctx    context.Context
+
+	keys   []string
+	seen   map[string]bool
+	once   sync.Once
+	values []interface{}
+	err    error
+}
+
+// add registers a key in the pending batch of ctx
+func (l *upstreamLoader) add(ctx context.Context, key string) *upstreamBatch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	batch, ok := l.batches[ctx]
+	if !ok {
+		batch = &upstreamBatch{loader: l, ctx: ctx, seen: make(map[string]bool)}
+		l.batches[ctx] = batch
+	}
+	if !batch.seen[key] {
+		batch.seen[key] = true
+		batch.keys = append(batch.keys, key)
+	}
+	return batch
+}
+
+// load fetches the batch once; later keys for the same context start a new batch
+func (b *upstreamBatch) load(fetch func(keys []string) ([]interface{}, error)) ([]interface{}, error) {
+	b.once.Do(func() {
+		b.loader.mu.Lock()
+		if b.loader.batches[b.ctx] == b {
+			delete(b.loader.batches, b.ctx)
+		}
+		b.loader.mu.Unlock()
+
+		b.values, b.err = fetch(b.keys)
+	})
+	return b.values, b.err
+}
+
+// placeholderValue returns a URL placeholder value: an argument, or a field of the parent object
+func placeholderValue(p ResolveParams, name string) (interface{}, bool) {
+	if value, ok := p.Args[name]; ok {
+		return value, true
+	}
+	if p.Source == nil {
+		return nil, false
+	}
+
+	value, err := graphql.DefaultResolveFn(graphql.ResolveParams{
+		Source: p.Source,
+		Info:   graphql.ResolveInfo{FieldName: name},
+	})
+	if err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// upstreamPlaceholder matches {name} placeholders in URL templates
+var upstreamPlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// expandURL fills the URL template's placeholders
+func (u RESTUpstream) expandURL(lookup func(name string) (interface{}, bool)) (string, error) {
+	var missing []string
+	expanded := upstreamPlaceholder.ReplaceAllStringFunc(u.URL, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := lookup(name)
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+		if name == "keys" {
+			// Batched keys are already joined; escape each one
+			parts := strings.Split(fmt.Sprint(value), ",")
+			for i, part := range parts {
+				parts[i] = url.QueryEscape(part)
+			}
+			return strings.Join(parts, ",")
+		}
+		return url.PathEscape(fmt.Sprint(value))
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("upstream URL %s: no value for %s", u.URL, strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+func (u RESTUpstream) method() string {
+	if u.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(u.Method)
+}
+
+// fetch calls the upstream with retries and returns the value at ResultPath
+func (u RESTUpstream) fetch(ctx context.Context, target string, body []byte) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	backoff := u.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultUpstreamRetryBackoff
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= u.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		data, retry, err := u.do(ctx, target, body)
+		if err == nil {
+			return selectResultPath(data, u.ResultPath)
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// do performs a single request. It reports whether a failure may be retried.
+func (u RESTUpstream) do(ctx context.Context, target string, body []byte) (interface{}, bool, error) {
+	timeout := u.Timeout
+	if timeout <= 0 {
+		timeout = defaultUpstreamTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, u.method(), target, reader)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if incoming, ok := RequestFromContext(ctx); ok {
+		for _, name := range u.ForwardHeaders {
+			if value := incoming.Header.Get(name); value != "" {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+	for name, value := range u.Headers {
+		req.Header.Set(name, value)
+	}
+
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Network errors are retried unless the request itself was cancelled
+		return nil, !errors.Is(err, context.Canceled), err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, retry, &UpstreamError{StatusCode: resp.StatusCode, URL: target}
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil && err != io.EOF {
+		return nil, false, fmt.Errorf("upstream %s: invalid JSON response: %w", target, err)
+	}
+	return data, false, nil
+}
+
+// selectResultPath returns the value at a JSONPath-style path such as "$.data.items[0]"
+func selectResultPath(data interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return data, nil
+	}
+
+	value := data
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		var indexes []int
+		if i := strings.Index(segment, "["); i >= 0 {
+			name = segment[:i]
+			for _, part := range strings.Split(strings.TrimSuffix(segment[i+1:], "]"), "][") {
+				index, err := strconv.Atoi(part)
+				if err != nil {
+					return nil, fmt.Errorf("invalid result path %q", path)
+				}
+				indexes = append(indexes, index)
+			}
+		}
+
+		if name != "" {
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil
+			}
+			value = fields[name]
+		}
+		for _, index := range indexes {
+			items, ok := value.([]interface{})
+			if !ok || index < 0 || index >= len(items) {
+				return nil, nil
+			}
+			value = items[index]
+		}
+	}
+	return value, nil
+}
+
+// decodeUpstreamValue converts a decoded JSON value into target
+func decodeUpstreamValue(value interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
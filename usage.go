@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultHotFields is the number of hot fields reported when no limit is given
+const defaultHotFields = 10
+
+// FieldUsage counts the sampled operations that selected a field
+type FieldUsage struct {
+	Type     string           `json:"type"`
+	Field    string           `json:"field"`
+	Count    int64            `json:"count"`
+	Clients  map[string]int64 `json:"clients"`
+	LastSeen time.Time        `json:"lastSeen"`
+}
+
+// UsageReport summarizes which schema fields are queried
+type UsageReport struct {
+	SampleRate float64      `json:"sampleRate"`
+	Operations int64        `json:"operations"` // Sampled operations
+	Hot        []FieldUsage `json:"hot"`        // Most selected fields first
+	NeverUsed  []string     `json:"neverUsed"`  // "Type.field" coordinates never selected
+}
+
+// UsageCollector tracks which fields operations select, with client attribution, to
+// inform schema pruning and caching decisions. Only a sample of operations is recorded,
+// so counts are proportional to real traffic. It is safe for concurrent use.
+//
+// Example:
+//
+//	usage := graph.NewUsageCollector(0.1) // record 10% of operations
+//
+//	http.Handle("/graphql", graph.NewHTTP(&graph.GraphContext{
+//	    SchemaParams:   params,
+//	    UsageCollector: usage,
+//	}))
+//	http.Handle("/graphql/usage", usage) // ?top=20 for the 20 hottest fields
+type UsageCollector struct {
+	sampleRate float64
+
+	mu         sync.Mutex
+	schema     *graphql.Schema
+	operations int64
+	fields     map[string]*FieldUsage
+}
+
+// NewUsageCollector creates a collector recording the given fraction of operations.
+// Rates outside (0, 1] record every operation.
+func NewUsageCollector(sampleRate float64) *UsageCollector {
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+	return &UsageCollector{
+		sampleRate: sampleRate,
+		fields:     make(map[string]*FieldUsage),
+	}
+}
+
+// bind sets the schema the collector reports never-used fields against
+func (c *UsageCollector) bind(schema *graphql.Schema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schema = schema
+}
+
+// sample reports whether the next operation should be recorded
+func (c *UsageCollector) sample() bool {
+	return c.sampleRate >= 1 || rand.Float64() < c.sampleRate
+}
+
+// recordOperation records the fields an operation selects, counting each field once
+func (c *UsageCollector) recordOperation(schema *graphql.Schema, query, operationName, client string) {
+	doc, err := parseGraphQLQuery(query)
+	if err != nil {
+		return
+	}
+
+	selected := make(map[string]bool)
+	var coordinates [][2]string
+	visitSelectedFields(schema, doc, operationName, func(parent graphql.Type, field *graphql.FieldDefinition, _ *ast.Field) {
+		coordinate := parent.Name() + "." + field.Name
+		if !selected[coordinate] {
+			selected[coordinate] = true
+			coordinates = append(coordinates, [2]string{parent.Name(), field.Name})
+		}
+	})
+	if len(coordinates) == 0 {
+		return
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.operations++
+	for _, coordinate := range coordinates {
+		key := coordinate[0] + "." + coordinate[1]
+		usage, ok := c.fields[key]
+		if !ok {
+			usage = &FieldUsage{Type: coordinate[0], Field: coordinate[1], Clients: make(map[string]int64)}
+			c.fields[key] = usage
+		}
+		usage.Count++
+		usage.Clients[client]++
+		usage.LastSeen = now
+	}
+}
+
+// Report returns the top most selected fields and the schema fields never selected.
+// A top of 0 or less returns every selected field.
+func (c *UsageCollector) Report(top int) UsageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := UsageReport{
+		SampleRate: c.sampleRate,
+		Operations: c.operations,
+		Hot:        make([]FieldUsage, 0, len(c.fields)),
+		NeverUsed:  []string{},
+	}
+
+	for _, usage := range c.fields {
+		clients := make(map[string]int64, len(usage.Clients))
+		for client, count := range usage.Clients {
+			clients[client] = count
+		}
+		copied := *usage
+		copied.Clients = clients
+		report.Hot = append(report.Hot, copied)
+	}
+	sort.Slice(report.Hot, func(i, j int) bool {
+		a, b := report.Hot[i], report.Hot[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Type+"."+a.Field < b.Type+"."+b.Field
+	})
+	if top > 0 && len(report.Hot) > top {
+		report.Hot = report.Hot[:top]
+	}
+
+	if c.schema != nil {
+		for name, t := range c.schema.TypeMap() {
+			if strings.HasPrefix(name, "__") {
+				continue
+			}
+			var fields graphql.FieldDefinitionMap
+			switch t := t.(type) {
+			case *graphql.Object:
+				fields = t.Fields()
+			case *graphql.Interface:
+				fields = t.Fields()
+			}
+			for fieldName := range fields {
+				coordinate := name + "." + fieldName
+				if _, ok := c.fields[coordinate]; !ok {
+					report.NeverUsed = append(report.NeverUsed, coordinate)
+				}
+			}
+		}
+		sort.Strings(report.NeverUsed)
+	}
+
+	return report
+}
+
+// Reset clears the recorded usage
+func (c *UsageCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.operations = 0
+	c.fields = make(map[string]*FieldUsage)
+}
+
+// ServeHTTP writes the usage report as JSON. The top query parameter limits the
+// number of hot fields (default 10, 0 for all).
+func (c *UsageCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	top := defaultHotFields
+	if param := r.URL.Query().Get("top"); param != "" {
+		if n, err := strconv.Atoi(param); err == nil {
+			top = n
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(c.Report(top))
+}
@@ -3,6 +3,8 @@ package graph
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 
 	"github.com/graphql-go/graphql"
 )
@@ -41,6 +43,22 @@ type MutationField interface {
 	Name() string
 }
 
+// SubscriptionField represents a GraphQL subscription field with its configuration.
+// Implementations must provide both the field configuration and its name.
+//
+// Use NewResolver with WithSubscriber to create SubscriptionField instances:
+//
+//	subscription := graph.NewResolver[Message]("messageAdded").
+//	    WithSubscriber(...).
+//	    BuildSubscription()
+type SubscriptionField interface {
+	// Serve returns the GraphQL field configuration
+	Serve() *graphql.Field
+
+	// Name returns the field name used in the GraphQL schema
+	Name() string
+}
+
 // GetRootInfo safely extracts a value from p.Info.RootValue and unmarshals it into the target.
 // This is commonly used to retrieve user details set by UserDetailsFn in the GraphContext.
 //
@@ -278,3 +296,33 @@ func GetArgBool(p ResolveParams, key string) (bool, error) {
 
 	return b, nil
 }
+
+// GetArgID safely extracts an ID argument from p.Args as a string.
+// Accepts both string and numeric values, since clients may send IDs either way.
+// Returns an error if the argument doesn't exist or is neither a string nor a number.
+//
+// Example:
+//
+//	id, err := graph.GetArgID(p, "id")
+func GetArgID(p ResolveParams, key string) (string, error) {
+	value, exists := p.Args[key]
+	if !exists {
+		return "", fmt.Errorf("argument '%s' not found", key)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return "", fmt.Errorf("argument '%s' is not a valid ID", key)
+		}
+		return strconv.FormatInt(int64(v), 10), nil
+	default:
+		return "", fmt.Errorf("argument '%s' is not a valid ID", key)
+	}
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/handler"
+)
+
+// ValidateOnlyHeader requests validate-only execution, like "validateOnly": true in the extensions
+const ValidateOnlyHeader = "X-GraphQL-Validate-Only"
+
+// ValidateOperation checks an operation against the schema without invoking any resolvers:
+// syntax, field selections, argument types and the provided variable values are all
+// validated. It returns the errors a real execution would report before resolving.
+//
+// Example usage:
+//
+//	if errs := graph.ValidateOperation(schema, query, variables, ""); len(errs) > 0 {
+//	    t.Fatalf("operation no longer matches the schema: %v", errs)
+//	}
+func ValidateOperation(schema *graphql.Schema, query string, variables map[string]interface{}, operationName string) []gqlerrors.FormattedError {
+	doc, err := parseGraphQLQuery(query)
+	if err != nil {
+		return gqlerrors.FormatErrors(err)
+	}
+
+	if result := graphql.ValidateDocument(schema, doc, nil); !result.IsValid {
+		return result.Errors
+	}
+
+	// Coerce variables by executing a copy of the operation that only selects __typename
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        *schema,
+		AST:           typenameOnlyDocument(doc),
+		OperationName: operationName,
+		Args:          variables,
+	})
+	return result.Errors
+}
+
+// typenameOnlyDocument returns a copy of doc whose operations select only __typename,
+// keeping variable definitions so variable values are still coerced
+func typenameOnlyDocument(doc *ast.Document) *ast.Document {
+	stripped := *doc
+	stripped.Definitions = make([]ast.Node, 0, len(doc.Definitions))
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			stripped.Definitions = append(stripped.Definitions, def)
+			continue
+		}
+
+		// Mutations execute serially, ordering fields by location, so the synthesized
+		// field takes the location of the selection set it replaces
+		loc := op.SelectionSet.Loc
+		if loc == nil {
+			loc = &ast.Location{}
+		}
+		opCopy := *op
+		opCopy.SelectionSet = ast.NewSelectionSet(&ast.SelectionSet{
+			Loc: loc,
+			Selections: []ast.Selection{
+				ast.NewField(&ast.Field{Loc: loc, Name: ast.NewName(&ast.Name{Loc: loc, Value: "__typename"})}),
+			},
+		})
+		stripped.Definitions = append(stripped.Definitions, &opCopy)
+	}
+
+	return &stripped
+}
+
+// isValidateOnlyRequest reports whether a request asks for validate-only execution
+func isValidateOnlyRequest(r *http.Request) bool {
+	if strings.EqualFold(r.Header.Get(ValidateOnlyHeader), "true") {
+		return true
+	}
+	validateOnly, _ := requestExtensions(r)["validateOnly"].(bool)
+	return validateOnly
+}
+
+// serveValidateOnly validates the requested operation and writes the errors without executing it
+func serveValidateOnly(w http.ResponseWriter, r *http.Request, schema *graphql.Schema, graphCtx *GraphContext) {
+	opts := handler.NewRequestOptions(r)
+	errs := ValidateOperation(schema, opts.Query, opts.Variables, opts.OperationName)
+
+	// Apply the security rules the operation would be checked against
+	if len(errs) == 0 && !graphCtx.DEBUG && graphCtx.EnableValidation {
+		if err := ValidateGraphQLQuery(opts.Query, schema); err != nil {
+			errs = validationErrors(err)
+		}
+	}
+
+	response := map[string]interface{}{
+		"extensions": map[string]interface{}{"valid": len(errs) == 0},
+	}
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
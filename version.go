@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+// expectedVersionArg is the argument of version-checked mutations holding the version
+// the client last read
+const expectedVersionArg = "expectedVersion"
+
+// VersionConflictError reports an update whose expected version is no longer the stored
+// version: another update won the race since the client read the object
+type VersionConflictError struct {
+	Expected int64
+	Current  int64
+}
+
+// Error describes the mismatching versions
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: expected version %d, current version is %d", e.Expected, e.Current)
+}
+
+// Extensions exposes the CONFLICT code and both versions in the GraphQL error
+func (e *VersionConflictError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":            "CONFLICT",
+		"expectedVersion": e.Expected,
+		"currentVersion":  e.Current,
+	}
+}
+
+// ExpectedVersion returns the expectedVersion argument of a version-checked mutation,
+// for resolvers making the write itself conditional:
+//
+//	UPDATE documents SET body = ?, version = version + 1 WHERE id = ? AND version = ?
+func ExpectedVersion(p ResolveParams) (int64, bool) {
+	version, ok := p.Args[expectedVersionArg].(int)
+	return int64(version), ok
+}
+
+// CheckVersion returns a VersionConflictError when current is not the expectedVersion
+// argument of p, or nil when they match or the argument is not set
+func CheckVersion(p ResolveParams, current int64) error {
+	if expected, ok := ExpectedVersion(p); ok && expected != current {
+		return &VersionConflictError{Expected: expected, Current: current}
+	}
+	return nil
+}
+
+// versionFieldIndex returns the index of the field of struct t tagged graphql:"version"
+func versionFieldIndex(t reflect.Type) ([]int, bool) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for _, field := range collectStructFields(t) {
+		if !parseGraphQLTag(field.StructField).version {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return field.index, true
+		}
+	}
+	return nil, false
+}
+
+// versionAt reads the version field at index of a struct value, or of the struct a
+// pointer points to
+func versionAt(value interface{}, index []int) (int64, bool) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return 0, false
+	}
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return 0, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	if v.CanInt() {
+		return v.Int(), true
+	}
+	return int64(v.Uint()), true
+}
+
+// withVersionArg adds the required expectedVersion argument to args
+func withVersionArg(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	merged := graphql.FieldConfigArgument{
+		expectedVersionArg: &graphql.ArgumentConfig{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "Version of the object the update is based on; the update fails with a CONFLICT error when it changed since",
+		},
+	}
+	for name, arg := range args {
+		merged[name] = arg
+	}
+	return merged
+}
+
+// checkVersion fails the field with a VersionConflictError, before resolve runs, when
+// the object load returns has another version than expectedVersion. Objects load
+// doesn't find are left to resolve.
+func checkVersion(resolve graphql.FieldResolveFn, load func(p ResolveParams) (interface{}, error), index []int) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		stored, err := load(ResolveParams(p))
+		if err != nil {
+			return nil, err
+		}
+		if current, ok := versionAt(stored, index); ok {
+			if err := CheckVersion(ResolveParams(p), current); err != nil {
+				return nil, err
+			}
+		}
+		return resolve(p)
+	}
+}
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WarmupOperation is an operation executed in-process when the handler is built, to
+// check the wiring of the schema and its resolvers and prime caches before the server
+// accepts traffic
+type WarmupOperation struct {
+	// Name identifies the operation in warm-up errors
+	Name string
+
+	// Query is the GraphQL document executed, with Variables
+	Query     string
+	Variables map[string]interface{}
+
+	// Headers are sent with the operation, e.g. the Authorization of a service account
+	// for resolvers requiring a user
+	Headers map[string]string
+}
+
+// WarmupError is returned by Warmup when an operation fails
+type WarmupError struct {
+	Operation  string
+	StatusCode int
+	Messages   []string
+}
+
+func (e *WarmupError) Error() string {
+	return fmt.Sprintf("warm-up operation %q failed with status %d: %s", e.Operation, e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+// Warmup executes operations through handler, in order, and returns a *WarmupError for
+// the first one answered with a status other than 200 or with GraphQL errors. The
+// operations run the whole request path, middleware and validation included, against
+// the resolvers of the schema, so mocked resolvers are exercised as well as real ones.
+//
+// NewHTTP runs GraphContext.WarmupOperations itself; call Warmup to check a handler
+// built otherwise, or to handle the failure rather than panic.
+//
+// Example:
+//
+//	handler := graph.NewHTTP(graphCtx)
+//	if err := graph.Warmup(ctx, handler, graph.WarmupOperation{Name: "me", Query: "{ me { id } }"}); err != nil {
+//	    log.Fatal(err)
+//	}
+func Warmup(ctx context.Context, handler http.Handler, operations ...WarmupOperation) error {
+	for _, operation := range operations {
+		body, err := json.Marshal(map[string]interface{}{
+			"query":     operation.Query,
+			"variables": operation.Variables,
+		})
+		if err != nil {
+			return fmt.Errorf("warm-up operation %q: %w", operation.Name, err)
+		}
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/graphql", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("warm-up operation %q: %w", operation.Name, err)
+		}
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "application/json")
+		for name, value := range operation.Headers {
+			r.Header.Set(name, value)
+		}
+
+		w := &lambdaResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+		handler.ServeHTTP(w, r)
+		if messages := warmupErrors(w.body.Bytes()); w.statusCode != http.StatusOK || len(messages) > 0 {
+			if len(messages) == 0 {
+				messages = []string{strings.TrimSpace(w.body.String())}
+			}
+			return &WarmupError{Operation: operation.Name, StatusCode: w.statusCode, Messages: messages}
+		}
+	}
+	return nil
+}
+
+// warmupErrors returns the messages of the GraphQL errors of a response body
+func warmupErrors(body []byte) []string {
+	var response struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return nil
+	}
+	messages := make([]string, 0, len(response.Errors))
+	for _, err := range response.Errors {
+		messages = append(messages, err.Message)
+	}
+	return messages
+}